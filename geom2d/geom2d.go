@@ -35,6 +35,66 @@ func IsPointInside(p [2]float32, v0 [2]float32, v1 [2]float32, v2 [2]float32) bo
 	return (c01 > 0 && c12 > 0 && c13 > 0) || (c01 < 0 && c12 < 0 && c13 < 0)
 }
 
+func IsPointInsidePolygon(p [2]float32, polygon [][2]float32) bool {
+	// even-odd rule (works for both convex and concave polygons), with the points on the boundary inside
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if GetDistanceToSegment(p, a, b) == 0 {
+			return true
+		}
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < a[0]+(p[1]-a[1])*(b[0]-a[0])/(b[1]-a[1]) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func GetDistanceToSegment(p [2]float32, a [2]float32, b [2]float32) float32 {
+	ab, ap := SubAB(b, a), SubAB(p, a)
+	len2 := ab[0]*ab[0] + ab[1]*ab[1]
+	if len2 == 0 {
+		return Length(ap)
+	}
+	t := (ap[0]*ab[0] + ap[1]*ab[1]) / len2
+	if t < 0 {
+		t = 0
+	} else if t > 1 {
+		t = 1
+	}
+	return Length(SubAB(ap, [2]float32{ab[0] * t, ab[1] * t}))
+}
+
+func IsSegmentsIntersecting(a0 [2]float32, a1 [2]float32, b0 [2]float32, b1 [2]float32) bool {
+	// check if the two segments intersect (including touching and collinear overlapping)
+	d1 := CrossAB(SubAB(a1, a0), SubAB(b0, a0))
+	d2 := CrossAB(SubAB(a1, a0), SubAB(b1, a0))
+	d3 := CrossAB(SubAB(b1, b0), SubAB(a0, b0))
+	d4 := CrossAB(SubAB(b1, b0), SubAB(a1, b0))
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	on_segment := func(p [2]float32, q0 [2]float32, q1 [2]float32) bool { // (for collinear p)
+		return BBoxInside([2][2]float32{{min32(q0[0], q1[0]), min32(q0[1], q1[1])}, {max32(q0[0], q1[0]), max32(q0[1], q1[1])}}, p)
+	}
+	return (d1 == 0 && on_segment(b0, a0, a1)) || (d2 == 0 && on_segment(b1, a0, a1)) ||
+		(d3 == 0 && on_segment(a0, b0, b1)) || (d4 == 0 && on_segment(a1, b0, b1))
+}
+
+func min32(a float32, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a float32, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // ----------------------------------------------------------------------------
 // Bounding Box
 // ----------------------------------------------------------------------------
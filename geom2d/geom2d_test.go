@@ -0,0 +1,86 @@
+package geom2d
+
+import "testing"
+
+func TestIsPointInsidePolygon(t *testing.T) {
+	square := [][2]float32{{0, 0}, {2, 0}, {2, 2}, {0, 2}}
+	concave := [][2]float32{{0, 0}, {4, 0}, {4, 4}, {2, 1}, {0, 4}} // notch at the top
+	tests := []struct {
+		name    string
+		p       [2]float32
+		polygon [][2]float32
+		want    bool
+	}{
+		{"inside", [2]float32{1, 1}, square, true},
+		{"outside", [2]float32{3, 1}, square, false},
+		{"outside_aligned", [2]float32{-1, 0}, square, false},
+		{"on_left_edge", [2]float32{0, 1}, square, true},
+		{"on_right_edge", [2]float32{2, 1}, square, true},
+		{"on_bottom_edge", [2]float32{1, 0}, square, true},
+		{"on_top_edge", [2]float32{1, 2}, square, true},
+		{"on_vertex", [2]float32{2, 2}, square, true},
+		{"concave_inside", [2]float32{1, 0.5}, concave, true},
+		{"concave_inside_arm", [2]float32{3.5, 3}, concave, true},
+		{"concave_notch", [2]float32{2, 3}, concave, false},
+		{"concave_on_notch_vertex", [2]float32{2, 1}, concave, true},
+		{"cw_order", [2]float32{1, 1}, [][2]float32{{0, 0}, {0, 2}, {2, 2}, {2, 0}}, true},
+		{"empty", [2]float32{0, 0}, [][2]float32{}, false},
+	}
+	for _, tt := range tests {
+		if got := IsPointInsidePolygon(tt.p, tt.polygon); got != tt.want {
+			t.Errorf("%s: IsPointInsidePolygon(%v) = %v, want %v", tt.name, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestGetDistanceToSegment(t *testing.T) {
+	tests := []struct {
+		name string
+		p    [2]float32
+		a, b [2]float32
+		want float32
+	}{
+		{"perpendicular", [2]float32{1, 3}, [2]float32{0, 0}, [2]float32{2, 0}, 3},
+		{"on_segment", [2]float32{1, 0}, [2]float32{0, 0}, [2]float32{2, 0}, 0},
+		{"before_start", [2]float32{-3, 4}, [2]float32{0, 0}, [2]float32{2, 0}, 5},
+		{"after_end", [2]float32{5, 4}, [2]float32{0, 0}, [2]float32{2, 0}, 5},
+		{"collinear_beyond", [2]float32{4, 0}, [2]float32{0, 0}, [2]float32{2, 0}, 2},
+		{"diagonal", [2]float32{0, 2}, [2]float32{0, 0}, [2]float32{2, 2}, 1.4142135},
+		{"degenerate", [2]float32{3, 4}, [2]float32{0, 0}, [2]float32{0, 0}, 5},
+	}
+	for _, tt := range tests {
+		if d := GetDistanceToSegment(tt.p, tt.a, tt.b); d-tt.want > 1e-6 || d-tt.want < -1e-6 {
+			t.Errorf("%s: GetDistanceToSegment(%v) = %v, want %v", tt.name, tt.p, d, tt.want)
+		}
+	}
+}
+
+func TestIsSegmentsIntersecting(t *testing.T) {
+	tests := []struct {
+		name   string
+		a0, a1 [2]float32
+		b0, b1 [2]float32
+		want   bool
+	}{
+		{"crossing", [2]float32{0, 0}, [2]float32{2, 2}, [2]float32{0, 2}, [2]float32{2, 0}, true},
+		{"apart", [2]float32{0, 0}, [2]float32{1, 0}, [2]float32{0, 1}, [2]float32{1, 1}, false},
+		{"would_cross_if_extended", [2]float32{0, 0}, [2]float32{1, 1}, [2]float32{3, 0}, [2]float32{2, 1}, false},
+		{"touching_at_endpoint", [2]float32{0, 0}, [2]float32{1, 0}, [2]float32{1, 0}, [2]float32{1, 1}, true},
+		{"touching_at_middle", [2]float32{0, 0}, [2]float32{2, 0}, [2]float32{1, 0}, [2]float32{1, 1}, true},
+		{"collinear_overlapping", [2]float32{0, 0}, [2]float32{2, 0}, [2]float32{1, 0}, [2]float32{3, 0}, true},
+		{"collinear_containing", [2]float32{0, 0}, [2]float32{3, 3}, [2]float32{1, 1}, [2]float32{2, 2}, true},
+		{"collinear_touching", [2]float32{0, 0}, [2]float32{1, 0}, [2]float32{1, 0}, [2]float32{2, 0}, true},
+		{"collinear_disjoint", [2]float32{0, 0}, [2]float32{1, 0}, [2]float32{2, 0}, [2]float32{3, 0}, false},
+		{"parallel", [2]float32{0, 0}, [2]float32{2, 0}, [2]float32{0, 1}, [2]float32{2, 1}, false},
+		{"point_on_segment", [2]float32{0, 0}, [2]float32{2, 0}, [2]float32{1, 0}, [2]float32{1, 0}, true},
+		{"point_off_segment", [2]float32{0, 0}, [2]float32{2, 0}, [2]float32{3, 0}, [2]float32{3, 0}, false},
+	}
+	for _, tt := range tests {
+		if got := IsSegmentsIntersecting(tt.a0, tt.a1, tt.b0, tt.b1); got != tt.want {
+			t.Errorf("%s: IsSegmentsIntersecting() = %v, want %v", tt.name, got, tt.want)
+		}
+		if got := IsSegmentsIntersecting(tt.b0, tt.b1, tt.a0, tt.a1); got != tt.want {
+			t.Errorf("%s: IsSegmentsIntersecting() (swapped) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
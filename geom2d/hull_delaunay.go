@@ -0,0 +1,235 @@
+package geom2d
+
+import (
+	"math"
+	"sort"
+)
+
+// ----------------------------------------------------------------------------
+// Convex Hull
+// ----------------------------------------------------------------------------
+
+func ConvexHull(points [][2]float32) []int {
+	// Return the indices of the points on the convex hull, in CCW order (Andrew's monotone chain).
+	// Collinear points on the hull boundary are excluded.
+	n := len(points)
+	if n < 3 {
+		indices := make([]int, n)
+		for i := 0; i < n; i++ {
+			indices[i] = i
+		}
+		return indices
+	}
+	order := make([]int, n)
+	for i := 0; i < n; i++ {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := points[order[i]], points[order[j]]
+		return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+	})
+	cross := func(o int, a int, b int) float64 {
+		po, pa, pb := points[o], points[a], points[b]
+		return float64(pa[0]-po[0])*float64(pb[1]-po[1]) - float64(pa[1]-po[1])*float64(pb[0]-po[0])
+	}
+	hull := make([]int, 0, 2*n)
+	for _, i := range order { // lower hull
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], i) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, i)
+	}
+	lower_len := len(hull) + 1
+	for k := n - 2; k >= 0; k-- { // upper hull
+		i := order[k]
+		for len(hull) >= lower_len && cross(hull[len(hull)-2], hull[len(hull)-1], i) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, i)
+	}
+	return hull[:len(hull)-1] // the last point is the same as the first one
+}
+
+// ----------------------------------------------------------------------------
+// Delaunay Triangulation
+// ----------------------------------------------------------------------------
+
+func DelaunayTriangulation(points [][2]float32) [][3]int {
+	// Return the Delaunay triangles (as CCW indices of the points), using Bowyer-Watson algorithm.
+	// Duplicated points are ignored (only the first one is used).
+	n := len(points)
+	if n < 3 {
+		return [][3]int{}
+	}
+	bbox := BBoxInit()
+	for _, p := range points {
+		BBoxAddPoint(&bbox, p)
+	}
+	size, center := BBoxSize(bbox), BBoxCenter(bbox)
+	d := 100 * math.Max(math.Max(float64(size[0]), float64(size[1])), 1e-6)
+	cx, cy := float64(center[0]), float64(center[1])
+	pts := make([][2]float64, n+3) // all the points, with 3 more for the super triangle
+	for i, p := range points {
+		pts[i] = [2]float64{float64(p[0]), float64(p[1])}
+	}
+	pts[n+0] = [2]float64{cx - 2*d, cy - d}
+	pts[n+1] = [2]float64{cx + 2*d, cy - d}
+	pts[n+2] = [2]float64{cx, cy + 2*d}
+	type triangle struct {
+		v  [3]int     // CCW indices of the vertices
+		cc [2]float64 // center of the circumcircle
+		r2 float64    // squared radius of the circumcircle
+	}
+	new_triangle := func(a int, b int, c int) triangle {
+		pa, pb, pc := pts[a], pts[b], pts[c]
+		if (pb[0]-pa[0])*(pc[1]-pa[1])-(pb[1]-pa[1])*(pc[0]-pa[0]) < 0 {
+			b, c = c, b
+			pb, pc = pc, pb
+		}
+		dd := 2 * (pa[0]*(pb[1]-pc[1]) + pb[0]*(pc[1]-pa[1]) + pc[0]*(pa[1]-pb[1]))
+		if dd == 0 { // degenerate (collinear) triangle
+			return triangle{v: [3]int{a, b, c}, cc: [2]float64{0, 0}, r2: math.Inf(1)}
+		}
+		a2, b2, c2 := pa[0]*pa[0]+pa[1]*pa[1], pb[0]*pb[0]+pb[1]*pb[1], pc[0]*pc[0]+pc[1]*pc[1]
+		ux := (a2*(pb[1]-pc[1]) + b2*(pc[1]-pa[1]) + c2*(pa[1]-pb[1])) / dd
+		uy := (a2*(pc[0]-pb[0]) + b2*(pa[0]-pc[0]) + c2*(pb[0]-pa[0])) / dd
+		return triangle{v: [3]int{a, b, c}, cc: [2]float64{ux, uy}, r2: (pa[0]-ux)*(pa[0]-ux) + (pa[1]-uy)*(pa[1]-uy)}
+	}
+	triangles := []triangle{new_triangle(n+0, n+1, n+2)}
+	used := map[[2]float32]bool{}
+	for i := 0; i < n; i++ {
+		if used[points[i]] {
+			continue
+		}
+		used[points[i]] = true
+		p := pts[i]
+		// find all the triangles whose circumcircle contains the point, and collect their boundary edges
+		edge_count := map[[2]int]int{}
+		edges := [][2]int{}
+		kept := make([]triangle, 0, len(triangles))
+		for _, t := range triangles {
+			dx, dy := p[0]-t.cc[0], p[1]-t.cc[1]
+			if dx*dx+dy*dy <= t.r2*(1+1e-12) {
+				for k := 0; k < 3; k++ {
+					e := [2]int{t.v[k], t.v[(k+1)%3]}
+					key := [2]int{e[0], e[1]}
+					if key[0] > key[1] {
+						key = [2]int{key[1], key[0]}
+					}
+					if edge_count[key] == 0 {
+						edges = append(edges, e)
+					}
+					edge_count[key]++
+				}
+			} else {
+				kept = append(kept, t)
+			}
+		}
+		triangles = kept
+		for _, e := range edges { // re-triangulate the polygonal hole with the new point
+			key := e
+			if key[0] > key[1] {
+				key = [2]int{key[1], key[0]}
+			}
+			if edge_count[key] == 1 {
+				triangles = append(triangles, new_triangle(e[0], e[1], i))
+			}
+		}
+	}
+	result := [][3]int{}
+	for _, t := range triangles {
+		if t.v[0] < n && t.v[1] < n && t.v[2] < n && !math.IsInf(t.r2, 1) {
+			result = append(result, t.v)
+		}
+	}
+	return result
+}
+
+// ----------------------------------------------------------------------------
+// Voronoi Cells
+// ----------------------------------------------------------------------------
+
+func VoronoiCells(points [][2]float32, bbox [2][2]float32) [][][2]float32 {
+	// Return the Voronoi cell (as CCW polygon) of each point, clipped by the bounding box.
+	// Each cell is built by clipping the bounding box with the bisectors of the Delaunay neighbors,
+	// or of the consecutive points along the line, if all the points are collinear (without any triangle).
+	// (Cells of duplicated points are empty, except for the first one)
+	n := len(points)
+	neighbors := make([]map[int]bool, n)
+	for i := 0; i < n; i++ {
+		neighbors[i] = map[int]bool{}
+	}
+	triangles := DelaunayTriangulation(points)
+	for _, t := range triangles {
+		for k := 0; k < 3; k++ {
+			a, b := t[k], t[(k+1)%3]
+			neighbors[a][b], neighbors[b][a] = true, true
+		}
+	}
+	if len(triangles) == 0 { // collinear points (or less than 3 points)
+		order, used := []int{}, map[[2]float32]bool{}
+		for i := 0; i < n; i++ {
+			if !used[points[i]] {
+				used[points[i]] = true
+				order = append(order, i)
+			}
+		}
+		sort.SliceStable(order, func(i, j int) bool { // sorted along the line
+			a, b := points[order[i]], points[order[j]]
+			return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+		})
+		for k := 1; k < len(order); k++ {
+			a, b := order[k-1], order[k]
+			neighbors[a][b], neighbors[b][a] = true, true
+		}
+	}
+	is_first := map[[2]float32]bool{}
+	cells := make([][][2]float32, n)
+	for i := 0; i < n; i++ {
+		cells[i] = [][2]float32{}
+		if is_first[points[i]] {
+			continue // duplicated point
+		}
+		is_first[points[i]] = true
+		cell := [][2]float64{
+			{float64(bbox[0][0]), float64(bbox[0][1])}, {float64(bbox[1][0]), float64(bbox[0][1])},
+			{float64(bbox[1][0]), float64(bbox[1][1])}, {float64(bbox[0][0]), float64(bbox[1][1])}}
+		nlist := []int{}
+		for j := range neighbors[i] {
+			nlist = append(nlist, j)
+		}
+		sort.Ints(nlist) // for deterministic result
+		p := [2]float64{float64(points[i][0]), float64(points[i][1])}
+		for _, j := range nlist {
+			q := [2]float64{float64(points[j][0]), float64(points[j][1])}
+			// keep the half-plane closer to 'p' :  dot(x - m, q - p) <= 0, where m is the midpoint
+			nx, ny := q[0]-p[0], q[1]-p[1]
+			c := nx*(p[0]+q[0])/2 + ny*(p[1]+q[1])/2
+			cell = clip_polygon_by_halfplane(cell, nx, ny, c)
+			if len(cell) == 0 {
+				break
+			}
+		}
+		for _, v := range cell {
+			cells[i] = append(cells[i], [2]float32{float32(v[0]), float32(v[1])})
+		}
+	}
+	return cells
+}
+
+func clip_polygon_by_halfplane(polygon [][2]float64, nx float64, ny float64, c float64) [][2]float64 {
+	// Sutherland-Hodgman clipping, keeping the part where (nx*x + ny*y <= c)
+	result := [][2]float64{}
+	for i := 0; i < len(polygon); i++ {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		da, db := nx*a[0]+ny*a[1]-c, nx*b[0]+ny*b[1]-c
+		if da <= 0 {
+			result = append(result, a)
+		}
+		if (da < 0 && db > 0) || (da > 0 && db < 0) {
+			t := da / (da - db)
+			result = append(result, [2]float64{a[0] + (b[0]-a[0])*t, a[1] + (b[1]-a[1])*t})
+		}
+	}
+	return result
+}
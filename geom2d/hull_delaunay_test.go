@@ -0,0 +1,179 @@
+package geom2d
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func random_points(n int, seed int64) [][2]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	points := make([][2]float32, n)
+	for i := range points {
+		points[i] = [2]float32{rng.Float32()*10 - 5, rng.Float32()*10 - 5}
+	}
+	return points
+}
+
+func cross_of(o [2]float32, a [2]float32, b [2]float32) float64 {
+	return float64(a[0]-o[0])*float64(b[1]-o[1]) - float64(a[1]-o[1])*float64(b[0]-o[0])
+}
+
+func TestConvexHull(t *testing.T) {
+	tests := []struct {
+		name   string
+		points [][2]float32
+		want   int // number of points on the hull
+	}{
+		{"square", [][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0.5, 0.5}}, 4},
+		{"duplicates", [][2]float32{{0, 0}, {1, 0}, {0, 0}, {1, 1}, {1, 0}, {0, 1}}, 4},
+		{"collinear_edge", [][2]float32{{0, 0}, {0.5, 0}, {1, 0}, {1, 1}, {0, 1}}, 4},
+		{"collinear", [][2]float32{{0, 0}, {1, 0}, {2, 0}, {3, 0}}, 2},
+		{"two", [][2]float32{{0, 0}, {1, 1}}, 2},
+		{"random", random_points(100, 1), -1},
+	}
+	for _, tt := range tests {
+		hull := ConvexHull(tt.points)
+		if tt.want >= 0 && len(hull) != tt.want {
+			t.Errorf("%s: ConvexHull() = %v, want %d points", tt.name, hull, tt.want)
+			continue
+		}
+		if len(hull) < 3 {
+			continue
+		}
+		for k := range hull { // CCW, with every point on the left side of the edges
+			a, b := tt.points[hull[k]], tt.points[hull[(k+1)%len(hull)]]
+			if c := cross_of(a, b, tt.points[hull[(k+2)%len(hull)]]); c <= 0 {
+				t.Errorf("%s: ConvexHull() = %v is not strictly CCW at %d", tt.name, hull, k)
+			}
+			for i, p := range tt.points {
+				if c := cross_of(a, b, p); c < -1e-9 {
+					t.Errorf("%s: point %d %v is outside of the hull edge %d", tt.name, i, p, k)
+				}
+			}
+		}
+	}
+}
+
+func TestDelaunayTriangulation(t *testing.T) {
+	tests := []struct {
+		name   string
+		points [][2]float32
+		want   int // number of triangles
+	}{
+		{"square", [][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1.1}}, 2},
+		{"square_with_center", [][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0.5, 0.5}}, 4},
+		{"duplicates", [][2]float32{{0, 0}, {1, 0}, {0, 0}, {1, 1.1}, {1, 0}, {0, 1}}, 2},
+		{"collinear", [][2]float32{{0, 0}, {1, 0}, {2, 0}, {3, 0}}, 0},
+		{"two", [][2]float32{{0, 0}, {1, 1}}, 0},
+		{"random", random_points(100, 2), -1},
+	}
+	for _, tt := range tests {
+		triangles := DelaunayTriangulation(tt.points)
+		if tt.want >= 0 && len(triangles) != tt.want {
+			t.Errorf("%s: DelaunayTriangulation() = %v, want %d triangles", tt.name, triangles, tt.want)
+			continue
+		}
+		area := 0.0
+		for _, tri := range triangles {
+			a, b, c := tt.points[tri[0]], tt.points[tri[1]], tt.points[tri[2]]
+			if cross_of(a, b, c) <= 0 {
+				t.Errorf("%s: triangle %v is not CCW", tt.name, tri)
+			}
+			area += cross_of(a, b, c) / 2
+			// empty circumcircle : no other point is inside of the circumcircle
+			for i, p := range tt.points {
+				if p == a || p == b || p == c {
+					continue
+				}
+				if d := in_circle(a, b, c, p); d > 1e-6 {
+					t.Errorf("%s: point %d %v is inside of the circumcircle of %v (%v)", tt.name, i, p, tri, d)
+				}
+			}
+		}
+		// triangles cover the convex hull without overlap
+		hull, hull_area := ConvexHull(tt.points), 0.0
+		for k := 1; k+1 < len(hull); k++ {
+			hull_area += cross_of(tt.points[hull[0]], tt.points[hull[k]], tt.points[hull[k+1]]) / 2
+		}
+		if math.Abs(area-hull_area) > 1e-4*math.Max(hull_area, 1) {
+			t.Errorf("%s: area of triangles %v, want %v (area of the convex hull)", tt.name, area, hull_area)
+		}
+	}
+}
+
+func in_circle(a [2]float32, b [2]float32, c [2]float32, p [2]float32) float64 {
+	// positive if 'p' is inside of the circumcircle of CCW triangle (a, b, c)
+	adx, ady := float64(a[0]-p[0]), float64(a[1]-p[1])
+	bdx, bdy := float64(b[0]-p[0]), float64(b[1]-p[1])
+	cdx, cdy := float64(c[0]-p[0]), float64(c[1]-p[1])
+	ad, bd, cd := adx*adx+ady*ady, bdx*bdx+bdy*bdy, cdx*cdx+cdy*cdy
+	return adx*(bdy*cd-bd*cdy) - ady*(bdx*cd-bd*cdx) + ad*(bdx*cdy-bdy*cdx)
+}
+
+func TestVoronoiCells(t *testing.T) {
+	tests := []struct {
+		name   string
+		points [][2]float32
+		bbox   [2][2]float32
+		xrange [][2]float32 // expected X range of each cell (only for collinear points)
+	}{
+		{"square", [][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}}, [2][2]float32{{-1, -1}, {2, 2}}, nil},
+		{"duplicates", [][2]float32{{0, 0}, {1, 0}, {0, 0}, {1, 1}}, [2][2]float32{{-1, -1}, {2, 2}}, nil},
+		{"collinear", [][2]float32{{0, 0}, {1, 0}, {2, 0}, {3, 0}}, [2][2]float32{{-1, -1}, {4, 1}},
+			[][2]float32{{-1, 0.5}, {0.5, 1.5}, {1.5, 2.5}, {2.5, 4}}},
+		{"collinear_unsorted", [][2]float32{{2, 0}, {0, 0}, {3, 0}, {1, 0}}, [2][2]float32{{-1, -1}, {4, 1}},
+			[][2]float32{{1.5, 2.5}, {-1, 0.5}, {2.5, 4}, {0.5, 1.5}}},
+		{"single", [][2]float32{{0, 0}}, [2][2]float32{{-1, -1}, {1, 1}}, [][2]float32{{-1, 1}}},
+		{"random", random_points(50, 3), [2][2]float32{{-6, -6}, {6, 6}}, nil},
+	}
+	for _, tt := range tests {
+		cells := VoronoiCells(tt.points, tt.bbox)
+		if len(cells) != len(tt.points) {
+			t.Errorf("%s: VoronoiCells() returned %d cells, want %d", tt.name, len(cells), len(tt.points))
+			continue
+		}
+		total_area, seen := 0.0, map[[2]float32]bool{}
+		for i, cell := range cells {
+			if seen[tt.points[i]] {
+				if len(cell) != 0 {
+					t.Errorf("%s: cell %d of duplicated point = %v, want empty", tt.name, i, cell)
+				}
+				continue
+			}
+			seen[tt.points[i]] = true
+			if len(cell) < 3 {
+				t.Errorf("%s: cell %d = %v, want a polygon", tt.name, i, cell)
+				continue
+			}
+			for k := 1; k+1 < len(cell); k++ {
+				total_area += cross_of(cell[0], cell[k], cell[k+1]) / 2
+			}
+			// every vertex of the cell is closer to its own point than to the others
+			for _, v := range cell {
+				own := Length(SubAB(v, tt.points[i]))
+				for j, q := range tt.points {
+					if d := Length(SubAB(v, q)); d < own-1e-4 {
+						t.Errorf("%s: vertex %v of cell %d is closer to point %d", tt.name, v, i, j)
+					}
+				}
+			}
+			if tt.xrange != nil {
+				xs := []float32{}
+				for _, v := range cell {
+					xs = append(xs, v[0])
+				}
+				sort.Slice(xs, func(a, b int) bool { return xs[a] < xs[b] })
+				if math.Abs(float64(xs[0]-tt.xrange[i][0])) > 1e-5 || math.Abs(float64(xs[len(xs)-1]-tt.xrange[i][1])) > 1e-5 {
+					t.Errorf("%s: cell %d = %v, want X range %v", tt.name, i, cell, tt.xrange[i])
+				}
+			}
+		}
+		// cells cover the bounding box without overlap
+		bbox_area := float64(tt.bbox[1][0]-tt.bbox[0][0]) * float64(tt.bbox[1][1]-tt.bbox[0][1])
+		if math.Abs(total_area-bbox_area) > 1e-3 {
+			t.Errorf("%s: total area of cells %v, want %v", tt.name, total_area, bbox_area)
+		}
+	}
+}
@@ -45,6 +45,14 @@ func (self *Matrix3) SetTranspose() *Matrix3 {
 	return self
 }
 
+func (self *Matrix3) SetInverse() *Matrix3 {
+	// Invert the matrix in place (unchanged, if the matrix is singular)
+	if inverse := self.Inverse(); inverse != nil {
+		self.elements = inverse.elements
+	}
+	return self
+}
+
 func (self *Matrix3) SetTranslation(tx float32, ty float32) *Matrix3 {
 	self.Set(
 		1.0, 0.0, tx,
@@ -83,6 +91,25 @@ func (self *Matrix3) SetMultiplyMatrices(matrices ...*Matrix3) *Matrix3 {
 	return self
 }
 
+// ----------------------------------------------------------------------------
+// Properties
+// ----------------------------------------------------------------------------
+
+func (self *Matrix3) Determinant() float32 {
+	o := &self.elements // reference
+	return o[0]*(o[4]*o[8]-o[5]*o[7]) - o[3]*(o[1]*o[8]-o[2]*o[7]) + o[6]*(o[1]*o[5]-o[2]*o[4])
+}
+
+func (self *Matrix3) IsEqual(m *Matrix3, epsilon float32) bool {
+	// Check if all the elements are equal (within 'epsilon')
+	for i := 0; i < 9; i++ {
+		if d := self.elements[i] - m.elements[i]; d > epsilon || d < -epsilon {
+			return false
+		}
+	}
+	return true
+}
+
 // ----------------------------------------------------------------------------
 // Creating new matrix
 // ----------------------------------------------------------------------------
@@ -93,7 +120,27 @@ func (self *Matrix3) Copy() *Matrix3 {
 
 func (self *Matrix3) Transpose() *Matrix3 {
 	o := &self.elements // reference
-	return &Matrix3{elements: [9]float32{o[0], o[1], o[2], o[3], o[4], o[5], o[6], o[7], o[8]}}
+	return &Matrix3{elements: [9]float32{o[0], o[3], o[6], o[1], o[4], o[7], o[2], o[5], o[8]}}
+}
+
+func (self *Matrix3) Inverse() *Matrix3 {
+	// Inverse matrix (nil, if the matrix is singular)
+	o := &self.elements // reference
+	det := self.Determinant()
+	if det == 0 {
+		return nil
+	}
+	d := 1 / det
+	return &Matrix3{elements: [9]float32{ // adjugate (transpose of cofactors) divided by determinant
+		(o[4]*o[8] - o[5]*o[7]) * d,
+		(o[2]*o[7] - o[1]*o[8]) * d,
+		(o[1]*o[5] - o[2]*o[4]) * d,
+		(o[5]*o[6] - o[3]*o[8]) * d,
+		(o[0]*o[8] - o[2]*o[6]) * d,
+		(o[2]*o[3] - o[0]*o[5]) * d,
+		(o[3]*o[7] - o[4]*o[6]) * d,
+		(o[1]*o[6] - o[0]*o[7]) * d,
+		(o[0]*o[4] - o[1]*o[3]) * d}}
 }
 
 func (self *Matrix3) MultiplyToTheLeft(matrix *Matrix3) *Matrix3 {
@@ -136,3 +183,11 @@ func (self *Matrix3) MultiplyVector2(v [2]float32) [2]float32 {
 		e[0]*v[0] + e[3]*v[1] + e[6], // COLUMN-MAJOR
 		e[1]*v[0] + e[4]*v[1] + e[7]}
 }
+
+func (self *Matrix3) MultiplyVector3(v [3]float32) [3]float32 {
+	e := &self.elements // reference
+	return [3]float32{
+		e[0]*v[0] + e[3]*v[1] + e[6]*v[2], // COLUMN-MAJOR
+		e[1]*v[0] + e[4]*v[1] + e[7]*v[2],
+		e[2]*v[0] + e[5]*v[1] + e[8]*v[2]}
+}
@@ -0,0 +1,137 @@
+package geom2d
+
+import "testing"
+
+func TestMatrix3Multiply(t *testing.T) {
+	tests := []struct {
+		name string
+		a    *Matrix3
+		b    *Matrix3
+		want *Matrix3 // a * b
+	}{
+		{"identity", NewMatrix3(), NewMatrix3().Set(1, 2, 3, 4, 5, 6, 7, 8, 9), NewMatrix3().Set(1, 2, 3, 4, 5, 6, 7, 8, 9)},
+		{"general", NewMatrix3().Set(1, 2, 3, 4, 5, 6, 7, 8, 9), NewMatrix3().Set(9, 8, 7, 6, 5, 4, 3, 2, 1),
+			NewMatrix3().Set(30, 24, 18, 84, 69, 54, 138, 114, 90)},
+		{"translations", NewMatrix3().SetTranslation(1, 2), NewMatrix3().SetTranslation(3, -5), NewMatrix3().SetTranslation(4, -3)},
+		{"rotations", NewMatrix3().SetRotation(30), NewMatrix3().SetRotation(60), NewMatrix3().SetRotation(90)},
+	}
+	for _, tt := range tests {
+		if m := tt.a.MultiplyToTheRight(tt.b); !m.IsEqual(tt.want, 1e-5) {
+			t.Errorf("%s: a.MultiplyToTheRight(b) = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+		if m := tt.b.MultiplyToTheLeft(tt.a); !m.IsEqual(tt.want, 1e-5) {
+			t.Errorf("%s: b.MultiplyToTheLeft(a) = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+		if m := NewMatrix3().SetMultiplyMatrices(tt.a, tt.b); !m.IsEqual(tt.want, 1e-5) {
+			t.Errorf("%s: SetMultiplyMatrices(a, b) = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+	}
+}
+
+func TestMatrix3Transpose(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *Matrix3
+		want *Matrix3
+	}{
+		{"identity", NewMatrix3(), NewMatrix3()},
+		{"general", NewMatrix3().Set(1, 2, 3, 4, 5, 6, 7, 8, 9), NewMatrix3().Set(1, 4, 7, 2, 5, 8, 3, 6, 9)},
+		{"translation", NewMatrix3().SetTranslation(2, 3), NewMatrix3().Set(1, 0, 0, 0, 1, 0, 2, 3, 1)},
+	}
+	for _, tt := range tests {
+		if m := tt.m.Transpose(); !m.IsEqual(tt.want, 0) {
+			t.Errorf("%s: Transpose() = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+		if m := tt.m.Copy().SetTranspose(); !m.IsEqual(tt.want, 0) {
+			t.Errorf("%s: SetTranspose() = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+	}
+}
+
+func TestMatrix3Inverse(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        *Matrix3
+		singular bool
+	}{
+		{"identity", NewMatrix3(), false},
+		{"translation", NewMatrix3().SetTranslation(3, -4), false},
+		{"rotation", NewMatrix3().SetRotation(37), false},
+		{"scaling", NewMatrix3().SetScaling(2, 0.5), false},
+		{"general", NewMatrix3().Set(2, 0, 1, 1, 3, 2, 1, 1, 2), false},
+		{"zero", NewMatrix3().Set(0, 0, 0, 0, 0, 0, 0, 0, 0), true},
+		{"singular", NewMatrix3().Set(1, 2, 3, 4, 5, 6, 7, 8, 9), true},
+		{"zero_scaling", NewMatrix3().SetScaling(0, 1), true},
+	}
+	for _, tt := range tests {
+		inverse := tt.m.Inverse()
+		if tt.singular {
+			if inverse != nil {
+				t.Errorf("%s: Inverse() = %v, want nil", tt.name, inverse.elements)
+			}
+			if m := tt.m.Copy().SetInverse(); !m.IsEqual(tt.m, 0) {
+				t.Errorf("%s: SetInverse() changed singular matrix to %v", tt.name, m.elements)
+			}
+			continue
+		}
+		if inverse == nil {
+			t.Errorf("%s: Inverse() = nil", tt.name)
+			continue
+		}
+		if m := tt.m.MultiplyToTheRight(inverse); !m.IsEqual(NewMatrix3(), 1e-5) {
+			t.Errorf("%s: M * Inverse() = %v, want identity", tt.name, m.elements)
+		}
+		if m := tt.m.Copy().SetInverse(); !m.IsEqual(inverse, 0) {
+			t.Errorf("%s: SetInverse() = %v, want %v", tt.name, m.elements, inverse.elements)
+		}
+	}
+}
+
+func TestMatrix3Transformations(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *Matrix3
+		v    [2]float32
+		want [2]float32
+	}{
+		{"translation", NewMatrix3().SetTranslation(3, -4), [2]float32{1, 2}, [2]float32{4, -2}},
+		{"scaling", NewMatrix3().SetScaling(2, 3), [2]float32{1, 2}, [2]float32{2, 6}},
+		{"rotation_90", NewMatrix3().SetRotation(90), [2]float32{1, 0}, [2]float32{0, 1}},
+		{"rotation_180", NewMatrix3().SetRotation(180), [2]float32{1, 2}, [2]float32{-1, -2}},
+		{"translation_after_rotation", NewMatrix3().SetMultiplyMatrices(NewMatrix3().SetTranslation(1, 1), NewMatrix3().SetRotation(90)),
+			[2]float32{1, 0}, [2]float32{1, 2}},
+	}
+	for _, tt := range tests {
+		if v := tt.m.MultiplyVector2(tt.v); !Vector2(v).IsEqual(Vector2(tt.want), 1e-5) {
+			t.Errorf("%s: MultiplyVector2(%v) = %v, want %v", tt.name, tt.v, v, tt.want)
+		}
+	}
+}
+
+func TestVector2(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     Vector2
+		b     Vector2
+		dot   float32
+		cross float32
+	}{
+		{"orthogonal", Vector2{1, 0}, Vector2{0, 1}, 0, 1},
+		{"opposite", Vector2{1, 2}, Vector2{-1, -2}, -5, 0},
+		{"general", Vector2{2, 3}, Vector2{4, -1}, 5, -14},
+	}
+	for _, tt := range tests {
+		if d := tt.a.Dot(tt.b); d != tt.dot {
+			t.Errorf("%s: Dot() = %v, want %v", tt.name, d, tt.dot)
+		}
+		if c := tt.a.Cross(tt.b); c != tt.cross {
+			t.Errorf("%s: Cross() = %v, want %v", tt.name, c, tt.cross)
+		}
+		if n := tt.a.Normalize(); n.Length() < 0.99999 || n.Length() > 1.00001 {
+			t.Errorf("%s: Normalize() = %v, with length %v", tt.name, n, n.Length())
+		}
+	}
+	if n := (Vector2{0, 0}).Normalize(); n != (Vector2{0, 0}) {
+		t.Errorf("zero: Normalize() = %v, want zero vector", n)
+	}
+}
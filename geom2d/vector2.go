@@ -0,0 +1,105 @@
+package geom2d
+
+import "math"
+
+// Vector2 is a 2D vector with methods, which can be converted to/from [2]float32 directly,
+// like 'geom2d.Vector2(v).Add(u)' or '[2]float32(v)'.
+type Vector2 [2]float32
+
+func NewVector2(x float32, y float32) Vector2 {
+	return Vector2{x, y}
+}
+
+func (self Vector2) Add(v Vector2) Vector2 {
+	return Vector2{self[0] + v[0], self[1] + v[1]}
+}
+
+func (self Vector2) Sub(v Vector2) Vector2 {
+	return Vector2{self[0] - v[0], self[1] - v[1]}
+}
+
+func (self Vector2) Scale(s float32) Vector2 {
+	return Vector2{self[0] * s, self[1] * s}
+}
+
+func (self Vector2) Dot(v Vector2) float32 {
+	return self[0]*v[0] + self[1]*v[1]
+}
+
+func (self Vector2) Cross(v Vector2) float32 {
+	return self[0]*v[1] - self[1]*v[0] // Z component of the 3D cross product
+}
+
+func (self Vector2) Length() float32 {
+	return float32(math.Sqrt(float64(self[0]*self[0] + self[1]*self[1])))
+}
+
+func (self Vector2) Normalize() Vector2 {
+	// unit vector (zero vector remains zero)
+	length := self.Length()
+	if length == 0 {
+		return self
+	}
+	return Vector2{self[0] / length, self[1] / length}
+}
+
+func (self Vector2) DistanceTo(v Vector2) float32 {
+	return self.Sub(v).Length()
+}
+
+func (self Vector2) Lerp(v Vector2, t float32) Vector2 {
+	return Vector2{self[0] + (v[0]-self[0])*t, self[1] + (v[1]-self[1])*t}
+}
+
+func (self Vector2) Perpendicular() Vector2 {
+	return Vector2{-self[1], self[0]} // rotated by 90 degree (CCW)
+}
+
+func (self Vector2) IsEqual(v Vector2, epsilon float32) bool {
+	dx, dy := self[0]-v[0], self[1]-v[1]
+	return dx <= epsilon && dx >= -epsilon && dy <= epsilon && dy >= -epsilon
+}
+
+func (self Vector2) Transform(m *Matrix3) Vector2 {
+	return Vector2(m.MultiplyVector2(self))
+}
+
+// ----------------------------------------------------------------------------
+// Vector2d (double-precision)
+// ----------------------------------------------------------------------------
+
+// Vector2d is the double-precision version of Vector2, for the coordinates too large for float32.
+type Vector2d [2]float64
+
+func NewVector2d(x float64, y float64) Vector2d {
+	return Vector2d{x, y}
+}
+
+func (self Vector2d) Add(v Vector2d) Vector2d {
+	return Vector2d{self[0] + v[0], self[1] + v[1]}
+}
+
+func (self Vector2d) Sub(v Vector2d) Vector2d {
+	return Vector2d{self[0] - v[0], self[1] - v[1]}
+}
+
+func (self Vector2d) Scale(s float64) Vector2d {
+	return Vector2d{self[0] * s, self[1] * s}
+}
+
+func (self Vector2d) Dot(v Vector2d) float64 {
+	return self[0]*v[0] + self[1]*v[1]
+}
+
+func (self Vector2d) Length() float64 {
+	return math.Sqrt(self.Dot(self))
+}
+
+func (self Vector2d) DistanceTo(v Vector2d) float64 {
+	return self.Sub(v).Length()
+}
+
+func (self Vector2d) ToVector2() Vector2 {
+	// single-precision copy (use it only for small values, like the ones relative to the camera)
+	return Vector2{float32(self[0]), float32(self[1])}
+}
@@ -0,0 +1,133 @@
+package geom3d
+
+import "math"
+
+// ----------------------------------------------------------------------------
+// Convex Hull
+// ----------------------------------------------------------------------------
+
+func ConvexHull(points [][3]float32) [][3]int {
+	// Return the triangular faces (as indices of the points, CCW seen from outside) of the convex hull,
+	// using the incremental algorithm. Coplanar hull faces are returned as multiple triangles.
+	// If all the points are coplanar (or less than 4), then an empty list is returned.
+	n := len(points)
+	if n < 4 {
+		return [][3]int{}
+	}
+	pts := make([][3]float64, n)
+	for i, p := range points {
+		pts[i] = [3]float64{float64(p[0]), float64(p[1]), float64(p[2])}
+	}
+	sub := func(a [3]float64, b [3]float64) [3]float64 { return [3]float64{a[0] - b[0], a[1] - b[1], a[2] - b[2]} }
+	dot := func(a [3]float64, b [3]float64) float64 { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+	cross := func(a [3]float64, b [3]float64) [3]float64 {
+		return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+	}
+	// tolerance relative to the size of the point set
+	extent := 0.0
+	for _, p := range pts {
+		extent = math.Max(extent, math.Max(math.Abs(p[0]), math.Max(math.Abs(p[1]), math.Abs(p[2]))))
+	}
+	eps := 1e-9 * math.Max(extent, 1e-6)
+	// initial tetrahedron with the points far from each other
+	i0, i1 := 0, 0
+	for i := 1; i < n; i++ {
+		if pts[i][0] < pts[i0][0] {
+			i0 = i
+		}
+		if pts[i][0] > pts[i1][0] {
+			i1 = i
+		}
+	}
+	if i0 == i1 {
+		for i := 0; i < n; i++ {
+			if dot(sub(pts[i], pts[i0]), sub(pts[i], pts[i0])) > eps*eps {
+				i1 = i
+				break
+			}
+		}
+		if i0 == i1 {
+			return [][3]int{}
+		}
+	}
+	i2, best := -1, 0.0
+	for i := 0; i < n; i++ {
+		c := cross(sub(pts[i1], pts[i0]), sub(pts[i], pts[i0]))
+		if d := dot(c, c); d > best {
+			i2, best = i, d
+		}
+	}
+	if i2 < 0 || best <= eps*eps*eps*eps {
+		return [][3]int{}
+	}
+	i3, best := -1, 0.0
+	nrm := cross(sub(pts[i1], pts[i0]), sub(pts[i2], pts[i0]))
+	for i := 0; i < n; i++ {
+		if d := math.Abs(dot(nrm, sub(pts[i], pts[i0]))); d > best {
+			i3, best = i, d
+		}
+	}
+	if i3 < 0 || best <= eps*math.Sqrt(dot(nrm, nrm)) {
+		return [][3]int{} // all the points are coplanar
+	}
+	type hface struct {
+		v      [3]int
+		normal [3]float64
+		offset float64
+		alive  bool
+	}
+	faces := []*hface{}
+	add_face := func(a int, b int, c int) {
+		normal := cross(sub(pts[b], pts[a]), sub(pts[c], pts[a]))
+		length := math.Sqrt(dot(normal, normal))
+		if length > 0 {
+			normal = [3]float64{normal[0] / length, normal[1] / length, normal[2] / length}
+		}
+		faces = append(faces, &hface{v: [3]int{a, b, c}, normal: normal, offset: dot(normal, pts[a]), alive: true})
+	}
+	if dot(nrm, sub(pts[i3], pts[i0])) > 0 { // make the faces to look outward
+		i1, i2 = i2, i1
+	}
+	add_face(i0, i1, i2)
+	add_face(i0, i3, i1)
+	add_face(i1, i3, i2)
+	add_face(i2, i3, i0)
+	for i := 0; i < n; i++ {
+		if i == i0 || i == i1 || i == i2 || i == i3 {
+			continue
+		}
+		p := pts[i]
+		visible := []*hface{}
+		for _, f := range faces {
+			if f.alive && dot(f.normal, p)-f.offset > eps {
+				visible = append(visible, f)
+			}
+		}
+		if len(visible) == 0 {
+			continue // the point is inside the hull
+		}
+		// horizon edges are the edges of visible faces, which are not shared by another visible face
+		edge_count := map[[2]int]int{}
+		for _, f := range visible {
+			f.alive = false
+			for k := 0; k < 3; k++ {
+				edge_count[[2]int{f.v[k], f.v[(k+1)%3]}]++
+			}
+		}
+		for _, f := range visible {
+			for k := 0; k < 3; k++ {
+				a, b := f.v[k], f.v[(k+1)%3]
+				if edge_count[[2]int{b, a}] == 0 {
+					add_face(a, b, i)
+				}
+			}
+		}
+	}
+	result := [][3]int{}
+	for _, f := range faces {
+		if f.alive {
+			result = append(result, f.v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package geom3d
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestConvexHull(t *testing.T) {
+	cube := [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {0, 0, 1}, {1, 0, 1}, {1, 1, 1}, {0, 1, 1}}
+	rng := rand.New(rand.NewSource(1))
+	random := make([][3]float32, 200)
+	for i := range random {
+		random[i] = [3]float32{rng.Float32()*2 - 1, rng.Float32()*2 - 1, rng.Float32()*2 - 1}
+	}
+	tests := []struct {
+		name   string
+		points [][3]float32
+		volume float64 // expected volume (negative to skip the check)
+		empty  bool
+	}{
+		{"tetrahedron", [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}}, 1.0 / 6, false},
+		{"cube", cube, 1, false},
+		{"cube_with_inner_points", append(append([][3]float32{}, cube...), [3]float32{0.5, 0.5, 0.5}, [3]float32{0.2, 0.7, 0.4}), 1, false},
+		{"duplicates", append(append([][3]float32{}, cube...), cube...), 1, false},
+		{"coplanar", [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {0.5, 0.5, 0}}, 0, true},
+		{"collinear", [][3]float32{{0, 0, 0}, {1, 1, 1}, {2, 2, 2}, {3, 3, 3}}, 0, true},
+		{"three", [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}, 0, true},
+		{"random", random, -1, false},
+	}
+	for _, tt := range tests {
+		faces := ConvexHull(tt.points)
+		if tt.empty {
+			if len(faces) != 0 {
+				t.Errorf("%s: ConvexHull() = %v, want empty", tt.name, faces)
+			}
+			continue
+		}
+		if len(faces) < 4 {
+			t.Errorf("%s: ConvexHull() = %v, want a closed hull", tt.name, faces)
+			continue
+		}
+		// closed surface : every edge is shared by exactly two faces, in opposite directions
+		edges := map[[2]int]int{}
+		for _, f := range faces {
+			for k := 0; k < 3; k++ {
+				edges[[2]int{f[k], f[(k+1)%3]}]++
+			}
+		}
+		for e, count := range edges {
+			if count != 1 || edges[[2]int{e[1], e[0]}] != 1 {
+				t.Errorf("%s: edge %v is not shared by two faces in opposite directions", tt.name, e)
+			}
+		}
+		// faces look outward, with all the points behind (or on) them
+		volume := 0.0
+		for _, f := range faces {
+			a, b, c := tt.points[f[0]], tt.points[f[1]], tt.points[f[2]]
+			normal := CrossAB(SubAB(b, a), SubAB(c, a))
+			volume += float64(DotAB(a, CrossAB(b, c))) / 6
+			for i, p := range tt.points {
+				if d := DotAB(normal, SubAB(p, a)); d > 1e-5 {
+					t.Errorf("%s: point %d %v is outside of the face %v", tt.name, i, p, f)
+				}
+			}
+		}
+		if tt.volume >= 0 && math.Abs(volume-tt.volume) > 1e-5 {
+			t.Errorf("%s: volume of the hull = %v, want %v", tt.name, volume, tt.volume)
+		}
+		if volume <= 0 {
+			t.Errorf("%s: volume of the hull = %v, want positive (faces looking outward)", tt.name, volume)
+		}
+	}
+}
@@ -30,6 +30,10 @@ func AverageAll(all [][3]float32) [3]float32 {
 	return [3]float32{sum[0] / count, sum[1] / count, sum[2] / count}
 }
 
+func Scale(v [3]float32, s float32) [3]float32 {
+	return [3]float32{v[0] * s, v[1] * s, v[2] * s}
+}
+
 func DotAB(a [3]float32, b [3]float32) float32 {
 	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
 }
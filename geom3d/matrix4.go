@@ -1,6 +1,10 @@
 package geom3d
 
-import "math"
+import (
+	"math"
+
+	"github.com/go4orward/gowebgl/geom2d"
+)
 
 type Matrix4 struct {
 	elements [16]float32 // COLUMN-MAJOR (just like WebGL)
@@ -87,6 +91,45 @@ func (self *Matrix4) SetRotationByAxis(axis [3]float32, angle_in_degree float32)
 	return self
 }
 
+func (self *Matrix4) SetRotationByQuaternion(q *Quaternion) *Matrix4 {
+	self.SetCopy(q.GetMatrix4())
+	return self
+}
+
+func (self *Matrix4) SetLookAt(eye [3]float32, center [3]float32, up [3]float32) *Matrix4 {
+	// Set the view matrix (from WORLD to CAMERA space) of the camera at 'eye', looking at 'center' (along -Z axis)
+	zaxis := Normalize(SubAB(eye, center))
+	xaxis := Normalize(CrossAB(up, zaxis))
+	if Length(xaxis) == 0 || xaxis[0] != xaxis[0] { // 'up' is parallel to the viewing direction
+		xaxis = Normalize(CrossAB([3]float32{0, 0, 1}, zaxis))
+		if Length(xaxis) == 0 || xaxis[0] != xaxis[0] {
+			xaxis = Normalize(CrossAB([3]float32{0, 1, 0}, zaxis))
+		}
+	}
+	yaxis := CrossAB(zaxis, xaxis)
+	self.Set(
+		xaxis[0], xaxis[1], xaxis[2], -DotAB(xaxis, eye),
+		yaxis[0], yaxis[1], yaxis[2], -DotAB(yaxis, eye),
+		zaxis[0], zaxis[1], zaxis[2], -DotAB(zaxis, eye),
+		0, 0, 0, 1)
+	return self
+}
+
+func (self *Matrix4) SetComposition(txyz [3]float32, rotation *Quaternion, sxyz [3]float32) *Matrix4 {
+	// Set the matrix as T * R * S (the opposite of Decompose())
+	translation := NewMatrix4().SetTranslation(txyz[0], txyz[1], txyz[2])
+	scaling := NewMatrix4().SetScaling(sxyz[0], sxyz[1], sxyz[2])
+	return self.SetMultiplyMatrices(translation, rotation.GetMatrix4(), scaling)
+}
+
+func (self *Matrix4) SetInverse() *Matrix4 {
+	// Invert the matrix in place (unchanged, if the matrix is singular)
+	if inverse := self.Inverse(); inverse != nil {
+		self.elements = inverse.elements
+	}
+	return self
+}
+
 func (self *Matrix4) SetMultiplyMatrices(matrices ...*Matrix4) *Matrix4 {
 	if len(matrices) > 0 {
 		m := matrices[0] // multiply all the matrices first,
@@ -98,6 +141,66 @@ func (self *Matrix4) SetMultiplyMatrices(matrices ...*Matrix4) *Matrix4 {
 	return self
 }
 
+// ----------------------------------------------------------------------------
+// Properties
+// ----------------------------------------------------------------------------
+
+func (self *Matrix4) Determinant() float32 {
+	o := &self.elements // reference
+	b00, b01, b02 := o[0]*o[5]-o[1]*o[4], o[0]*o[6]-o[2]*o[4], o[0]*o[7]-o[3]*o[4]
+	b03, b04, b05 := o[1]*o[6]-o[2]*o[5], o[1]*o[7]-o[3]*o[5], o[2]*o[7]-o[3]*o[6]
+	b06, b07, b08 := o[8]*o[13]-o[9]*o[12], o[8]*o[14]-o[10]*o[12], o[8]*o[15]-o[11]*o[12]
+	b09, b10, b11 := o[9]*o[14]-o[10]*o[13], o[9]*o[15]-o[11]*o[13], o[10]*o[15]-o[11]*o[14]
+	return b00*b11 - b01*b10 + b02*b09 + b03*b08 - b04*b07 + b05*b06
+}
+
+func (self *Matrix4) IsEqual(m *Matrix4, epsilon float32) bool {
+	// Check if all the elements are equal (within 'epsilon')
+	for i := 0; i < 16; i++ {
+		if d := self.elements[i] - m.elements[i]; d > epsilon || d < -epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func (self *Matrix4) Decompose() ([3]float32, *Quaternion, [3]float32) {
+	// Decompose the (affine) matrix into translation, rotation and scaling, such that M = T * R * S.
+	// Negative determinant (mirroring) is represented with negative scaling along X axis.
+	o := &self.elements // reference
+	txyz := [3]float32{o[12], o[13], o[14]}
+	sxyz := [3]float32{
+		Length([3]float32{o[0], o[1], o[2]}),
+		Length([3]float32{o[4], o[5], o[6]}),
+		Length([3]float32{o[8], o[9], o[10]})}
+	if self.Determinant() < 0 {
+		sxyz[0] = -sxyz[0]
+	}
+	r := NewMatrix4()
+	for c := 0; c < 3; c++ {
+		if sxyz[c] != 0 {
+			for k := 0; k < 3; k++ {
+				r.elements[c*4+k] = o[c*4+k] / sxyz[c]
+			}
+		}
+	}
+	return txyz, NewQuaternion().SetRotationByMatrix4(r), sxyz
+}
+
+func (self *Matrix4) GetNormalMatrix() *geom2d.Matrix3 {
+	// Normal matrix (inverse transpose of the upper-left 3x3 matrix), for transforming normal vectors
+	// correctly even with non-uniform scaling. (identity, if the matrix is singular)
+	o := &self.elements // reference
+	m := geom2d.NewMatrix3().Set(
+		o[0], o[4], o[8],
+		o[1], o[5], o[9],
+		o[2], o[6], o[10])
+	if inverse := m.Inverse(); inverse != nil {
+		return inverse.Transpose()
+	}
+	return geom2d.NewMatrix3()
+}
+
 // ----------------------------------------------------------------------------
 // Creating new matrix
 // ----------------------------------------------------------------------------
@@ -109,10 +212,41 @@ func (self *Matrix4) Copy() *Matrix4 {
 func (self *Matrix4) Transpose() *Matrix4 {
 	o := &self.elements // reference
 	return &Matrix4{elements: [16]float32{
-		o[0], o[1], o[2], o[3],
-		o[4], o[5], o[6], o[7],
-		o[8], o[9], o[10], o[11],
-		o[12], o[13], o[14], o[15]}}
+		o[0], o[4], o[8], o[12],
+		o[1], o[5], o[9], o[13],
+		o[2], o[6], o[10], o[14],
+		o[3], o[7], o[11], o[15]}}
+}
+
+func (self *Matrix4) Inverse() *Matrix4 {
+	// Inverse matrix (nil, if the matrix is singular)
+	o := &self.elements // reference
+	b00, b01, b02 := o[0]*o[5]-o[1]*o[4], o[0]*o[6]-o[2]*o[4], o[0]*o[7]-o[3]*o[4]
+	b03, b04, b05 := o[1]*o[6]-o[2]*o[5], o[1]*o[7]-o[3]*o[5], o[2]*o[7]-o[3]*o[6]
+	b06, b07, b08 := o[8]*o[13]-o[9]*o[12], o[8]*o[14]-o[10]*o[12], o[8]*o[15]-o[11]*o[12]
+	b09, b10, b11 := o[9]*o[14]-o[10]*o[13], o[9]*o[15]-o[11]*o[13], o[10]*o[15]-o[11]*o[14]
+	det := b00*b11 - b01*b10 + b02*b09 + b03*b08 - b04*b07 + b05*b06
+	if det == 0 {
+		return nil
+	}
+	d := 1 / det
+	return &Matrix4{elements: [16]float32{
+		(o[5]*b11 - o[6]*b10 + o[7]*b09) * d,
+		(o[2]*b10 - o[1]*b11 - o[3]*b09) * d,
+		(o[13]*b05 - o[14]*b04 + o[15]*b03) * d,
+		(o[10]*b04 - o[9]*b05 - o[11]*b03) * d,
+		(o[6]*b08 - o[4]*b11 - o[7]*b07) * d,
+		(o[0]*b11 - o[2]*b08 + o[3]*b07) * d,
+		(o[14]*b02 - o[12]*b05 - o[15]*b01) * d,
+		(o[8]*b05 - o[10]*b02 + o[11]*b01) * d,
+		(o[4]*b10 - o[5]*b08 + o[7]*b06) * d,
+		(o[1]*b08 - o[0]*b10 - o[3]*b06) * d,
+		(o[12]*b04 - o[13]*b02 + o[15]*b00) * d,
+		(o[9]*b02 - o[8]*b04 - o[11]*b00) * d,
+		(o[5]*b07 - o[4]*b09 - o[6]*b06) * d,
+		(o[0]*b09 - o[1]*b07 + o[2]*b06) * d,
+		(o[13]*b01 - o[12]*b03 - o[14]*b00) * d,
+		(o[8]*b03 - o[9]*b01 + o[10]*b00) * d}}
 }
 
 func (self *Matrix4) MultiplyToTheLeft(matrix *Matrix4) *Matrix4 {
@@ -170,3 +304,21 @@ func (self *Matrix4) MultiplyVector3(v [3]float32) [3]float32 {
 		e[1]*v[0] + e[5]*v[1] + e[9]*v[2] + e[13],
 		e[2]*v[0] + e[6]*v[1] + e[10]*v[2] + e[14]}
 }
+
+func (self *Matrix4) MultiplyVector4(v [4]float32) [4]float32 {
+	e := &self.elements // reference
+	return [4]float32{
+		e[0]*v[0] + e[4]*v[1] + e[8]*v[2] + e[12]*v[3], // COLUMN-MAJOR
+		e[1]*v[0] + e[5]*v[1] + e[9]*v[2] + e[13]*v[3],
+		e[2]*v[0] + e[6]*v[1] + e[10]*v[2] + e[14]*v[3],
+		e[3]*v[0] + e[7]*v[1] + e[11]*v[2] + e[15]*v[3]}
+}
+
+func (self *Matrix4) MultiplyDirection3(v [3]float32) [3]float32 {
+	// direction vector (without translation)
+	e := &self.elements // reference
+	return [3]float32{
+		e[0]*v[0] + e[4]*v[1] + e[8]*v[2], // COLUMN-MAJOR
+		e[1]*v[0] + e[5]*v[1] + e[9]*v[2],
+		e[2]*v[0] + e[6]*v[1] + e[10]*v[2]}
+}
@@ -0,0 +1,185 @@
+package geom3d
+
+import (
+	"testing"
+
+	"github.com/go4orward/gowebgl/geom2d"
+)
+
+func TestMatrix4Multiply(t *testing.T) {
+	general := NewMatrix4().Set(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16)
+	tests := []struct {
+		name string
+		a    *Matrix4
+		b    *Matrix4
+		want *Matrix4 // a * b
+	}{
+		{"identity", NewMatrix4(), general, general},
+		{"general", general, NewMatrix4().Set(16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1),
+			NewMatrix4().Set(80, 70, 60, 50, 240, 214, 188, 162, 400, 358, 316, 274, 560, 502, 444, 386)},
+		{"translations", NewMatrix4().SetTranslation(1, 2, 3), NewMatrix4().SetTranslation(-4, 5, 6), NewMatrix4().SetTranslation(-3, 7, 9)},
+		{"scalings", NewMatrix4().SetScaling(1, 2, 3), NewMatrix4().SetScaling(4, 5, 6), NewMatrix4().SetScaling(4, 10, 18)},
+		{"rotations", NewMatrix4().SetRotationByAxis([3]float32{0, 0, 1}, 30), NewMatrix4().SetRotationByAxis([3]float32{0, 0, 1}, 60),
+			NewMatrix4().SetRotationByAxis([3]float32{0, 0, 1}, 90)},
+	}
+	for _, tt := range tests {
+		if m := tt.a.MultiplyToTheRight(tt.b); !m.IsEqual(tt.want, 1e-4) {
+			t.Errorf("%s: a.MultiplyToTheRight(b) = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+		if m := tt.b.MultiplyToTheLeft(tt.a); !m.IsEqual(tt.want, 1e-4) {
+			t.Errorf("%s: b.MultiplyToTheLeft(a) = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+		if m := NewMatrix4().SetMultiplyMatrices(tt.a, tt.b); !m.IsEqual(tt.want, 1e-4) {
+			t.Errorf("%s: SetMultiplyMatrices(a, b) = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+	}
+}
+
+func TestMatrix4Transpose(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *Matrix4
+		want *Matrix4
+	}{
+		{"identity", NewMatrix4(), NewMatrix4()},
+		{"general", NewMatrix4().Set(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16),
+			NewMatrix4().Set(1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15, 4, 8, 12, 16)},
+		{"translation", NewMatrix4().SetTranslation(2, 3, 4), NewMatrix4().Set(1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 2, 3, 4, 1)},
+	}
+	for _, tt := range tests {
+		if m := tt.m.Transpose(); !m.IsEqual(tt.want, 0) {
+			t.Errorf("%s: Transpose() = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+		if m := tt.m.Copy().SetTranspose(); !m.IsEqual(tt.want, 0) {
+			t.Errorf("%s: SetTranspose() = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+	}
+}
+
+func TestMatrix4Inverse(t *testing.T) {
+	rotation := NewMatrix4().SetRotationByAxis([3]float32{1, 2, 3}, 40)
+	tests := []struct {
+		name     string
+		m        *Matrix4
+		singular bool
+	}{
+		{"identity", NewMatrix4(), false},
+		{"translation", NewMatrix4().SetTranslation(3, -4, 5), false},
+		{"rotation", rotation, false},
+		{"scaling", NewMatrix4().SetScaling(2, 0.5, 4), false},
+		{"composition", NewMatrix4().SetMultiplyMatrices(NewMatrix4().SetTranslation(1, 2, 3), rotation, NewMatrix4().SetScaling(2, 3, 4)), false},
+		{"perspective", NewMatrix4().Set(1.5, 0, 0, 0, 0, 2, 0, 0, 0, 0, -1.2, -2.2, 0, 0, -1, 0), false},
+		{"zero", NewMatrix4().Set(0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0), true},
+		{"singular", NewMatrix4().Set(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16), true},
+		{"zero_scaling", NewMatrix4().SetScaling(1, 0, 1), true},
+	}
+	for _, tt := range tests {
+		inverse := tt.m.Inverse()
+		if tt.singular {
+			if inverse != nil {
+				t.Errorf("%s: Inverse() = %v, want nil", tt.name, inverse.elements)
+			}
+			if m := tt.m.Copy().SetInverse(); !m.IsEqual(tt.m, 0) {
+				t.Errorf("%s: SetInverse() changed singular matrix to %v", tt.name, m.elements)
+			}
+			continue
+		}
+		if inverse == nil {
+			t.Errorf("%s: Inverse() = nil", tt.name)
+			continue
+		}
+		if m := tt.m.MultiplyToTheRight(inverse); !m.IsEqual(NewMatrix4(), 1e-5) {
+			t.Errorf("%s: M * Inverse() = %v, want identity", tt.name, m.elements)
+		}
+		if m := inverse.MultiplyToTheRight(tt.m); !m.IsEqual(NewMatrix4(), 1e-5) {
+			t.Errorf("%s: Inverse() * M = %v, want identity", tt.name, m.elements)
+		}
+		if m := tt.m.Copy().SetInverse(); !m.IsEqual(inverse, 0) {
+			t.Errorf("%s: SetInverse() = %v, want %v", tt.name, m.elements, inverse.elements)
+		}
+	}
+}
+
+func TestMatrix4NormalMatrix(t *testing.T) {
+	rotation := NewMatrix4().SetRotationByAxis([3]float32{0, 1, 1}, 70)
+	re := rotation.GetElements()
+	tests := []struct {
+		name string
+		m    *Matrix4
+		want *geom2d.Matrix3
+	}{
+		{"identity", NewMatrix4(), geom2d.NewMatrix3()},
+		{"translation", NewMatrix4().SetTranslation(3, 4, 5), geom2d.NewMatrix3()},
+		{"scaling", NewMatrix4().SetScaling(2, 4, 8), geom2d.NewMatrix3().Set(0.5, 0, 0, 0, 0.25, 0, 0, 0, 0.125)},
+		{"rotation", rotation, geom2d.NewMatrix3().Set(re[0], re[4], re[8], re[1], re[5], re[9], re[2], re[6], re[10])},
+		{"singular", NewMatrix4().SetScaling(1, 0, 1), geom2d.NewMatrix3()},
+	}
+	for _, tt := range tests {
+		if m := tt.m.GetNormalMatrix(); !m.IsEqual(tt.want, 1e-5) {
+			t.Errorf("%s: GetNormalMatrix() = %v, want %v", tt.name, *m.GetElements(), *tt.want.GetElements())
+		}
+	}
+	// normal vectors remain perpendicular to the surface under non-uniform scaling
+	m := NewMatrix4().SetScaling(1, 3, 1)
+	tangent, normal := [3]float32{1, -1, 0}, [3]float32{1, 1, 0}
+	tangent, normal = m.MultiplyDirection3(tangent), m.GetNormalMatrix().MultiplyVector3(normal)
+	if d := DotAB(tangent, normal); d > 1e-5 || d < -1e-5 {
+		t.Errorf("transformed normal %v is not perpendicular to the tangent %v", normal, tangent)
+	}
+}
+
+func TestMatrix4Transformations(t *testing.T) {
+	tests := []struct {
+		name string
+		m    *Matrix4
+		v    [3]float32
+		want [3]float32
+		dir  [3]float32 // expected direction (without translation)
+	}{
+		{"translation", NewMatrix4().SetTranslation(3, -4, 5), [3]float32{1, 2, 3}, [3]float32{4, -2, 8}, [3]float32{1, 2, 3}},
+		{"scaling", NewMatrix4().SetScaling(2, 3, 4), [3]float32{1, 2, 3}, [3]float32{2, 6, 12}, [3]float32{2, 6, 12}},
+		{"rotation_x", NewMatrix4().SetRotationByAxis([3]float32{1, 0, 0}, 90), [3]float32{0, 1, 0}, [3]float32{0, 0, 1}, [3]float32{0, 0, 1}},
+		{"rotation_y", NewMatrix4().SetRotationByAxis([3]float32{0, 1, 0}, 90), [3]float32{0, 0, 1}, [3]float32{1, 0, 0}, [3]float32{1, 0, 0}},
+		{"rotation_z", NewMatrix4().SetRotationByAxis([3]float32{0, 0, 2}, 90), [3]float32{1, 0, 0}, [3]float32{0, 1, 0}, [3]float32{0, 1, 0}},
+		{"translation_after_rotation", NewMatrix4().SetMultiplyMatrices(NewMatrix4().SetTranslation(1, 1, 1), NewMatrix4().SetRotationByAxis([3]float32{0, 0, 1}, 90)),
+			[3]float32{1, 0, 0}, [3]float32{1, 2, 1}, [3]float32{0, 1, 0}},
+	}
+	for _, tt := range tests {
+		if v := tt.m.MultiplyVector3(tt.v); !Vector3(v).IsEqual(Vector3(tt.want), 1e-5) {
+			t.Errorf("%s: MultiplyVector3(%v) = %v, want %v", tt.name, tt.v, v, tt.want)
+		}
+		if v := tt.m.MultiplyDirection3(tt.v); !Vector3(v).IsEqual(Vector3(tt.dir), 1e-5) {
+			t.Errorf("%s: MultiplyDirection3(%v) = %v, want %v", tt.name, tt.v, v, tt.dir)
+		}
+		v4 := tt.m.MultiplyVector4([4]float32{tt.v[0], tt.v[1], tt.v[2], 1})
+		if !Vector4(v4).IsEqual(Vector4{tt.want[0], tt.want[1], tt.want[2], 1}, 1e-5) {
+			t.Errorf("%s: MultiplyVector4(%v) = %v, want %v", tt.name, tt.v, v4, tt.want)
+		}
+	}
+}
+
+func TestMatrix4Decompose(t *testing.T) {
+	tests := []struct {
+		name     string
+		txyz     [3]float32
+		rotation *Quaternion
+		sxyz     [3]float32
+	}{
+		{"identity", [3]float32{0, 0, 0}, NewQuaternion(), [3]float32{1, 1, 1}},
+		{"general", [3]float32{1, -2, 3}, NewQuaternion().SetRotationByAxis([3]float32{1, 1, 0}, 50), [3]float32{2, 3, 0.5}},
+		{"mirrored", [3]float32{0, 5, 0}, NewQuaternion().SetRotationByAxis([3]float32{0, 0, 1}, 120), [3]float32{-2, 1, 1}},
+	}
+	for _, tt := range tests {
+		m := NewMatrix4().SetComposition(tt.txyz, tt.rotation, tt.sxyz)
+		txyz, rotation, sxyz := m.Decompose()
+		if !Vector3(txyz).IsEqual(Vector3(tt.txyz), 1e-5) || !Vector3(sxyz).IsEqual(Vector3(tt.sxyz), 1e-5) {
+			t.Errorf("%s: Decompose() = %v, %v, want %v, %v", tt.name, txyz, sxyz, tt.txyz, tt.sxyz)
+		}
+		if d := rotation.Dot(tt.rotation); d < 0.99999 && d > -0.99999 {
+			t.Errorf("%s: Decompose() rotation = %v, want %v", tt.name, rotation.elements, tt.rotation.elements)
+		}
+		if c := NewMatrix4().SetComposition(txyz, rotation, sxyz); !c.IsEqual(m, 1e-5) {
+			t.Errorf("%s: SetComposition(Decompose()) = %v, want %v", tt.name, c.elements, m.elements)
+		}
+	}
+}
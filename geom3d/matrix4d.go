@@ -0,0 +1,183 @@
+package geom3d
+
+import "math"
+
+// Matrix4d is the double-precision version of Matrix4, for the coordinates too large for float32
+// (like geographic or CAD coordinates in millions). It can be converted to Matrix4 for WebGL,
+// after its translation becomes small enough (like relative to the camera).
+type Matrix4d struct {
+	elements [16]float64 // COLUMN-MAJOR (just like WebGL)
+}
+
+func NewMatrix4d() *Matrix4d {
+	matrix := Matrix4d{elements: [16]float64{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}} // identity matrix
+	return &matrix
+}
+
+func (self *Matrix4d) GetElements() *[16]float64 {
+	return &self.elements // reference
+}
+
+func (self *Matrix4d) GetMatrix4() *Matrix4 {
+	// single-precision copy of the matrix
+	m := Matrix4{}
+	for i := 0; i < 16; i++ {
+		m.elements[i] = float32(self.elements[i])
+	}
+	return &m
+}
+
+// ----------------------------------------------------------------------------
+// Setting element values
+// ----------------------------------------------------------------------------
+
+func (self *Matrix4d) Set(
+	v00 float64, v01 float64, v02 float64, v03 float64,
+	v10 float64, v11 float64, v12 float64, v13 float64,
+	v20 float64, v21 float64, v22 float64, v23 float64,
+	v30 float64, v31 float64, v32 float64, v33 float64) *Matrix4d {
+	self.elements = [16]float64{ // COLUMN-MAJOR (just like WebGL)
+		v00, v10, v20, v30,
+		v01, v11, v21, v31,
+		v02, v12, v22, v32,
+		v03, v13, v23, v33}
+	return self
+}
+
+func (self *Matrix4d) SetIdentity() *Matrix4d {
+	self.Set(1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1)
+	return self
+}
+
+func (self *Matrix4d) SetCopy(m *Matrix4d) *Matrix4d {
+	self.elements = m.elements
+	return self
+}
+
+func (self *Matrix4d) SetMatrix4(m *Matrix4) *Matrix4d {
+	// Set with the values of single-precision matrix
+	for i := 0; i < 16; i++ {
+		self.elements[i] = float64(m.elements[i])
+	}
+	return self
+}
+
+func (self *Matrix4d) SetTranslation(tx float64, ty float64, tz float64) *Matrix4d {
+	self.Set(
+		1.0, 0.0, 0.0, tx,
+		0.0, 1.0, 0.0, ty,
+		0.0, 0.0, 1.0, tz,
+		0.0, 0.0, 0.0, 1.0)
+	return self
+}
+
+func (self *Matrix4d) SetScaling(sx float64, sy float64, sz float64) *Matrix4d {
+	self.Set(
+		sx, 0.0, 0.0, 0,
+		0.0, sy, 0.0, 0,
+		0.0, 0.0, sz, 0,
+		0.0, 0.0, 0.0, 1.0)
+	return self
+}
+
+func (self *Matrix4d) SetRotationByAxis(axis [3]float64, angle_in_degree float64) *Matrix4d {
+	axis = Vector3d(axis).Normalize()
+	c := math.Cos(angle_in_degree * (math.Pi / 180.0))
+	s := math.Sin(angle_in_degree * (math.Pi / 180.0))
+	t := 1 - c
+	x, y, z := axis[0], axis[1], axis[2]
+	tx, ty := t*x, t*y
+	self.Set(
+		tx*x+c, tx*y-s*z, tx*z+s*y, 0,
+		tx*y+s*z, ty*y+c, ty*z-s*x, 0,
+		tx*z-s*y, ty*z+s*x, t*z*z+c, 0,
+		0, 0, 0, 1)
+	return self
+}
+
+func (self *Matrix4d) SetMultiplyMatrices(matrices ...*Matrix4d) *Matrix4d {
+	if len(matrices) > 0 {
+		m := matrices[0] // multiply all the matrices first,
+		for i := 1; i < len(matrices); i++ {
+			m = m.MultiplyToTheRight(matrices[i])
+		}
+		self.SetCopy(m) // and then copy (overwriting old values)
+	}
+	return self
+}
+
+// ----------------------------------------------------------------------------
+// Creating new matrix
+// ----------------------------------------------------------------------------
+
+func (self *Matrix4d) Copy() *Matrix4d {
+	return &Matrix4d{elements: self.elements}
+}
+
+func (self *Matrix4d) Transpose() *Matrix4d {
+	o := &self.elements // reference
+	return &Matrix4d{elements: [16]float64{
+		o[0], o[4], o[8], o[12],
+		o[1], o[5], o[9], o[13],
+		o[2], o[6], o[10], o[14],
+		o[3], o[7], o[11], o[15]}}
+}
+
+func (self *Matrix4d) Inverse() *Matrix4d {
+	// Inverse matrix (nil, if the matrix is singular)
+	o := &self.elements // reference
+	b00, b01, b02 := o[0]*o[5]-o[1]*o[4], o[0]*o[6]-o[2]*o[4], o[0]*o[7]-o[3]*o[4]
+	b03, b04, b05 := o[1]*o[6]-o[2]*o[5], o[1]*o[7]-o[3]*o[5], o[2]*o[7]-o[3]*o[6]
+	b06, b07, b08 := o[8]*o[13]-o[9]*o[12], o[8]*o[14]-o[10]*o[12], o[8]*o[15]-o[11]*o[12]
+	b09, b10, b11 := o[9]*o[14]-o[10]*o[13], o[9]*o[15]-o[11]*o[13], o[10]*o[15]-o[11]*o[14]
+	det := b00*b11 - b01*b10 + b02*b09 + b03*b08 - b04*b07 + b05*b06
+	if det == 0 {
+		return nil
+	}
+	d := 1 / det
+	return &Matrix4d{elements: [16]float64{
+		(o[5]*b11 - o[6]*b10 + o[7]*b09) * d,
+		(o[2]*b10 - o[1]*b11 - o[3]*b09) * d,
+		(o[13]*b05 - o[14]*b04 + o[15]*b03) * d,
+		(o[10]*b04 - o[9]*b05 - o[11]*b03) * d,
+		(o[6]*b08 - o[4]*b11 - o[7]*b07) * d,
+		(o[0]*b11 - o[2]*b08 + o[3]*b07) * d,
+		(o[14]*b02 - o[12]*b05 - o[15]*b01) * d,
+		(o[8]*b05 - o[10]*b02 + o[11]*b01) * d,
+		(o[4]*b10 - o[5]*b08 + o[7]*b06) * d,
+		(o[1]*b08 - o[0]*b10 - o[3]*b06) * d,
+		(o[12]*b04 - o[13]*b02 + o[15]*b00) * d,
+		(o[9]*b02 - o[8]*b04 - o[11]*b00) * d,
+		(o[5]*b07 - o[4]*b09 - o[6]*b06) * d,
+		(o[0]*b09 - o[1]*b07 + o[2]*b06) * d,
+		(o[13]*b01 - o[12]*b03 - o[14]*b00) * d,
+		(o[8]*b03 - o[9]*b01 + o[10]*b00) * d}}
+}
+
+func (self *Matrix4d) MultiplyToTheLeft(matrix *Matrix4d) *Matrix4d {
+	return matrix.MultiplyToTheRight(self) // M * O
+}
+
+func (self *Matrix4d) MultiplyToTheRight(matrix *Matrix4d) *Matrix4d {
+	o := &self.elements   // reference        (O*M)
+	m := &matrix.elements // reference
+	result := Matrix4d{}
+	for c := 0; c < 4; c++ { // column of the result
+		for r := 0; r < 4; r++ { // row of the result
+			result.elements[c*4+r] = o[r]*m[c*4] + o[4+r]*m[c*4+1] + o[8+r]*m[c*4+2] + o[12+r]*m[c*4+3]
+		}
+	}
+	return &result
+}
+
+// ----------------------------------------------------------------------------
+// Handling Vector
+// ----------------------------------------------------------------------------
+
+func (self *Matrix4d) MultiplyVector3(v [3]float64) [3]float64 {
+	e := &self.elements // reference
+	return [3]float64{
+		e[0]*v[0] + e[4]*v[1] + e[8]*v[2] + e[12], // COLUMN-MAJOR
+		e[1]*v[0] + e[5]*v[1] + e[9]*v[2] + e[13],
+		e[2]*v[0] + e[6]*v[1] + e[10]*v[2] + e[14]}
+}
@@ -0,0 +1,257 @@
+package geom3d
+
+import "math"
+
+type Quaternion struct {
+	elements [4]float32 // [x, y, z, w]  (vector part x,y,z and scalar part w)
+}
+
+func NewQuaternion() *Quaternion {
+	quaternion := Quaternion{elements: [4]float32{0, 0, 0, 1}} // identity (no rotation)
+	return &quaternion
+}
+
+func (self *Quaternion) GetElements() *[4]float32 {
+	return &self.elements // reference
+}
+
+// ----------------------------------------------------------------------------
+// Setting element values
+// ----------------------------------------------------------------------------
+
+func (self *Quaternion) Set(x float32, y float32, z float32, w float32) *Quaternion {
+	self.elements = [4]float32{x, y, z, w}
+	return self
+}
+
+func (self *Quaternion) SetIdentity() *Quaternion {
+	self.elements = [4]float32{0, 0, 0, 1}
+	return self
+}
+
+func (self *Quaternion) SetCopy(q *Quaternion) *Quaternion {
+	self.elements = q.elements
+	return self
+}
+
+func (self *Quaternion) SetRotationByAxis(axis [3]float32, angle_in_degree float32) *Quaternion {
+	axis = Normalize(axis)
+	half := float64(angle_in_degree) * (math.Pi / 180.0) / 2
+	s := float32(math.Sin(half))
+	self.elements = [4]float32{axis[0] * s, axis[1] * s, axis[2] * s, float32(math.Cos(half))}
+	return self
+}
+
+func (self *Quaternion) SetRotationByEuler(x_in_degree float32, y_in_degree float32, z_in_degree float32) *Quaternion {
+	// Rotation around X axis first, and then Y axis, and then Z axis (of the fixed WORLD space),
+	// which is the same as  Rz * Ry * Rx  (like roll, pitch and yaw of an airplane flying along X axis).
+	hx := float64(x_in_degree) * (math.Pi / 180.0) / 2
+	hy := float64(y_in_degree) * (math.Pi / 180.0) / 2
+	hz := float64(z_in_degree) * (math.Pi / 180.0) / 2
+	cx, sx := math.Cos(hx), math.Sin(hx)
+	cy, sy := math.Cos(hy), math.Sin(hy)
+	cz, sz := math.Cos(hz), math.Sin(hz)
+	self.elements = [4]float32{
+		float32(sx*cy*cz - cx*sy*sz),
+		float32(cx*sy*cz + sx*cy*sz),
+		float32(cx*cy*sz - sx*sy*cz),
+		float32(cx*cy*cz + sx*sy*sz)}
+	return self
+}
+
+func (self *Quaternion) SetRotationByMatrix4(m *Matrix4) *Quaternion {
+	// Extract the rotation from the upper-left 3x3 of the matrix (which is assumed to be a pure rotation)
+	e := m.GetElements() // COLUMN-MAJOR
+	m00, m01, m02 := float64(e[0]), float64(e[4]), float64(e[8])
+	m10, m11, m12 := float64(e[1]), float64(e[5]), float64(e[9])
+	m20, m21, m22 := float64(e[2]), float64(e[6]), float64(e[10])
+	var x, y, z, w float64
+	if trace := m00 + m11 + m22; trace > 0 {
+		s := 0.5 / math.Sqrt(trace+1.0)
+		w, x, y, z = 0.25/s, (m21-m12)*s, (m02-m20)*s, (m10-m01)*s
+	} else if m00 > m11 && m00 > m22 {
+		s := 2.0 * math.Sqrt(1.0+m00-m11-m22)
+		w, x, y, z = (m21-m12)/s, 0.25*s, (m01+m10)/s, (m02+m20)/s
+	} else if m11 > m22 {
+		s := 2.0 * math.Sqrt(1.0+m11-m00-m22)
+		w, x, y, z = (m02-m20)/s, (m01+m10)/s, 0.25*s, (m12+m21)/s
+	} else {
+		s := 2.0 * math.Sqrt(1.0+m22-m00-m11)
+		w, x, y, z = (m10-m01)/s, (m02+m20)/s, (m12+m21)/s, 0.25*s
+	}
+	self.elements = [4]float32{float32(x), float32(y), float32(z), float32(w)}
+	return self.Normalize()
+}
+
+func (self *Quaternion) SetLookRotation(direction [3]float32, up [3]float32) *Quaternion {
+	// Rotation which makes -Z axis to look at 'direction', with +Y axis toward 'up'
+	// (just like the camera looking along its -Z axis, so that it can be used for camera poses).
+	axisZ := Normalize([3]float32{-direction[0], -direction[1], -direction[2]})
+	axisX := CrossAB(up, axisZ)
+	if Length(axisX) < 1e-6 { // 'up' is parallel to 'direction'
+		axisX = CrossAB([3]float32{1, 0, 0}, axisZ)
+		if Length(axisX) < 1e-6 {
+			axisX = CrossAB([3]float32{0, 1, 0}, axisZ)
+		}
+	}
+	axisX = Normalize(axisX)
+	axisY := CrossAB(axisZ, axisX)
+	m := NewMatrix4().Set(
+		axisX[0], axisY[0], axisZ[0], 0,
+		axisX[1], axisY[1], axisZ[1], 0,
+		axisX[2], axisY[2], axisZ[2], 0,
+		0, 0, 0, 1)
+	return self.SetRotationByMatrix4(m)
+}
+
+func (self *Quaternion) SetMultiplyQuaternions(quaternions ...*Quaternion) *Quaternion {
+	// q0 * q1 * q2 ...  (rotation by the last quaternion is applied first, just like matrices)
+	if len(quaternions) > 0 {
+		q := quaternions[0] // multiply all the quaternions first,
+		for i := 1; i < len(quaternions); i++ {
+			q = q.MultiplyToTheRight(quaternions[i])
+		}
+		self.SetCopy(q) // and then copy (overwriting old values)
+	}
+	return self
+}
+
+func (self *Quaternion) SetSlerp(a *Quaternion, b *Quaternion, t float32) *Quaternion {
+	// Spherical linear interpolation from 'a' (t=0) to 'b' (t=1), with constant angular velocity
+	ae, be := a.elements, b.elements
+	cos := float64(ae[0]*be[0] + ae[1]*be[1] + ae[2]*be[2] + ae[3]*be[3])
+	if cos < 0 { // take the shorter path
+		be = [4]float32{-be[0], -be[1], -be[2], -be[3]}
+		cos = -cos
+	}
+	var wa, wb float64
+	if cos > 0.9995 { // almost the same orientation (linear interpolation is good enough)
+		wa, wb = 1-float64(t), float64(t)
+	} else {
+		theta := math.Acos(math.Min(cos, 1))
+		sin := math.Sin(theta)
+		wa, wb = math.Sin((1-float64(t))*theta)/sin, math.Sin(float64(t)*theta)/sin
+	}
+	for i := 0; i < 4; i++ {
+		self.elements[i] = float32(wa*float64(ae[i]) + wb*float64(be[i]))
+	}
+	return self.Normalize()
+}
+
+func (self *Quaternion) SetNlerp(a *Quaternion, b *Quaternion, t float32) *Quaternion {
+	// Normalized linear interpolation from 'a' (t=0) to 'b' (t=1), which is faster than slerp (non-constant velocity)
+	ae, be := a.elements, b.elements
+	if ae[0]*be[0]+ae[1]*be[1]+ae[2]*be[2]+ae[3]*be[3] < 0 { // take the shorter path
+		be = [4]float32{-be[0], -be[1], -be[2], -be[3]}
+	}
+	for i := 0; i < 4; i++ {
+		self.elements[i] = ae[i]*(1-t) + be[i]*t
+	}
+	return self.Normalize()
+}
+
+func (self *Quaternion) Normalize() *Quaternion {
+	// Normalize to unit length (to remove the drift accumulated by repeated compositions)
+	e := &self.elements // reference
+	length := math.Sqrt(float64(e[0]*e[0] + e[1]*e[1] + e[2]*e[2] + e[3]*e[3]))
+	if length == 0 {
+		return self.SetIdentity()
+	}
+	for i := 0; i < 4; i++ {
+		e[i] = float32(float64(e[i]) / length)
+	}
+	return self
+}
+
+// ----------------------------------------------------------------------------
+// Creating new quaternion
+// ----------------------------------------------------------------------------
+
+func (self *Quaternion) Copy() *Quaternion {
+	return &Quaternion{elements: self.elements}
+}
+
+func (self *Quaternion) Conjugate() *Quaternion {
+	// Inverse rotation (for unit quaternion)
+	e := &self.elements // reference
+	return &Quaternion{elements: [4]float32{-e[0], -e[1], -e[2], e[3]}}
+}
+
+func (self *Quaternion) MultiplyToTheRight(q *Quaternion) *Quaternion {
+	// self * q  (rotation by 'q' first, and then by 'self')
+	a, b := &self.elements, &q.elements // reference
+	return &Quaternion{elements: [4]float32{
+		a[3]*b[0] + a[0]*b[3] + a[1]*b[2] - a[2]*b[1],
+		a[3]*b[1] - a[0]*b[2] + a[1]*b[3] + a[2]*b[0],
+		a[3]*b[2] + a[0]*b[1] - a[1]*b[0] + a[2]*b[3],
+		a[3]*b[3] - a[0]*b[0] - a[1]*b[1] - a[2]*b[2]}}
+}
+
+func (self *Quaternion) MultiplyToTheLeft(q *Quaternion) *Quaternion {
+	// q * self  (rotation by 'self' first, and then by 'q')
+	return q.MultiplyToTheRight(self)
+}
+
+func (self *Quaternion) GetMatrix4() *Matrix4 {
+	e := &self.elements // reference
+	x, y, z, w := e[0], e[1], e[2], e[3]
+	return NewMatrix4().Set(
+		1-2*(y*y+z*z), 2*(x*y-z*w), 2*(x*z+y*w), 0,
+		2*(x*y+z*w), 1-2*(x*x+z*z), 2*(y*z-x*w), 0,
+		2*(x*z-y*w), 2*(y*z+x*w), 1-2*(x*x+y*y), 0,
+		0, 0, 0, 1)
+}
+
+// ----------------------------------------------------------------------------
+// Handling Vector & Angles
+// ----------------------------------------------------------------------------
+
+func (self *Quaternion) RotateVector3(v [3]float32) [3]float32 {
+	// v' = q * v * q^-1  (computed as  v + 2w(u x v) + 2u x (u x v)  with u = [x,y,z])
+	e := &self.elements // reference
+	u := [3]float32{e[0], e[1], e[2]}
+	uv := CrossAB(u, v)
+	uuv := CrossAB(u, uv)
+	return [3]float32{
+		v[0] + 2*(e[3]*uv[0]+uuv[0]),
+		v[1] + 2*(e[3]*uv[1]+uuv[1]),
+		v[2] + 2*(e[3]*uv[2]+uuv[2])}
+}
+
+func (self *Quaternion) GetAxisAngle() ([3]float32, float32) {
+	// Get the rotation axis and angle (in degree)
+	q := self.Copy().Normalize()
+	e := &q.elements // reference
+	if e[3] < 0 {    // angle in [0, 180]
+		e[0], e[1], e[2], e[3] = -e[0], -e[1], -e[2], -e[3]
+	}
+	angle := 2 * math.Acos(math.Min(float64(e[3]), 1))
+	s := math.Sqrt(math.Max(1-float64(e[3]*e[3]), 0))
+	if s < 1e-6 {
+		return [3]float32{1, 0, 0}, 0 // no rotation (any axis)
+	}
+	return [3]float32{e[0] / float32(s), e[1] / float32(s), e[2] / float32(s)}, float32(angle * (180.0 / math.Pi))
+}
+
+func (self *Quaternion) GetEuler() [3]float32 {
+	// Get the Euler angles (in degree) for SetRotationByEuler()  (X, Y and Z in the order of rotation)
+	e := &self.elements // reference
+	x, y, z, w := float64(e[0]), float64(e[1]), float64(e[2]), float64(e[3])
+	sinY := 2 * (w*y - z*x)
+	var ax, ay, az float64
+	if math.Abs(sinY) >= 0.99999 { // gimbal lock (Y is +90 or -90 degree)
+		ay = math.Copysign(math.Pi/2, sinY)
+		ax = 0
+		az = math.Atan2(-2*(x*y-w*z), 1-2*(x*x+z*z))
+	} else {
+		ay = math.Asin(sinY)
+		ax = math.Atan2(2*(w*x+y*z), 1-2*(x*x+y*y))
+		az = math.Atan2(2*(w*z+x*y), 1-2*(y*y+z*z))
+	}
+	return [3]float32{float32(ax * (180.0 / math.Pi)), float32(ay * (180.0 / math.Pi)), float32(az * (180.0 / math.Pi))}
+}
+
+func (self *Quaternion) Dot(q *Quaternion) float32 {
+	a, b := &self.elements, &q.elements // reference
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] + a[3]*b[3]
+}
@@ -0,0 +1,172 @@
+package geom3d
+
+import "testing"
+
+func is_same_rotation(a *Quaternion, b *Quaternion) bool {
+	// 'q' and '-q' represent the same rotation
+	d := a.Dot(b)
+	return d > 0.99999 || d < -0.99999
+}
+
+func TestQuaternionAxisAngle(t *testing.T) {
+	tests := []struct {
+		name  string
+		axis  [3]float32
+		angle float32
+	}{
+		{"x_30", [3]float32{1, 0, 0}, 30},
+		{"y_90", [3]float32{0, 1, 0}, 90},
+		{"z_179", [3]float32{0, 0, 1}, 179},
+		{"general", [3]float32{1, 2, 3}, 75},
+		{"negative_axis", [3]float32{0, -1, 1}, 120},
+	}
+	for _, tt := range tests {
+		q := NewQuaternion().SetRotationByAxis(tt.axis, tt.angle)
+		axis, angle := q.GetAxisAngle()
+		if !Vector3(axis).IsEqual(Vector3(Normalize(tt.axis)), 1e-4) || angle < tt.angle-1e-3 || angle > tt.angle+1e-3 {
+			t.Errorf("%s: GetAxisAngle() = %v, %v, want %v, %v", tt.name, axis, angle, Normalize(tt.axis), tt.angle)
+		}
+		// rotating a vector is the same as with the rotation matrix
+		m := NewMatrix4().SetRotationByAxis(tt.axis, tt.angle)
+		if !q.GetMatrix4().IsEqual(m, 1e-5) {
+			t.Errorf("%s: GetMatrix4() = %v, want %v", tt.name, q.GetMatrix4().elements, m.elements)
+		}
+		v := [3]float32{0.3, -1.2, 2.5}
+		if r := q.RotateVector3(v); !Vector3(r).IsEqual(Vector3(m.MultiplyDirection3(v)), 1e-5) {
+			t.Errorf("%s: RotateVector3() = %v, want %v", tt.name, r, m.MultiplyDirection3(v))
+		}
+	}
+	if _, angle := NewQuaternion().GetAxisAngle(); angle != 0 {
+		t.Errorf("identity: GetAxisAngle() angle = %v, want 0", angle)
+	}
+}
+
+func TestQuaternionMatrixRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		q    *Quaternion
+	}{
+		{"identity", NewQuaternion()},
+		{"x_90", NewQuaternion().SetRotationByAxis([3]float32{1, 0, 0}, 90)},
+		{"y_180", NewQuaternion().SetRotationByAxis([3]float32{0, 1, 0}, 180)},
+		{"z_270", NewQuaternion().SetRotationByAxis([3]float32{0, 0, 1}, 270)},
+		{"general", NewQuaternion().SetRotationByAxis([3]float32{-2, 1, 0.5}, 143)},
+		{"euler", NewQuaternion().SetRotationByEuler(10, 20, 30)},
+	}
+	for _, tt := range tests {
+		q := NewQuaternion().SetRotationByMatrix4(tt.q.GetMatrix4())
+		if !is_same_rotation(q, tt.q) {
+			t.Errorf("%s: SetRotationByMatrix4(GetMatrix4()) = %v, want %v", tt.name, q.elements, tt.q.elements)
+		}
+		if m := NewMatrix4().SetRotationByQuaternion(tt.q); !m.IsEqual(tt.q.GetMatrix4(), 0) {
+			t.Errorf("%s: SetRotationByQuaternion() = %v, want %v", tt.name, m.elements, tt.q.GetMatrix4().elements)
+		}
+	}
+}
+
+func TestQuaternionEulerRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		euler [3]float32
+	}{
+		{"zero", [3]float32{0, 0, 0}},
+		{"x_only", [3]float32{45, 0, 0}},
+		{"y_only", [3]float32{0, -60, 0}},
+		{"z_only", [3]float32{0, 0, 120}},
+		{"general", [3]float32{10, 20, 30}},
+		{"negative", [3]float32{-100, 35, -170}},
+	}
+	for _, tt := range tests {
+		q := NewQuaternion().SetRotationByEuler(tt.euler[0], tt.euler[1], tt.euler[2])
+		if euler := q.GetEuler(); !Vector3(euler).IsEqual(Vector3(tt.euler), 1e-3) {
+			t.Errorf("%s: GetEuler() = %v, want %v", tt.name, euler, tt.euler)
+		}
+		// Rz * Ry * Rx
+		rx := NewQuaternion().SetRotationByAxis([3]float32{1, 0, 0}, tt.euler[0])
+		ry := NewQuaternion().SetRotationByAxis([3]float32{0, 1, 0}, tt.euler[1])
+		rz := NewQuaternion().SetRotationByAxis([3]float32{0, 0, 1}, tt.euler[2])
+		if r := NewQuaternion().SetMultiplyQuaternions(rz, ry, rx); !is_same_rotation(q, r) {
+			t.Errorf("%s: SetRotationByEuler() = %v, want %v", tt.name, q.elements, r.elements)
+		}
+	}
+	// gimbal lock (Y is 90 degree) still gives the same rotation
+	q := NewQuaternion().SetRotationByEuler(30, 90, 10)
+	euler := q.GetEuler()
+	if r := NewQuaternion().SetRotationByEuler(euler[0], euler[1], euler[2]); !is_same_rotation(q, r) {
+		t.Errorf("gimbal_lock: GetEuler() = %v gives different rotation", euler)
+	}
+}
+
+func TestQuaternionMultiply(t *testing.T) {
+	a := NewQuaternion().SetRotationByAxis([3]float32{1, 0, 0}, 90)
+	b := NewQuaternion().SetRotationByAxis([3]float32{0, 0, 1}, 90)
+	tests := []struct {
+		name string
+		q    *Quaternion
+		want *Matrix4
+	}{
+		{"a_b", a.MultiplyToTheRight(b), a.GetMatrix4().MultiplyToTheRight(b.GetMatrix4())},
+		{"b_a", a.MultiplyToTheLeft(b), b.GetMatrix4().MultiplyToTheRight(a.GetMatrix4())},
+		{"conjugate", a.MultiplyToTheRight(a.Conjugate()), NewMatrix4()},
+		{"same_axis", NewQuaternion().SetMultiplyQuaternions(b, b, b, b), NewMatrix4()},
+	}
+	for _, tt := range tests {
+		if m := tt.q.GetMatrix4(); !m.IsEqual(tt.want, 1e-5) {
+			t.Errorf("%s: GetMatrix4() = %v, want %v", tt.name, m.elements, tt.want.elements)
+		}
+	}
+}
+
+func TestQuaternionInterpolation(t *testing.T) {
+	a := NewQuaternion()
+	b := NewQuaternion().SetRotationByAxis([3]float32{0, 1, 0}, 120)
+	tests := []struct {
+		t     float32
+		angle float32
+	}{
+		{0, 0}, {0.25, 30}, {0.5, 60}, {1, 120},
+	}
+	for _, tt := range tests {
+		want := NewQuaternion().SetRotationByAxis([3]float32{0, 1, 0}, tt.angle)
+		if q := NewQuaternion().SetSlerp(a, b, tt.t); !is_same_rotation(q, want) {
+			t.Errorf("SetSlerp(t=%v) = %v, want %v", tt.t, q.elements, want.elements)
+		}
+		if q := NewQuaternion().SetNlerp(a, b, tt.t); tt.t == 0.5 && !is_same_rotation(q, want) {
+			t.Errorf("SetNlerp(t=%v) = %v, want %v", tt.t, q.elements, want.elements)
+		}
+	}
+	// the shorter path is taken for the opposite sign
+	negated := NewQuaternion().Set(-b.elements[0], -b.elements[1], -b.elements[2], -b.elements[3])
+	want := NewQuaternion().SetRotationByAxis([3]float32{0, 1, 0}, 60)
+	if q := NewQuaternion().SetSlerp(a, negated, 0.5); !is_same_rotation(q, want) {
+		t.Errorf("SetSlerp() with negated quaternion = %v, want %v", q.elements, want.elements)
+	}
+}
+
+func TestQuaternionLookRotation(t *testing.T) {
+	tests := []struct {
+		name      string
+		direction [3]float32
+		up        [3]float32
+	}{
+		{"forward", [3]float32{0, 0, -1}, [3]float32{0, 1, 0}},
+		{"right", [3]float32{1, 0, 0}, [3]float32{0, 1, 0}},
+		{"down", [3]float32{0, -1, 0}, [3]float32{0, 1, 0}},
+		{"general", [3]float32{1, 2, -3}, [3]float32{0, 0, 1}},
+	}
+	for _, tt := range tests {
+		q := NewQuaternion().SetLookRotation(tt.direction, tt.up)
+		if v := q.RotateVector3([3]float32{0, 0, -1}); !Vector3(v).IsEqual(Vector3(Normalize(tt.direction)), 1e-5) {
+			t.Errorf("%s: -Z axis is rotated to %v, want %v", tt.name, v, Normalize(tt.direction))
+		}
+	}
+}
+
+func TestQuaternionNormalize(t *testing.T) {
+	if q := NewQuaternion().Set(0, 0, 3, 4).Normalize(); q.elements != [4]float32{0, 0, 0.6, 0.8} {
+		t.Errorf("Normalize() = %v, want [0 0 0.6 0.8]", q.elements)
+	}
+	if q := NewQuaternion().Set(0, 0, 0, 0).Normalize(); q.elements != [4]float32{0, 0, 0, 1} {
+		t.Errorf("Normalize() of zero = %v, want identity", q.elements)
+	}
+}
@@ -0,0 +1,239 @@
+package geom3d
+
+import "math"
+
+// ----------------------------------------------------------------------------
+// Ray
+// ----------------------------------------------------------------------------
+
+type Ray struct {
+	Origin    [3]float32
+	Direction [3]float32 // unit vector (by NewRay), although intersections work with any non-zero length
+}
+
+type RayHit struct {
+	Distance    float32    // ray parameter 't' of the hit point (Origin + t * Direction), which is the distance only for unit Direction
+	Point       [3]float32 // hit point
+	Normal      [3]float32 // surface normal at the hit point
+	Barycentric [3]float32 // barycentric coordinates of the hit point (only for triangles)
+}
+
+func NewRay(origin [3]float32, direction [3]float32) *Ray {
+	return &Ray{Origin: origin, Direction: Normalize(direction)}
+}
+
+func NewRayFromPoints(from [3]float32, to [3]float32) *Ray {
+	return NewRay(from, SubAB(to, from))
+}
+
+func (self *Ray) GetPoint(t float32) [3]float32 {
+	return AddAB(self.Origin, Scale(self.Direction, t))
+}
+
+func (self *Ray) Transform(m *Matrix4) *Ray {
+	// new Ray transformed by 'm' (Note that distances change, if 'm' has scaling)
+	origin := m.MultiplyVector3(self.Origin)
+	return NewRay(origin, m.MultiplyDirection3(self.Direction))
+}
+
+func (self *Ray) GetClosestPoint(p [3]float32) (float32, [3]float32) {
+	// closest point on the ray (with its ray parameter 't', like RayHit.Distance) to the point 'p'
+	dd := DotAB(self.Direction, self.Direction)
+	if dd == 0 {
+		return 0, self.Origin
+	}
+	t := float32(math.Max(0, float64(DotAB(SubAB(p, self.Origin), self.Direction)/dd)))
+	return t, self.GetPoint(t)
+}
+
+func (self *Ray) new_hit(t float32, normal [3]float32) *RayHit {
+	return &RayHit{Distance: t, Point: self.GetPoint(t), Normal: normal}
+}
+
+// ----------------------------------------------------------------------------
+// Ray Intersection
+// ----------------------------------------------------------------------------
+
+// Note that all the intersection functions return the first hit in front of the ray (nil, if missed).
+
+func (self *Ray) IntersectPlane(plane *Plane) *RayHit {
+	denom := DotAB(plane.Normal, self.Direction)
+	if denom == 0 { // parallel to the plane
+		return nil
+	}
+	t := -plane.GetSignedDistance(self.Origin) / denom
+	if t < 0 {
+		return nil
+	}
+	return self.new_hit(t, plane.Normal)
+}
+
+func (self *Ray) IntersectSphere(sphere *Sphere) *RayHit {
+	// If the ray starts inside the sphere, then the exit point is returned.
+	oc := SubAB(self.Origin, sphere.Center)
+	a := DotAB(self.Direction, self.Direction)
+	b := DotAB(oc, self.Direction)
+	c := DotAB(oc, oc) - sphere.Radius*sphere.Radius
+	discriminant := float64(b*b - a*c)
+	if a == 0 || discriminant < 0 {
+		return nil
+	}
+	sqrt_d := float32(math.Sqrt(discriminant))
+	t := (-b - sqrt_d) / a
+	if t < 0 {
+		t = (-b + sqrt_d) / a
+		if t < 0 {
+			return nil
+		}
+	}
+	hit := self.new_hit(t, [3]float32{0, 0, 0})
+	if sphere.Radius > 0 {
+		hit.Normal = Scale(SubAB(hit.Point, sphere.Center), 1/sphere.Radius)
+	}
+	return hit
+}
+
+func (self *Ray) IntersectAABB(aabb *AABB) *RayHit {
+	// Slab method. If the ray starts inside the box, then the exit point is returned.
+	tmin, tmax := float32(-math.MaxFloat32), float32(math.MaxFloat32)
+	nmin, nmax := -1, -1 // axis of the entering and exiting slab
+	for k := 0; k < 3; k++ {
+		if self.Direction[k] == 0 {
+			if self.Origin[k] < aabb.Min[k] || self.Origin[k] > aabb.Max[k] {
+				return nil
+			}
+			continue
+		}
+		t0 := (aabb.Min[k] - self.Origin[k]) / self.Direction[k]
+		t1 := (aabb.Max[k] - self.Origin[k]) / self.Direction[k]
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > tmin {
+			tmin, nmin = t0, k
+		}
+		if t1 < tmax {
+			tmax, nmax = t1, k
+		}
+		if tmin > tmax {
+			return nil
+		}
+	}
+	if tmax < 0 {
+		return nil
+	}
+	normal := [3]float32{0, 0, 0}
+	if tmin >= 0 && nmin >= 0 { // entering the box
+		normal[nmin] = -sign_of_float32(self.Direction[nmin])
+		return self.new_hit(tmin, normal)
+	}
+	if nmax >= 0 { // starting inside the box
+		normal[nmax] = +sign_of_float32(self.Direction[nmax])
+	}
+	return self.new_hit(tmax, normal)
+}
+
+func (self *Ray) IntersectOBB(obb *OBB) *RayHit {
+	// The ray is transformed into the box space, and intersected with the AABB.
+	local := Ray{Origin: obb.ToLocal(self.Origin)}
+	for k := 0; k < 3; k++ {
+		local.Direction[k] = DotAB(self.Direction, obb.Axes[k])
+	}
+	box := AABB{Min: Scale(obb.HalfSize, -1), Max: obb.HalfSize}
+	hit := local.IntersectAABB(&box)
+	if hit == nil {
+		return nil
+	}
+	normal := [3]float32{0, 0, 0}
+	for k := 0; k < 3; k++ {
+		normal = AddAB(normal, Scale(obb.Axes[k], hit.Normal[k]))
+	}
+	return self.new_hit(hit.Distance, normal)
+}
+
+func (self *Ray) IntersectTriangle(triangle *Triangle, cull_backface bool) *RayHit {
+	// Möller–Trumbore algorithm, with barycentric coordinates of the hit point.
+	// If 'cull_backface' is true, then the triangles facing away from the ray (CW) are ignored.
+	// The threshold of the determinant is scaled by the lengths of the edges (and the direction, which is not
+	// normalized for the rays in MODEL space), so that it works for both tiny and huge triangles.
+	const epsilon = 1e-7
+	e1 := SubAB(triangle.V[1], triangle.V[0])
+	e2 := SubAB(triangle.V[2], triangle.V[0])
+	pvec := CrossAB(self.Direction, e2)
+	det := DotAB(e1, pvec)
+	threshold := epsilon * Length(e1) * Length(e2) * Length(self.Direction)
+	if (cull_backface && det < threshold) || (det > -threshold && det < threshold) || threshold == 0 {
+		return nil
+	}
+	inv_det := 1 / det
+	tvec := SubAB(self.Origin, triangle.V[0])
+	u := DotAB(tvec, pvec) * inv_det
+	if u < 0 || u > 1 {
+		return nil
+	}
+	qvec := CrossAB(tvec, e1)
+	v := DotAB(self.Direction, qvec) * inv_det
+	if v < 0 || u+v > 1 {
+		return nil
+	}
+	t := DotAB(e2, qvec) * inv_det
+	if t < 0 {
+		return nil
+	}
+	hit := self.new_hit(t, Normalize(CrossAB(e1, e2)))
+	hit.Barycentric = [3]float32{1 - u - v, u, v}
+	return hit
+}
+
+func sign_of_float32(v float32) float32 {
+	if v < 0 {
+		return -1
+	}
+	return +1
+}
+
+// ----------------------------------------------------------------------------
+// Closest Points
+// ----------------------------------------------------------------------------
+
+func GetClosestPointsOfSegments(p0 [3]float32, p1 [3]float32, q0 [3]float32, q1 [3]float32) (float32, float32, [3]float32, [3]float32) {
+	// Closest points of the two segments (p0,p1) and (q0,q1), returning their parameters 's' and 't' in [0,1]
+	// and the points (p0 + s*(p1-p0)) and (q0 + t*(q1-q0)). Their distance is the distance of the segments.
+	// Based on 'Real-Time Collision Detection' by Christer Ericson (section 5.1.9).
+	const epsilon = 1e-12
+	d1, d2, r := SubAB(p1, p0), SubAB(q1, q0), SubAB(p0, q0)
+	a, e, f := float64(DotAB(d1, d1)), float64(DotAB(d2, d2)), float64(DotAB(d2, r))
+	var s, t float64
+	if a <= epsilon && e <= epsilon { // both segments are points
+		return 0, 0, p0, q0
+	}
+	if a <= epsilon { // first segment is a point
+		s, t = 0, clamp_float64(f/e, 0, 1)
+	} else {
+		c := float64(DotAB(d1, r))
+		if e <= epsilon { // second segment is a point
+			s, t = clamp_float64(-c/a, 0, 1), 0
+		} else {
+			b := float64(DotAB(d1, d2))
+			denom := a*e - b*b
+			if denom != 0 { // not parallel
+				s = clamp_float64((b*f-c*e)/denom, 0, 1)
+			} else {
+				s = 0
+			}
+			t = (b*s + f) / e
+			if t < 0 {
+				s, t = clamp_float64(-c/a, 0, 1), 0
+			} else if t > 1 {
+				s, t = clamp_float64((b-c)/a, 0, 1), 1
+			}
+		}
+	}
+	cp := AddAB(p0, Scale(d1, float32(s)))
+	cq := AddAB(q0, Scale(d2, float32(t)))
+	return float32(s), float32(t), cp, cq
+}
+
+func clamp_float64(v float64, min float64, max float64) float64 {
+	return math.Max(min, math.Min(v, max))
+}
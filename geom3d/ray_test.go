@@ -0,0 +1,229 @@
+package geom3d
+
+import "testing"
+
+func TestRayIntersectTriangle(t *testing.T) {
+	ccw := NewTriangle([3]float32{-1, -1, 0}, [3]float32{1, -1, 0}, [3]float32{0, 1, 0}) // facing +Z
+	tests := []struct {
+		name          string
+		ray           *Ray
+		triangle      *Triangle
+		cull_backface bool
+		hit           bool
+		distance      float32
+		barycentric   [3]float32
+	}{
+		{"hit", NewRay([3]float32{0, 0, 5}, [3]float32{0, 0, -1}), ccw, false, true, 5, [3]float32{0.25, 0.25, 0.5}},
+		{"hit_vertex", NewRay([3]float32{-1, -1, 2}, [3]float32{0, 0, -1}), ccw, false, true, 2, [3]float32{1, 0, 0}},
+		{"hit_oblique", NewRayFromPoints([3]float32{0, 3, 3}, [3]float32{0, 0, 0}), ccw, false, true, 4.2426405, [3]float32{0.25, 0.25, 0.5}},
+		{"miss", NewRay([3]float32{2, 2, 5}, [3]float32{0, 0, -1}), ccw, false, false, 0, [3]float32{}},
+		{"miss_outside_edge", NewRay([3]float32{0, -1.01, 5}, [3]float32{0, 0, -1}), ccw, false, false, 0, [3]float32{}},
+		{"parallel", NewRay([3]float32{0, 0, 1}, [3]float32{1, 0, 0}), ccw, false, false, 0, [3]float32{}},
+		{"parallel_in_plane", NewRay([3]float32{-5, 0, 0}, [3]float32{1, 0, 0}), ccw, false, false, 0, [3]float32{}},
+		{"backface", NewRay([3]float32{0, 0, -5}, [3]float32{0, 0, 1}), ccw, false, true, 5, [3]float32{0.25, 0.25, 0.5}},
+		{"backface_culled", NewRay([3]float32{0, 0, -5}, [3]float32{0, 0, 1}), ccw, true, false, 0, [3]float32{}},
+		{"frontface_not_culled", NewRay([3]float32{0, 0, 5}, [3]float32{0, 0, -1}), ccw, true, true, 5, [3]float32{0.25, 0.25, 0.5}},
+		{"behind_origin", NewRay([3]float32{0, 0, 5}, [3]float32{0, 0, 1}), ccw, false, false, 0, [3]float32{}},
+		{"behind_origin_backface", NewRay([3]float32{0, 0, -5}, [3]float32{0, 0, -1}), ccw, false, false, 0, [3]float32{}},
+		{"degenerate", NewRay([3]float32{0, 0, 5}, [3]float32{0, 0, -1}),
+			NewTriangle([3]float32{-1, 0, 0}, [3]float32{0, 0, 0}, [3]float32{1, 0, 0}), false, false, 0, [3]float32{}},
+		{"tiny", NewRay([3]float32{0, 0, 1}, [3]float32{0, 0, -1}),
+			NewTriangle([3]float32{-1e-5, -1e-5, 0}, [3]float32{1e-5, -1e-5, 0}, [3]float32{0, 1e-5, 0}), false, true, 1, [3]float32{0.25, 0.25, 0.5}},
+		{"huge", NewRay([3]float32{0, 0, 1e7}, [3]float32{0, 0, -1}),
+			NewTriangle([3]float32{-1e6, -1e6, 0}, [3]float32{1e6, -1e6, 0}, [3]float32{0, 1e6, 0}), false, true, 1e7, [3]float32{0.25, 0.25, 0.5}},
+		{"unnormalized_direction", &Ray{Origin: [3]float32{0, 0, 5}, Direction: [3]float32{0, 0, -1e-3}}, ccw, false, true, 5000, [3]float32{0.25, 0.25, 0.5}},
+	}
+	for _, tt := range tests {
+		hit := tt.ray.IntersectTriangle(tt.triangle, tt.cull_backface)
+		if !tt.hit {
+			if hit != nil {
+				t.Errorf("%s: IntersectTriangle() = %+v, want nil", tt.name, *hit)
+			}
+			continue
+		}
+		if hit == nil {
+			t.Errorf("%s: IntersectTriangle() = nil, want hit", tt.name)
+			continue
+		}
+		if d := hit.Distance - tt.distance; d > tt.distance*1e-5 || d < -tt.distance*1e-5 {
+			t.Errorf("%s: Distance = %v, want %v", tt.name, hit.Distance, tt.distance)
+		}
+		if !Vector3(hit.Barycentric).IsEqual(Vector3(tt.barycentric), 1e-4) {
+			t.Errorf("%s: Barycentric = %v, want %v", tt.name, hit.Barycentric, tt.barycentric)
+		}
+		if !Vector3(hit.Normal).IsEqual(Vector3{0, 0, 1}, 1e-5) {
+			t.Errorf("%s: Normal = %v, want [0 0 1]", tt.name, hit.Normal)
+		}
+	}
+}
+
+func check_ray_hit(t *testing.T, name string, hit *RayHit, want bool, distance float32, normal [3]float32) {
+	t.Helper()
+	if !want {
+		if hit != nil {
+			t.Errorf("%s: hit = %+v, want nil", name, *hit)
+		}
+		return
+	}
+	if hit == nil {
+		t.Errorf("%s: hit = nil, want hit", name)
+		return
+	}
+	if d := hit.Distance - distance; d > 1e-5*(1+distance) || d < -1e-5*(1+distance) {
+		t.Errorf("%s: Distance = %v, want %v", name, hit.Distance, distance)
+	}
+	if !Vector3(hit.Normal).IsEqual(Vector3(normal), 1e-5) {
+		t.Errorf("%s: Normal = %v, want %v", name, hit.Normal, normal)
+	}
+}
+
+func TestRayIntersectPlane(t *testing.T) {
+	plane := NewPlane([3]float32{0, 0, 1}, [3]float32{0, 0, 2}) // Z = 2
+	tests := []struct {
+		name     string
+		ray      *Ray
+		hit      bool
+		distance float32
+	}{
+		{"hit", NewRay([3]float32{0, 0, 5}, [3]float32{0, 0, -1}), true, 3},
+		{"hit_from_behind", NewRay([3]float32{1, 1, 0}, [3]float32{0, 0, 1}), true, 2},
+		{"hit_oblique", NewRayFromPoints([3]float32{0, 0, 5}, [3]float32{3, 0, 1}), true, 3.75},
+		{"on_plane", NewRay([3]float32{0, 0, 2}, [3]float32{0, 0, -1}), true, 0},
+		{"parallel", NewRay([3]float32{0, 0, 5}, [3]float32{1, 0, 0}), false, 0},
+		{"behind_origin", NewRay([3]float32{0, 0, 5}, [3]float32{0, 0, 1}), false, 0},
+		{"unnormalized_direction", &Ray{Origin: [3]float32{0, 0, 5}, Direction: [3]float32{0, 0, -2}}, true, 1.5},
+	}
+	for _, tt := range tests {
+		check_ray_hit(t, tt.name, tt.ray.IntersectPlane(plane), tt.hit, tt.distance, plane.Normal)
+	}
+}
+
+func TestRayIntersectSphere(t *testing.T) {
+	sphere := NewSphere([3]float32{0, 0, 0}, 2)
+	tests := []struct {
+		name     string
+		ray      *Ray
+		hit      bool
+		distance float32
+		normal   [3]float32
+	}{
+		{"hit", NewRay([3]float32{0, 0, 5}, [3]float32{0, 0, -1}), true, 3, [3]float32{0, 0, 1}},
+		{"hit_side", NewRay([3]float32{-5, 0, 0}, [3]float32{1, 0, 0}), true, 3, [3]float32{-1, 0, 0}},
+		{"tangent", NewRay([3]float32{-5, 2, 0}, [3]float32{1, 0, 0}), true, 5, [3]float32{0, 1, 0}},
+		{"miss", NewRay([3]float32{-5, 2.1, 0}, [3]float32{1, 0, 0}), false, 0, [3]float32{}},
+		{"inside", NewRay([3]float32{0, 0, 1}, [3]float32{0, 0, 1}), true, 1, [3]float32{0, 0, 1}},
+		{"behind_origin", NewRay([3]float32{0, 0, 5}, [3]float32{0, 0, 1}), false, 0, [3]float32{}},
+		{"unnormalized_direction", &Ray{Origin: [3]float32{0, 0, 5}, Direction: [3]float32{0, 0, -0.5}}, true, 6, [3]float32{0, 0, 1}},
+		{"zero_direction", &Ray{Origin: [3]float32{0, 0, 5}, Direction: [3]float32{0, 0, 0}}, false, 0, [3]float32{}},
+	}
+	for _, tt := range tests {
+		check_ray_hit(t, tt.name, tt.ray.IntersectSphere(sphere), tt.hit, tt.distance, tt.normal)
+	}
+}
+
+func TestRayIntersectAABB(t *testing.T) {
+	aabb := NewAABB([3]float32{-1, -1, -1}, [3]float32{1, 2, 3})
+	tests := []struct {
+		name     string
+		ray      *Ray
+		hit      bool
+		distance float32
+		normal   [3]float32
+	}{
+		{"hit_x", NewRay([3]float32{-5, 0, 0}, [3]float32{1, 0, 0}), true, 4, [3]float32{-1, 0, 0}},
+		{"hit_y", NewRay([3]float32{0, 5, 0}, [3]float32{0, -1, 0}), true, 3, [3]float32{0, 1, 0}},
+		{"hit_z", NewRay([3]float32{0, 0, -4}, [3]float32{0, 0, 1}), true, 3, [3]float32{0, 0, -1}},
+		{"hit_oblique", NewRayFromPoints([3]float32{-3, -3, 0}, [3]float32{0, 0, 0}), true, 2.8284271, [3]float32{-1, 0, 0}},
+		{"inside", NewRay([3]float32{0, 0, 0}, [3]float32{0, 1, 0}), true, 2, [3]float32{0, 1, 0}},
+		{"inside_negative", NewRay([3]float32{0, 0, 0}, [3]float32{0, 0, -1}), true, 1, [3]float32{0, 0, -1}},
+		{"parallel_inside_slab", NewRay([3]float32{0.5, 0.5, -5}, [3]float32{0, 0, 1}), true, 4, [3]float32{0, 0, -1}},
+		{"parallel_outside_slab", NewRay([3]float32{1.5, 0.5, -5}, [3]float32{0, 0, 1}), false, 0, [3]float32{}},
+		{"miss", NewRay([3]float32{-5, 5, 0}, [3]float32{1, 0, 0}), false, 0, [3]float32{}},
+		{"miss_oblique", NewRayFromPoints([3]float32{-5, 0, 0}, [3]float32{0, 10, 0}), false, 0, [3]float32{}},
+		{"behind_origin", NewRay([3]float32{-5, 0, 0}, [3]float32{-1, 0, 0}), false, 0, [3]float32{}},
+		{"unnormalized_direction", &Ray{Origin: [3]float32{-5, 0, 0}, Direction: [3]float32{2, 0, 0}}, true, 2, [3]float32{-1, 0, 0}},
+	}
+	for _, tt := range tests {
+		check_ray_hit(t, tt.name, tt.ray.IntersectAABB(aabb), tt.hit, tt.distance, tt.normal)
+	}
+}
+
+func TestRayIntersectOBB(t *testing.T) {
+	// unit cube rotated by 45 degrees around Z axis, and moved to (10,0,0)
+	m := NewMatrix4().SetMultiplyMatrices(NewMatrix4().SetTranslation(10, 0, 0), NewMatrix4().SetRotationByAxis([3]float32{0, 0, 1}, 45))
+	obb := NewOBBFromAABB(NewAABB([3]float32{-1, -1, -1}, [3]float32{1, 1, 1}), m)
+	s := float32(0.70710678)
+	tests := []struct {
+		name     string
+		ray      *Ray
+		hit      bool
+		distance float32
+		normal   [3]float32
+	}{
+		{"hit_near_corner", NewRay([3]float32{0, -0.5, 0}, [3]float32{1, 0, 0}), true, 10 - 1.4142136 + 0.5, [3]float32{-s, -s, 0}},
+		{"hit_face", NewRayFromPoints([3]float32{10 - 5*s, -5 * s, 0}, [3]float32{10, 0, 0}), true, 4, [3]float32{-s, -s, 0}},
+		{"hit_top", NewRay([3]float32{10, 0, 5}, [3]float32{0, 0, -1}), true, 4, [3]float32{0, 0, 1}},
+		{"inside", NewRay([3]float32{10, 0, 0}, [3]float32{0, 0, 1}), true, 1, [3]float32{0, 0, 1}},
+		{"miss", NewRay([3]float32{0, 1.5, 0}, [3]float32{1, 0, 0}), false, 0, [3]float32{}},
+		{"behind_origin", NewRay([3]float32{0, 0, 0}, [3]float32{-1, 0, 0}), false, 0, [3]float32{}},
+	}
+	for _, tt := range tests {
+		check_ray_hit(t, tt.name, tt.ray.IntersectOBB(obb), tt.hit, tt.distance, tt.normal)
+	}
+}
+
+func TestRayGetClosestPoint(t *testing.T) {
+	tests := []struct {
+		name  string
+		ray   *Ray
+		p     [3]float32
+		t     float32
+		point [3]float32
+	}{
+		{"front", NewRay([3]float32{0, 0, 0}, [3]float32{1, 0, 0}), [3]float32{3, 4, 0}, 3, [3]float32{3, 0, 0}},
+		{"behind", NewRay([3]float32{0, 0, 0}, [3]float32{1, 0, 0}), [3]float32{-3, 4, 0}, 0, [3]float32{0, 0, 0}},
+		{"unnormalized_direction", &Ray{Origin: [3]float32{0, 0, 0}, Direction: [3]float32{2, 0, 0}}, [3]float32{3, 4, 0}, 1.5, [3]float32{3, 0, 0}},
+	}
+	for _, tt := range tests {
+		if d, p := tt.ray.GetClosestPoint(tt.p); d != tt.t || !Vector3(p).IsEqual(Vector3(tt.point), 1e-6) {
+			t.Errorf("%s: GetClosestPoint() = %v, %v, want %v, %v", tt.name, d, p, tt.t, tt.point)
+		}
+	}
+}
+
+func TestGetClosestPointsOfSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		p0, p1   [3]float32
+		q0, q1   [3]float32
+		distance float32
+		s, t     float32 // expected parameters (negative to skip the check, like for parallel segments)
+	}{
+		{"crossing", [3]float32{-1, 0, 0}, [3]float32{1, 0, 0}, [3]float32{0, -1, 1}, [3]float32{0, 1, 1}, 1, 0.5, 0.5},
+		{"skew_clamped", [3]float32{0, 0, 0}, [3]float32{1, 0, 0}, [3]float32{2, -1, 1}, [3]float32{2, 1, 1}, 1.4142136, 1, 0.5},
+		{"endpoints", [3]float32{0, 0, 0}, [3]float32{1, 0, 0}, [3]float32{2, 0, 0}, [3]float32{3, 0, 0}, 1, 1, 0},
+		{"intersecting", [3]float32{-1, -1, 0}, [3]float32{1, 1, 0}, [3]float32{-1, 1, 0}, [3]float32{1, -1, 0}, 0, 0.5, 0.5},
+		{"parallel", [3]float32{0, 0, 0}, [3]float32{2, 0, 0}, [3]float32{1, 1, 0}, [3]float32{3, 1, 0}, 1, -1, -1},
+		{"parallel_apart", [3]float32{0, 0, 0}, [3]float32{1, 0, 0}, [3]float32{3, 1, 0}, [3]float32{5, 1, 0}, 2.2360680, 1, 0},
+		{"collinear_overlapping", [3]float32{0, 0, 0}, [3]float32{2, 0, 0}, [3]float32{1, 0, 0}, [3]float32{3, 0, 0}, 0, -1, -1},
+		{"both_points", [3]float32{0, 0, 0}, [3]float32{0, 0, 0}, [3]float32{3, 4, 0}, [3]float32{3, 4, 0}, 5, 0, 0},
+		{"first_point", [3]float32{1, 1, 0}, [3]float32{1, 1, 0}, [3]float32{0, 0, 0}, [3]float32{2, 0, 0}, 1, 0, 0.5},
+		{"second_point", [3]float32{0, 0, 0}, [3]float32{2, 0, 0}, [3]float32{3, 1, 0}, [3]float32{3, 1, 0}, 1.4142136, 1, 0},
+	}
+	for _, tt := range tests {
+		s, u, cp, cq := GetClosestPointsOfSegments(tt.p0, tt.p1, tt.q0, tt.q1)
+		if d := Length(SubAB(cp, cq)); d-tt.distance > 1e-5 || d-tt.distance < -1e-5 {
+			t.Errorf("%s: distance of the closest points %v, %v = %v, want %v", tt.name, cp, cq, d, tt.distance)
+		}
+		if s < 0 || s > 1 || u < 0 || u > 1 {
+			t.Errorf("%s: parameters %v, %v are out of [0,1]", tt.name, s, u)
+		}
+		if !Vector3(cp).IsEqual(Vector3(AddAB(tt.p0, Scale(SubAB(tt.p1, tt.p0), s))), 1e-6) ||
+			!Vector3(cq).IsEqual(Vector3(AddAB(tt.q0, Scale(SubAB(tt.q1, tt.q0), u))), 1e-6) {
+			t.Errorf("%s: closest points %v, %v don't match the parameters %v, %v", tt.name, cp, cq, s, u)
+		}
+		if tt.s >= 0 && (s-tt.s > 1e-5 || s-tt.s < -1e-5 || u-tt.t > 1e-5 || u-tt.t < -1e-5) {
+			t.Errorf("%s: parameters = %v, %v, want %v, %v", tt.name, s, u, tt.s, tt.t)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package geom3d
+
+import "math"
+
+// ----------------------------------------------------------------------------
+// Plane
+// ----------------------------------------------------------------------------
+
+type Plane struct {
+	Normal [3]float32 // unit normal vector
+	D      float32    // signed distance, such that (Normal · P + D == 0) for points P on the plane
+}
+
+func NewPlane(normal [3]float32, point [3]float32) *Plane {
+	normal = Normalize(normal)
+	return &Plane{Normal: normal, D: -DotAB(normal, point)}
+}
+
+func NewPlaneFromPoints(a [3]float32, b [3]float32, c [3]float32) *Plane {
+	// plane of the triangle (a,b,c), with its normal following the right-hand rule (CCW)
+	return NewPlane(CrossAB(SubAB(b, a), SubAB(c, a)), a)
+}
+
+func (self *Plane) GetSignedDistance(p [3]float32) float32 {
+	// positive on the front side (where the normal points), and negative on the back side
+	return DotAB(self.Normal, p) + self.D
+}
+
+func (self *Plane) ProjectPoint(p [3]float32) [3]float32 {
+	return SubAB(p, Scale(self.Normal, self.GetSignedDistance(p)))
+}
+
+// ----------------------------------------------------------------------------
+// Sphere
+// ----------------------------------------------------------------------------
+
+type Sphere struct {
+	Center [3]float32
+	Radius float32
+}
+
+func NewSphere(center [3]float32, radius float32) *Sphere {
+	return &Sphere{Center: center, Radius: radius}
+}
+
+func (self *Sphere) IsPointInside(p [3]float32) bool {
+	d := SubAB(p, self.Center)
+	return DotAB(d, d) <= self.Radius*self.Radius
+}
+
+// ----------------------------------------------------------------------------
+// AABB (Axis-Aligned Bounding Box)
+// ----------------------------------------------------------------------------
+
+type AABB struct {
+	Min [3]float32
+	Max [3]float32
+}
+
+func NewAABB(min [3]float32, max [3]float32) *AABB {
+	return &AABB{Min: min, Max: max}
+}
+
+func NewAABBFromPoints(points [][3]float32) *AABB {
+	// AABB enclosing all the points (empty, with Min > Max, if there's no point)
+	aabb := AABB{Min: [3]float32{+math.MaxFloat32, +math.MaxFloat32, +math.MaxFloat32},
+		Max: [3]float32{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}}
+	for _, p := range points {
+		aabb.AddPoint(p)
+	}
+	return &aabb
+}
+
+func (self *AABB) AddPoint(p [3]float32) *AABB {
+	for k := 0; k < 3; k++ {
+		if p[k] < self.Min[k] {
+			self.Min[k] = p[k]
+		}
+		if p[k] > self.Max[k] {
+			self.Max[k] = p[k]
+		}
+	}
+	return self
+}
+
+func (self *AABB) IsEmpty() bool {
+	return self.Min[0] > self.Max[0] || self.Min[1] > self.Max[1] || self.Min[2] > self.Max[2]
+}
+
+func (self *AABB) GetCenter() [3]float32 {
+	return AverageAB(self.Min, self.Max)
+}
+
+func (self *AABB) GetSize() [3]float32 {
+	return SubAB(self.Max, self.Min)
+}
+
+func (self *AABB) IsPointInside(p [3]float32) bool {
+	for k := 0; k < 3; k++ {
+		if p[k] < self.Min[k] || p[k] > self.Max[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func (self *AABB) IsOverlapping(b *AABB) bool {
+	for k := 0; k < 3; k++ {
+		if self.Max[k] < b.Min[k] || b.Max[k] < self.Min[k] {
+			return false
+		}
+	}
+	return true
+}
+
+func (self *AABB) Transform(m *Matrix4) *AABB {
+	// new AABB enclosing the 8 corners transformed by 'm'
+	corners := [][3]float32{}
+	for c := 0; c < 8; c++ {
+		corner := self.Min
+		for k := 0; k < 3; k++ {
+			if c&(1<<k) != 0 {
+				corner[k] = self.Max[k]
+			}
+		}
+		corners = append(corners, m.MultiplyVector3(corner))
+	}
+	return NewAABBFromPoints(corners)
+}
+
+// ----------------------------------------------------------------------------
+// OBB (Oriented Bounding Box)
+// ----------------------------------------------------------------------------
+
+type OBB struct {
+	Center   [3]float32
+	Axes     [3][3]float32 // orthonormal axes of the box
+	HalfSize [3]float32    // half of the size along each axis
+}
+
+func NewOBB(center [3]float32, axes [3][3]float32, half_size [3]float32) *OBB {
+	return &OBB{Center: center, Axes: axes, HalfSize: half_size}
+}
+
+func NewOBBFromAABB(aabb *AABB, m *Matrix4) *OBB {
+	// OBB of the AABB transformed by 'm' (rotation, translation and scaling, without shearing)
+	center := m.MultiplyVector3(aabb.GetCenter())
+	size := aabb.GetSize()
+	obb := OBB{Center: center}
+	for k := 0; k < 3; k++ {
+		unit := [3]float32{0, 0, 0}
+		unit[k] = 1
+		axis := m.MultiplyDirection3(unit)
+		length := Length(axis)
+		obb.HalfSize[k] = size[k] / 2 * length
+		if length > 0 {
+			obb.Axes[k] = Scale(axis, 1/length)
+		} else {
+			obb.Axes[k] = unit
+		}
+	}
+	return &obb
+}
+
+func (self *OBB) ToLocal(p [3]float32) [3]float32 {
+	// coordinates of point 'p' in the box space (with its center at the origin)
+	d := SubAB(p, self.Center)
+	return [3]float32{DotAB(d, self.Axes[0]), DotAB(d, self.Axes[1]), DotAB(d, self.Axes[2])}
+}
+
+func (self *OBB) IsPointInside(p [3]float32) bool {
+	local := self.ToLocal(p)
+	for k := 0; k < 3; k++ {
+		if local[k] < -self.HalfSize[k] || local[k] > +self.HalfSize[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// ----------------------------------------------------------------------------
+// Triangle
+// ----------------------------------------------------------------------------
+
+type Triangle struct {
+	V [3][3]float32 // vertices in CCW order
+}
+
+func NewTriangle(v0 [3]float32, v1 [3]float32, v2 [3]float32) *Triangle {
+	return &Triangle{V: [3][3]float32{v0, v1, v2}}
+}
+
+func (self *Triangle) GetNormal() [3]float32 {
+	return Normalize(CrossAB(SubAB(self.V[1], self.V[0]), SubAB(self.V[2], self.V[0])))
+}
+
+func (self *Triangle) GetArea() float32 {
+	return Length(CrossAB(SubAB(self.V[1], self.V[0]), SubAB(self.V[2], self.V[0]))) / 2
+}
+
+func (self *Triangle) GetBarycentric(p [3]float32) [3]float32 {
+	// barycentric coordinates (u,v,w) of point 'p' (projected onto the plane), such that p = u*V0 + v*V1 + w*V2
+	e1, e2, ep := SubAB(self.V[1], self.V[0]), SubAB(self.V[2], self.V[0]), SubAB(p, self.V[0])
+	d11, d12, d22 := DotAB(e1, e1), DotAB(e1, e2), DotAB(e2, e2)
+	dp1, dp2 := DotAB(ep, e1), DotAB(ep, e2)
+	denom := d11*d22 - d12*d12
+	if denom == 0 { // degenerate triangle
+		return [3]float32{1, 0, 0}
+	}
+	v := (d22*dp1 - d12*dp2) / denom
+	w := (d11*dp2 - d12*dp1) / denom
+	return [3]float32{1 - v - w, v, w}
+}
+
+func (self *Triangle) GetPointFromBarycentric(b [3]float32) [3]float32 {
+	return AddAB(AddAB(Scale(self.V[0], b[0]), Scale(self.V[1], b[1])), Scale(self.V[2], b[2]))
+}
@@ -0,0 +1,205 @@
+package geom3d
+
+import "math"
+
+// ----------------------------------------------------------------------------
+// Vector3
+// ----------------------------------------------------------------------------
+
+// Vector3 is a 3D vector with methods, which can be converted to/from [3]float32 directly,
+// like 'geom3d.Vector3(v).Cross(u)' or '[3]float32(v)'.
+type Vector3 [3]float32
+
+func NewVector3(x float32, y float32, z float32) Vector3 {
+	return Vector3{x, y, z}
+}
+
+func (self Vector3) Add(v Vector3) Vector3 {
+	return Vector3{self[0] + v[0], self[1] + v[1], self[2] + v[2]}
+}
+
+func (self Vector3) Sub(v Vector3) Vector3 {
+	return Vector3{self[0] - v[0], self[1] - v[1], self[2] - v[2]}
+}
+
+func (self Vector3) Scale(s float32) Vector3 {
+	return Vector3{self[0] * s, self[1] * s, self[2] * s}
+}
+
+func (self Vector3) Dot(v Vector3) float32 {
+	return self[0]*v[0] + self[1]*v[1] + self[2]*v[2]
+}
+
+func (self Vector3) Cross(v Vector3) Vector3 {
+	return Vector3{self[1]*v[2] - self[2]*v[1], self[2]*v[0] - self[0]*v[2], self[0]*v[1] - self[1]*v[0]}
+}
+
+func (self Vector3) Length() float32 {
+	return float32(math.Sqrt(float64(self.Dot(self))))
+}
+
+func (self Vector3) Normalize() Vector3 {
+	// unit vector (zero vector remains zero)
+	length := self.Length()
+	if length == 0 {
+		return self
+	}
+	return Vector3{self[0] / length, self[1] / length, self[2] / length}
+}
+
+func (self Vector3) DistanceTo(v Vector3) float32 {
+	return self.Sub(v).Length()
+}
+
+func (self Vector3) Lerp(v Vector3, t float32) Vector3 {
+	return Vector3{self[0] + (v[0]-self[0])*t, self[1] + (v[1]-self[1])*t, self[2] + (v[2]-self[2])*t}
+}
+
+func (self Vector3) AngleTo(v Vector3) float32 {
+	// angle between the two vectors (in degree)
+	d := float64(self.Length() * v.Length())
+	if d == 0 {
+		return 0
+	}
+	c := math.Max(-1, math.Min(1, float64(self.Dot(v))/d))
+	return float32(math.Acos(c) * 180 / math.Pi)
+}
+
+func (self Vector3) IsEqual(v Vector3, epsilon float32) bool {
+	for i := 0; i < 3; i++ {
+		if d := self[i] - v[i]; d > epsilon || d < -epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func (self Vector3) Transform(m *Matrix4) Vector3 {
+	return Vector3(m.MultiplyVector3(self))
+}
+
+func (self Vector3) Rotate(q *Quaternion) Vector3 {
+	return Vector3(q.RotateVector3(self))
+}
+
+// ----------------------------------------------------------------------------
+// Vector4
+// ----------------------------------------------------------------------------
+
+// Vector4 is a homogeneous (or RGBA) vector with methods, which can be converted to/from [4]float32 directly.
+type Vector4 [4]float32
+
+func NewVector4(x float32, y float32, z float32, w float32) Vector4 {
+	return Vector4{x, y, z, w}
+}
+
+func (self Vector4) Add(v Vector4) Vector4 {
+	return Vector4{self[0] + v[0], self[1] + v[1], self[2] + v[2], self[3] + v[3]}
+}
+
+func (self Vector4) Sub(v Vector4) Vector4 {
+	return Vector4{self[0] - v[0], self[1] - v[1], self[2] - v[2], self[3] - v[3]}
+}
+
+func (self Vector4) Scale(s float32) Vector4 {
+	return Vector4{self[0] * s, self[1] * s, self[2] * s, self[3] * s}
+}
+
+func (self Vector4) Dot(v Vector4) float32 {
+	return self[0]*v[0] + self[1]*v[1] + self[2]*v[2] + self[3]*v[3]
+}
+
+func (self Vector4) Length() float32 {
+	return float32(math.Sqrt(float64(self.Dot(self))))
+}
+
+func (self Vector4) Normalize() Vector4 {
+	// unit vector (zero vector remains zero)
+	length := self.Length()
+	if length == 0 {
+		return self
+	}
+	return self.Scale(1 / length)
+}
+
+func (self Vector4) Lerp(v Vector4, t float32) Vector4 {
+	return self.Add(v.Sub(self).Scale(t))
+}
+
+func (self Vector4) IsEqual(v Vector4, epsilon float32) bool {
+	for i := 0; i < 4; i++ {
+		if d := self[i] - v[i]; d > epsilon || d < -epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func (self Vector4) Transform(m *Matrix4) Vector4 {
+	return Vector4(m.MultiplyVector4(self))
+}
+
+func (self Vector4) ToVector3() Vector3 {
+	// perspective division by W (W=0 for direction vector is ignored)
+	if self[3] == 0 || self[3] == 1 {
+		return Vector3{self[0], self[1], self[2]}
+	}
+	return Vector3{self[0] / self[3], self[1] / self[3], self[2] / self[3]}
+}
+
+// ----------------------------------------------------------------------------
+// Vector3d (double-precision)
+// ----------------------------------------------------------------------------
+
+// Vector3d is the double-precision version of Vector3, for the coordinates too large for float32.
+type Vector3d [3]float64
+
+func NewVector3d(x float64, y float64, z float64) Vector3d {
+	return Vector3d{x, y, z}
+}
+
+func NewVector3dFromVector3(v [3]float32) Vector3d {
+	return Vector3d{float64(v[0]), float64(v[1]), float64(v[2])}
+}
+
+func (self Vector3d) Add(v Vector3d) Vector3d {
+	return Vector3d{self[0] + v[0], self[1] + v[1], self[2] + v[2]}
+}
+
+func (self Vector3d) Sub(v Vector3d) Vector3d {
+	return Vector3d{self[0] - v[0], self[1] - v[1], self[2] - v[2]}
+}
+
+func (self Vector3d) Scale(s float64) Vector3d {
+	return Vector3d{self[0] * s, self[1] * s, self[2] * s}
+}
+
+func (self Vector3d) Dot(v Vector3d) float64 {
+	return self[0]*v[0] + self[1]*v[1] + self[2]*v[2]
+}
+
+func (self Vector3d) Cross(v Vector3d) Vector3d {
+	return Vector3d{self[1]*v[2] - self[2]*v[1], self[2]*v[0] - self[0]*v[2], self[0]*v[1] - self[1]*v[0]}
+}
+
+func (self Vector3d) Length() float64 {
+	return math.Sqrt(self.Dot(self))
+}
+
+func (self Vector3d) Normalize() Vector3d {
+	// unit vector (zero vector remains zero)
+	length := self.Length()
+	if length == 0 {
+		return self
+	}
+	return Vector3d{self[0] / length, self[1] / length, self[2] / length}
+}
+
+func (self Vector3d) DistanceTo(v Vector3d) float64 {
+	return self.Sub(v).Length()
+}
+
+func (self Vector3d) ToVector3() Vector3 {
+	// single-precision copy (use it only for small values, like the ones relative to the camera)
+	return Vector3{float32(self[0]), float32(self[1]), float32(self[2])}
+}
@@ -0,0 +1,117 @@
+package geom3d
+
+import "testing"
+
+func TestVector3DotCross(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     Vector3
+		b     Vector3
+		dot   float32
+		cross Vector3
+	}{
+		{"x_y", Vector3{1, 0, 0}, Vector3{0, 1, 0}, 0, Vector3{0, 0, 1}},
+		{"y_z", Vector3{0, 1, 0}, Vector3{0, 0, 1}, 0, Vector3{1, 0, 0}},
+		{"z_x", Vector3{0, 0, 1}, Vector3{1, 0, 0}, 0, Vector3{0, 1, 0}},
+		{"y_x", Vector3{0, 1, 0}, Vector3{1, 0, 0}, 0, Vector3{0, 0, -1}},
+		{"parallel", Vector3{1, 2, 3}, Vector3{2, 4, 6}, 28, Vector3{0, 0, 0}},
+		{"general", Vector3{1, 2, 3}, Vector3{4, 5, 6}, 32, Vector3{-3, 6, -3}},
+	}
+	for _, tt := range tests {
+		if d := tt.a.Dot(tt.b); d != tt.dot {
+			t.Errorf("%s: Dot() = %v, want %v", tt.name, d, tt.dot)
+		}
+		if d := DotAB(tt.a, tt.b); d != tt.dot {
+			t.Errorf("%s: DotAB() = %v, want %v", tt.name, d, tt.dot)
+		}
+		if c := tt.a.Cross(tt.b); c != tt.cross {
+			t.Errorf("%s: Cross() = %v, want %v", tt.name, c, tt.cross)
+		}
+		if c := CrossAB(tt.a, tt.b); c != tt.cross {
+			t.Errorf("%s: CrossAB() = %v, want %v", tt.name, c, tt.cross)
+		}
+		// cross product is perpendicular to both of the vectors
+		if c := tt.a.Cross(tt.b); c.Dot(tt.a) != 0 || c.Dot(tt.b) != 0 {
+			t.Errorf("%s: Cross() = %v is not perpendicular", tt.name, c)
+		}
+	}
+}
+
+func TestVector3Normalize(t *testing.T) {
+	tests := []struct {
+		name string
+		v    Vector3
+		want Vector3
+	}{
+		{"unit", Vector3{0, 1, 0}, Vector3{0, 1, 0}},
+		{"axis", Vector3{0, 0, -5}, Vector3{0, 0, -1}},
+		{"general", Vector3{3, 4, 12}, Vector3{3.0 / 13, 4.0 / 13, 12.0 / 13}},
+	}
+	for _, tt := range tests {
+		if n := tt.v.Normalize(); !n.IsEqual(tt.want, 1e-6) {
+			t.Errorf("%s: Normalize() = %v, want %v", tt.name, n, tt.want)
+		}
+		if n := Normalize(tt.v); !Vector3(n).IsEqual(tt.want, 1e-6) {
+			t.Errorf("%s: geom3d.Normalize() = %v, want %v", tt.name, n, tt.want)
+		}
+	}
+	if n := (Vector3{0, 0, 0}).Normalize(); n != (Vector3{0, 0, 0}) {
+		t.Errorf("zero: Normalize() = %v, want zero vector", n)
+	}
+}
+
+func TestVector3Operations(t *testing.T) {
+	a, b := Vector3{1, 2, 3}, Vector3{4, 6, 3}
+	tests := []struct {
+		name string
+		got  Vector3
+		want Vector3
+	}{
+		{"Add", a.Add(b), Vector3{5, 8, 6}},
+		{"Sub", b.Sub(a), Vector3{3, 4, 0}},
+		{"Scale", a.Scale(-2), Vector3{-2, -4, -6}},
+		{"Lerp", a.Lerp(b, 0.5), Vector3{2.5, 4, 3}},
+		{"Transform", a.Transform(NewMatrix4().SetTranslation(1, 1, 1)), Vector3{2, 3, 4}},
+		{"Rotate", Vector3{1, 0, 0}.Rotate(NewQuaternion().SetRotationByAxis([3]float32{0, 0, 1}, 90)), Vector3{0, 1, 0}},
+	}
+	for _, tt := range tests {
+		if !tt.got.IsEqual(tt.want, 1e-6) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if d := a.DistanceTo(b); d != 5 {
+		t.Errorf("DistanceTo() = %v, want 5", d)
+	}
+	if angle := (Vector3{1, 0, 0}).AngleTo(Vector3{1, 1, 0}); angle < 44.999 || angle > 45.001 {
+		t.Errorf("AngleTo() = %v, want 45", angle)
+	}
+}
+
+func TestVector4(t *testing.T) {
+	a, b := Vector4{1, 2, 3, 4}, Vector4{4, 3, 2, 1}
+	if d := a.Dot(b); d != 20 {
+		t.Errorf("Dot() = %v, want 20", d)
+	}
+	if n := (Vector4{0, 3, 0, 4}).Normalize(); !n.IsEqual(Vector4{0, 0.6, 0, 0.8}, 1e-6) {
+		t.Errorf("Normalize() = %v, want [0 0.6 0 0.8]", n)
+	}
+	if v := (Vector4{2, 4, 6, 2}).ToVector3(); v != (Vector3{1, 2, 3}) {
+		t.Errorf("ToVector3() = %v, want [1 2 3]", v)
+	}
+}
+
+func TestVector3d(t *testing.T) {
+	a, b := NewVector3d(1, 2, 3), NewVector3d(4, 5, 6)
+	if d := a.Dot(b); d != 32 {
+		t.Errorf("Dot() = %v, want 32", d)
+	}
+	if c := a.Cross(b); c != NewVector3d(-3, 6, -3) {
+		t.Errorf("Cross() = %v, want [-3 6 -3]", c)
+	}
+	if l := NewVector3d(2e7, 0, 0).Add(NewVector3d(0.25, 0, 0)).Sub(NewVector3d(2e7, 0, 0)).Length(); l != 0.25 {
+		t.Errorf("Length() = %v, want 0.25 (without losing precision)", l)
+	}
+	if n := NewVector3d(0, 0, 7).Normalize(); n != NewVector3d(0, 0, 1) {
+		t.Errorf("Normalize() = %v, want [0 0 1]", n)
+	}
+}
@@ -0,0 +1,32 @@
+package wcommon
+
+import "fmt"
+
+// Blending modes for SceneObjects with 'UseBlend' (the color of the fragment is blended with the color behind it)
+//   "PREMULTIPLIED"     : src.rgb + dst.rgb * (1 - src.a)   for colors pre-multiplied by alpha (default)
+//   "NON_PREMULTIPLIED" : src.rgb * src.a + dst.rgb * (1 - src.a)
+//   "ADDITIVE"          : src.rgb * src.a + dst.rgb         for glows, particles or lights
+//   "MULTIPLY"          : src.rgb * dst.rgb                 for tinted glass or shadows (ignoring alpha)
+
+func SetBlendMode(wctx *WebGLContext, mode string) {
+	// Enable blending with the given mode ("" for the default "PREMULTIPLIED"), or disable it with "NONE"
+	context, c := wctx.GetContext(), wctx.GetConstants()
+	if mode == "NONE" {
+		context.Call("disable", c.BLEND)
+		return
+	}
+	context.Call("enable", c.BLEND)
+	switch mode {
+	case "", "PREMULTIPLIED":
+		context.Call("blendFunc", c.ONE, c.ONE_MINUS_SRC_ALPHA)
+	case "NON_PREMULTIPLIED":
+		context.Call("blendFunc", c.SRC_ALPHA, c.ONE_MINUS_SRC_ALPHA)
+	case "ADDITIVE":
+		context.Call("blendFunc", c.SRC_ALPHA, c.ONE)
+	case "MULTIPLY":
+		context.Call("blendFunc", c.DST_COLOR, c.ZERO)
+	default:
+		fmt.Printf("Failed to SetBlendMode('%s') : try 'PREMULTIPLIED', 'NON_PREMULTIPLIED', 'ADDITIVE' or 'MULTIPLY'\n", mode)
+		context.Call("blendFunc", c.ONE, c.ONE_MINUS_SRC_ALPHA)
+	}
+}
@@ -0,0 +1,85 @@
+package wcommon
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// ----------------------------------------------------------------------------
+// Buffer Usage
+// ----------------------------------------------------------------------------
+
+func GetBufferUsage(constants *Constants, usage string) js.Value {
+	// Get the usage hint for gl.bufferData()
+	//   "STATIC"  : data is set once, and drawn many times (default)
+	//   "DYNAMIC" : data is modified repeatedly, and drawn many times (like moving instances)
+	//   "STREAM"  : data is modified every frame, and drawn a few times
+	switch usage {
+	case "DYNAMIC":
+		return constants.DYNAMIC_DRAW
+	case "STREAM":
+		return constants.STREAM_DRAW
+	case "STATIC", "":
+		return constants.STATIC_DRAW
+	default:
+		fmt.Printf("Invalid buffer usage '%s' (STATIC_DRAW is used instead)\n", usage)
+		return constants.STATIC_DRAW
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Dirty Range (of data buffer modified after the last upload)
+// ----------------------------------------------------------------------------
+
+type DirtyRange struct {
+	start int // first dirty element
+	end   int // last dirty element + 1 (same as 'start', if clean)
+}
+
+func (self *DirtyRange) Mark(start int, end int) {
+	// Mark the range [start, end) of the data buffer as dirty (merged with the existing dirty range)
+	if start >= end {
+		return
+	}
+	if self.start == self.end {
+		self.start, self.end = start, end
+		return
+	}
+	if start < self.start {
+		self.start = start
+	}
+	if end > self.end {
+		self.end = end
+	}
+}
+
+func (self *DirtyRange) IsDirty() bool {
+	return self.start < self.end
+}
+
+func (self *DirtyRange) Get() (int, int) {
+	return self.start, self.end
+}
+
+func (self *DirtyRange) Reset() {
+	self.start, self.end = 0, 0
+}
+
+func UploadDirtyRange(wctx *WebGLContext, buffer js.Value, data []float32, dirty *DirtyRange) {
+	// Upload only the dirty range of the data buffer to the WebGL (ARRAY_BUFFER) buffer, using gl.bufferSubData().
+	if buffer.IsNull() || !dirty.IsDirty() {
+		return
+	}
+	context, constants := wctx.GetContext(), wctx.GetConstants()
+	start, end := dirty.Get()
+	if end > len(data) {
+		end = len(data)
+	}
+	if start < end {
+		context.Call("bindBuffer", constants.ARRAY_BUFFER, buffer)
+		var sub_array = ConvertGoSliceToJsTypedArray(data[start:end])
+		context.Call("bufferSubData", constants.ARRAY_BUFFER, start*4, sub_array) // offset in bytes
+		context.Call("bindBuffer", constants.ARRAY_BUFFER, nil)
+	}
+	dirty.Reset()
+}
@@ -5,39 +5,56 @@ import (
 )
 
 type Constants struct {
-	ARRAY_BUFFER         js.Value //
-	BLEND                js.Value // for gl.enable(gl.BLEND)
-	BYTE                 js.Value //
-	CLAMP_TO_EDGE        js.Value // for gl.texParameteri()
-	COLOR_BUFFER_BIT     js.Value //
-	COMPILE_STATUS       js.Value //
-	DEPTH_BUFFER_BIT     js.Value //
-	DEPTH_TEST           js.Value //
-	ELEMENT_ARRAY_BUFFER js.Value //
-	FLOAT                js.Value //
-	FRAGMENT_SHADER      js.Value //
-	LEQUAL               js.Value //
-	LINEAR               js.Value // for gl.texParameteri()
-	LINES                js.Value //
-	LINK_STATUS          js.Value //
-	NEAREST              js.Value // for gl.texParameteri()
-	ONE                  js.Value // for gl.blendFunc()
-	ONE_MINUS_SRC_ALPHA  js.Value // for gl.blendFunc()
-	POINTS               js.Value //
-	RGBA                 js.Value //
-	SRC_ALPHA            js.Value // for gl.blendFunc()
-	STATIC_DRAW          js.Value //
-	TEXTURE_2D           js.Value // for gl.texParameteri()
-	TEXTURE0             js.Value //
-	TEXTURE1             js.Value //
-	TEXTURE_MIN_FILTER   js.Value // for gl.texParameteri()
-	TEXTURE_WRAP_S       js.Value // for gl.texParameteri()
-	TEXTURE_WRAP_T       js.Value // for gl.texParameteri()
-	TRIANGLES            js.Value //
-	UNSIGNED_BYTE        js.Value //
-	UNSIGNED_INT         js.Value //
-	UNSIGNED_SHORT       js.Value //
-	VERTEX_SHADER        js.Value //
+	ARRAY_BUFFER                js.Value //
+	BLEND                       js.Value // for gl.enable(gl.BLEND)
+	BYTE                        js.Value //
+	CLAMP_TO_EDGE               js.Value // for gl.texParameteri()
+	COLOR_ATTACHMENT0           js.Value // for gl.framebufferTexture2D()
+	COLOR_BUFFER_BIT            js.Value //
+	COMPILE_STATUS              js.Value //
+	DEPTH_ATTACHMENT            js.Value // for gl.framebufferRenderbuffer()
+	DEPTH_BUFFER_BIT            js.Value //
+	DEPTH_COMPONENT16           js.Value // for gl.renderbufferStorage()
+	DEPTH_STENCIL               js.Value // for gl.renderbufferStorage()
+	DEPTH_STENCIL_ATTACHMENT    js.Value // for gl.framebufferRenderbuffer()
+	DEPTH_TEST                  js.Value //
+	DST_COLOR                   js.Value // for gl.blendFunc()
+	DYNAMIC_DRAW                js.Value // for gl.bufferData()
+	ELEMENT_ARRAY_BUFFER        js.Value //
+	FLOAT                       js.Value //
+	FRAGMENT_SHADER             js.Value //
+	FRAMEBUFFER                 js.Value // for gl.bindFramebuffer()
+	FRAMEBUFFER_COMPLETE        js.Value // for gl.checkFramebufferStatus()
+	LEQUAL                      js.Value //
+	LINEAR                      js.Value // for gl.texParameteri()
+	LINES                       js.Value //
+	LINK_STATUS                 js.Value //
+	NEAREST                     js.Value // for gl.texParameteri()
+	ONE                         js.Value // for gl.blendFunc()
+	ONE_MINUS_SRC_ALPHA         js.Value // for gl.blendFunc()
+	ONE_MINUS_SRC_COLOR         js.Value // for gl.blendFunc()
+	POINTS                      js.Value //
+	RENDERBUFFER                js.Value // for gl.bindRenderbuffer()
+	RGBA                        js.Value //
+	STENCIL_BUFFER_BIT          js.Value // for gl.clear()
+	SRC_ALPHA                   js.Value // for gl.blendFunc()
+	STATIC_DRAW                 js.Value //
+	STREAM_DRAW                 js.Value // for gl.bufferData()
+	TEXTURE_2D                  js.Value // for gl.texParameteri()
+	TEXTURE_CUBE_MAP            js.Value // for gl.texParameteri()
+	TEXTURE_CUBE_MAP_POSITIVE_X js.Value // for gl.texImage2D() (followed by -X, +Y, -Y, +Z, -Z)
+	TEXTURE0                    js.Value //
+	TEXTURE1                    js.Value //
+	TEXTURE_MAG_FILTER          js.Value // for gl.texParameteri()
+	TEXTURE_MIN_FILTER          js.Value // for gl.texParameteri()
+	TEXTURE_WRAP_S              js.Value // for gl.texParameteri()
+	TEXTURE_WRAP_T              js.Value // for gl.texParameteri()
+	TRIANGLES                   js.Value //
+	UNSIGNED_BYTE               js.Value //
+	UNSIGNED_INT                js.Value //
+	UNSIGNED_SHORT              js.Value //
+	VERTEX_SHADER               js.Value //
+	ZERO                        js.Value // for gl.blendFunc()
 }
 
 func (self *Constants) LoadFromContext(context js.Value) {
@@ -46,13 +63,22 @@ func (self *Constants) LoadFromContext(context js.Value) {
 	self.BLEND = context.Get("BLEND")
 	self.BYTE = context.Get("BYTE")
 	self.CLAMP_TO_EDGE = context.Get("CLAMP_TO_EDGE")
+	self.COLOR_ATTACHMENT0 = context.Get("COLOR_ATTACHMENT0")
 	self.COLOR_BUFFER_BIT = context.Get("COLOR_BUFFER_BIT")
 	self.COMPILE_STATUS = context.Get("COMPILE_STATUS")
+	self.DEPTH_ATTACHMENT = context.Get("DEPTH_ATTACHMENT")
 	self.DEPTH_BUFFER_BIT = context.Get("DEPTH_BUFFER_BIT")
+	self.DEPTH_COMPONENT16 = context.Get("DEPTH_COMPONENT16")
+	self.DEPTH_STENCIL = context.Get("DEPTH_STENCIL")
+	self.DEPTH_STENCIL_ATTACHMENT = context.Get("DEPTH_STENCIL_ATTACHMENT")
 	self.DEPTH_TEST = context.Get("DEPTH_TEST")
+	self.DST_COLOR = context.Get("DST_COLOR")
+	self.DYNAMIC_DRAW = context.Get("DYNAMIC_DRAW")
 	self.ELEMENT_ARRAY_BUFFER = context.Get("ELEMENT_ARRAY_BUFFER")
 	self.FLOAT = context.Get("FLOAT")
 	self.FRAGMENT_SHADER = context.Get("FRAGMENT_SHADER")
+	self.FRAMEBUFFER = context.Get("FRAMEBUFFER")
+	self.FRAMEBUFFER_COMPLETE = context.Get("FRAMEBUFFER_COMPLETE")
 	self.LEQUAL = context.Get("LEQUAL")
 	self.LINEAR = context.Get("LINEAR")
 	self.LINES = context.Get("LINES")
@@ -60,13 +86,20 @@ func (self *Constants) LoadFromContext(context js.Value) {
 	self.NEAREST = context.Get("NEAREST")
 	self.ONE = context.Get("ONE")
 	self.ONE_MINUS_SRC_ALPHA = context.Get("ONE_MINUS_SRC_ALPHA")
+	self.ONE_MINUS_SRC_COLOR = context.Get("ONE_MINUS_SRC_COLOR")
 	self.POINTS = context.Get("POINTS")
+	self.RENDERBUFFER = context.Get("RENDERBUFFER")
 	self.RGBA = context.Get("RGBA")
+	self.STENCIL_BUFFER_BIT = context.Get("STENCIL_BUFFER_BIT")
 	self.SRC_ALPHA = context.Get("SRC_ALPHA")
 	self.STATIC_DRAW = context.Get("STATIC_DRAW")
+	self.STREAM_DRAW = context.Get("STREAM_DRAW")
 	self.TEXTURE_2D = context.Get("TEXTURE_2D")
+	self.TEXTURE_CUBE_MAP = context.Get("TEXTURE_CUBE_MAP")
+	self.TEXTURE_CUBE_MAP_POSITIVE_X = context.Get("TEXTURE_CUBE_MAP_POSITIVE_X")
 	self.TEXTURE0 = context.Get("TEXTURE0")
 	self.TEXTURE1 = context.Get("TEXTURE1")
+	self.TEXTURE_MAG_FILTER = context.Get("TEXTURE_MAG_FILTER")
 	self.TEXTURE_MIN_FILTER = context.Get("TEXTURE_MIN_FILTER")
 	self.TEXTURE_WRAP_S = context.Get("TEXTURE_WRAP_S")
 	self.TEXTURE_WRAP_T = context.Get("TEXTURE_WRAP_T")
@@ -75,4 +108,5 @@ func (self *Constants) LoadFromContext(context js.Value) {
 	self.UNSIGNED_INT = context.Get("UNSIGNED_INT")
 	self.UNSIGNED_SHORT = context.Get("UNSIGNED_SHORT")
 	self.VERTEX_SHADER = context.Get("VERTEX_SHADER")
+	self.ZERO = context.Get("ZERO")
 }
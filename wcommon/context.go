@@ -16,6 +16,9 @@ type WebGLContext struct {
 	constants Constants // WebGL constant values
 	ext_uint  js.Value  // extension for "OES_element_index_uint"
 	ext_angle js.Value  // extension for "ANGLE_instanced_arrays"
+	ext_deriv js.Value  // extension for "OES_standard_derivatives"
+	ext_mrt   js.Value  // extension for "WEBGL_draw_buffers"
+	ext_half  js.Value  // extension for "OES_texture_half_float" (with "EXT_color_buffer_half_float")
 }
 
 func NewWebGLContext(canvas_id string) (*WebGLContext, error) {
@@ -50,6 +53,7 @@ func NewWebGLContext(canvas_id string) (*WebGLContext, error) {
 	wctx.constants.LoadFromContext(wctx.context) // load WebGL constants
 	wctx.SetupExtension("UINT32")                // extension for UINT32 index
 	wctx.SetupExtension("ANGLE")                 // extension for geometry instancing
+	wctx.SetupExtension("DERIVATIVES")           // extension for dFdx() & dFdy() in fragment shaders
 	return &wctx, nil
 }
 
@@ -84,6 +88,13 @@ func (self *WebGLContext) SetupExtension(extname string) {
 		self.ext_uint = self.context.Call("getExtension", "OES_element_index_uint")
 	case "ANGLE": // extension for geometry instancing
 		self.ext_angle = self.context.Call("getExtension", "ANGLE_instanced_arrays")
+	case "DERIVATIVES": // extension for dFdx() & dFdy() in fragment shaders (like for normal mapping)
+		self.ext_deriv = self.context.Call("getExtension", "OES_standard_derivatives")
+	case "DRAWBUFFERS": // extension for multiple color attachments of framebuffer (like for RenderTarget)
+		self.ext_mrt = self.context.Call("getExtension", "WEBGL_draw_buffers")
+	case "HALFFLOAT": // extension for rendering into HALF_FLOAT textures (like for order-independent transparency)
+		self.ext_half = self.context.Call("getExtension", "OES_texture_half_float")
+		self.context.Call("getExtension", "EXT_color_buffer_half_float") // (implicitly enabled in some browsers)
 	}
 }
 
@@ -93,6 +104,12 @@ func (self *WebGLContext) IsExtensionReady(extname string) bool {
 		return !self.ext_uint.IsNull() && !self.ext_uint.IsUndefined()
 	case "ANGLE": // extension for geometry instancing
 		return !self.ext_angle.IsNull() && !self.ext_angle.IsUndefined()
+	case "DERIVATIVES": // extension for dFdx() & dFdy() in fragment shaders (like for normal mapping)
+		return !self.ext_deriv.IsNull() && !self.ext_deriv.IsUndefined()
+	case "DRAWBUFFERS": // extension for multiple color attachments of framebuffer (like for RenderTarget)
+		return !self.ext_mrt.IsNull() && !self.ext_mrt.IsUndefined()
+	case "HALFFLOAT": // extension for rendering into HALF_FLOAT textures (like for order-independent transparency)
+		return !self.ext_half.IsNull() && !self.ext_half.IsUndefined()
 	}
 	return false
 }
@@ -103,6 +120,12 @@ func (self *WebGLContext) GetExtension(extname string) js.Value {
 		return self.ext_uint
 	case "ANGLE": // extension for geometry instancing
 		return self.ext_angle
+	case "DERIVATIVES": // extension for dFdx() & dFdy() in fragment shaders (like for normal mapping)
+		return self.ext_deriv
+	case "DRAWBUFFERS": // extension for multiple color attachments of framebuffer (like for RenderTarget)
+		return self.ext_mrt
+	case "HALFFLOAT": // extension for rendering into HALF_FLOAT textures (like for order-independent transparency)
+		return self.ext_half
 	}
 	return js.Null()
 }
@@ -6,6 +6,7 @@ type Geometry interface {
 	IsDataBufferReady() bool
 	IsWebGLBufferReady() bool
 	BuildWebGLBuffers(wctx *WebGLContext, for_points bool, for_lines bool, for_faces bool)
+	UpdateWebGLBuffers(wctx *WebGLContext)
 	GetWebGLBuffer(draw_mode int) (js.Value, int, [4]int)
 	ShowInfo()
 }
@@ -16,17 +16,37 @@ import (
 )
 
 type Material struct {
-	wctx            *WebGLContext //
-	color           [4][4]float32 // color ([0]:common, [1]:vert, [2]:edge, [3]:face)
-	texture         js.Value      // texture
-	texture_wh      [2]int        // texture size
-	alphabet_cwh    [2]float32    // character width & height of ALPHABET_STRING
-	texture_loading bool          // true, only if texture is being loaded
+	wctx            *WebGLContext            //
+	color           [4][4]float32            // color ([0]:common, [1]:vert, [2]:edge, [3]:face)
+	texture         js.Value                 // texture
+	texture_wh      [2]int                   // texture size
+	alphabet_cwh    [2]float32               // character width & height of ALPHABET_STRING
+	texture_loading bool                     // true, only if texture is being loaded
+	specular        [3]float32               // specular color (for Blinn-Phong shading)
+	shininess       float32                  // specular exponent (for Blinn-Phong shading)
+	emissive        [3]float32               // emissive color (added regardless of lighting)
+	metallic        float32                  // metallic factor (for PBR shading)
+	roughness       float32                  // roughness factor (for PBR shading)
+	normal_scale    float32                  // scale of normal map
+	occlusion       float32                  // strength of occlusion map
+	maps            map[string]*texture_slot // texture maps in named slots, like "diffuse", "normal", "basecolor", etc
+	cubemap         *TextureCube             // cube map (for skybox or environment reflection)
+	reflectivity    float32                  // amount of environment reflection (0 ~ 1)
+	refraction      float32                  // ratio of indices of refraction (like 1/1.5 for glass, 0 for opaque)
+}
+
+type texture_slot struct {
+	texture   *Texture   // texture map (nil, if not given yet)
+	uvset     int        // UV set of the geometry (0: first UV, 1: second UV like for lightmaps)
+	transform [9]float32 // UV transformation (mat3 in column-major order) with offset, scale & rotation
 }
 
 func NewMaterial(wctx *WebGLContext, source string) *Material {
 	mat := Material{wctx: wctx, texture: js.Null(), texture_wh: [2]int{0, 0}}
 	mat.SetDrawModeColor(0, [4]float32{0, 1, 1, 1})
+	mat.SetSpecular("#333333").SetShininess(32) // dim specular highlight by default
+	mat.SetNormalScale(1.0).SetOcclusionStrength(1.0)
+	mat.SetMetallicRoughness(0, 1) // dielectric & fully rough (matte) by default
 	if len(source) > 0 {
 		if source[0] == '#' { // COLOR RGB value
 			rgba := GetRGBAFromString(source)
@@ -44,7 +64,23 @@ func (self *Material) ShowInfo() {
 		c := [4]uint8{uint8(self.color[i][0] * 255), uint8(self.color[i][1] * 255), uint8(self.color[i][2] * 255), uint8(self.color[i][3] * 255)}
 		colors += fmt.Sprintf("#%02x%02x%02x%02x ", c[0], c[1], c[2], c[3])
 	}
-	fmt.Printf("Material with TEXTURE %dx%d and COLOR %s\n", self.texture_wh[0], self.texture_wh[1], colors)
+	fmt.Printf("Material with TEXTURE %dx%d and COLOR %s (specular=%v shininess=%v emissive=%v)\n",
+		self.texture_wh[0], self.texture_wh[1], colors, self.specular, self.shininess, self.emissive)
+	for slot, tslot := range self.maps {
+		if tslot.texture != nil {
+			fmt.Printf("    texture map %-10s: %dx%d with UV%d\n", slot, tslot.texture.wh[0], tslot.texture.wh[1], tslot.uvset+1)
+		}
+	}
+}
+
+func NewMaterial_Plastic(wctx *WebGLContext, color string) *Material {
+	// Plastic material with white (dielectric) specular highlight
+	return NewMaterial(wctx, color).SetSpecular("#808080").SetShininess(32)
+}
+
+func NewMaterial_Metal(wctx *WebGLContext, color string) *Material {
+	// Metal material with sharp specular highlight tinted by its own color
+	return NewMaterial(wctx, color).SetSpecular(color).SetShininess(128)
 }
 
 // ----------------------------------------------------------------------------
@@ -108,10 +144,241 @@ func GetRGBAFromString(s string) [4]float32 {
 	return [4]float32{float32(c[0]) / 255, float32(c[1]) / 255, float32(c[2]) / 255, float32(c[3]) / 255}
 }
 
+// ----------------------------------------------------------------------------
+// SPECULAR & EMISSIVE (for Blinn-Phong shading)
+// ----------------------------------------------------------------------------
+
+func (self *Material) SetSpecular(color string) *Material {
+	rgba := GetRGBAFromString(color)
+	self.specular = [3]float32{rgba[0], rgba[1], rgba[2]}
+	return self
+}
+
+func (self *Material) GetSpecular() [3]float32 {
+	return self.specular
+}
+
+func (self *Material) SetShininess(shininess float32) *Material {
+	// 'shininess' : specular exponent, like 8 (rough & wide highlight) ~ 256 (polished & sharp highlight)
+	self.shininess = shininess
+	return self
+}
+
+func (self *Material) GetShininess() float32 {
+	return self.shininess
+}
+
+func (self *Material) SetEmissive(color string) *Material {
+	rgba := GetRGBAFromString(color)
+	self.emissive = [3]float32{rgba[0], rgba[1], rgba[2]}
+	return self
+}
+
+func (self *Material) GetEmissive() [3]float32 {
+	return self.emissive
+}
+
+// ----------------------------------------------------------------------------
+// PBR (Metallic-Roughness, just like glTF)
+// ----------------------------------------------------------------------------
+
+func NewMaterial_PBR(wctx *WebGLContext, base_color string, metallic float32, roughness float32) *Material {
+	// PBR material with base color factor, metallic & roughness factors (without any texture map)
+	return NewMaterial(wctx, base_color).SetMetallicRoughness(metallic, roughness)
+}
+
+func (self *Material) SetMetallicRoughness(metallic float32, roughness float32) *Material {
+	// 'metallic' : 0 (dielectric) ~ 1 (metal),  'roughness' : 0 (smooth) ~ 1 (rough)
+	self.metallic = metallic
+	self.roughness = roughness
+	return self
+}
+
+func (self *Material) GetMetallic() float32 {
+	return self.metallic
+}
+
+func (self *Material) GetRoughness() float32 {
+	return self.roughness
+}
+
+func (self *Material) SetNormalScale(scale float32) *Material {
+	self.normal_scale = scale
+	return self
+}
+
+func (self *Material) GetNormalScale() float32 {
+	return self.normal_scale
+}
+
+func (self *Material) SetOcclusionStrength(strength float32) *Material {
+	self.occlusion = strength
+	return self
+}
+
+func (self *Material) GetOcclusionStrength() float32 {
+	return self.occlusion
+}
+
+// ----------------------------------------------------------------------------
+// TEXTURE MAPS (in named slots, each with its own UV set & UV transformation)
+// ----------------------------------------------------------------------------
+
+func NewMaterial_NormalMapped(wctx *WebGLContext, diffuse string, normal string) *Material {
+	// Material with diffuse & normal (tangent space) texture maps, loaded from server paths
+	// (like for building facades or terrain, rendered with NewShader_NormalMapping())
+	mat := NewMaterial(wctx, "#ffffff")
+	if diffuse != "" {
+		mat.LoadTextureMap("diffuse", diffuse)
+	}
+	if normal != "" {
+		mat.LoadTextureMap("normal", normal)
+	}
+	return mat
+}
+
+func (self *Material) get_texture_slot(slot string) *texture_slot {
+	// 'slot' : "diffuse" (or "basecolor"), "normal", "specular", "emissive", "occlusion", "lightmap" or "metalrough"
+	switch slot {
+	case "diffuse", "normal", "specular", "emissive", "occlusion", "lightmap", "basecolor", "metalrough":
+		if self.maps == nil {
+			self.maps = map[string]*texture_slot{}
+		}
+		if self.maps[slot] == nil {
+			self.maps[slot] = &texture_slot{texture: nil, uvset: 0, transform: [9]float32{1, 0, 0, 0, 1, 0, 0, 0, 1}}
+		}
+		return self.maps[slot]
+	default:
+		return nil
+	}
+}
+
+func (self *Material) SetTextureMap(slot string, texture *Texture) *Material {
+	// 'slot' : "diffuse" (RGBA), "normal" (tangent space), "specular" (RGB), "emissive" (RGB), "occlusion" (R),
+	//          "lightmap" (RGB, usually with the second UV), or "basecolor" (sRGB), "metalrough" (G:roughness, B:metallic) for PBR
+	if tslot := self.get_texture_slot(slot); tslot != nil {
+		tslot.texture = texture
+	} else {
+		fmt.Printf("Failed to SetTextureMap() : invalid slot '%s'\n", slot)
+	}
+	return self
+}
+
+func (self *Material) LoadTextureMap(slot string, path string) *Material {
+	// Load texture map from server path (same as SetTextureMap(slot, NewTexture(wctx, path)))
+	return self.SetTextureMap(slot, NewTexture(self.wctx, path))
+}
+
+func (self *Material) GetTextureMap(slot string) *Texture {
+	if tslot := self.maps[slot]; tslot != nil {
+		return tslot.texture
+	}
+	return nil // not found
+}
+
+func (self *Material) SetTextureMapUVSet(slot string, uvset int) *Material {
+	// 'uvset' : 0 (first UV coordinates, by default) or 1 (second UV coordinates, like for lightmaps)
+	if tslot := self.get_texture_slot(slot); tslot != nil && (uvset == 0 || uvset == 1) {
+		tslot.uvset = uvset
+	} else {
+		fmt.Printf("Failed to SetTextureMapUVSet() : invalid slot '%s' or UV set %d\n", slot, uvset)
+	}
+	return self
+}
+
+func (self *Material) GetTextureMapUVSet(slot string) int {
+	if tslot := self.maps[slot]; tslot != nil {
+		return tslot.uvset
+	}
+	return 0
+}
+
+func (self *Material) SetTextureMapTransform(slot string, offset [2]float32, scale [2]float32, rotation_in_degree float32) *Material {
+	// UV transformation of the texture map, as  UV' = offset + Rotation * (scale * UV)
+	// (like scale {8,8} for repeating a texture of bricks, with REPEAT wrapping of POWER-OF-2 textures)
+	if tslot := self.get_texture_slot(slot); tslot != nil {
+		rad := float64(rotation_in_degree) * math.Pi / 180
+		cos, sin := float32(math.Cos(rad)), float32(math.Sin(rad))
+		tslot.transform = [9]float32{cos * scale[0], sin * scale[0], 0, -sin * scale[1], cos * scale[1], 0, offset[0], offset[1], 1}
+	} else {
+		fmt.Printf("Failed to SetTextureMapTransform() : invalid slot '%s'\n", slot)
+	}
+	return self
+}
+
+func (self *Material) GetTextureMapTransform(slot string) [9]float32 {
+	// UV transformation of the texture map (mat3 in column-major order)
+	if tslot := self.maps[slot]; tslot != nil {
+		return tslot.transform
+	}
+	return [9]float32{1, 0, 0, 0, 1, 0, 0, 0, 1}
+}
+
+// ----------------------------------------------------------------------------
+// CUBE MAP (for skybox and environment reflection/refraction)
+// ----------------------------------------------------------------------------
+
+func NewMaterial_Mirror(wctx *WebGLContext, color string, cubemap *TextureCube) *Material {
+	// Material reflecting the environment (cube map) like a chrome surface
+	return NewMaterial(wctx, color).SetCubeMap(cubemap).SetReflectivity(0.9).SetSpecular("#ffffff").SetShininess(128)
+}
+
+func NewMaterial_Glass(wctx *WebGLContext, color string, cubemap *TextureCube) *Material {
+	// Material refracting the environment (cube map) like a clear glass, with a little reflection
+	return NewMaterial(wctx, color).SetCubeMap(cubemap).SetReflectivity(0.1).SetRefractionRatio(1 / 1.5).SetSpecular("#ffffff").SetShininess(128)
+}
+
+func (self *Material) SetCubeMap(cubemap *TextureCube) *Material {
+	self.cubemap = cubemap
+	return self
+}
+
+func (self *Material) LoadCubeMap(paths [6]string) *Material {
+	// Load cube map from six images (+X, -X, +Y, -Y, +Z, -Z) on server (same as SetCubeMap(NewTextureCube(wctx, paths)))
+	return self.SetCubeMap(NewTextureCube(self.wctx, paths))
+}
+
+func (self *Material) LoadCubeMapFromPanorama(path string, size int) *Material {
+	// Load cube map from an equirectangular panorama on server (same as SetCubeMap(NewTextureCube_Panorama(wctx, path, size)))
+	return self.SetCubeMap(NewTextureCube_Panorama(self.wctx, path, size))
+}
+
+func (self *Material) GetCubeMap() *TextureCube {
+	return self.cubemap // nil, if not found
+}
+
+func (self *Material) SetReflectivity(reflectivity float32) *Material {
+	// 'reflectivity' : 0 (no reflection) ~ 1 (perfect mirror)
+	self.reflectivity = reflectivity
+	return self
+}
+
+func (self *Material) GetReflectivity() float32 {
+	return self.reflectivity
+}
+
+func (self *Material) SetRefractionRatio(ratio float32) *Material {
+	// 'ratio' : ratio of indices of refraction (like 1/1.33 for water, 1/1.5 for glass), or 0 for opaque materials
+	self.refraction = ratio
+	return self
+}
+
+func (self *Material) GetRefractionRatio() float32 {
+	return self.refraction
+}
+
 // ----------------------------------------------------------------------------
 // TEXTURE
 // ----------------------------------------------------------------------------
 
+func (self *Material) SetTexture(texture *Texture) *Material {
+	// Use the (shared) Texture as the single texture of the material, like the color texture of RenderTarget
+	self.texture = texture.texture
+	self.texture_wh = texture.wh
+	self.texture_loading = false
+	return self
+}
+
 func (self *Material) GetTexture() js.Value {
 	return self.texture
 }
@@ -145,72 +412,93 @@ func (self *Material) LoadTexture(path string) *Material {
 	if path != "" {
 		go func() {
 			defer func() { self.texture_loading = false }()
-			// log.Printf("Texture started GET %s\n", path)
-			resp, err := http.Get(path)
-			if err != nil {
-				log.Printf("Failed to GET %s : %v\n", path, err)
-				return
-			}
-			defer resp.Body.Close()
-			response_body, err := ioutil.ReadAll(resp.Body)
-			if err != nil {
-				log.Printf("Failed to GET %s : %v\n", path, err)
-			} else if resp.StatusCode < 200 || resp.StatusCode > 299 { // response with error message
-				log.Printf("Failed to GET %s : (%d) %s\n", path, resp.StatusCode, string(response_body))
-			} else { // successful response with texture image
-				// log.Printf("Texture image downloaded from server\n")
-				var img image.Image
-				var err error
-				switch filepath.Ext(path) {
-				case ".png", ".PNG":
-					img, err = png.Decode(bytes.NewBuffer(response_body))
-				case ".jpg", ".JPG":
-					img, err = jpeg.Decode(bytes.NewBuffer(response_body))
-				default:
-					fmt.Printf("Invalid image format for %s\n", path)
-					return
-				}
-				if err != nil {
-					log.Printf("Failed to decode %s : %v\n", path, err)
-				} else {
-					size := img.Bounds().Size()
-					// log.Printf("Texture image (%dx%d) decoded as %T\n", size.X, size.Y, img)
-					var pixbuf []uint8
-					switch img.(type) {
-					case *image.RGBA: // traditional 32-bit alpha-premultiplied R/G/B/A color
-						pixbuf = img.(*image.RGBA).Pix
-					case *image.NRGBA: // non-alpha-premultiplied 32-bit R/G/B/A color
-						pixbuf = img.(*image.NRGBA).Pix
-					default: // we need conversion, otherwise
-						pixbuf = make([]uint8, size.X*size.Y*4)
-						for y := 0; y < size.Y; y++ {
-							y_idx := y * size.X * 4
-							for x := 0; x < size.X; x++ {
-								rgba := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
-								idx := y_idx + x*4
-								set_pixbuf_with_rgba(pixbuf, idx, rgba.R, rgba.G, rgba.B, rgba.A)
-							}
-						}
-					}
-					js_buffer := ConvertGoSliceToJsTypedArray(pixbuf)
-					context.Call("bindTexture", c.TEXTURE_2D, self.texture)
-					context.Call("texImage2D", c.TEXTURE_2D, 0, c.RGBA, size.X, size.Y, 0, c.RGBA, c.UNSIGNED_BYTE, js_buffer)
-					if size.X&(size.X-1) == 0 && size.Y&(size.Y-1) == 0 { // POWER-OF-2 width & height
-						context.Call("generateMipmap", c.TEXTURE_2D)
-					} else { // NON-POWER-OF-2 textures : CLAMP_TO_EDGE & NEAREST/LINEAR only
-						context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_S, c.CLAMP_TO_EDGE)
-						context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_T, c.CLAMP_TO_EDGE)
-						context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MIN_FILTER, c.LINEAR)
-					}
-					self.texture_wh = [2]int{size.X, size.Y}
-					// log.Printf("Texture ready for WebGL\n")
-				}
+			if size, err := upload_texture_from_server(self.wctx, self.texture, path); err == nil {
+				self.texture_wh = size
 			}
 		}()
 	}
 	return self
 }
 
+func upload_texture_from_server(wctx *WebGLContext, texture js.Value, path string) ([2]int, error) {
+	// Download the image from server path, and upload it to the WebGL texture (with mipmaps if POWER-OF-2).
+	// Note that this function blocks until the image is downloaded, so call it in a goroutine.
+	context, c := wctx.GetContext(), wctx.GetConstants()
+	// log.Printf("Texture started GET %s\n", path)
+	img, err := LoadImageFromServer(path)
+	if err != nil {
+		log.Println(err.Error())
+		return [2]int{0, 0}, err
+	}
+	pixbuf, size := GetPixelBufferFromImage(img)
+	js_buffer := ConvertGoSliceToJsTypedArray(pixbuf)
+	context.Call("bindTexture", c.TEXTURE_2D, texture)
+	context.Call("texImage2D", c.TEXTURE_2D, 0, c.RGBA, size[0], size[1], 0, c.RGBA, c.UNSIGNED_BYTE, js_buffer)
+	if size[0]&(size[0]-1) == 0 && size[1]&(size[1]-1) == 0 { // POWER-OF-2 width & height
+		context.Call("generateMipmap", c.TEXTURE_2D)
+	} else { // NON-POWER-OF-2 textures : CLAMP_TO_EDGE & NEAREST/LINEAR only
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_S, c.CLAMP_TO_EDGE)
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_T, c.CLAMP_TO_EDGE)
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MIN_FILTER, c.LINEAR)
+	}
+	// log.Printf("Texture ready for WebGL\n")
+	return size, nil
+}
+
+func LoadImageFromServer(path string) (image.Image, error) {
+	// Download image from server path (like "/assets/world.jpg"), and decode it (PNG or JPEG).
+	// Note that this function blocks until the image is downloaded, so call it in a goroutine.
+	resp, err := http.Get(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to GET %s : %v", path, err)
+	}
+	defer resp.Body.Close()
+	response_body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to GET %s : %v", path, err)
+	} else if resp.StatusCode < 200 || resp.StatusCode > 299 { // response with error message
+		return nil, fmt.Errorf("Failed to GET %s : (%d) %s", path, resp.StatusCode, string(response_body))
+	}
+	var img image.Image
+	switch filepath.Ext(path) {
+	case ".png", ".PNG":
+		img, err = png.Decode(bytes.NewBuffer(response_body))
+	case ".jpg", ".JPG", ".jpeg", ".JPEG":
+		img, err = jpeg.Decode(bytes.NewBuffer(response_body))
+	default:
+		return nil, fmt.Errorf("Invalid image format for %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode %s : %v", path, err)
+	}
+	return img, nil
+}
+
+func GetPixelBufferFromImage(img image.Image) ([]uint8, [2]int) {
+	// Get the pixel buffer (non-alpha-premultiplied R/G/B/A) of the image, with its width & height.
+	size := img.Bounds().Size()
+	// log.Printf("Texture image (%dx%d) decoded as %T\n", size.X, size.Y, img)
+	var pixbuf []uint8
+	switch img.(type) {
+	case *image.RGBA: // traditional 32-bit alpha-premultiplied R/G/B/A color
+		pixbuf = img.(*image.RGBA).Pix
+	case *image.NRGBA: // non-alpha-premultiplied 32-bit R/G/B/A color
+		pixbuf = img.(*image.NRGBA).Pix
+	default: // we need conversion, otherwise
+		min := img.Bounds().Min
+		pixbuf = make([]uint8, size.X*size.Y*4)
+		for y := 0; y < size.Y; y++ {
+			y_idx := y * size.X * 4
+			for x := 0; x < size.X; x++ {
+				rgba := color.RGBAModel.Convert(img.At(min.X+x, min.Y+y)).(color.RGBA)
+				idx := y_idx + x*4
+				set_pixbuf_with_rgba(pixbuf, idx, rgba.R, rgba.G, rgba.B, rgba.A)
+			}
+		}
+	}
+	return pixbuf, [2]int{size.X, size.Y}
+}
+
 func set_pixbuf_with_rgba(pbuffer []uint8, idx int, R uint8, G uint8, B uint8, A uint8) {
 	pbuffer[idx+0] = R
 	pbuffer[idx+1] = G
@@ -0,0 +1,287 @@
+package wcommon
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall/js"
+	"time"
+)
+
+// PostProcessor is a chain of full-screen passes (like FXAA, bloom, tone mapping, color grading, vignette
+// and outline), which are applied in order to the scene rendered into its RenderTarget.
+// Passes can be added, removed, enabled or reordered at any time (by their names).
+//   pp := wcommon.NewPostProcessor(wctx).AddPass(wcommon.NewPostPass_Bloom(wctx, 0.8, 1.0)).AddPass(wcommon.NewPostPass_FXAA(wctx))
+//   renderer.SetRenderTarget(pp.GetRenderTarget())  // render the scene into the PostProcessor
+//   renderer.Clear(scene)                           //
+//   renderer.RenderScene(scene, camera)             //
+//   pp.Render(nil)                                  // apply the passes, and render the result onto the canvas
+
+type PostProcessor struct {
+	wctx     *WebGLContext    //
+	scene    *RenderTarget    // RenderTarget for the scene to be rendered into (following the canvas size)
+	pingpong [2]*RenderTarget // RenderTargets for the intermediate results of the passes
+	passes   []*PostPass      // passes to be applied in order (only if enabled)
+	copier   *PostPass        // pass for copying the scene, when no pass is enabled
+	triangle js.Value         // vertex buffer of a single triangle covering the whole screen
+	start    time.Time        // creation time (for "postprocess.time")
+	units    map[int]bool     // texture units used while rendering the passes
+}
+
+func NewPostProcessor(wctx *WebGLContext) *PostProcessor {
+	self := PostProcessor{wctx: wctx, passes: []*PostPass{}, start: time.Now(), units: map[int]bool{}}
+	self.scene = NewRenderTarget_Canvas(wctx, 1.0, 1, true)
+	self.pingpong[0] = NewRenderTarget_Canvas(wctx, 1.0, 1, false)
+	self.pingpong[1] = NewRenderTarget_Canvas(wctx, 1.0, 1, false)
+	self.copier = NewPostPass_Copy(wctx)
+	context, c := wctx.GetContext(), wctx.GetConstants()
+	self.triangle = context.Call("createBuffer")
+	context.Call("bindBuffer", c.ARRAY_BUFFER, self.triangle)
+	context.Call("bufferData", c.ARRAY_BUFFER, ConvertGoSliceToJsTypedArray([]float32{-1, -1, 3, -1, -1, 3}), c.STATIC_DRAW)
+	return &self
+}
+
+func (self *PostProcessor) ShowInfo() {
+	fmt.Printf("PostProcessor with %d passes\n", len(self.passes))
+	for _, pass := range self.passes {
+		fmt.Printf("    Pass %-12s: enabled=%t  presteps=%d\n", pass.name, pass.enabled, len(pass.presteps))
+	}
+}
+
+func (self *PostProcessor) GetRenderTarget() *RenderTarget {
+	// RenderTarget for the scene, to be set to the renderer (like Renderer.SetRenderTarget())
+	return self.scene
+}
+
+// ----------------------------------------------------------------------------
+// Passes
+// ----------------------------------------------------------------------------
+
+func (self *PostProcessor) AddPass(pass *PostPass) *PostProcessor {
+	// Add the pass at the end of the chain
+	return self.InsertPass(len(self.passes), pass)
+}
+
+func (self *PostProcessor) InsertPass(index int, pass *PostPass) *PostProcessor {
+	// Insert the pass at the given position of the chain (with a unique name)
+	if pass == nil || self.GetPass(pass.name) != nil {
+		fmt.Printf("Failed to InsertPass() : invalid or duplicate pass\n")
+		return self
+	}
+	index = clamp_index(index, len(self.passes))
+	self.passes = append(self.passes, nil)
+	copy(self.passes[index+1:], self.passes[index:])
+	self.passes[index] = pass
+	return self
+}
+
+func (self *PostProcessor) RemovePass(name string) *PostProcessor {
+	if index := self.get_pass_index(name); index >= 0 {
+		self.passes = append(self.passes[:index], self.passes[index+1:]...)
+	}
+	return self
+}
+
+func (self *PostProcessor) MovePass(name string, index int) *PostProcessor {
+	// Move the pass to the given position of the chain (like MovePass("fxaa", 0) to apply it first)
+	if pass := self.GetPass(name); pass != nil {
+		self.RemovePass(name)
+		self.InsertPass(index, pass)
+	}
+	return self
+}
+
+func (self *PostProcessor) SetPassEnabled(name string, enabled bool) *PostProcessor {
+	if pass := self.GetPass(name); pass != nil {
+		pass.SetEnabled(enabled)
+	}
+	return self
+}
+
+func (self *PostProcessor) GetPass(name string) *PostPass {
+	if index := self.get_pass_index(name); index >= 0 {
+		return self.passes[index]
+	}
+	return nil
+}
+
+func (self *PostProcessor) GetPassNames() []string {
+	// names of the passes in the order of the chain
+	names := make([]string, len(self.passes))
+	for i, pass := range self.passes {
+		names[i] = pass.name
+	}
+	return names
+}
+
+func (self *PostProcessor) get_pass_index(name string) int {
+	for i, pass := range self.passes {
+		if pass.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func clamp_index(index int, length int) int {
+	if index < 0 {
+		return 0
+	} else if index > length {
+		return length
+	}
+	return index
+}
+
+// ----------------------------------------------------------------------------
+// Rendering
+// ----------------------------------------------------------------------------
+
+func (self *PostProcessor) Render(output *RenderTarget) error {
+	// Apply the enabled passes to the scene in order, and render the result into 'output' ('nil' for the canvas).
+	context, c := self.wctx.GetContext(), self.wctx.GetConstants()
+	context.Call("disable", c.DEPTH_TEST) // full-screen passes don't need depth test
+	context.Call("disable", c.BLEND)      //   nor blending
+	passes := []*PostPass{}
+	for _, pass := range self.passes {
+		if pass.enabled {
+			passes = append(passes, pass)
+		}
+	}
+	if len(passes) == 0 {
+		passes = append(passes, self.copier)
+	}
+	source := self.scene.GetTexture(0)
+	var err error = nil
+	for i, pass := range passes {
+		var extra *Texture = nil
+		if len(pass.presteps) > 0 { // prepare the intermediate image (like blurred highlights for bloom)
+			if extra, err = self.render_presteps(pass, source); err != nil {
+				break
+			}
+		}
+		target := output // the last pass renders into the output
+		if i < len(passes)-1 {
+			target = self.pingpong[i%2]
+		}
+		BindRenderTarget(self.wctx, target)
+		if err = self.render_shader(pass.shader, source, extra); err != nil {
+			break
+		}
+		if target != nil {
+			source = target.GetTexture(0)
+		}
+	}
+	for unit := range self.units { // unbind the textures, to avoid feedback loops when rendering the scene again
+		context.Call("activeTexture", js.ValueOf(c.TEXTURE0.Int()+unit))
+		context.Call("bindTexture", c.TEXTURE_2D, js.Null())
+	}
+	self.units = map[int]bool{}
+	BindRenderTarget(self.wctx, output)
+	return err
+}
+
+func (self *PostProcessor) render_presteps(pass *PostPass, source *Texture) (*Texture, error) {
+	// Render the presteps of the pass in order, alternating its two RenderTargets (of reduced size)
+	for k, shader := range pass.presteps {
+		target := pass.pretargets[k%2]
+		BindRenderTarget(self.wctx, target)
+		if err := self.render_shader(shader, source, nil); err != nil {
+			return nil, err
+		}
+		source = target.GetTexture(0)
+	}
+	return source, nil
+}
+
+func (self *PostProcessor) render_shader(shader *Shader, source *Texture, extra *Texture) error {
+	context, c := self.wctx.GetContext(), self.wctx.GetConstants()
+	if shader == nil || shader.err != nil {
+		return errors.New("Failed to render PostPass : invalid shader")
+	}
+	context.Call("useProgram", shader.GetShaderProgram())
+	for uname, umap := range shader.GetUniformBindings() {
+		if err := self.bind_uniform(uname, umap, source, extra); err != nil {
+			fmt.Println(err.Error())
+			return err
+		}
+	}
+	for aname, amap := range shader.GetAttributeBindings() {
+		if amap["location"] == nil || amap["autobinding"] != "geometry.coords" {
+			err := fmt.Errorf("Failed to bind attribute '%s' : only 'geometry.coords' for PostPass", aname)
+			fmt.Println(err.Error())
+			return err
+		}
+		location := amap["location"].(js.Value)
+		context.Call("bindBuffer", c.ARRAY_BUFFER, self.triangle)
+		context.Call("vertexAttribPointer", location, 2, c.FLOAT, false, 0, 0)
+		context.Call("enableVertexAttribArray", location)
+		if self.wctx.IsExtensionReady("ANGLE") {
+			self.wctx.GetExtension("ANGLE").Call("vertexAttribDivisorANGLE", location, 0) // divisor == 0
+		}
+	}
+	context.Call("drawArrays", c.TRIANGLES, 0, 3) // (mode, first, count)
+	return nil
+}
+
+func (self *PostProcessor) bind_uniform(uname string, umap map[string]interface{}, source *Texture, extra *Texture) error {
+	context, c := self.wctx.GetContext(), self.wctx.GetConstants()
+	if umap["location"] == nil {
+		err := errors.New("Failed to bind uniform : call 'shader.CheckBinding()' before rendering")
+		return err
+	}
+	location, dtype := umap["location"].(js.Value), umap["dtype"].(string)
+	if umap["autobinding"] != nil {
+		autobinding := umap["autobinding"].(string)
+		autobinding_split := strings.Split(autobinding, ":")
+		autobinding0 := autobinding_split[0]
+		switch autobinding0 {
+		case "postprocess.source", "postprocess.scene", "postprocess.extra": // sampler2D
+			texture := source
+			if autobinding0 == "postprocess.scene" {
+				texture = self.scene.GetTexture(0)
+			} else if autobinding0 == "postprocess.extra" && extra != nil {
+				texture = extra
+			}
+			txt_unit := 0
+			if len(autobinding_split) >= 2 {
+				txt_unit, _ = strconv.Atoi(autobinding_split[1])
+			}
+			context.Call("activeTexture", js.ValueOf(c.TEXTURE0.Int()+txt_unit)) // activate texture unit N
+			context.Call("bindTexture", c.TEXTURE_2D, texture.texture)           // bind the texture
+			context.Call("uniform1i", location, txt_unit)                        // give shader the unit number
+			self.units[txt_unit] = true
+			return nil
+		case "postprocess.texel": // vec2
+			wh := source.GetWH()
+			context.Call("uniform2f", location, 1/float32(max_int(wh[0], 1)), 1/float32(max_int(wh[1], 1)))
+			return nil
+		case "postprocess.time": // float
+			context.Call("uniform1f", location, float32(time.Since(self.start).Seconds()))
+			return nil
+		}
+		return fmt.Errorf("Failed to bind uniform '%s' (%s) with %v", uname, dtype, autobinding)
+	} else if umap["value"] != nil {
+		v := umap["value"].([]float32)
+		switch dtype {
+		case "int":
+			context.Call("uniform1i", location, int(v[0]))
+			return nil
+		case "float":
+			context.Call("uniform1f", location, v[0])
+			return nil
+		case "vec2":
+			context.Call("uniform2f", location, v[0], v[1])
+			return nil
+		case "vec3":
+			context.Call("uniform3f", location, v[0], v[1], v[2])
+			return nil
+		case "vec4":
+			context.Call("uniform4f", location, v[0], v[1], v[2], v[3])
+			return nil
+		}
+		return fmt.Errorf("Failed to bind uniform '%s' (%s) with %v", uname, dtype, v)
+	} else {
+		return fmt.Errorf("Failed to bind uniform '%s' (%s)", uname, dtype)
+	}
+}
@@ -0,0 +1,309 @@
+package wcommon
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// PostPass is a full-screen pass of PostProcessor, with a fragment shader taking the output of the previous pass
+// ("source"), and OPTIONAL presteps rendered in reduced size before it (like the blurred highlights for bloom).
+// Custom passes can be built with any fragment shader declaring the uniforms & varying below:
+//   uniform sampler2D source;  // output of the previous pass   ("postprocess.source:0")
+//   uniform sampler2D scene;   // original image of the scene   ("postprocess.scene:1")
+//   uniform vec2      texel;   // size of a texel of 'source'   ("postprocess.texel")
+//   uniform float     time;    // time in seconds               ("postprocess.time")
+//   varying vec2      v_uv;    // UV coordinates on the screen (origin at lower-left)
+
+type PostPass struct {
+	name       string           // name of the pass (unique in the PostProcessor)
+	enabled    bool             //
+	shader     *Shader          // shader rendering the result of the pass
+	presteps   []*Shader        // OPTIONAL shaders rendered in order, to prepare the "extra" image
+	pretargets [2]*RenderTarget // RenderTargets for the presteps (alternating)
+}
+
+func NewPostPass(wctx *WebGLContext, name string, fragment_shader string) *PostPass {
+	// Custom pass with the fragment shader (see above for the uniforms bound automatically).
+	// Other uniforms can be set with SetParameter(), like SetParameter("strength", "float", 0.5).
+	return &PostPass{name: name, enabled: true, shader: new_post_shader(wctx, fragment_shader)}
+}
+
+func (self *PostPass) GetName() string {
+	return self.name
+}
+
+func (self *PostPass) GetShader() *Shader {
+	return self.shader
+}
+
+func (self *PostPass) SetEnabled(enabled bool) *PostPass {
+	self.enabled = enabled
+	return self
+}
+
+func (self *PostPass) IsEnabled() bool {
+	return self.enabled
+}
+
+func (self *PostPass) SetParameter(uname string, dtype string, values ...float32) *PostPass {
+	// Set the value of the uniform (declared in any shader of the pass), like SetParameter("exposure", "float", 1.5)
+	found := false
+	for _, shader := range append([]*Shader{self.shader}, self.presteps...) {
+		if umap := shader.uniforms[uname]; umap != nil && umap["autobinding"] == nil {
+			umap["value"] = append([]float32{}, values...) // (keeping its location)
+			found = true
+		} else if umap == nil && regexp.MustCompile(`uniform\s+\w+\s+`+regexp.QuoteMeta(uname)+`\b`).MatchString(shader.fshader_code) {
+			shader.SetBindingForUniform(uname, dtype, append([]float32{}, values...))
+			shader.CheckBindings()
+			found = true
+		}
+	}
+	if !found {
+		fmt.Printf("Failed to SetParameter('%s') : uniform not found in the PostPass '%s'\n", uname, self.name)
+	}
+	return self
+}
+
+func (self *PostPass) GetParameter(uname string) []float32 {
+	for _, shader := range append([]*Shader{self.shader}, self.presteps...) {
+		if umap := shader.uniforms[uname]; umap != nil && umap["value"] != nil {
+			return umap["value"].([]float32)
+		}
+	}
+	return nil
+}
+
+func new_post_shader(wctx *WebGLContext, fragment_shader string) *Shader {
+	// Shader for a full-screen pass, with the uniforms of PostProcessor bound automatically (if declared)
+	var vertex_shader_code = `
+		precision mediump float;
+		attribute vec2 xy;			// XY coordinates of the full-screen triangle
+		varying vec2 v_uv;			// (varying) UV coordinates on the screen
+		void main() {
+			gl_Position = vec4(xy, 0.0, 1.0);
+			v_uv = xy * 0.5 + 0.5;
+		}`
+	shader, _ := NewShader(wctx, vertex_shader_code, fragment_shader)
+	autobindings := [][3]string{
+		{"source", "sampler2D", "postprocess.source:0"}, // output of the previous pass
+		{"scene", "sampler2D", "postprocess.scene:1"},   // original image of the scene
+		{"extra", "sampler2D", "postprocess.extra:2"},   // image prepared by the presteps
+		{"texel", "vec2", "postprocess.texel"},          // size of a texel of the source
+		{"time", "float", "postprocess.time"},           // time in seconds
+	}
+	for _, a := range autobindings {
+		if regexp.MustCompile(`uniform\s+\w+\s+` + a[0] + `\b`).MatchString(fragment_shader) {
+			shader.SetBindingForUniform(a[0], a[1], a[2])
+		}
+	}
+	shader.SetBindingForAttribute("xy", "vec2", "geometry.coords") // full-screen triangle
+	shader.CheckBindings()                                         // check validity of the shader
+	return shader
+}
+
+// ----------------------------------------------------------------------------
+// Built-in Passes
+// ----------------------------------------------------------------------------
+
+func NewPostPass_Copy(wctx *WebGLContext) *PostPass {
+	// Pass copying the source as it is
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform sampler2D source;	// output of the previous pass
+		varying vec2 v_uv;			// (varying) UV coordinates
+		void main() {
+			gl_FragColor = texture2D(source, v_uv);
+		}`
+	return NewPostPass(wctx, "copy", fragment_shader_code)
+}
+
+func NewPostPass_FXAA(wctx *WebGLContext) *PostPass {
+	// Fast approximate antialiasing (FXAA), which smooths the edges found by luminance (like on devices without MSAA)
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform sampler2D source;	// output of the previous pass
+		uniform vec2 texel;			// size of a texel
+		varying vec2 v_uv;			// (varying) UV coordinates
+		const vec3  LUMA = vec3(0.299, 0.587, 0.114);
+		const float REDUCE_MIN = 1.0 / 128.0;
+		const float REDUCE_MUL = 1.0 / 8.0;
+		const float SPAN_MAX   = 8.0;
+		void main() {
+			vec4  rgbM  = texture2D(source, v_uv);
+			float lumNW = dot(texture2D(source, v_uv + vec2(-1.0, -1.0) * texel).rgb, LUMA);
+			float lumNE = dot(texture2D(source, v_uv + vec2(+1.0, -1.0) * texel).rgb, LUMA);
+			float lumSW = dot(texture2D(source, v_uv + vec2(-1.0, +1.0) * texel).rgb, LUMA);
+			float lumSE = dot(texture2D(source, v_uv + vec2(+1.0, +1.0) * texel).rgb, LUMA);
+			float lumM  = dot(rgbM.rgb, LUMA);
+			float lumMin = min(lumM, min(min(lumNW, lumNE), min(lumSW, lumSE)));
+			float lumMax = max(lumM, max(max(lumNW, lumNE), max(lumSW, lumSE)));
+			vec2  dir = vec2(-((lumNW + lumNE) - (lumSW + lumSE)), ((lumNW + lumSW) - (lumNE + lumSE)));
+			float reduce = max((lumNW + lumNE + lumSW + lumSE) * (0.25 * REDUCE_MUL), REDUCE_MIN);
+			float rcpmin = 1.0 / (min(abs(dir.x), abs(dir.y)) + reduce);
+			dir = clamp(dir * rcpmin, vec2(-SPAN_MAX), vec2(SPAN_MAX)) * texel;
+			vec3  rgbA = 0.5 * (texture2D(source, v_uv + dir * (1.0/3.0 - 0.5)).rgb + texture2D(source, v_uv + dir * (2.0/3.0 - 0.5)).rgb);
+			vec3  rgbB = 0.5 * rgbA + 0.25 * (texture2D(source, v_uv - dir * 0.5).rgb + texture2D(source, v_uv + dir * 0.5).rgb);
+			float lumB = dot(rgbB, LUMA);
+			gl_FragColor = vec4((lumB < lumMin || lumB > lumMax) ? rgbA : rgbB, rgbM.a);
+		}`
+	return NewPostPass(wctx, "fxaa", fragment_shader_code)
+}
+
+func NewPostPass_Bloom(wctx *WebGLContext, threshold float32, intensity float32) *PostPass {
+	// Bloom (glow) of the highlights brighter than 'threshold' (luminance in [0,1]), blurred in half resolution
+	// and added to the source with 'intensity'. (parameters : "threshold", "intensity" & "radius" of blur)
+	var bright_shader_code = `
+		precision mediump float;
+		uniform sampler2D source;	// output of the previous pass
+		uniform float threshold;	// luminance threshold of highlights
+		varying vec2 v_uv;			// (varying) UV coordinates
+		void main() {
+			vec3  c = texture2D(source, v_uv).rgb;
+			float l = dot(c, vec3(0.2126, 0.7152, 0.0722));
+			gl_FragColor = vec4(c * smoothstep(threshold, threshold + 0.1, l), 1.0);
+		}`
+	var blur_shader_code = `
+		precision mediump float;
+		uniform sampler2D source;	// highlights (to be blurred)
+		uniform vec2  texel;		// size of a texel
+		uniform vec2  direction;	// direction of the blur, (1,0) or (0,1)
+		uniform float radius;		// radius of the blur (in texels)
+		varying vec2 v_uv;			// (varying) UV coordinates
+		void main() {				// 9-tap gaussian blur, with 5 samples using linear filtering
+			vec2 d = direction * texel * radius;
+			vec3 c = texture2D(source, v_uv).rgb * 0.2270270270;
+			c += (texture2D(source, v_uv + d * 1.3846153846).rgb + texture2D(source, v_uv - d * 1.3846153846).rgb) * 0.3162162162;
+			c += (texture2D(source, v_uv + d * 3.2307692308).rgb + texture2D(source, v_uv - d * 3.2307692308).rgb) * 0.0702702703;
+			gl_FragColor = vec4(c, 1.0);
+		}`
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform sampler2D source;	// output of the previous pass
+		uniform sampler2D extra;	// blurred highlights
+		uniform float intensity;	// intensity of the bloom
+		varying vec2 v_uv;			// (varying) UV coordinates
+		void main() {
+			vec4 c = texture2D(source, v_uv);
+			gl_FragColor = vec4(c.rgb + intensity * texture2D(extra, v_uv).rgb, c.a);
+		}`
+	pass := NewPostPass(wctx, "bloom", fragment_shader_code)
+	pass.presteps = append(pass.presteps, new_post_shader(wctx, bright_shader_code))
+	for i := 0; i < 4; i++ { // blur twice, horizontally & vertically
+		blur := new_post_shader(wctx, blur_shader_code)
+		blur.SetBindingForUniform("direction", "vec2", []float32{float32(1 - i%2), float32(i % 2)})
+		blur.CheckBindings()
+		pass.presteps = append(pass.presteps, blur)
+	}
+	pass.pretargets[0] = NewRenderTarget_Canvas(wctx, 0.5, 1, false)
+	pass.pretargets[1] = NewRenderTarget_Canvas(wctx, 0.5, 1, false)
+	pass.SetParameter("threshold", "float", threshold)
+	pass.SetParameter("intensity", "float", intensity)
+	pass.SetParameter("radius", "float", 1.0)
+	return pass
+}
+
+func NewPostPass_ToneMapping(wctx *WebGLContext, mode string, exposure float32) *PostPass {
+	// Tone mapping with exposure, 'mode' : "NONE" (exposure only), "REINHARD" or "ACES" (filmic)
+	// (parameters : "tonemap" (0:NONE, 1:REINHARD, 2:ACES) & "exposure")
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform sampler2D source;	// output of the previous pass
+		uniform int   tonemap;		// tone mapping (0:NONE, 1:REINHARD, 2:ACES)
+		uniform float exposure;		// exposure
+		varying vec2 v_uv;			// (varying) UV coordinates
+		void main() {
+			vec4 s = texture2D(source, v_uv);
+			vec3 c = s.rgb * exposure;
+			if (tonemap == 1) {
+				c = c / (1.0 + c);
+			} else if (tonemap == 2) {
+				c = (c * (2.51 * c + 0.03)) / (c * (2.43 * c + 0.59) + 0.14);
+			}
+			gl_FragColor = vec4(clamp(c, 0.0, 1.0), s.a);
+		}`
+	pass := NewPostPass(wctx, "tonemap", fragment_shader_code)
+	pass.SetParameter("tonemap", "int", float32(map[string]int{"NONE": 0, "REINHARD": 1, "ACES": 2}[mode]))
+	pass.SetParameter("exposure", "float", exposure)
+	return pass
+}
+
+func NewPostPass_ColorGrading(wctx *WebGLContext, brightness float32, contrast float32, saturation float32) *PostPass {
+	// Color grading with 'brightness' (0 for no change), 'contrast' & 'saturation' (1 for no change)
+	// (parameters : "brightness", "contrast", "saturation" & "tint" (RGB multiplier))
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform sampler2D source;	// output of the previous pass
+		uniform float brightness;	// added to RGB
+		uniform float contrast;		// scale around the middle gray
+		uniform float saturation;	// scale from the luminance
+		uniform vec3  tint;			// RGB multiplier
+		varying vec2 v_uv;			// (varying) UV coordinates
+		void main() {
+			vec4  s = texture2D(source, v_uv);
+			vec3  c = (s.rgb + brightness - 0.5) * contrast + 0.5;
+			float l = dot(c, vec3(0.2126, 0.7152, 0.0722));
+			c = mix(vec3(l), c, saturation) * tint;
+			gl_FragColor = vec4(clamp(c, 0.0, 1.0), s.a);
+		}`
+	pass := NewPostPass(wctx, "colorgrading", fragment_shader_code)
+	pass.SetParameter("brightness", "float", brightness)
+	pass.SetParameter("contrast", "float", contrast)
+	pass.SetParameter("saturation", "float", saturation)
+	pass.SetParameter("tint", "vec3", 1, 1, 1)
+	return pass
+}
+
+func NewPostPass_Vignette(wctx *WebGLContext, radius float32, strength float32) *PostPass {
+	// Vignette darkening the screen beyond 'radius' (1.0 at the edges) by 'strength' (0 ~ 1)
+	// (parameters : "radius", "softness" & "strength")
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform sampler2D source;	// output of the previous pass
+		uniform float radius;		// radius where the darkening ends (1.0 at the edges)
+		uniform float softness;		// width of the darkening
+		uniform float strength;		// amount of the darkening
+		varying vec2 v_uv;			// (varying) UV coordinates
+		void main() {
+			vec4  s = texture2D(source, v_uv);
+			float d = length((v_uv - 0.5) * 2.0);
+			float v = 1.0 - strength * smoothstep(radius - softness, radius, d);
+			gl_FragColor = vec4(s.rgb * v, s.a);
+		}`
+	pass := NewPostPass(wctx, "vignette", fragment_shader_code)
+	pass.SetParameter("radius", "float", radius)
+	pass.SetParameter("softness", "float", 0.5)
+	pass.SetParameter("strength", "float", strength)
+	return pass
+}
+
+func NewPostPass_Outline(wctx *WebGLContext, color string, threshold float32) *PostPass {
+	// Outline drawn with 'color' on the edges found by Sobel filter on luminance (stronger than 'threshold')
+	// (parameters : "color" (RGB), "threshold" & "thickness" (in texels))
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform sampler2D source;	// output of the previous pass
+		uniform vec2  texel;		// size of a texel
+		uniform vec3  color;		// color of the outline
+		uniform float threshold;	// strength of the edges to be outlined
+		uniform float thickness;	// thickness of the outline (in texels)
+		varying vec2 v_uv;			// (varying) UV coordinates
+		float lum(vec2 offset) {
+			return dot(texture2D(source, v_uv + offset * texel * thickness).rgb, vec3(0.2126, 0.7152, 0.0722));
+		}
+		void main() {
+			float tl = lum(vec2(-1.0, 1.0)), t = lum(vec2(0.0, 1.0)), tr = lum(vec2(1.0, 1.0));
+			float  l = lum(vec2(-1.0, 0.0)),                           r = lum(vec2(1.0, 0.0));
+			float bl = lum(vec2(-1.0,-1.0)), b = lum(vec2(0.0,-1.0)), br = lum(vec2(1.0,-1.0));
+			float gx = (tr + 2.0 * r + br) - (tl + 2.0 * l + bl);
+			float gy = (tl + 2.0 * t + tr) - (bl + 2.0 * b + br);
+			float e  = smoothstep(threshold, threshold * 2.0, length(vec2(gx, gy)));
+			vec4  s  = texture2D(source, v_uv);
+			gl_FragColor = vec4(mix(s.rgb, color, e), s.a);
+		}`
+	pass := NewPostPass(wctx, "outline", fragment_shader_code)
+	rgb := ParseHexColor(color)
+	pass.SetParameter("color", "vec3", rgb[0], rgb[1], rgb[2])
+	pass.SetParameter("threshold", "float", threshold)
+	pass.SetParameter("thickness", "float", 1.0)
+	return pass
+}
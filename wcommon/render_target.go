@@ -0,0 +1,183 @@
+package wcommon
+
+import (
+	"errors"
+	"fmt"
+	"syscall/js"
+)
+
+// RenderTarget is an offscreen framebuffer with color texture(s) and a depth (& stencil) renderbuffer,
+// which Renderers can render into (instead of the canvas), and whose textures can be used
+// by Materials (like for mirrors, minimaps or post-processing).
+
+type RenderTarget struct {
+	wctx        *WebGLContext //
+	framebuffer js.Value      // WebGL framebuffer
+	textures    []*Texture    // color attachments (more than one only with "WEBGL_draw_buffers" extension)
+	depthbuffer js.Value      // depth (& stencil) renderbuffer
+	stencil     bool          // true, if the renderbuffer has stencil as well as depth
+	wh          [2]int        // size of the framebuffer
+	scale       float32       // size relative to the canvas (0 for fixed size)
+	err         error         //
+}
+
+func NewRenderTarget(wctx *WebGLContext, width int, height int, ncolors int, stencil bool) *RenderTarget {
+	// RenderTarget of fixed size, with 'ncolors' color textures (usually 1) and depth (& stencil) renderbuffer
+	self := new_render_target(wctx, ncolors, stencil, 0)
+	self.Resize(width, height)
+	return self
+}
+
+func NewRenderTarget_Canvas(wctx *WebGLContext, scale float32, ncolors int, stencil bool) *RenderTarget {
+	// RenderTarget following the size of the canvas (multiplied by 'scale', like 0.5 for half resolution),
+	// which is resized automatically when it's bound after the canvas was resized.
+	self := new_render_target(wctx, ncolors, stencil, scale)
+	wh := self.get_canvas_wh()
+	self.Resize(wh[0], wh[1])
+	return self
+}
+
+func new_render_target(wctx *WebGLContext, ncolors int, stencil bool, scale float32) *RenderTarget {
+	context := wctx.GetContext()
+	self := RenderTarget{wctx: wctx, stencil: stencil, scale: scale}
+	if ncolors > 1 && !wctx.IsExtensionReady("DRAWBUFFERS") {
+		wctx.SetupExtension("DRAWBUFFERS")
+		if !wctx.IsExtensionReady("DRAWBUFFERS") {
+			fmt.Printf("RenderTarget Warning : multiple color textures not supported (WEBGL_draw_buffers)\n")
+			ncolors = 1
+		}
+	}
+	if ncolors < 1 {
+		ncolors = 1
+	}
+	self.framebuffer = context.Call("createFramebuffer")
+	self.depthbuffer = context.Call("createRenderbuffer")
+	self.textures = make([]*Texture, ncolors)
+	for i := 0; i < ncolors; i++ {
+		self.textures[i] = &Texture{wctx: wctx, texture: context.Call("createTexture"), wh: [2]int{0, 0}}
+	}
+	return &self
+}
+
+func (self *RenderTarget) ShowInfo() {
+	fmt.Printf("RenderTarget %dx%d with %d color textures (stencil=%t scale=%v)\n", self.wh[0], self.wh[1], len(self.textures), self.stencil, self.scale)
+	if self.err != nil {
+		fmt.Printf("    with Error - %s\n", self.err.Error())
+	}
+}
+
+func (self *RenderTarget) GetWH() [2]int {
+	return self.wh
+}
+
+func (self *RenderTarget) GetTexture(index int) *Texture {
+	// color texture of the RenderTarget (to be used by Materials, like Material.SetTexture() or SetTextureMap())
+	if index < 0 || index >= len(self.textures) {
+		return nil
+	}
+	return self.textures[index]
+}
+
+func (self *RenderTarget) GetTextureCount() int {
+	return len(self.textures)
+}
+
+func (self *RenderTarget) GetFramebuffer() js.Value {
+	return self.framebuffer
+}
+
+func (self *RenderTarget) IsReady() bool {
+	return self.err == nil && self.wh[0] > 0 && self.wh[1] > 0
+}
+
+// ----------------------------------------------------------------------------
+// Resizing
+// ----------------------------------------------------------------------------
+
+func (self *RenderTarget) get_canvas_wh() [2]int {
+	wh := self.wctx.GetWH()
+	w, h := int(float32(wh[0])*self.scale), int(float32(wh[1])*self.scale)
+	return [2]int{max_int(w, 1), max_int(h, 1)}
+}
+
+func (self *RenderTarget) Resize(width int, height int) error {
+	// Resize the color textures and the depth (& stencil) renderbuffer (keeping the same WebGL objects)
+	if width <= 0 || height <= 0 {
+		self.err = fmt.Errorf("Failed to resize RenderTarget : invalid size %dx%d", width, height)
+		return self.err
+	}
+	if self.wh == [2]int{width, height} {
+		return self.err
+	}
+	context, c := self.wctx.GetContext(), self.wctx.GetConstants()
+	self.wh = [2]int{width, height}
+	context.Call("bindFramebuffer", c.FRAMEBUFFER, self.framebuffer)
+	attachments := []interface{}{}
+	for i, texture := range self.textures {
+		context.Call("bindTexture", c.TEXTURE_2D, texture.texture)
+		context.Call("texImage2D", c.TEXTURE_2D, 0, c.RGBA, width, height, 0, c.RGBA, c.UNSIGNED_BYTE, js.Null())
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_S, c.CLAMP_TO_EDGE)
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_T, c.CLAMP_TO_EDGE)
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MIN_FILTER, c.LINEAR)
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MAG_FILTER, c.LINEAR)
+		texture.wh = self.wh
+		attachment := c.COLOR_ATTACHMENT0
+		if len(self.textures) > 1 { // COLOR_ATTACHMENTi_WEBGL of "WEBGL_draw_buffers" extension
+			attachment = js.ValueOf(self.wctx.GetExtension("DRAWBUFFERS").Get("COLOR_ATTACHMENT0_WEBGL").Int() + i)
+			attachments = append(attachments, attachment)
+		}
+		context.Call("framebufferTexture2D", c.FRAMEBUFFER, attachment, c.TEXTURE_2D, texture.texture, 0)
+	}
+	if len(attachments) > 1 {
+		self.wctx.GetExtension("DRAWBUFFERS").Call("drawBuffersWEBGL", js.ValueOf(attachments))
+	}
+	context.Call("bindRenderbuffer", c.RENDERBUFFER, self.depthbuffer)
+	if self.stencil {
+		context.Call("renderbufferStorage", c.RENDERBUFFER, c.DEPTH_STENCIL, width, height)
+		context.Call("framebufferRenderbuffer", c.FRAMEBUFFER, c.DEPTH_STENCIL_ATTACHMENT, c.RENDERBUFFER, self.depthbuffer)
+	} else {
+		context.Call("renderbufferStorage", c.RENDERBUFFER, c.DEPTH_COMPONENT16, width, height)
+		context.Call("framebufferRenderbuffer", c.FRAMEBUFFER, c.DEPTH_ATTACHMENT, c.RENDERBUFFER, self.depthbuffer)
+	}
+	status := context.Call("checkFramebufferStatus", c.FRAMEBUFFER)
+	context.Call("bindFramebuffer", c.FRAMEBUFFER, js.Null())
+	context.Call("bindRenderbuffer", c.RENDERBUFFER, js.Null())
+	context.Call("bindTexture", c.TEXTURE_2D, js.Null())
+	if !status.Equal(c.FRAMEBUFFER_COMPLETE) {
+		self.err = errors.New("Failed to setup RenderTarget : incomplete framebuffer")
+		fmt.Println(self.err.Error())
+	} else {
+		self.err = nil
+	}
+	return self.err
+}
+
+// ----------------------------------------------------------------------------
+// Binding
+// ----------------------------------------------------------------------------
+
+func BindRenderTarget(wctx *WebGLContext, target *RenderTarget) [2]int {
+	// Bind the RenderTarget (or the canvas, if 'target' is nil) with its viewport, and return its size.
+	// (RenderTarget following the canvas is resized, if the size of the canvas has changed)
+	context, c := wctx.GetContext(), wctx.GetConstants()
+	if target == nil || !target.IsReady() {
+		wh := wctx.GetWH()
+		context.Call("bindFramebuffer", c.FRAMEBUFFER, js.Null())
+		context.Call("viewport", 0, 0, wh[0], wh[1])
+		return wh
+	}
+	if target.scale > 0 {
+		wh := target.get_canvas_wh()
+		target.Resize(wh[0], wh[1])
+	}
+	context.Call("bindFramebuffer", c.FRAMEBUFFER, target.framebuffer)
+	context.Call("viewport", 0, 0, target.wh[0], target.wh[1])
+	return target.wh
+}
+
+func max_int(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
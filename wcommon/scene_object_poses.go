@@ -6,10 +6,17 @@ import (
 )
 
 type SceneObjectPoses struct {
-	Size        int       //
-	Count       int       //
-	DataBuffer  []float32 //
-	WebGLBuffer js.Value  //
+	Size        int        //
+	Count       int        //
+	DataBuffer  []float32  //
+	WebGLBuffer js.Value   //
+	usage       string     // usage hint for WebGL buffer ("STATIC", "DYNAMIC" or "STREAM")
+	dirty       DirtyRange // range of DataBuffer modified after the last upload
+	IndexBuffer js.Value   // WebGL buffer of instance indices (0, 1, 2, ...), only if required
+	StateBuffer js.Value   // WebGL buffer of instance highlight states, only if required
+	states      []float32  // highlight states of instances (0:NONE, 1:HOVERED, 2:SELECTED, 3:BOTH)
+	states_set  int        // number of instances with any highlight state
+	states_mod  bool       // true, if the states were modified after the last upload
 }
 
 func NewSceneObjectPoses(size int, count int, data []float32) *SceneObjectPoses {
@@ -21,11 +28,23 @@ func NewSceneObjectPoses(size int, count int, data []float32) *SceneObjectPoses
 		}
 	}
 	poses.WebGLBuffer = js.Null()
+	poses.IndexBuffer = js.Null()
+	poses.StateBuffer = js.Null()
+	poses.usage = "STATIC"
 	return &poses
 }
 
 func (self *SceneObjectPoses) ShowInfo() {
-	fmt.Printf("Instance Poses : size = %d & count = %d\n", self.Size, self.Count)
+	fmt.Printf("Instance Poses : size = %d & count = %d (%s)\n", self.Size, self.Count, self.usage)
+}
+
+func (self *SceneObjectPoses) SetBufferUsage(usage string) *SceneObjectPoses {
+	// 'usage' : "STATIC" (default), "DYNAMIC" (for poses updated frequently) or "STREAM" (updated every frame)
+	if usage != self.usage {
+		self.usage = usage
+		self.WebGLBuffer = js.Null() // WebGL buffer will be re-created with the new usage
+	}
+	return self
 }
 
 // ------------------------------------------------------------------------
@@ -40,9 +59,67 @@ func (self *SceneObjectPoses) SetPose(index int, offset int, values ...float32)
 	for i := 0; i < len(values); i++ {
 		self.DataBuffer[pos+offset+i] = values[i]
 	}
+	self.dirty.Mark(pos+offset, pos+offset+len(values)) // to be uploaded before rendering
+	return true
+}
+
+func (self *SceneObjectPoses) MarkDirty(index int, count int) *SceneObjectPoses {
+	// Mark the poses [index, index+count) as modified, after changing DataBuffer directly.
+	self.dirty.Mark(index*self.Size, (index+count)*self.Size)
+	return self
+}
+
+func (self *SceneObjectPoses) IsDirty() bool {
+	return self.dirty.IsDirty()
+}
+
+// ------------------------------------------------------------------------
+// Highlight States of Instances
+// ------------------------------------------------------------------------
+
+const (
+	INSTANCE_HOVERED  = 1 // bit flag of instance highlight state
+	INSTANCE_SELECTED = 2 // bit flag of instance highlight state
+)
+
+func (self *SceneObjectPoses) SetState(index int, flag int, on bool) bool {
+	// Set (or clear) the highlight state flag of the instance, and return true if it was changed.
+	if index < 0 || index >= self.Count {
+		return false
+	}
+	if self.states == nil {
+		self.states = make([]float32, self.Count)
+	}
+	old_state := int(self.states[index])
+	new_state := old_state &^ flag
+	if on {
+		new_state = old_state | flag
+	}
+	if new_state == old_state {
+		return false
+	}
+	if old_state == 0 {
+		self.states_set++
+	} else if new_state == 0 {
+		self.states_set--
+	}
+	self.states[index] = float32(new_state)
+	self.states_mod = true
 	return true
 }
 
+func (self *SceneObjectPoses) GetState(index int) int {
+	if self.states == nil || index < 0 || index >= self.Count {
+		return 0
+	}
+	return int(self.states[index])
+}
+
+func (self *SceneObjectPoses) HasStates() bool {
+	// true, if any of the instances is hovered or selected
+	return self.states_set > 0
+}
+
 // ----------------------------------------------------------------------------
 // Build WebGL Buffers
 // ----------------------------------------------------------------------------
@@ -59,9 +136,52 @@ func (self *SceneObjectPoses) BuildWebGLBuffer(wctx *WebGLContext) {
 		self.WebGLBuffer = context.Call("createBuffer", constants.ARRAY_BUFFER)
 		context.Call("bindBuffer", constants.ARRAY_BUFFER, self.WebGLBuffer)
 		var vertices_array = ConvertGoSliceToJsTypedArray(self.DataBuffer)
-		context.Call("bufferData", constants.ARRAY_BUFFER, vertices_array, constants.STATIC_DRAW)
+		context.Call("bufferData", constants.ARRAY_BUFFER, vertices_array, GetBufferUsage(constants, self.usage))
 		context.Call("bindBuffer", constants.ARRAY_BUFFER, nil)
 	} else {
 		self.WebGLBuffer = js.Null()
 	}
+	self.dirty.Reset()
+}
+
+func (self *SceneObjectPoses) UpdateWebGLBuffer(wctx *WebGLContext) {
+	// THIS FUCNTION IS MEANT TO BE CALLED BY RENDERER. NO NEED TO BE EXPORTED
+	// Upload only the modified range of DataBuffer (with gl.bufferSubData)
+	UploadDirtyRange(wctx, self.WebGLBuffer, self.DataBuffer, &self.dirty)
+}
+
+func (self *SceneObjectPoses) BuildIndexBuffer(wctx *WebGLContext) js.Value {
+	// THIS FUCNTION IS MEANT TO BE CALLED BY RENDERER. NO NEED TO BE EXPORTED
+	// Build the WebGL buffer of instance indices for "instance.index" attribute (like for GPU picking)
+	if self.IndexBuffer.IsNull() {
+		context, constants := wctx.GetContext(), wctx.GetConstants()
+		indices := make([]float32, self.Count)
+		for i := 0; i < self.Count; i++ {
+			indices[i] = float32(i)
+		}
+		self.IndexBuffer = context.Call("createBuffer", constants.ARRAY_BUFFER)
+		context.Call("bindBuffer", constants.ARRAY_BUFFER, self.IndexBuffer)
+		context.Call("bufferData", constants.ARRAY_BUFFER, ConvertGoSliceToJsTypedArray(indices), constants.STATIC_DRAW)
+		context.Call("bindBuffer", constants.ARRAY_BUFFER, nil)
+	}
+	return self.IndexBuffer
+}
+
+func (self *SceneObjectPoses) BuildStateBuffer(wctx *WebGLContext) js.Value {
+	// THIS FUCNTION IS MEANT TO BE CALLED BY RENDERER. NO NEED TO BE EXPORTED
+	// Build (or update) the WebGL buffer of instance states for "instance.state" attribute (for highlighting)
+	if self.states == nil {
+		self.states = make([]float32, self.Count)
+	}
+	if self.StateBuffer.IsNull() || self.states_mod {
+		context, constants := wctx.GetContext(), wctx.GetConstants()
+		if self.StateBuffer.IsNull() {
+			self.StateBuffer = context.Call("createBuffer", constants.ARRAY_BUFFER)
+		}
+		context.Call("bindBuffer", constants.ARRAY_BUFFER, self.StateBuffer)
+		context.Call("bufferData", constants.ARRAY_BUFFER, ConvertGoSliceToJsTypedArray(self.states), constants.DYNAMIC_DRAW)
+		context.Call("bindBuffer", constants.ARRAY_BUFFER, nil)
+		self.states_mod = false
+	}
+	return self.StateBuffer
 }
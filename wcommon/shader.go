@@ -3,6 +3,7 @@ package wcommon
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 	"syscall/js"
 )
@@ -60,6 +61,11 @@ func (self *Shader) GetShaderProgram() js.Value {
 	return self.shader_program
 }
 
+func (self *Shader) GetSourceCode() (string, string) {
+	// source code of vertex shader and fragment shader (like for building a variant of the shader)
+	return self.vshader_code, self.fshader_code
+}
+
 func (self *Shader) GetUniformBindings() map[string]map[string]interface{} {
 	return self.uniforms
 }
@@ -95,12 +101,57 @@ func (self *Shader) SetBindingForUniform(name string, dtype string, option inter
 		autobinding0 := autobinding_split[0] // "material.texture:0" (with texture UNIT value)
 		switch autobinding0 {
 		case "lighting.dlight": // [mat3](3D) directional light information with (direction[3], color[3], ambient[3])
+		case "lighting.ambient": // [vec3](3D) ambient light color
+		case "lighting.dcount": // [int](3D) number of directional lights
+		case "lighting.ddir": //   [vec3 array](3D) direction toward directional lights (in camera space)
+		case "lighting.dcolor": // [vec3 array](3D) color of directional lights
+		case "lighting.pcount": // [int](3D) number of point lights
+		case "lighting.ppos": //   [vec3 array](3D) position of point lights (in camera space)
+		case "lighting.pcolor": // [vec3 array](3D) color of point lights
+		case "lighting.patten": // [vec3 array](3D) attenuation (constant, linear, quadratic) of point lights
+		case "lighting.scount": // [int](3D) number of spot lights
+		case "lighting.spos": //   [vec3 array](3D) position of spot lights (in camera space)
+		case "lighting.sdir": //   [vec3 array](3D) direction of spot lights (in camera space)
+		case "lighting.scolor": // [vec3 array](3D) color of spot lights
+		case "lighting.satten": // [vec3 array](3D) attenuation (constant, linear, quadratic) of spot lights
+		case "lighting.scone": //  [vec2 array](3D) cosine of inner & outer cone angles of spot lights
+		case "lighting.envmap": // [sampler2D](3D) environment map sampler(unit), like "lighting.envmap:5"
+		case "lighting.envintensity": // [float](3D) intensity of image-based lighting (0 without environment map)
+		case "shadow.count": //  [int](3D) number of shadow maps
+		case "shadow.matrix": // [mat4 array](3D) transformation from camera space to shadow maps
+		case "shadow.info": //   [vec4 array](3D) (light index, near & far depth of cascade, depth bias) of shadow maps
+		case "shadow.tile": //   [vec4 array](3D) tile (U, V, width, height) of shadow maps in the atlas
+		case "shadow.filter": // [vec4 array](3D) (PCF radius, texel size, normal offset, perspective) of shadow maps
+		case "shadow.map": //    [sampler2D](3D) shadow map atlas sampler(unit), like "shadow.map:7"
+		case "shadow.receive": // [float](3D) receiving shadow flag of SceneObject (0 or 1)
 		case "material.color": //  [vec3] uniform color taken from Material
 		case "material.texture": // [sampler2D] texture sampler(unit), like "material.texture:0"
+		case "material.specular": // [vec3] specular color taken from Material
+		case "material.shininess": // [float] specular exponent taken from Material
+		case "material.emissive": // [vec3] emissive color taken from Material
+		case "material.metallic": // [float] metallic factor taken from Material (PBR)
+		case "material.roughness": // [float] roughness factor taken from Material (PBR)
+		case "material.normalscale": // [float] scale of normal map taken from Material
+		case "material.occlusion": // [float] strength of occlusion map taken from Material
+		case "material.map": // [sampler2D] texture map sampler(unit) of Material, like "material.map:normal:2"
+		case "material.maptransform": // [mat3] UV transformation of texture map, like "material.maptransform:normal"
+		case "material.mapuvset": // [float] UV set (0 or 1) of texture map, like "material.mapuvset:lightmap"
+		case "material.cubemap": // [samplerCube] cube map sampler(unit) of Material, like "material.cubemap:6"
+		case "material.reflectivity": // [float] amount of environment reflection taken from Material
+		case "material.refraction": // [float] ratio of indices of refraction taken from Material (0 for opaque)
 		case "renderer.aspect": // AspectRatio of camera, Width : Height
 		case "renderer.pvm": //  [mat3](2D) or [mat4](3D) (Proj * View * Model) matrix
 		case "renderer.proj": // [mat3](2D) or [mat4](3D) (Projection) matrix
 		case "renderer.vwmd": // [mat3](2D) or [mat4](3D) (View * Model) matrix
+		case "renderer.normal": // [mat3](3D) Normal matrix (inverse transpose of View * Model)
+		case "renderer.view": // [mat4](3D) View matrix (of the camera)
+		case "renderer.tonemap": // [int] tone mapping mode (0:NONE, 1:REINHARD, 2:ACES)
+		case "renderer.exposure": // [float] exposure for tone mapping
+		case "postprocess.source": // [sampler2D] output image of the previous pass of PostProcessor, like "postprocess.source:0"
+		case "postprocess.scene": //  [sampler2D] original image of the scene (before any pass), like "postprocess.scene:1"
+		case "postprocess.extra": //  [sampler2D] intermediate image prepared by the pass itself (like bloom), like "postprocess.extra:2"
+		case "postprocess.texel": //  [vec2] size of a texel of the source image (1/width, 1/height)
+		case "postprocess.time": //   [float] time in seconds since the PostProcessor was created
 		default:
 			fmt.Printf("Failed to SetBindingForUniform('%s') : unknown autobinding '%s'\n", name, autobinding)
 			return
@@ -121,7 +172,11 @@ func (self *Shader) SetBindingForAttribute(name string, dtype string, autobindin
 	switch autobinding0 {
 	case "geometry.coords": // point coordinates
 	case "geometry.textuv": // texture UV coordinates
+	case "geometry.textuv2": // (3D only) second texture UV coordinates (like for lightmaps)
 	case "geometry.normal": // (3D only) normal vector
+	case "geometry.tangent": // (3D only) tangent vector with handedness (for normal mapping)
+	case "instance.index": // [float] index of the instance pose (0, 1, 2, ...)
+	case "instance.state": // [float] highlight state of the instance (0:NONE, 1:HOVERED, 2:SELECTED, 3:BOTH)
 	case "instance.pose": // instance pose, like "instance.pose:<stride>:<offset>"
 		if len(autobinding_split) != 3 {
 			fmt.Printf("Failed to SetBindingForAttribute('%s') : try 'instance.pose:<stride>:<offset>'\n", name)
@@ -161,3 +216,71 @@ func (self *Shader) CheckBindings() {
 		amap["location"] = location
 	}
 }
+
+// ----------------------------------------------------------------------------
+// Shader Variant
+// ----------------------------------------------------------------------------
+
+func (self *Shader) BuildVariant(vshader_decls string, vshader_main string, fragment_shader string) (*Shader, error) {
+	// Build a variant of the shader, which runs the original vertex shader first (renamed as '_original_main()'),
+	// followed by 'vshader_main', with a new fragment shader (like for GPU picking or highlighting).
+	// The bindings of the original shader are copied (only the uniforms declared in the vertex shader),
+	// and new bindings can be added before calling CheckBindings() of the variant.
+	main_finder := regexp.MustCompile(`void\s+main\s*\(`)
+	if main_finder.FindStringIndex(self.vshader_code) == nil {
+		err := errors.New("Failed to BuildVariant() : 'main()' not found in the vertex shader")
+		fmt.Println(err.Error())
+		return nil, err
+	}
+	vertex_shader := main_finder.ReplaceAllString(self.vshader_code, "void _original_main(")
+	vertex_shader += "\n" + vshader_decls + "\nvoid main() {\n\t_original_main();\n" + vshader_main + "\n}\n"
+	variant, err := NewShader(self.wctx, vertex_shader, fragment_shader)
+	if err != nil {
+		return nil, err
+	}
+	for uname, umap := range self.uniforms {
+		if !regexp.MustCompile(`uniform\s+[^;]*\b` + regexp.QuoteMeta(uname) + `\b`).MatchString(self.vshader_code) {
+			continue // uniforms used only in the original fragment shader
+		}
+		variant.uniforms[uname] = copy_binding_without_location(umap)
+	}
+	for aname, amap := range self.attributes {
+		variant.attributes[aname] = copy_binding_without_location(amap)
+	}
+	return variant, nil
+}
+
+func (self *Shader) BuildFragmentVariant(fshader_decls string, fshader_main string) (*Shader, error) {
+	// Build a variant of the shader with the same vertex shader, whose fragment shader runs the original one first
+	// (renamed as '_original_main()'), followed by 'fshader_main' modifying 'gl_FragColor' (like for transparency).
+	// All the bindings of the original shader are copied, and new bindings can be added before CheckBindings().
+	main_finder := regexp.MustCompile(`void\s+main\s*\(`)
+	if main_finder.FindStringIndex(self.fshader_code) == nil {
+		err := errors.New("Failed to BuildFragmentVariant() : 'main()' not found in the fragment shader")
+		fmt.Println(err.Error())
+		return nil, err
+	}
+	fragment_shader := main_finder.ReplaceAllString(self.fshader_code, "void _original_main(")
+	fragment_shader += "\n" + fshader_decls + "\nvoid main() {\n\t_original_main();\n" + fshader_main + "\n}\n"
+	variant, err := NewShader(self.wctx, self.vshader_code, fragment_shader)
+	if err != nil {
+		return nil, err
+	}
+	for uname, umap := range self.uniforms {
+		variant.uniforms[uname] = copy_binding_without_location(umap)
+	}
+	for aname, amap := range self.attributes {
+		variant.attributes[aname] = copy_binding_without_location(amap)
+	}
+	return variant, nil
+}
+
+func copy_binding_without_location(binding map[string]interface{}) map[string]interface{} {
+	copied := map[string]interface{}{} // (including manual bindings with 'value', or 'buffer' & 'stride' & 'offset')
+	for key, value := range binding {
+		if key != "location" && key != "cached" { // (values cached for the original shader program)
+			copied[key] = value
+		}
+	}
+	return copied
+}
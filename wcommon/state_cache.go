@@ -0,0 +1,139 @@
+package wcommon
+
+import (
+	"strings"
+	"syscall/js"
+)
+
+// StateCache keeps track of the WebGL states set by a Renderer (shader program, depth test, depth mask,
+// blending and vertex attributes) and the values of the uniforms, so that redundant WebGL calls can be skipped
+// while rendering many SceneObjects sharing the same shaders, materials or geometries.
+// Note that Invalidate() should be called after the states were changed directly (without the StateCache),
+// like by shadow mapping, GPU picking or PostProcessor.
+
+type StateCache struct {
+	wctx    *WebGLContext //
+	shader  *Shader       // shader in use (nil if unknown)
+	depth   int           // depth test (0:UNKNOWN, 1:ENABLED, -1:DISABLED)
+	dmask   int           // depth mask (0:UNKNOWN, 1:WRITING, -1:READ_ONLY)
+	blend   string        // blending mode ("NONE" if disabled, "" if unknown)
+	attribs interface{}   // key of the vertex attributes bound last (nil if unknown)
+}
+
+type cached_uniform struct {
+	cache  *StateCache // StateCache which set the value last (uniforms can be shared by Renderers)
+	values []float32   // uniform value set last
+}
+
+func NewStateCache(wctx *WebGLContext) *StateCache {
+	self := StateCache{wctx: wctx}
+	self.Invalidate()
+	return &self
+}
+
+func (self *StateCache) Invalidate() {
+	// Forget the states, so that all of them will be set again (uniform values are kept with the shaders)
+	self.shader = nil
+	self.depth = 0
+	self.dmask = 0
+	self.blend = ""
+	self.attribs = nil
+}
+
+func (self *StateCache) UseShader(shader *Shader) {
+	if shader != self.shader {
+		self.wctx.GetContext().Call("useProgram", shader.GetShaderProgram())
+		self.shader = shader
+		self.attribs = nil // attributes should be bound again for the new shader
+	}
+}
+
+func (self *StateCache) SetDepthTest(enable bool) {
+	context, c := self.wctx.GetContext(), self.wctx.GetConstants()
+	if enable && self.depth != 1 {
+		context.Call("enable", c.DEPTH_TEST) // Enable depth test
+		context.Call("depthFunc", c.LEQUAL)  // Near things obscure far things
+		self.depth = 1
+	} else if !enable && self.depth != -1 {
+		context.Call("disable", c.DEPTH_TEST) // Disable depth test
+		self.depth = -1
+	}
+}
+
+func (self *StateCache) SetDepthMask(write bool) {
+	// Enable/disable writing into the depth buffer (like for TRANSPARENT objects)
+	if write && self.dmask != 1 {
+		self.wctx.GetContext().Call("depthMask", true)
+		self.dmask = 1
+	} else if !write && self.dmask != -1 {
+		self.wctx.GetContext().Call("depthMask", false)
+		self.dmask = -1
+	}
+}
+
+func (self *StateCache) GetDepthMask() bool {
+	// Get the depth mask set last (true if unknown, which is the default of WebGL),
+	// so that it can be restored after temporary changes.
+	return self.dmask != -1
+}
+
+func (self *StateCache) SetBlendMode(mode string) {
+	// Same as SetBlendMode(), if the mode has changed ("NONE" to disable blending)
+	if mode == "" {
+		mode = "PREMULTIPLIED"
+	}
+	if mode != self.blend {
+		SetBlendMode(self.wctx, mode)
+		self.blend = mode
+	}
+}
+
+func (self *StateCache) CheckAttributes(key interface{}) bool {
+	// Check whether the vertex attributes should be bound for the 'key' (comparable, like a struct of pointers),
+	// which is remembered as bound. Attributes are always bound for 'nil' key (like for instance poses).
+	if key != nil && key == self.attribs {
+		return false
+	}
+	self.attribs = key
+	return true
+}
+
+func (self *StateCache) SetUniform(umap map[string]interface{}, fname string, values []float32) {
+	// Set the uniform (of the shader in use) with the WebGL function (like "uniform3f", "uniform4fv" or
+	// "uniformMatrix4fv"), only if its value is different from the one set last.
+	if cached, ok := umap["cached"].(*cached_uniform); ok && cached.cache == self && is_same_values(cached.values, values) {
+		return
+	}
+	context, location := self.wctx.GetContext(), umap["location"].(js.Value)
+	switch {
+	case strings.HasPrefix(fname, "uniformMatrix"):
+		context.Call(fname, location, false, ConvertGoSliceToJsTypedArray(values))
+	case strings.HasSuffix(fname, "v"):
+		context.Call(fname, location, ConvertGoSliceToJsTypedArray(values))
+	case strings.HasSuffix(fname, "i"):
+		args := []interface{}{location}
+		for _, v := range values {
+			args = append(args, int(v))
+		}
+		context.Call(fname, args...)
+	default:
+		args := []interface{}{location}
+		for _, v := range values {
+			args = append(args, v)
+		}
+		context.Call(fname, args...)
+	}
+	umap["cached"] = &cached_uniform{cache: self, values: append([]float32{}, values...)}
+}
+
+func is_same_values(a []float32, b []float32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
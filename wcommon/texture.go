@@ -0,0 +1,61 @@
+package wcommon
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// Texture is a standalone WebGL texture, which can be shared among Materials
+// (like the texture maps of PBR materials, or the environment map of lighting).
+
+type Texture struct {
+	wctx    *WebGLContext //
+	texture js.Value      // WebGL texture
+	wh      [2]int        // texture size
+	loading bool          // true, only if texture image is being loaded
+}
+
+func NewTexture(wctx *WebGLContext, path string) *Texture {
+	// Texture loaded from server path, like "/assets/metal_roughness.png" (ready to use after loading)
+	self := NewTexture_SolidColor(wctx, [4]uint8{255, 255, 255, 255})
+	self.wh = [2]int{0, 0} // not ready until the image is loaded
+	self.loading = true
+	go func() {
+		defer func() { self.loading = false }()
+		if size, err := upload_texture_from_server(wctx, self.texture, path); err == nil {
+			self.wh = size
+		}
+	}()
+	return self
+}
+
+func NewTexture_SolidColor(wctx *WebGLContext, rgba [4]uint8) *Texture {
+	// Texture with a single pixel of the color (like a default texture map)
+	self := Texture{wctx: wctx, texture: js.Null(), wh: [2]int{1, 1}}
+	context, c := wctx.GetContext(), wctx.GetConstants()
+	self.texture = context.Call("createTexture")
+	context.Call("bindTexture", c.TEXTURE_2D, self.texture)
+	context.Call("texImage2D", c.TEXTURE_2D, 0, c.RGBA, 1, 1, 0, c.RGBA, c.UNSIGNED_BYTE, ConvertGoSliceToJsTypedArray(rgba[:]))
+	context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MIN_FILTER, c.NEAREST)
+	return &self
+}
+
+func (self *Texture) ShowInfo() {
+	fmt.Printf("Texture %dx%d (loading=%t)\n", self.wh[0], self.wh[1], self.loading)
+}
+
+func (self *Texture) GetTexture() js.Value {
+	return self.texture
+}
+
+func (self *Texture) GetWH() [2]int {
+	return self.wh
+}
+
+func (self *Texture) IsReady() bool {
+	return !self.texture.IsNull() && self.wh[0] > 0 && self.wh[1] > 0 && !self.loading
+}
+
+func (self *Texture) IsLoading() bool {
+	return self.loading
+}
@@ -0,0 +1,184 @@
+package wcommon
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"syscall/js"
+)
+
+// TextureCube is a WebGL cube-map texture with six square faces (+X, -X, +Y, -Y, +Z, -Z),
+// sampled with a direction vector in WORLD space (like for skyboxes and environment reflections).
+
+type TextureCube struct {
+	wctx    *WebGLContext //
+	texture js.Value      // WebGL texture (TEXTURE_CUBE_MAP)
+	size    int           // width (and height) of each face
+	loading bool          // true, only if texture images are being loaded
+}
+
+func NewTextureCube(wctx *WebGLContext, paths [6]string) *TextureCube {
+	// Cube map loaded from six square images of the faces (+X, -X, +Y, -Y, +Z, -Z) in WebGL convention,
+	// like "/assets/sky_px.jpg", etc. (ready to use after loading)
+	self := NewTextureCube_SolidColor(wctx, [4]uint8{0, 0, 0, 255})
+	self.size = 0 // not ready until all the images are loaded
+	self.loading = true
+	go func() {
+		defer func() { self.loading = false }()
+		faces := [6][]uint8{}
+		size := 0
+		for i, path := range paths {
+			img, err := LoadImageFromServer(path)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			pixbuf, wh := GetPixelBufferFromImage(img)
+			if wh[0] != wh[1] || (i > 0 && wh[0] != size) {
+				fmt.Printf("Failed to load TextureCube : invalid size %dx%d of %s (square faces of the same size)\n", wh[0], wh[1], path)
+				return
+			}
+			faces[i], size = pixbuf, wh[0]
+		}
+		self.upload_faces(faces, size)
+	}()
+	return self
+}
+
+func NewTextureCube_Panorama(wctx *WebGLContext, path string, size int) *TextureCube {
+	// Cube map converted from a panorama image in equirectangular (longitude-latitude) projection,
+	// with +Z axis as the zenith (just like the environment map of Lighting), and 'size' for each face.
+	self := NewTextureCube_SolidColor(wctx, [4]uint8{0, 0, 0, 255})
+	self.size = 0 // not ready until the image is loaded & converted
+	self.loading = true
+	go func() {
+		defer func() { self.loading = false }()
+		img, err := LoadImageFromServer(path)
+		if err != nil {
+			fmt.Println(err.Error())
+			return
+		}
+		self.upload_faces(convert_panorama_to_cube_faces(img, size), size)
+	}()
+	return self
+}
+
+func NewTextureCube_SolidColor(wctx *WebGLContext, rgba [4]uint8) *TextureCube {
+	// Cube map with a single pixel of the color for each face (like a default cube map)
+	self := TextureCube{wctx: wctx, texture: js.Null(), size: 1}
+	context, c := wctx.GetContext(), wctx.GetConstants()
+	self.texture = context.Call("createTexture")
+	context.Call("bindTexture", c.TEXTURE_CUBE_MAP, self.texture)
+	for i := 0; i < 6; i++ {
+		target := js.ValueOf(c.TEXTURE_CUBE_MAP_POSITIVE_X.Int() + i)
+		context.Call("texImage2D", target, 0, c.RGBA, 1, 1, 0, c.RGBA, c.UNSIGNED_BYTE, ConvertGoSliceToJsTypedArray(rgba[:]))
+	}
+	context.Call("texParameteri", c.TEXTURE_CUBE_MAP, c.TEXTURE_MIN_FILTER, c.NEAREST)
+	return &self
+}
+
+func NewTextureCube_StarrySky(wctx *WebGLContext, size int, count int) *TextureCube {
+	// Cube map of black sky with 'count' stars of random brightness (like the background of a globe)
+	self := TextureCube{wctx: wctx, texture: js.Null(), size: 0}
+	faces := [6][]uint8{}
+	for i := 0; i < 6; i++ {
+		faces[i] = make([]uint8, size*size*4)
+		for k := 3; k < len(faces[i]); k += 4 {
+			faces[i][k] = 255 // opaque black
+		}
+	}
+	rnd := rand.New(rand.NewSource(1)) // same sky every time
+	for n := 0; n < count; n++ {
+		f, x, y := rnd.Intn(6), rnd.Intn(size), rnd.Intn(size)
+		b := uint8(64 + rnd.Intn(192)) // brightness
+		set_pixbuf_with_rgba(faces[f], (y*size+x)*4, b, b, uint8(math.Min(255, float64(b)*1.1)), 255)
+	}
+	self.texture = wctx.GetContext().Call("createTexture")
+	self.upload_faces(faces, size)
+	return &self
+}
+
+func (self *TextureCube) ShowInfo() {
+	fmt.Printf("TextureCube %dx%d (loading=%t)\n", self.size, self.size, self.loading)
+}
+
+func (self *TextureCube) GetTexture() js.Value {
+	return self.texture
+}
+
+func (self *TextureCube) GetSize() int {
+	return self.size
+}
+
+func (self *TextureCube) IsReady() bool {
+	return !self.texture.IsNull() && self.size > 0 && !self.loading
+}
+
+func (self *TextureCube) IsLoading() bool {
+	return self.loading
+}
+
+func (self *TextureCube) upload_faces(faces [6][]uint8, size int) {
+	// Upload the pixel buffers of six faces (+X, -X, +Y, -Y, +Z, -Z) to the WebGL texture (with mipmaps if POWER-OF-2).
+	context, c := self.wctx.GetContext(), self.wctx.GetConstants()
+	context.Call("bindTexture", c.TEXTURE_CUBE_MAP, self.texture)
+	for i := 0; i < 6; i++ {
+		target := js.ValueOf(c.TEXTURE_CUBE_MAP_POSITIVE_X.Int() + i)
+		context.Call("texImage2D", target, 0, c.RGBA, size, size, 0, c.RGBA, c.UNSIGNED_BYTE, ConvertGoSliceToJsTypedArray(faces[i]))
+	}
+	context.Call("texParameteri", c.TEXTURE_CUBE_MAP, c.TEXTURE_WRAP_S, c.CLAMP_TO_EDGE)
+	context.Call("texParameteri", c.TEXTURE_CUBE_MAP, c.TEXTURE_WRAP_T, c.CLAMP_TO_EDGE)
+	if size&(size-1) == 0 { // POWER-OF-2 size
+		context.Call("generateMipmap", c.TEXTURE_CUBE_MAP)
+	} else { // NON-POWER-OF-2 textures : NEAREST/LINEAR only
+		context.Call("texParameteri", c.TEXTURE_CUBE_MAP, c.TEXTURE_MIN_FILTER, c.LINEAR)
+	}
+	self.size = size
+}
+
+func convert_panorama_to_cube_faces(img image.Image, size int) [6][]uint8 {
+	// Resample the equirectangular panorama (with bilinear filtering) for each face of the cube map,
+	// where the direction of pixel (i,j) of each face follows the WebGL convention of cube maps.
+	pixbuf, wh := GetPixelBufferFromImage(img)
+	sample := func(u float64, v float64, pix []uint8) {
+		x, y := u*float64(wh[0])-0.5, math.Min(math.Max(v*float64(wh[1])-0.5, 0), float64(wh[1]-1))
+		x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+		fx, fy := x-float64(x0), y-float64(y0)
+		for k := 0; k < 4; k++ {
+			value := 0.0
+			for n := 0; n < 4; n++ {
+				px, py := (x0+n%2+wh[0])%wh[0], y0+n/2 // wrap around horizontally
+				if py >= wh[1] {
+					py = wh[1] - 1
+				}
+				wx, wy := 1-fx, 1-fy
+				if n%2 == 1 {
+					wx = fx
+				}
+				if n/2 == 1 {
+					wy = fy
+				}
+				value += wx * wy * float64(pixbuf[(py*wh[0]+px)*4+k])
+			}
+			pix[k] = uint8(math.Min(255, value+0.5))
+		}
+	}
+	faces := [6][]uint8{}
+	for f := 0; f < 6; f++ {
+		faces[f] = make([]uint8, size*size*4)
+		for j := 0; j < size; j++ {
+			t := 2*(float64(j)+0.5)/float64(size) - 1
+			for i := 0; i < size; i++ {
+				s := 2*(float64(i)+0.5)/float64(size) - 1
+				d := [6][3]float64{{1, -t, -s}, {-1, -t, s}, {s, 1, t}, {s, -1, -t}, {s, -t, 1}, {-s, -t, -1}}[f]
+				r := math.Sqrt(d[0]*d[0] + d[1]*d[1] + d[2]*d[2])
+				u := math.Atan2(d[1], d[0])/(2*math.Pi) + 0.5
+				v := math.Acos(d[2]/r) / math.Pi
+				idx := (j*size + i) * 4
+				sample(u, v, faces[f][idx:idx+4])
+			}
+		}
+	}
+	return faces
+}
@@ -0,0 +1,249 @@
+package webgl2d
+
+import (
+	"github.com/go4orward/gowebgl/geom2d"
+	"github.com/go4orward/gowebgl/wcommon"
+)
+
+// ----------------------------------------------------------------------------
+// Static Batching
+// ----------------------------------------------------------------------------
+
+// 'Static' SceneObjects sharing the same shaders and material (and OPAQUE, without instance poses and
+// highlighting) are merged into a single SceneObject by RenderScene(), with their points transformed
+// by their model matrices, so that all of them are rendered with a single draw call (for each draw mode).
+// Without depth test, only consecutive SceneObjects are merged (since new drawings overwrite old ones).
+// A batch is rebuilt only if any of its members has changed (like its model matrix or its geometry).
+
+type static_batcher struct {
+	renderer *Renderer       //
+	batches  []*static_batch // batches rendered in the last frame
+}
+
+type static_batch struct {
+	key     batch_key      // shaders, material & data layout shared by the members
+	members []batch_member // SceneObjects merged into the batch
+	sobj    *SceneObject   // SceneObject with the merged geometry
+}
+
+type batch_key struct {
+	vshader  *wcommon.Shader   //
+	eshader  *wcommon.Shader   //
+	fshader  *wcommon.Shader   //
+	material *wcommon.Material //
+	depth    bool              // depth test flag
+	finfo    [4]int            // data size of a point for triangles
+	vinfo    [4]int            // data size of a point for points & lines
+	buffers  [5]bool           // existing data buffers : [ vpoints, fpoints, lines, faces, shared_points ]
+}
+
+type batch_group struct {
+	key     batch_key //
+	indices []int     // indices of the items to be merged
+}
+
+type batch_member struct {
+	sobj     *SceneObject //
+	geometry *Geometry    //
+	model    [9]float32   // model matrix
+	vpoints  *float32     // data buffers (to detect rebuilding)
+	fpoints  *float32     //
+	lines    *uint32      //
+	faces    *uint32      //
+	lengths  [4]int       // lengths of the data buffers
+	dirty    bool         // true, if the data buffers were modified after the last upload
+}
+
+func new_static_batcher(renderer *Renderer) *static_batcher {
+	return &static_batcher{renderer: renderer, batches: []*static_batch{}}
+}
+
+func (self *static_batcher) batch_items(items []render_item, camera *Camera) []render_item {
+	// Replace the items of 'Static' SceneObjects with the items of their batches (at the position of the first member)
+	groups, current, run := []*batch_group{}, map[batch_key]*batch_group{}, (*batch_group)(nil)
+	for i, item := range items {
+		key, ok := get_batch_key(item)
+		if ok && key.depth { // merged with any of the consecutive OPAQUE objects with depth test
+			if current[key] == nil {
+				current[key] = &batch_group{key: key, indices: []int{}}
+				groups = append(groups, current[key])
+			}
+			current[key].indices = append(current[key].indices, i)
+			run = nil
+		} else if ok && run != nil && run.key == key { // merged with the previous object only
+			run.indices = append(run.indices, i)
+		} else if ok {
+			run = &batch_group{key: key, indices: []int{i}}
+			groups = append(groups, run)
+			current = map[batch_key]*batch_group{}
+		} else {
+			run = nil
+			if !item.sobj.UseDepth || item.sobj.UseBlend {
+				current = map[batch_key]*batch_group{} // objects without depth test (or blended) keep their order
+			}
+		}
+	}
+	batches, merged, skipped := []*static_batch{}, map[int]*static_batch{}, map[int]bool{}
+	for _, group := range groups {
+		key, indices := group.key, group.indices
+		if len(indices) < 2 {
+			continue
+		}
+		members := make([]batch_member, len(indices))
+		for k, i := range indices {
+			members[k] = new_batch_member(items[i])
+		}
+		batch := self.find_batch(key, members)
+		if batch == nil {
+			batch = self.build_batch(key, members, items, indices)
+		}
+		batches = append(batches, batch)
+		merged[indices[0]] = batch
+		for _, i := range indices[1:] {
+			skipped[i] = true
+		}
+	}
+	self.release_batches(batches)
+	self.batches = batches
+	if len(merged) == 0 {
+		return items
+	}
+	result := make([]render_item, 0, len(items)-len(skipped))
+	for i, item := range items {
+		if batch := merged[i]; batch != nil {
+			identity := geom2d.NewMatrix3()
+			result = append(result, render_item{sobj: batch.sobj, pvm: &camera.pjvwmatrix, model: identity})
+		} else if !skipped[i] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func get_batch_key(item render_item) (batch_key, bool) {
+	s, g := item.sobj, item.sobj.Geometry
+	if !s.Static || s.UseBlend || s.poses != nil || s.is_highlighted() || !g.IsDataBufferReady() {
+		return batch_key{}, false
+	}
+	key := batch_key{vshader: s.VShader, eshader: s.EShader, fshader: s.FShader, material: s.Material, depth: s.UseDepth}
+	key.finfo, key.vinfo = g.fpoint_info, g.vpoint_info
+	key.buffers[0], key.buffers[1] = g.data_buffer_vpoints != nil, g.data_buffer_fpoints != nil
+	key.buffers[2], key.buffers[3] = g.data_buffer_lines != nil, g.data_buffer_faces != nil
+	key.buffers[4] = len(g.data_buffer_vpoints) > 0 && len(g.data_buffer_fpoints) > 0 && &g.data_buffer_vpoints[0] == &g.data_buffer_fpoints[0]
+	return key, true
+}
+
+func new_batch_member(item render_item) batch_member {
+	g := item.sobj.Geometry
+	m := batch_member{sobj: item.sobj, geometry: g, model: *item.model.GetElements()}
+	if len(g.data_buffer_vpoints) > 0 {
+		m.vpoints = &g.data_buffer_vpoints[0]
+	}
+	if len(g.data_buffer_fpoints) > 0 {
+		m.fpoints = &g.data_buffer_fpoints[0]
+	}
+	if len(g.data_buffer_lines) > 0 {
+		m.lines = &g.data_buffer_lines[0]
+	}
+	if len(g.data_buffer_faces) > 0 {
+		m.faces = &g.data_buffer_faces[0]
+	}
+	m.lengths = [4]int{len(g.data_buffer_vpoints), len(g.data_buffer_fpoints), len(g.data_buffer_lines), len(g.data_buffer_faces)}
+	m.dirty = g.dirty_vpoints.IsDirty() || g.dirty_fpoints.IsDirty()
+	return m
+}
+
+func (self *static_batcher) find_batch(key batch_key, members []batch_member) *static_batch {
+	// find the batch of the last frame with the same members (without any change)
+	for _, batch := range self.batches {
+		if batch.key != key || len(batch.members) != len(members) {
+			continue
+		}
+		same := true
+		for k := range members {
+			if members[k] != batch.members[k] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return batch
+		}
+	}
+	return nil
+}
+
+func (self *static_batcher) build_batch(key batch_key, members []batch_member, items []render_item, indices []int) *static_batch {
+	// Merge the data buffers of the members, with their points transformed by their model matrices
+	merged := NewGeometry()
+	merged.fpoint_info, merged.vpoint_info = key.finfo, key.vinfo
+	vcount, fcount := uint32(0), uint32(0) // number of points merged so far
+	for k, m := range members {
+		g, model := m.geometry, items[indices[k]].model
+		vbase, fbase := vcount, fcount
+		if key.buffers[1] {
+			points := transform_points(g.data_buffer_fpoints, key.finfo, model)
+			merged.data_buffer_fpoints = append(merged.data_buffer_fpoints, points...)
+			fcount += uint32(len(points) / key.finfo[0])
+		}
+		if key.buffers[0] && key.buffers[4] {
+			vbase, vcount = fbase, fcount // (vertex points shared with the points for triangles)
+		} else if key.buffers[0] {
+			points := transform_points(g.data_buffer_vpoints, key.vinfo, model)
+			merged.data_buffer_vpoints = append(merged.data_buffer_vpoints, points...)
+			vcount += uint32(len(points) / key.vinfo[0])
+		}
+		for _, vidx := range g.data_buffer_lines {
+			merged.data_buffer_lines = append(merged.data_buffer_lines, vbase+vidx)
+		}
+		if !key.buffers[1] {
+			fbase = vbase // (triangles with vertex points)
+		}
+		for _, vidx := range g.data_buffer_faces {
+			merged.data_buffer_faces = append(merged.data_buffer_faces, fbase+vidx)
+		}
+		// the modified data of the member is merged already, so upload it (if necessary) to avoid rebuilding again
+		if g.IsWebGLBufferReady() {
+			g.UpdateWebGLBuffers(self.renderer.wctx)
+		} else {
+			g.dirty_vpoints.Reset()
+			g.dirty_fpoints.Reset()
+		}
+		members[k].dirty = false
+	}
+	if key.buffers[4] {
+		merged.data_buffer_vpoints = merged.data_buffer_fpoints // shared with vertex points
+	}
+	sobj := NewSceneObject(merged, key.material, key.vshader, key.eshader, key.fshader)
+	sobj.UseDepth = key.depth
+	return &static_batch{key: key, members: members, sobj: sobj}
+}
+
+func (self *static_batcher) release_batches(keep []*static_batch) {
+	// delete the WebGL buffers of the batches which are not rendered any more
+	context := self.renderer.wctx.GetContext()
+	for _, batch := range self.batches {
+		kept := false
+		for _, b := range keep {
+			kept = kept || b == batch
+		}
+		if g := batch.sobj.Geometry; !kept && g.IsWebGLBufferReady() {
+			for _, buffer := range []interface{}{g.webgl_buffer_vpoints, g.webgl_buffer_fpoints, g.webgl_buffer_lines, g.webgl_buffer_faces} {
+				context.Call("deleteBuffer", buffer)
+			}
+			g.Clear(false, false, true)
+		}
+	}
+}
+
+func transform_points(points []float32, pinfo [4]int, model *geom2d.Matrix3) []float32 {
+	// Copy the serialized points, with their XY coordinates transformed
+	stride := pinfo[0] // [ stride, xy_offset, uv_offset, RESERVED ]
+	result := append([]float32{}, points...)
+	for pos := 0; stride > 0 && pos+stride <= len(result); pos += stride {
+		p := pos + pinfo[1]
+		xy := model.MultiplyVector2([2]float32{result[p], result[p+1]})
+		result[p], result[p+1] = xy[0], xy[1]
+	}
+	return result
+}
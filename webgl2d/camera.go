@@ -188,23 +188,20 @@ func (self *Camera) ProjectWorldToCanvas(wxy [2]float32) [2]int {
 func (self *Camera) UnprojectCanvasToWorld(canvasxy [2]int) [2]float32 {
 	hw, hh := (float32(self.wh[0]) / 2), (float32(self.wh[1]) / 2)
 	clipxy := [2]float32{(float32(canvasxy[0]) - hw) / hw, -(float32(canvasxy[1]) - hh) / hh}
-	ex, ey := self.projmatrix.GetElements()[0], self.projmatrix.GetElements()[4]
-	cameraxy := [2]float32{clipxy[0] / ex, clipxy[1] / ey}
-	e := self.viewmatrix.GetElements()
-	r00, r10, r01, r11, tx, ty := e[0], e[1], e[3], e[4], e[6], e[7]
-	x, y := cameraxy[0]-tx, cameraxy[1]-ty          // inverse of (Translation from WORLD to CAMERA)
-	wxy := [2]float32{r00*x + r10*y, r01*x + r11*y} // inverse of (Rotation from WORLD to CAMERA)
-	return wxy
+	inverse := self.pjvwmatrix.Inverse() // from CLIP to WORLD space
+	if inverse == nil {
+		return [2]float32{0, 0}
+	}
+	return inverse.MultiplyVector2(clipxy)
 }
 
 func (self *Camera) UnprojectCanvasDeltaToWorld(deltaxy [2]int) [2]float32 {
 	hw, hh := (float32(self.wh[0]) / 2), (float32(self.wh[1]) / 2)
-	clip_delta := [2]float32{float32(deltaxy[0]) / hw, -float32(deltaxy[1]) / hh}
-	ex, ey := self.projmatrix.GetElements()[0], self.projmatrix.GetElements()[4]
-	cam_delta := [2]float32{clip_delta[0] / ex, clip_delta[1] / ey}
-	e := self.viewmatrix.GetElements()
-	r00, r10, r01, r11 := e[0], e[1], e[3], e[4]
-	wx := r00*cam_delta[0] + r10*cam_delta[1] // inverse of (Rotation from WORLD to CAMERA)
-	wy := r01*cam_delta[0] + r11*cam_delta[1]
-	return [2]float32{wx, wy}
+	clip_delta := [3]float32{float32(deltaxy[0]) / hw, -float32(deltaxy[1]) / hh, 0}
+	inverse := self.pjvwmatrix.Inverse() // from CLIP to WORLD space
+	if inverse == nil {
+		return [2]float32{0, 0}
+	}
+	wdelta := inverse.MultiplyVector3(clip_delta) // (without translation)
+	return [2]float32{wdelta[0], wdelta[1]}
 }
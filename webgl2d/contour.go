@@ -0,0 +1,84 @@
+package webgl2d
+
+import (
+	"fmt"
+)
+
+// ----------------------------------------------------------------------------
+// Contour Lines (Marching Squares)
+// ----------------------------------------------------------------------------
+
+func NewGeometry_Contours(dims [2]int, spacing [2]float32, values []float32, iso_values []float32) *Geometry {
+	// Build contour lines of the scalar grid for each of 'iso_values', as edges of line segments.
+	//   'dims'    : number of samples along X and Y axis (with the first sample at the origin)
+	//   'spacing' : distance between samples along X and Y axis
+	//   'values'  : samples, with X varying fastest : values[y*nx+x]
+	// Vertices on the same grid edge are welded, and saddle cells are resolved with the average of its corners.
+	geometry := NewGeometry()
+	nx, ny := dims[0], dims[1]
+	if len(values) != nx*ny {
+		fmt.Printf("Invalid number of values (%d) for contours of %v\n", len(values), dims)
+		return geometry
+	}
+	for _, iso_value := range iso_values {
+		vindex := map[[2]int]uint32{} // welded vertex for each grid edge (pair of sample indices)
+		get_vertex := func(ia int, ib int) uint32 {
+			va, vb := values[ia], values[ib]
+			t := float32(0.5)
+			if va != vb {
+				t = (iso_value - va) / (vb - va)
+			}
+			key := [2]int{ia, ib}
+			if t <= 0 { // the vertex is exactly on the sample
+				t, key = 0, [2]int{ia, ia}
+			} else if t >= 1 {
+				t, key = 1, [2]int{ib, ib}
+			} else if ia > ib {
+				key = [2]int{ib, ia}
+			}
+			if vidx, ok := vindex[key]; ok {
+				return vidx
+			}
+			ax, ay, bx, by := float32(ia%nx), float32(ia/nx), float32(ib%nx), float32(ib/nx)
+			vidx := geometry.AddVertex([2]float32{(ax + (bx-ax)*t) * spacing[0], (ay + (by-ay)*t) * spacing[1]})
+			vindex[key] = vidx
+			return vidx
+		}
+		add_segment := func(a uint32, b uint32) {
+			if a != b {
+				geometry.AddEdge([]uint32{a, b})
+			}
+		}
+		for y := 0; y < ny-1; y++ {
+			for x := 0; x < nx-1; x++ {
+				// corners in CCW order, and cell edge k connects corner k and corner (k+1)%4
+				c := [4]int{y*nx + x, y*nx + x + 1, (y+1)*nx + x + 1, (y+1)*nx + x}
+				above := [4]bool{}
+				crossing := []int{}
+				for k := 0; k < 4; k++ {
+					above[k] = values[c[k]] >= iso_value
+				}
+				for k := 0; k < 4; k++ {
+					if above[k] != above[(k+1)%4] {
+						crossing = append(crossing, k)
+					}
+				}
+				edge_vertex := func(k int) uint32 { return get_vertex(c[k], c[(k+1)%4]) }
+				switch len(crossing) {
+				case 2:
+					add_segment(edge_vertex(crossing[0]), edge_vertex(crossing[1]))
+				case 4: // saddle cell
+					center := (values[c[0]] + values[c[1]] + values[c[2]] + values[c[3]]) / 4
+					if (center >= iso_value) == above[0] { // corner 0 and 2 are connected through the center
+						add_segment(edge_vertex(0), edge_vertex(1)) // around corner 1
+						add_segment(edge_vertex(2), edge_vertex(3)) // around corner 3
+					} else {
+						add_segment(edge_vertex(3), edge_vertex(0)) // around corner 0
+						add_segment(edge_vertex(1), edge_vertex(2)) // around corner 2
+					}
+				}
+			}
+		}
+	}
+	return geometry
+}
@@ -34,11 +34,17 @@ type Geometry struct {
 	webgl_buffer_fpoints js.Value // WebGL data buffer for data_buffer_fpoints (points for PER_FACE vertices)
 	webgl_buffer_lines   js.Value // WebGL data buffer for data_buffer_lines (indices for lines)
 	webgl_buffer_faces   js.Value // WebGL data buffer for data_buffer_faces (indices for triangles)
+
+	buffer_usage  string             // usage hint for WebGL buffers of points ("STATIC", "DYNAMIC" or "STREAM")
+	dirty_vpoints wcommon.DirtyRange // range of data_buffer_vpoints modified after the last upload
+	dirty_fpoints wcommon.DirtyRange // range of data_buffer_fpoints modified after the last upload
+	webgl_version int                // incremented whenever WebGL buffers are built (to tell new buffers from old ones)
 }
 
 func NewGeometry() *Geometry {
 	var geometry Geometry
 	geometry.Clear(true, true, true)
+	geometry.buffer_usage = "STATIC"
 	return &geometry
 }
 
@@ -64,6 +70,8 @@ func (self *Geometry) Clear(geom bool, data_buf bool, webgl_buf bool) *Geometry
 		self.webgl_buffer_fpoints = js.Null()
 		self.webgl_buffer_lines = js.Null()
 		self.webgl_buffer_faces = js.Null()
+		self.dirty_vpoints.Reset()
+		self.dirty_fpoints.Reset()
 	}
 	return self
 }
@@ -136,13 +144,15 @@ func (self *Geometry) AddFace(face []uint32) uint32 {
 // ----------------------------------------------------------------------------
 // Transformation of Vertex Coordinates
 // ----------------------------------------------------------------------------
+// Note that the transformations update the existing data buffers in place (instead of clearing them),
+// and only the modified data will be uploaded to WebGL before rendering.
 
 func (self *Geometry) Translate(tx float32, ty float32) *Geometry {
 	for i := 0; i < len(self.verts); i++ {
 		self.verts[i][0] += tx
 		self.verts[i][1] += ty
 	}
-	self.Clear(false, true, true)
+	self.refresh_data_buffers(0, len(self.verts))
 	return self
 }
 
@@ -154,7 +164,7 @@ func (self *Geometry) Rotate(angle_in_degree float32) *Geometry {
 		self.verts[i][0] = float32(cos*x - sin*y)
 		self.verts[i][1] = float32(sin*x + cos*y)
 	}
-	self.Clear(false, true, true)
+	self.refresh_data_buffers(0, len(self.verts))
 	return self
 }
 
@@ -163,7 +173,7 @@ func (self *Geometry) Scale(sx float32, sy float32) *Geometry {
 		self.verts[i][0] *= sx
 		self.verts[i][1] *= sy
 	}
-	self.Clear(false, true, true)
+	self.refresh_data_buffers(0, len(self.verts))
 	return self
 }
 
@@ -171,7 +181,7 @@ func (self *Geometry) AppyMatrix(matrix *geom2d.Matrix3) *Geometry {
 	for i := 0; i < len(self.verts); i++ {
 		self.verts[i] = matrix.MultiplyVector2(self.verts[i])
 	}
-	self.Clear(false, true, true)
+	self.refresh_data_buffers(0, len(self.verts))
 	return self
 }
 
@@ -457,6 +467,73 @@ func (self *Geometry) BuildDataBuffersForWireframe() {
 	self.Clear(false, false, true)
 }
 
+// ----------------------------------------------------------------------------
+// Partial Update of Data Buffers
+// ----------------------------------------------------------------------------
+
+func (self *Geometry) UpdateVertices(vidx int, coords ...[2]float32) *Geometry {
+	// Change the coordinates of the vertices [vidx, vidx+len(coords)), and update the data buffers in place.
+	// Only the modified range will be uploaded to WebGL before rendering.
+	for i, xy := range coords {
+		self.verts[vidx+i] = xy
+	}
+	self.refresh_data_buffers(vidx, vidx+len(coords))
+	return self
+}
+
+func (self *Geometry) refresh_data_buffers(vstt int, vend int) {
+	// Copy the vertices [vstt, vend) into the existing data buffers, marking dirty ranges.
+	// If the data buffers are missing or out of date (after adding vertices or faces), they're cleared to be rebuilt.
+	if !self.IsDataBufferReady() || !self.is_data_buffer_size_valid() {
+		self.Clear(false, true, true)
+		return
+	}
+	if self.data_buffer_fpoints != nil {
+		pinfo := self.fpoint_info
+		if self.HasTextureFor("FACE") { // vertices were duplicated for each face
+			for fidx, face := range self.faces {
+				for i, v := range face {
+					if int(v) >= vstt && int(v) < vend {
+						new_vidx := self.get_fpoint_new_vidx(fidx, i)
+						self.buffer_copy_xy(self.data_buffer_fpoints, pinfo, new_vidx, int(v))
+						self.dirty_fpoints.Mark(new_vidx*pinfo[0], (new_vidx+1)*pinfo[0])
+					}
+				}
+			}
+		} else {
+			for v := vstt; v < vend; v++ {
+				self.buffer_copy_xy(self.data_buffer_fpoints, pinfo, v, v)
+			}
+			self.dirty_fpoints.Mark(vstt*pinfo[0], vend*pinfo[0])
+		}
+	}
+	if self.data_buffer_vpoints != nil {
+		shared := len(self.data_buffer_fpoints) > 0 && &self.data_buffer_vpoints[0] == &self.data_buffer_fpoints[0]
+		if !shared { // separate data buffer with coordinates only
+			for v := vstt; v < vend; v++ {
+				self.buffer_copy_xy(self.data_buffer_vpoints, self.vpoint_info, v, v)
+			}
+		}
+		self.dirty_vpoints.Mark(vstt*self.vpoint_info[0], vend*self.vpoint_info[0])
+	}
+}
+
+func (self *Geometry) is_data_buffer_size_valid() bool {
+	if self.data_buffer_vpoints != nil && len(self.data_buffer_vpoints) != len(self.verts)*self.vpoint_info[0] {
+		return false
+	}
+	if self.data_buffer_fpoints != nil {
+		if self.HasTextureFor("FACE") {
+			if self.fpoint_vidx_list == nil || len(self.fpoint_vidx_list) != len(self.faces) {
+				return false
+			}
+			return len(self.data_buffer_fpoints) == self.fpoint_vert_total*self.fpoint_info[0]
+		}
+		return len(self.data_buffer_fpoints) == len(self.verts)*self.fpoint_info[0]
+	}
+	return true
+}
+
 // ----------------------------------------------------------------------------
 // Build WebGL Buffers
 // ----------------------------------------------------------------------------
@@ -465,15 +542,28 @@ func (self *Geometry) IsWebGLBufferReady() bool {
 	return !self.webgl_buffer_vpoints.IsNull()
 }
 
+func (self *Geometry) SetBufferUsage(usage string) *Geometry {
+	// 'usage' : "STATIC" (default), "DYNAMIC" (for vertices updated frequently) or "STREAM" (updated every frame)
+	if usage != self.buffer_usage {
+		self.buffer_usage = usage
+		self.Clear(false, false, true) // WebGL buffers will be re-created with the new usage
+	}
+	return self
+}
+
 func (self *Geometry) BuildWebGLBuffers(wctx *wcommon.WebGLContext, for_points bool, for_lines bool, for_faces bool) {
 	// THIS FUCNTION IS MEANT TO BE CALLED BY RENDERER. NO NEED TO BE EXPORTED
 	context := wctx.GetContext()     // js.Value
 	constants := wctx.GetConstants() // *wcommon.Constants
+	usage := wcommon.GetBufferUsage(constants, self.buffer_usage)
+	self.webgl_version++
+	self.dirty_vpoints.Reset()
+	self.dirty_fpoints.Reset()
 	if for_points && self.data_buffer_vpoints != nil {
 		self.webgl_buffer_vpoints = context.Call("createBuffer", constants.ARRAY_BUFFER)
 		context.Call("bindBuffer", constants.ARRAY_BUFFER, self.webgl_buffer_vpoints)
 		var vertices_array = wcommon.ConvertGoSliceToJsTypedArray(self.data_buffer_vpoints)
-		context.Call("bufferData", constants.ARRAY_BUFFER, vertices_array, constants.STATIC_DRAW)
+		context.Call("bufferData", constants.ARRAY_BUFFER, vertices_array, usage)
 		context.Call("bindBuffer", constants.ARRAY_BUFFER, nil)
 	} else {
 		self.webgl_buffer_vpoints = js.Null()
@@ -492,7 +582,7 @@ func (self *Geometry) BuildWebGLBuffers(wctx *wcommon.WebGLContext, for_points b
 			self.webgl_buffer_fpoints = context.Call("createBuffer", constants.ARRAY_BUFFER)
 			context.Call("bindBuffer", constants.ARRAY_BUFFER, self.webgl_buffer_fpoints)
 			var points_array = wcommon.ConvertGoSliceToJsTypedArray(self.data_buffer_fpoints)
-			context.Call("bufferData", constants.ARRAY_BUFFER, points_array, constants.STATIC_DRAW)
+			context.Call("bufferData", constants.ARRAY_BUFFER, points_array, usage)
 			context.Call("bindBuffer", constants.ARRAY_BUFFER, nil)
 		}
 		self.webgl_buffer_faces = context.Call("createBuffer", constants.ELEMENT_ARRAY_BUFFER)
@@ -505,6 +595,13 @@ func (self *Geometry) BuildWebGLBuffers(wctx *wcommon.WebGLContext, for_points b
 	}
 }
 
+func (self *Geometry) UpdateWebGLBuffers(wctx *wcommon.WebGLContext) {
+	// THIS FUCNTION IS MEANT TO BE CALLED BY RENDERER. NO NEED TO BE EXPORTED
+	// Upload only the modified range of data buffers (with gl.bufferSubData)
+	wcommon.UploadDirtyRange(wctx, self.webgl_buffer_vpoints, self.data_buffer_vpoints, &self.dirty_vpoints)
+	wcommon.UploadDirtyRange(wctx, self.webgl_buffer_fpoints, self.data_buffer_fpoints, &self.dirty_fpoints)
+}
+
 func (self *Geometry) GetWebGLBuffer(draw_mode int) (js.Value, int, [4]int) {
 	switch draw_mode {
 	case 1: // "POINTS", "VERTICES"
@@ -2,6 +2,8 @@ package webgl2d
 
 import (
 	"math"
+
+	"github.com/go4orward/gowebgl/geom2d"
 )
 
 var geometry_origin *Geometry // Geometry with only one vertex at (0,0)
@@ -42,3 +44,55 @@ func NewGeometry_Polygon(n int, radius float32, starting_angle_in_degree float32
 	geometry.AddFace(face_indices)
 	return geometry
 }
+
+func NewGeometry_ConvexHull(points [][2]float32) *Geometry {
+	geometry := NewGeometry() // convex hull of the points, as a single face with its outline
+	hull := geom2d.ConvexHull(points)
+	face_indices := make([]uint32, len(hull))
+	for i, pidx := range hull {
+		geometry.AddVertex(points[pidx])
+		face_indices[i] = uint32(i)
+	}
+	if len(hull) >= 3 {
+		geometry.AddFace(face_indices)
+		geometry.AddEdge(append(face_indices, 0))
+	}
+	return geometry
+}
+
+func NewGeometry_DelaunayTriangulation(points [][2]float32) *Geometry {
+	geometry := NewGeometry() // Delaunay triangles of the points, with all the triangle edges
+	geometry.SetVertices(points)
+	edge_done := map[[2]uint32]bool{}
+	for _, t := range geom2d.DelaunayTriangulation(points) {
+		face := []uint32{uint32(t[0]), uint32(t[1]), uint32(t[2])}
+		geometry.AddFace(face)
+		for k := 0; k < 3; k++ {
+			a, b := face[k], face[(k+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			if !edge_done[[2]uint32{a, b}] {
+				edge_done[[2]uint32{a, b}] = true
+				geometry.AddEdge([]uint32{a, b})
+			}
+		}
+	}
+	return geometry
+}
+
+func NewGeometry_VoronoiCells(points [][2]float32, bbox [2][2]float32) *Geometry {
+	geometry := NewGeometry() // Voronoi cells of the points (clipped by 'bbox'), as faces with their outlines
+	for _, cell := range geom2d.VoronoiCells(points, bbox) {
+		if len(cell) < 3 {
+			continue
+		}
+		face_indices := make([]uint32, len(cell))
+		for i, v := range cell {
+			face_indices[i] = geometry.AddVertex(v)
+		}
+		geometry.AddFace(face_indices)
+		geometry.AddEdge(append(append([]uint32{}, face_indices...), face_indices[0]))
+	}
+	return geometry
+}
@@ -6,19 +6,20 @@ import (
 )
 
 type OverlayMarkerLayer struct {
-	wctx    *wcommon.WebGLContext //
-	Markers []*SceneObject        // list of OverlayMarkers to be rendered (in pixels in CAMERA space)
+	wctx     *wcommon.WebGLContext //
+	renderer *Renderer             // renderer for the markers (keeping its highlight shaders)
+	Markers  []*SceneObject        // list of OverlayMarkers to be rendered (in pixels in CAMERA space)
 }
 
 func NewOverlayMarkerLayer(wctx *wcommon.WebGLContext) *OverlayMarkerLayer {
-	self := OverlayMarkerLayer{wctx: wctx}
+	self := OverlayMarkerLayer{wctx: wctx, renderer: NewRenderer(wctx)}
 	self.Markers = make([]*SceneObject, 0)
 	return &self
 }
 
 func (self *OverlayMarkerLayer) Render(pvm *geom2d.Matrix3) {
 	// 'Overlay' interface function, called by Renderer
-	renderer := NewRenderer(self.wctx)
+	renderer := self.renderer
 	for _, marker := range self.Markers {
 		if marker.poses != nil {
 			renderer.RenderSceneObject(marker, pvm)
@@ -33,6 +34,11 @@ func (self *OverlayMarkerLayer) Render(pvm *geom2d.Matrix3) {
 // Managing Markers
 // ----------------------------------------------------------------------------
 
+func (self *OverlayMarkerLayer) GetRenderer() *Renderer {
+	// Renderer of the markers (like for setting its highlight colors)
+	return self.renderer
+}
+
 func (self *OverlayMarkerLayer) AddMarker(marker ...*SceneObject) *OverlayMarkerLayer {
 	for i := 0; i < len(marker); i++ {
 		self.Markers = append(self.Markers, marker[i])
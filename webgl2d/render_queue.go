@@ -0,0 +1,82 @@
+package webgl2d
+
+import (
+	"sort"
+
+	"github.com/go4orward/gowebgl/geom2d"
+	"github.com/go4orward/gowebgl/wcommon"
+)
+
+// ----------------------------------------------------------------------------
+// Render Queue (sorted by states)
+// ----------------------------------------------------------------------------
+
+// RenderScene() renders the SceneObjects in the order of the scene (as they were added), except that
+// OPAQUE SceneObjects with depth test are sorted by their states (shaders, material and geometry),
+// so that the Renderer can skip redundant WebGL calls (like useProgram() or binding the same attributes)
+// between them. The order of the states follows their first appearance in the Scene, and only the
+// consecutive runs of such SceneObjects are sorted, since the order of the others matters.
+
+type render_item struct {
+	sobj  *SceneObject    //
+	pvm   *geom2d.Matrix3 // (Proj * View * Model) matrix
+	model *geom2d.Matrix3 // Model matrix (including those of its parents)
+}
+
+func collect_render_items(sobj *SceneObject, model *geom2d.Matrix3, pvm *geom2d.Matrix3, items []render_item) []render_item {
+	// Collect the SceneObject and all its children (in the order of RenderSceneObject())
+	items = append(items, render_item{sobj: sobj, pvm: pvm, model: model})
+	for _, child := range sobj.children {
+		child_model := model.MultiplyToTheRight(&child.modelmatrix)
+		items = collect_render_items(child, child_model, pvm.MultiplyToTheRight(&child.modelmatrix), items)
+	}
+	return items
+}
+
+func sort_render_queue(items []render_item) {
+	ids := map[interface{}]int{} // IDs of the states, in the order of their first appearance
+	get_id := func(state interface{}) int {
+		if id, ok := ids[state]; ok {
+			return id
+		}
+		ids[state] = len(ids)
+		return ids[state]
+	}
+	keys := make([][3]int, len(items))
+	for i, item := range items {
+		s := item.sobj
+		keys[i] = [3]int{get_id([3]*wcommon.Shader{s.FShader, s.EShader, s.VShader}), get_id(s.Material), get_id(s.Geometry)}
+	}
+	sortable := func(i int) bool { return items[i].sobj.UseDepth && !items[i].sobj.UseBlend }
+	for start := 0; start < len(items); {
+		end := start
+		for end < len(items) && sortable(end) {
+			end++
+		}
+		if end-start > 1 {
+			sort_render_queue_run(items[start:end], keys[start:end])
+		}
+		start = end + 1
+	}
+}
+
+func sort_render_queue_run(items []render_item, keys [][3]int) {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ka, kb := keys[order[a]], keys[order[b]]
+		if ka[0] != kb[0] {
+			return ka[0] < kb[0]
+		} else if ka[1] != kb[1] {
+			return ka[1] < kb[1]
+		}
+		return ka[2] < kb[2]
+	})
+	sorted := make([]render_item, len(items))
+	for i, k := range order {
+		sorted[i] = items[k]
+	}
+	copy(items, sorted)
+}
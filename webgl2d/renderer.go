@@ -12,15 +12,56 @@ import (
 )
 
 type Renderer struct {
-	wctx *wcommon.WebGLContext
-	axes *SceneObject
+	wctx   *wcommon.WebGLContext
+	axes   *SceneObject
+	target *wcommon.RenderTarget // OPTIONAL, offscreen RenderTarget to render into (nil for the canvas)
+
+	state   *wcommon.StateCache // WebGL states & uniform values set last (to skip redundant WebGL calls)
+	batcher *static_batcher     // batches of 'Static' SceneObjects merged for fewer draw calls
+
+	hlcolors  [2][4]float32                                // highlight colors for HOVERED & SELECTED states
+	hlmode    string                                       // highlight mode ("COLOR" or "OUTLINE")
+	hlshaders map[*wcommon.Shader]map[bool]*wcommon.Shader // highlight variants of shaders (for instanced or not)
 }
 
 func NewRenderer(wctx *wcommon.WebGLContext) *Renderer {
 	renderer := Renderer{wctx: wctx, axes: nil}
+	renderer.SetHighlightColors("#ffff0066", "#ff880099").SetHighlightMode("COLOR")
+	renderer.hlshaders = map[*wcommon.Shader]map[bool]*wcommon.Shader{}
+	renderer.state = wcommon.NewStateCache(wctx)     // redundant WebGL calls are skipped
+	renderer.batcher = new_static_batcher(&renderer) // 'Static' SceneObjects are merged automatically
 	return &renderer
 }
 
+// ----------------------------------------------------------------------------
+// Render Target
+// ----------------------------------------------------------------------------
+
+func (self *Renderer) SetRenderTarget(target *wcommon.RenderTarget) *Renderer {
+	// Render into the offscreen RenderTarget ('nil' for the canvas), starting from the next Clear() or RenderScene().
+	self.target = target
+	return self
+}
+
+func (self *Renderer) GetRenderTarget() *wcommon.RenderTarget {
+	return self.target
+}
+
+func (self *Renderer) get_target_wh() [2]int {
+	// size of the RenderTarget (or the canvas) being rendered
+	if self.target != nil && self.target.IsReady() {
+		return self.target.GetWH()
+	}
+	return self.wctx.GetWH()
+}
+
+func (self *Renderer) InvalidateStates() *Renderer {
+	// Forget the WebGL states set last (like shader program, depth test, blending and attributes), so that all of them
+	// are set again. (Clear() and RenderScene() call it, so call it only after changing WebGL states directly.)
+	self.state.Invalidate()
+	return self
+}
+
 // ----------------------------------------------------------------------------
 // Clear
 // ----------------------------------------------------------------------------
@@ -28,6 +69,8 @@ func NewRenderer(wctx *wcommon.WebGLContext) *Renderer {
 func (self *Renderer) Clear(scene *Scene) {
 	context := self.wctx.GetContext()
 	constants := self.wctx.GetConstants()
+	wcommon.BindRenderTarget(self.wctx, self.target) // clear the RenderTarget (or the canvas)
+	self.state.Invalidate()                          // WebGL states might have been changed outside
 	rgb := scene.GetBkgColor()
 	context.Call("clearColor", rgb[0], rgb[1], rgb[2], 1.0) // Set clearing color
 	context.Call("clear", constants.COLOR_BUFFER_BIT)       // clear the canvas
@@ -45,15 +88,104 @@ func (self *Renderer) RenderAxes(camera *Camera, length float32) {
 	self.RenderSceneObject(self.axes, &camera.pjvwmatrix) // (Proj * View) matrix
 }
 
+// ----------------------------------------------------------------------------
+// Highlighting (Selection & Hover States)
+// ----------------------------------------------------------------------------
+
+func (self *Renderer) SetHighlightColors(hovered string, selected string) *Renderer {
+	// Colors to highlight hovered/selected SceneObjects (and instances), with alpha for blending (like "#ffff0066")
+	self.hlcolors = [2][4]float32{wcommon.ParseHexColor(hovered), wcommon.ParseHexColor(selected)}
+	return self
+}
+
+func (self *Renderer) SetHighlightMode(mode string) *Renderer {
+	// 'mode' : "COLOR" (default, tinting the faces) or "OUTLINE" (drawing the edges, if any)
+	self.hlmode = mode
+	return self
+}
+
+func (self *Renderer) get_highlight_drawing(sobj *SceneObject) (int, *wcommon.Shader) {
+	// decide which draw_mode & (original) shader to use for highlighting
+	_, edge_count, _ := sobj.Geometry.GetWebGLBuffer(2)
+	if self.hlmode == "OUTLINE" && edge_count > 0 {
+		if sobj.EShader != nil {
+			return 2, sobj.EShader
+		} else if sobj.FShader != nil {
+			return 2, sobj.FShader // vertex shader of the FACE shader works for the edges as well
+		}
+	}
+	if sobj.FShader != nil {
+		return 3, sobj.FShader
+	} else if sobj.EShader != nil {
+		return 2, sobj.EShader
+	} else if sobj.VShader != nil {
+		return 1, sobj.VShader
+	}
+	return 0, nil
+}
+
+func (self *Renderer) get_highlight_shader(shader *wcommon.Shader, instanced bool) *wcommon.Shader {
+	if self.hlshaders[shader] == nil {
+		self.hlshaders[shader] = map[bool]*wcommon.Shader{}
+	}
+	if hl_shader, ok := self.hlshaders[shader][instanced]; ok {
+		return hl_shader // (it can be nil, if it failed to compile before)
+	}
+	// The original vertex shader is followed by choosing the highlight color with the state
+	// of the object ('_hl_state') and the state of the instance ('_hl_istate'), while
+	// the vertices without any state are moved out of the clip space.
+	decls, istate := "uniform vec4 _hl_hovered;\nuniform vec4 _hl_selected;\nuniform float _hl_state;\n", "0.0"
+	if instanced {
+		decls, istate = decls+"attribute float _hl_istate;\n", "_hl_istate"
+	}
+	decls += "varying vec4 _hl_color;"
+	vmain := `	float state = max(_hl_state, ` + istate + `);
+	if (state >= 2.0) {
+		_hl_color = _hl_selected;
+	} else if (state >= 1.0) {
+		_hl_color = _hl_hovered;
+	} else {
+		_hl_color = vec4(0.0);
+		gl_Position = vec4(2.0, 2.0, 2.0, 1.0);
+	}`
+	fsource := `precision mediump float;
+varying vec4 _hl_color;
+void main() {
+	gl_FragColor = vec4(_hl_color.rgb * _hl_color.a, _hl_color.a);
+}`
+	hl_shader, err := shader.BuildVariant(decls, vmain, fsource)
+	if err == nil {
+		hl_shader.SetBindingForUniform("_hl_hovered", "vec4", []float32{0, 0, 0, 0})
+		hl_shader.SetBindingForUniform("_hl_selected", "vec4", []float32{0, 0, 0, 0})
+		hl_shader.SetBindingForUniform("_hl_state", "float", []float32{0})
+		if instanced {
+			hl_shader.SetBindingForAttribute("_hl_istate", "float", "instance.state")
+		}
+		hl_shader.CheckBindings()
+	} else {
+		hl_shader = nil
+	}
+	self.hlshaders[shader][instanced] = hl_shader
+	return hl_shader
+}
+
 // ----------------------------------------------------------------------------
 // Rendering Scene
 // ----------------------------------------------------------------------------
 
 func (self *Renderer) RenderScene(scene *Scene, camera *Camera) {
-	// Render all the scene objects
+	wcommon.BindRenderTarget(self.wctx, self.target) // render into the RenderTarget (or the canvas)
+	self.state.Invalidate()                          // WebGL states might have been changed outside
+	// Render all the scene objects, with 'Static' ones merged (see batching.go) and sorted by their states (see render_queue.go)
+	items := []render_item{}
 	for _, sobj := range scene.objects {
-		pvm_matrix := camera.pjvwmatrix.MultiplyToTheRight(&sobj.modelmatrix)
-		self.RenderSceneObject(sobj, pvm_matrix) // (Proj * View * Model) matrix
+		pvm_matrix := camera.pjvwmatrix.MultiplyToTheRight(&sobj.modelmatrix) // (Proj * View * Model) matrix
+		items = collect_render_items(sobj, &sobj.modelmatrix, pvm_matrix, items)
+	}
+	items = self.batcher.batch_items(items, camera)
+	sort_render_queue(items)
+	for _, item := range items {
+		self.render_scene_object(item.sobj, item.pvm)
 	}
 	// Render all the OverlayLayers
 	for _, overlay := range scene.overlays {
@@ -66,21 +198,26 @@ func (self *Renderer) RenderScene(scene *Scene, camera *Camera) {
 // ----------------------------------------------------------------------------
 
 func (self *Renderer) RenderSceneObject(sobj *SceneObject, pvm *geom2d.Matrix3) error {
-	context := self.wctx.GetContext()
-	constants := self.wctx.GetConstants()
-	// Set DepthTest & Blending options
-	if sobj.UseDepth {
-		context.Call("enable", constants.DEPTH_TEST) // Enable depth test
-		context.Call("depthFunc", constants.LEQUAL)  // Near things obscure far things
-	} else {
-		context.Call("disable", constants.DEPTH_TEST) // Disable depth test
+	// Render the SceneObject and all its children (in order)
+	if err := self.render_scene_object(sobj, pvm); err != nil {
+		return err
+	}
+	// Render all the children
+	for _, child := range sobj.children {
+		new_pvm := pvm.MultiplyToTheRight(&child.modelmatrix)
+		self.RenderSceneObject(child, new_pvm)
 	}
+	return nil
+}
+
+func (self *Renderer) render_scene_object(sobj *SceneObject, pvm *geom2d.Matrix3) error {
+	// Render the SceneObject only (without its children)
+	// Set DepthTest & Blending options (only if they're changed)
+	self.state.SetDepthTest(sobj.UseDepth)
 	if sobj.UseBlend {
-		context.Call("enable", constants.BLEND)                                 // for pre-multiplied alpha
-		context.Call("blendFunc", constants.ONE, constants.ONE_MINUS_SRC_ALPHA) // for pre-multiplied alpha
-		// context.Call("blendFunc", constants.SRC_ALPHA, constants.ONE_MINUS_SRC_ALPHA) // for non pre-multiplied alpha
+		self.state.SetBlendMode(sobj.BlendMode) // "PREMULTIPLIED" by default
 	} else {
-		context.Call("disable", constants.BLEND) // Disable blending
+		self.state.SetBlendMode("NONE") // Disable blending
 	}
 	// If necessary, then build WebGLBuffers for the SceneObject's Geometry
 	if sobj.Geometry.IsDataBufferReady() == false {
@@ -88,12 +225,18 @@ func (self *Renderer) RenderSceneObject(sobj *SceneObject, pvm *geom2d.Matrix3)
 	}
 	if sobj.Geometry.IsWebGLBufferReady() == false {
 		sobj.Geometry.BuildWebGLBuffers(self.wctx, true, true, true)
+		self.state.CheckAttributes(nil) // attributes should be bound again with the new buffers
+	} else {
+		sobj.Geometry.UpdateWebGLBuffers(self.wctx) // upload modified data only (if any)
 	}
 	if sobj.poses != nil && sobj.poses.IsWebGLBufferReady() == false {
 		sobj.poses.BuildWebGLBuffer(self.wctx)
+		self.state.CheckAttributes(nil) // attributes should be bound again with the new buffers
 		if !self.wctx.IsExtensionReady("ANGLE") {
 			self.wctx.SetupExtension("ANGLE")
 		}
+	} else if sobj.poses != nil && sobj.poses.IsDirty() {
+		sobj.poses.UpdateWebGLBuffer(self.wctx) // upload modified poses only
 	}
 	// R3: Render the object with FACE shader
 	if sobj.FShader != nil {
@@ -116,14 +259,20 @@ func (self *Renderer) RenderSceneObject(sobj *SceneObject, pvm *geom2d.Matrix3)
 			return err
 		}
 	}
-	// Render all the children
-	for _, child := range sobj.children {
-		new_pvm := pvm.MultiplyToTheRight(&child.modelmatrix)
-		self.RenderSceneObject(child, new_pvm)
+	// Highlight the object (or its instances), if it's hovered or selected
+	if sobj.is_highlighted() {
+		self.render_highlight(sobj, pvm)
 	}
 	return nil
 }
 
+type attribute_key struct {
+	shader    *wcommon.Shader //
+	geometry  *Geometry       //
+	version   int             // version of the WebGL buffers of the geometry (which can be rebuilt by anyone)
+	draw_mode int             //
+}
+
 func (self *Renderer) render_scene_object_with_shader(sobj *SceneObject, pvm *geom2d.Matrix3, draw_mode int, shader *wcommon.Shader) error {
 	context := self.wctx.GetContext()
 	constants := self.wctx.GetConstants()
@@ -131,8 +280,8 @@ func (self *Renderer) render_scene_object_with_shader(sobj *SceneObject, pvm *ge
 	if shader == nil {
 		return errors.New("Failed to RenderSceneObject() : shader not found")
 	}
-	context.Call("useProgram", shader.GetShaderProgram())
-	// 2. bind the uniforms of the shader program
+	self.state.UseShader(shader) // (only if it's not in use)
+	// 2. bind the uniforms of the shader program (only those with changed values)
 	for uname, umap := range shader.GetUniformBindings() {
 		if err := self.bind_uniform(uname, umap, draw_mode, sobj.Material, pvm); err != nil {
 			if err.Error() != "Texture is not ready" {
@@ -141,11 +290,18 @@ func (self *Renderer) render_scene_object_with_shader(sobj *SceneObject, pvm *ge
 			return err
 		}
 	}
-	// 3. bind the attributes of the shader program
-	for aname, amap := range shader.GetAttributeBindings() {
-		if err := self.bind_attribute(aname, amap, draw_mode, sobj.Geometry, sobj.poses); err != nil {
-			fmt.Println(err.Error())
-			return err
+	// 3. bind the attributes of the shader program (only if they're not bound already, except for instance poses)
+	var key interface{} = nil
+	if sobj.poses == nil {
+		key = attribute_key{shader: shader, geometry: sobj.Geometry, version: sobj.Geometry.webgl_version, draw_mode: draw_mode}
+	}
+	if self.state.CheckAttributes(key) {
+		for aname, amap := range shader.GetAttributeBindings() {
+			if err := self.bind_attribute(aname, amap, draw_mode, sobj.Geometry, sobj.poses); err != nil {
+				fmt.Println(err.Error())
+				self.state.CheckAttributes(nil)
+				return err
+			}
 		}
 	}
 	// 4. draw  (Note that ARRAY_BUFFER was binded already in the attribut-binding step)
@@ -191,6 +347,36 @@ func (self *Renderer) render_scene_object_with_shader(sobj *SceneObject, pvm *ge
 	return nil
 }
 
+func (self *Renderer) render_highlight(sobj *SceneObject, pvm *geom2d.Matrix3) error {
+	// Render the highlight of hovered/selected SceneObject (or its instances) over the original rendering,
+	// without swapping its material or shaders.
+	draw_mode, shader := self.get_highlight_drawing(sobj)
+	if shader == nil {
+		return nil
+	}
+	hl_shader := self.get_highlight_shader(shader, sobj.poses != nil)
+	if hl_shader == nil {
+		return errors.New("Failed to render highlight : invalid shader")
+	}
+	state := float32(0)
+	if sobj.hovered {
+		state = 1
+	}
+	if sobj.selected {
+		state = 2
+	}
+	uniforms := hl_shader.GetUniformBindings()
+	uniforms["_hl_hovered"]["value"] = self.hlcolors[0][:]
+	uniforms["_hl_selected"]["value"] = self.hlcolors[1][:]
+	uniforms["_hl_state"]["value"] = []float32{state}
+	dmask := self.state.GetDepthMask()
+	self.state.SetBlendMode("PREMULTIPLIED") // for pre-multiplied alpha
+	self.state.SetDepthMask(false)           // keep the depth of the original rendering
+	err := self.render_scene_object_with_shader(sobj, pvm, draw_mode, hl_shader)
+	self.state.SetDepthMask(dmask)
+	return err
+}
+
 func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 	draw_mode int, material *wcommon.Material, pvm *geom2d.Matrix3) error {
 	context := self.wctx.GetContext()
@@ -199,7 +385,7 @@ func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 		err := errors.New("Failed to bind uniform : call 'shader.CheckBinding()' before rendering")
 		return err
 	}
-	location, dtype := umap["location"].(js.Value), umap["dtype"].(string)
+	dtype := umap["dtype"].(string)
 	if umap["autobinding"] != nil {
 		autobinding := umap["autobinding"].(string)
 		autobinding_split := strings.Split(autobinding, ":")
@@ -212,10 +398,10 @@ func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 			}
 			switch dtype {
 			case "vec3":
-				context.Call("uniform3f", location, c[0], c[1], c[2])
+				self.state.SetUniform(umap, "uniform3f", c[:3])
 				return nil
 			case "vec4":
-				context.Call("uniform4f", location, c[0], c[1], c[2], c[3])
+				self.state.SetUniform(umap, "uniform4f", c[:])
 				return nil
 			}
 		case "material.texture":
@@ -229,16 +415,15 @@ func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 			texture_unit := js.ValueOf(constants.TEXTURE0.Int() + txt_unit)
 			context.Call("activeTexture", texture_unit)                              // activate texture unit N
 			context.Call("bindTexture", constants.TEXTURE_2D, material.GetTexture()) // bind the texture
-			context.Call("uniform1i", location, txt_unit)                            // give shader the unit number
+			self.state.SetUniform(umap, "uniform1i", []float32{float32(txt_unit)})   // give shader the unit number
 			return nil
 		case "renderer.aspect": // vec2
-			wh := self.wctx.GetWH()
-			context.Call("uniform2f", location, float32(wh[0]), float32(wh[1]))
+			wh := self.get_target_wh()
+			self.state.SetUniform(umap, "uniform2f", []float32{float32(wh[0]), float32(wh[1])})
 			return nil
 		case "renderer.pvm": // mat3
-			elements := pvm.GetElements()
-			e := wcommon.ConvertGoSliceToJsTypedArray(elements[:]) // ModelView matrix, converted to JavaScript 'Float32Array'
-			context.Call("uniformMatrix3fv", location, false, e)   // gl.uniformMatrix3fv(location, transpose, values_array)
+			elements := pvm.GetElements()                                // (Proj * View * Model) matrix
+			self.state.SetUniform(umap, "uniformMatrix3fv", elements[:]) // gl.uniformMatrix3fv(location, transpose, values_array)
 			return nil
 		}
 		return fmt.Errorf("Failed to bind uniform '%s' (%s) with %v", uname, dtype, autobinding)
@@ -246,19 +431,19 @@ func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 		v := umap["value"].([]float32)
 		switch dtype {
 		case "int":
-			context.Call("uniform1i", location, int(v[0]))
+			self.state.SetUniform(umap, "uniform1i", v[:1])
 			return nil
 		case "float":
-			context.Call("uniform1f", location, v[0])
+			self.state.SetUniform(umap, "uniform1f", v[:1])
 			return nil
 		case "vec2":
-			context.Call("uniform2f", location, v[0], v[1])
+			self.state.SetUniform(umap, "uniform2f", v[:2])
 			return nil
 		case "vec3":
-			context.Call("uniform3f", location, v[0], v[1], v[2])
+			self.state.SetUniform(umap, "uniform3f", v[:3])
 			return nil
 		case "vec4":
-			context.Call("uniform4f", location, v[0], v[1], v[2], v[3])
+			self.state.SetUniform(umap, "uniform4f", v[:4])
 			return nil
 		}
 		return fmt.Errorf("Failed to bind uniform '%s' (%s) with %v", uname, dtype, v)
@@ -301,6 +486,14 @@ func (self *Renderer) bind_attribute(aname string, amap map[string]interface{},
 			self.wctx.GetExtension("ANGLE").Call("vertexAttribDivisorANGLE", location, 0) // divisor == 0
 		}
 		return nil
+	case "instance.state": // 1 float32 (highlight state) for each instance
+		if poses != nil {
+			context.Call("bindBuffer", constants.ARRAY_BUFFER, poses.BuildStateBuffer(self.wctx))
+			context.Call("vertexAttribPointer", location, 1, constants.FLOAT, false, 0, 0)
+			context.Call("enableVertexAttribArray", location)
+			self.wctx.GetExtension("ANGLE").Call("vertexAttribDivisorANGLE", location, 1) // divisor == 1
+			return nil
+		}
 	case "instance.pose":
 		if poses != nil && len(autobinding_split) == 3 { // it's like "instance.pose:<stride>:<offset>"
 			size := get_count_from_type(dtype)
@@ -65,6 +65,14 @@ func (self *Scene) Get(indices ...int) *SceneObject {
 	return nil
 }
 
+func (self *Scene) ClearStates() *Scene {
+	// Clear the selection/hover states of all the SceneObjects (and their instances) in the scene
+	for _, sobj := range self.objects {
+		sobj.ClearStates(true)
+	}
+	return self
+}
+
 // ----------------------------------------------------------------------------
 // Bounding Box
 // ----------------------------------------------------------------------------
@@ -16,8 +16,13 @@ type SceneObject struct {
 	modelmatrix geom2d.Matrix3            // model transformation matrix of this SceneObject
 	UseDepth    bool                      // depth test flag (default is true)
 	UseBlend    bool                      // blending flag with alpha (default is false)
+	BlendMode   string                    // blending mode with UseBlend ("PREMULTIPLIED", "NON_PREMULTIPLIED", "ADDITIVE" or "MULTIPLY")
+	Static      bool                      // static flag (default is false), merged with others sharing shaders & material
 	poses       *wcommon.SceneObjectPoses // OPTIONAL, poses for multiple instances of this (geometry+material) object
 	children    []*SceneObject            // OPTIONAL, children of this SceneObject (to be rendered recursively)
+	selected    bool                      // selection state (to be highlighted by Renderer)
+	hovered     bool                      // hover state (to be highlighted by Renderer)
+	on_state    StateChangeHandler        // OPTIONAL, callback for the changes of selection/hover state
 	bbox        [2][2]float32             // bounding box
 }
 
@@ -34,10 +39,12 @@ func NewSceneObject(geometry *Geometry, material *wcommon.Material,
 	}
 	sobj := SceneObject{Geometry: geometry, Material: material, VShader: vshader, EShader: eshader, FShader: fshader}
 	sobj.modelmatrix.SetIdentity()
-	sobj.UseDepth = false // new drawings will overwrite old ones by default
-	sobj.UseBlend = false // alpha blending is turned off by default
-	sobj.poses = nil      // OPTIONAL, only if multiple instances of the geometry are rendered
-	sobj.children = nil   // OPTIONAL, only if current SceneObject has any child SceneObjects
+	sobj.UseDepth = false            // new drawings will overwrite old ones by default
+	sobj.UseBlend = false            // alpha blending is turned off by default
+	sobj.BlendMode = "PREMULTIPLIED" // blending for colors pre-multiplied by alpha by default
+	sobj.Static = false              // static batching is turned off by default (see batching.go)
+	sobj.poses = nil                 // OPTIONAL, only if multiple instances of the geometry are rendered
+	sobj.children = nil              // OPTIONAL, only if current SceneObject has any child SceneObjects
 	sobj.bbox = geom2d.BBoxInit()
 	return &sobj
 }
@@ -65,7 +72,7 @@ func (self *SceneObject) ShowInfo() {
 		fmt.Printf("  FACE ")
 		self.FShader.ShowInfo()
 	}
-	fmt.Printf("  Flags    : UseDepth=%t  UseBlend=%t\n", self.UseDepth, self.UseBlend)
+	fmt.Printf("  Flags    : UseDepth=%t  UseBlend=%t (%s)  Static=%t\n", self.UseDepth, self.UseBlend, self.BlendMode, self.Static)
 	fmt.Printf("  Children : %d\n", len(self.children))
 }
 
@@ -171,3 +178,100 @@ func (self *SceneObject) GetBoundingBox(m *geom2d.Matrix3, renew bool) [2][2]flo
 	}
 	return self.bbox
 }
+
+// ----------------------------------------------------------------------------
+// Selection & Hover States (highlighted by Renderer)
+// ----------------------------------------------------------------------------
+
+// StateChangeHandler is called whenever the selection/hover state of a SceneObject (or its instance) changes.
+// 'instance' is the index of the instance pose (-1 for the SceneObject itself), and 'state' is "SELECTED" or "HOVERED".
+type StateChangeHandler func(sobj *SceneObject, instance int, state string, on bool)
+
+func (self *SceneObject) SetStateChangeHandler(handler StateChangeHandler) *SceneObject {
+	self.on_state = handler
+	return self
+}
+
+func (self *SceneObject) SetSelected(on bool) *SceneObject {
+	if self.selected != on {
+		self.selected = on
+		self.notify_state_change(-1, "SELECTED", on)
+	}
+	return self
+}
+
+func (self *SceneObject) IsSelected() bool {
+	return self.selected
+}
+
+func (self *SceneObject) SetHovered(on bool) *SceneObject {
+	if self.hovered != on {
+		self.hovered = on
+		self.notify_state_change(-1, "HOVERED", on)
+	}
+	return self
+}
+
+func (self *SceneObject) IsHovered() bool {
+	return self.hovered
+}
+
+func (self *SceneObject) SetInstanceSelected(index int, on bool) *SceneObject {
+	if self.poses != nil && self.poses.SetState(index, wcommon.INSTANCE_SELECTED, on) {
+		self.notify_state_change(index, "SELECTED", on)
+	}
+	return self
+}
+
+func (self *SceneObject) IsInstanceSelected(index int) bool {
+	return self.poses != nil && self.poses.GetState(index)&wcommon.INSTANCE_SELECTED != 0
+}
+
+func (self *SceneObject) SetInstanceHovered(index int, on bool) *SceneObject {
+	if self.poses != nil && self.poses.SetState(index, wcommon.INSTANCE_HOVERED, on) {
+		self.notify_state_change(index, "HOVERED", on)
+	}
+	return self
+}
+
+func (self *SceneObject) IsInstanceHovered(index int) bool {
+	return self.poses != nil && self.poses.GetState(index)&wcommon.INSTANCE_HOVERED != 0
+}
+
+func (self *SceneObject) GetSelectedInstances() []int {
+	selected := []int{}
+	if self.poses != nil && self.poses.HasStates() {
+		for i := 0; i < self.poses.Count; i++ {
+			if self.poses.GetState(i)&wcommon.INSTANCE_SELECTED != 0 {
+				selected = append(selected, i)
+			}
+		}
+	}
+	return selected
+}
+
+func (self *SceneObject) ClearStates(recursive bool) *SceneObject {
+	// Clear all the selection/hover states of the SceneObject and its instances (and its children, if 'recursive')
+	self.SetSelected(false).SetHovered(false)
+	if self.poses != nil && self.poses.HasStates() {
+		for i := 0; i < self.poses.Count; i++ {
+			self.SetInstanceSelected(i, false).SetInstanceHovered(i, false)
+		}
+	}
+	if recursive {
+		for _, child := range self.children {
+			child.ClearStates(true)
+		}
+	}
+	return self
+}
+
+func (self *SceneObject) is_highlighted() bool {
+	return self.selected || self.hovered || (self.poses != nil && self.poses.HasStates())
+}
+
+func (self *SceneObject) notify_state_change(instance int, state string, on bool) {
+	if self.on_state != nil {
+		self.on_state(self, instance, state, on)
+	}
+}
@@ -1,12 +1,213 @@
 package webgl2d
 
-func SelectObjectByWorldXY(scene *Scene) *SceneObject {
-	// for _, sobj := range scene.objects {
-	// 	pvm_matrix := camera.pjvwmatrix.MultiplyRight(&sobj.modelmatrix)
-	// 	self.RenderSceneObject(sobj, pvm_matrix) // (Proj * View * Model) matrix
-	// }
-	return nil
+import (
+	"github.com/go4orward/gowebgl/geom2d"
+)
+
+// ----------------------------------------------------------------------------
+// Selection
+// ----------------------------------------------------------------------------
+
+// Note that the instance poses are assumed to have XY translation in their first two values
+// (like "instance.pose:<stride>:0"), just like SceneObject.GetBoundingBox().
+
+type SelectionHit struct {
+	SceneObject   *SceneObject // selected SceneObject
+	Path          []int        // indices of the SceneObject (in the scene and its parents), to be used with Scene.Get()
+	InstanceIndex int          // index of the selected instance pose (-1, if the SceneObject has no poses)
+}
+
+type selection_candidate struct {
+	sobj     *SceneObject
+	path     []int
+	instance int
+	verts    [][2]float32 // vertices of the geometry in WORLD space
+}
+
+func SelectObjectByWorldXY(scene *Scene, wxy [2]float32, tolerance float32) *SceneObject {
+	// Select the topmost (rendered last) SceneObject at the world position (nil, if nothing was found).
+	// 'tolerance' is the distance in WORLD space for picking edges and vertices (and near the boundary of faces).
+	hits := SelectAllByWorldXY(scene, wxy, tolerance)
+	if len(hits) == 0 {
+		return nil
+	}
+	return hits[0].SceneObject
+}
+
+func SelectPoseByWorldXY(sobj *SceneObject, wxy [2]float32, tolerance float32) int {
+	// Select the topmost (rendered last) instance pose of the SceneObject at the world position (-1, if not found).
+	// Note that the SceneObject is assumed to have no parent (its own MODEL matrix only).
+	if sobj == nil || sobj.poses == nil {
+		return -1
+	}
+	candidates := collect_selection_candidates(sobj, geom2d.NewMatrix3(), nil, []selection_candidate{})
+	for i := len(candidates) - 1; i >= 0; i-- {
+		c := candidates[i]
+		if c.sobj == sobj && is_selected_by_point(c, wxy, tolerance) {
+			return c.instance
+		}
+	}
+	return -1
+}
+
+func SelectAllByWorldXY(scene *Scene, wxy [2]float32, tolerance float32) []*SelectionHit {
+	// Select all the SceneObjects (and their instances) at the world position, with the topmost first.
+	hits := []*SelectionHit{}
+	candidates := collect_scene_candidates(scene)
+	for i := len(candidates) - 1; i >= 0; i-- { // reverse rendering order (topmost first)
+		if is_selected_by_point(candidates[i], wxy, tolerance) {
+			hits = append(hits, candidates[i].get_hit())
+		}
+	}
+	return hits
+}
+
+func SelectAllInRectangle(scene *Scene, bbox [2][2]float32, crossing bool) []*SelectionHit {
+	// Select all the SceneObjects (and their instances) in the rectangle (in WORLD space), in rendering order.
+	// If 'crossing' is false, then only the ones completely inside are selected (WINDOW selection).
+	// If 'crossing' is true, then the ones overlapping with the rectangle are selected, too (CROSSING selection).
+	lasso := [][2]float32{{bbox[0][0], bbox[0][1]}, {bbox[1][0], bbox[0][1]}, {bbox[1][0], bbox[1][1]}, {bbox[0][0], bbox[1][1]}}
+	return SelectAllInLasso(scene, lasso, crossing)
+}
+
+func SelectAllInLasso(scene *Scene, lasso [][2]float32, crossing bool) []*SelectionHit {
+	// Select all the SceneObjects (and their instances) in the lasso polygon (in WORLD space), in rendering order.
+	// 'crossing' works in the same way as SelectAllInRectangle().
+	hits := []*SelectionHit{}
+	if len(lasso) < 3 {
+		return hits
+	}
+	for _, c := range collect_scene_candidates(scene) {
+		if is_selected_by_lasso(c, lasso, crossing) {
+			hits = append(hits, c.get_hit())
+		}
+	}
+	return hits
+}
+
+// ----------------------------------------------------------------------------
+// Selection Candidates (geometry of each SceneObject and instance in WORLD space)
+// ----------------------------------------------------------------------------
+
+func collect_scene_candidates(scene *Scene) []selection_candidate {
+	candidates := []selection_candidate{}
+	for idx, sobj := range scene.objects {
+		candidates = collect_selection_candidates(sobj, geom2d.NewMatrix3(), []int{idx}, candidates)
+	}
+	return candidates
+}
+
+func collect_selection_candidates(sobj *SceneObject, parent *geom2d.Matrix3, path []int, candidates []selection_candidate) []selection_candidate {
+	// collect the candidates in rendering order (parent first, and then its children)
+	mm := parent.MultiplyToTheRight(&sobj.modelmatrix)
+	if sobj.Geometry != nil {
+		if sobj.poses == nil {
+			verts := make([][2]float32, len(sobj.Geometry.verts))
+			for i, v := range sobj.Geometry.verts {
+				verts[i] = mm.MultiplyVector2(v)
+			}
+			candidates = append(candidates, selection_candidate{sobj: sobj, path: path, instance: -1, verts: verts})
+		} else if sobj.poses.Size >= 2 { // (instance poses without translation cannot be selected)
+			for k := 0; k < sobj.poses.Count && (k+1)*sobj.poses.Size <= len(sobj.poses.DataBuffer); k++ {
+				txy := sobj.poses.DataBuffer[k*sobj.poses.Size : k*sobj.poses.Size+2]
+				verts := make([][2]float32, len(sobj.Geometry.verts))
+				for i, v := range sobj.Geometry.verts {
+					verts[i] = mm.MultiplyVector2([2]float32{v[0] + txy[0], v[1] + txy[1]})
+				}
+				candidates = append(candidates, selection_candidate{sobj: sobj, path: path, instance: k, verts: verts})
+			}
+		}
+	}
+	for cidx, child := range sobj.children {
+		var child_path []int = nil
+		if path != nil {
+			child_path = append(append([]int{}, path...), cidx)
+		}
+		candidates = collect_selection_candidates(child, mm, child_path, candidates)
+	}
+	return candidates
+}
+
+func (self *selection_candidate) get_hit() *SelectionHit {
+	return &SelectionHit{SceneObject: self.sobj, Path: self.path, InstanceIndex: self.instance}
+}
+
+func (self *selection_candidate) get_polygon(vlist []uint32) [][2]float32 {
+	polygon := make([][2]float32, len(vlist))
+	for i, vidx := range vlist {
+		polygon[i] = self.verts[vidx]
+	}
+	return polygon
+}
+
+func (self *selection_candidate) for_each_segment(callback func(a [2]float32, b [2]float32) bool) bool {
+	// call 'callback' for all the edges and the boundary of faces, until it returns true
+	geometry := self.sobj.Geometry
+	for _, edge := range geometry.edges {
+		for i := 0; i+1 < len(edge); i++ {
+			if callback(self.verts[edge[i]], self.verts[edge[i+1]]) {
+				return true
+			}
+		}
+	}
+	for _, face := range geometry.faces {
+		for i := 0; i < len(face); i++ {
+			if callback(self.verts[face[i]], self.verts[face[(i+1)%len(face)]]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func is_selected_by_point(c selection_candidate, wxy [2]float32, tolerance float32) bool {
+	for _, face := range c.sobj.Geometry.faces {
+		if geom2d.IsPointInsidePolygon(wxy, c.get_polygon(face)) {
+			return true
+		}
+	}
+	if c.for_each_segment(func(a [2]float32, b [2]float32) bool {
+		return geom2d.GetDistanceToSegment(wxy, a, b) <= tolerance
+	}) {
+		return true
+	}
+	for _, v := range c.verts {
+		if geom2d.Length(geom2d.SubAB(wxy, v)) <= tolerance {
+			return true
+		}
+	}
+	return false
 }
 
-func SelectPoseByWorldXY(sobj *SceneObject) {
+func is_selected_by_lasso(c selection_candidate, lasso [][2]float32, crossing bool) bool {
+	if len(c.verts) == 0 {
+		return false
+	}
+	if !crossing { // WINDOW selection : all the vertices inside
+		for _, v := range c.verts {
+			if !geom2d.IsPointInsidePolygon(v, lasso) {
+				return false
+			}
+		}
+		return true
+	}
+	// CROSSING selection : any vertex inside, any lasso point inside a face, or any segment crossing the lasso
+	for _, v := range c.verts {
+		if geom2d.IsPointInsidePolygon(v, lasso) {
+			return true
+		}
+	}
+	for _, face := range c.sobj.Geometry.faces {
+		if geom2d.IsPointInsidePolygon(lasso[0], c.get_polygon(face)) {
+			return true
+		}
+	}
+	return c.for_each_segment(func(a [2]float32, b [2]float32) bool {
+		for i := 0; i < len(lasso); i++ {
+			if geom2d.IsSegmentsIntersecting(a, b, lasso[i], lasso[(i+1)%len(lasso)]) {
+				return true
+			}
+		}
+		return false
+	})
 }
@@ -0,0 +1,285 @@
+package webgl3d
+
+import (
+	"math"
+
+	"github.com/go4orward/gowebgl/geom2d"
+	"github.com/go4orward/gowebgl/geom3d"
+	"github.com/go4orward/gowebgl/wcommon"
+)
+
+// ----------------------------------------------------------------------------
+// Static Batching
+// ----------------------------------------------------------------------------
+
+// 'Static' SceneObjects sharing the same shaders and material (and OPAQUE, without instance poses and
+// highlighting) are merged into a single SceneObject by RenderScene(), with their points transformed
+// by their model matrices, so that all of them are rendered with a single draw call (for each draw mode).
+// A batch is rebuilt only if any of its members has changed (like its model matrix or its geometry),
+// while shadow maps and GPU picking render the original SceneObjects as usual.
+
+type static_batcher struct {
+	renderer *Renderer       //
+	batches  []*static_batch // batches rendered in the last frame
+}
+
+type static_batch struct {
+	key     batch_key      // shaders, material, origin & data layout shared by the members
+	members []batch_member // SceneObjects merged into the batch
+	sobj    *SceneObject   // SceneObject with the merged geometry
+}
+
+type batch_key struct {
+	vshader  *wcommon.Shader   //
+	eshader  *wcommon.Shader   //
+	fshader  *wcommon.Shader   //
+	material *wcommon.Material //
+	origin   [3]float64        // origin of the top-level SceneObject
+	receive  bool              // receiving shadow flag
+	finfo    [4]int            // data size of a point for triangles
+	vinfo    [4]int            // data size of a point for points & lines
+	fextra   [2]int            // offsets of optional data in a point for triangles
+	tuvfloat bool              // texture UVs in 2 float32
+	buffers  [5]bool           // existing data buffers : [ vpoints, fpoints, lines, faces, shared_points ]
+}
+
+type batch_group struct {
+	key     batch_key //
+	indices []int     // indices of the items to be merged
+}
+
+type batch_member struct {
+	sobj     *SceneObject //
+	geometry *Geometry    //
+	model    [16]float32  // model matrix (relative to the origin)
+	vpoints  *float32     // data buffers (to detect rebuilding)
+	fpoints  *float32     //
+	lines    *uint32      //
+	faces    *uint32      //
+	lengths  [4]int       // lengths of the data buffers
+	dirty    bool         // true, if the data buffers were modified after the last upload
+}
+
+func new_static_batcher(renderer *Renderer) *static_batcher {
+	return &static_batcher{renderer: renderer, batches: []*static_batch{}}
+}
+
+func (self *static_batcher) batch_items(items []render_item, camera *Camera) []render_item {
+	// Replace the items of 'Static' SceneObjects with the items of their batches (at the position of the first member)
+	groups, current := []*batch_group{}, map[batch_key]*batch_group{}
+	for i, item := range items {
+		if !item.sobj.UseDepth && !item.sobj.UseBlend {
+			current = map[batch_key]*batch_group{} // OPAQUE objects without depth test keep their order
+		} else if key, ok := get_batch_key(item); ok {
+			if current[key] == nil {
+				current[key] = &batch_group{key: key, indices: []int{}}
+				groups = append(groups, current[key])
+			}
+			current[key].indices = append(current[key].indices, i)
+		}
+	}
+	batches, merged, skipped := []*static_batch{}, map[int]*static_batch{}, map[int]bool{}
+	for _, group := range groups {
+		key, indices := group.key, group.indices
+		if len(indices) < 2 {
+			continue
+		}
+		members := make([]batch_member, len(indices))
+		for k, i := range indices {
+			members[k] = new_batch_member(items[i])
+		}
+		batch := self.find_batch(key, members)
+		if batch == nil {
+			batch = self.build_batch(key, members, items, indices)
+		}
+		batches = append(batches, batch)
+		merged[indices[0]] = batch
+		for _, i := range indices[1:] {
+			skipped[i] = true
+		}
+	}
+	self.release_batches(batches)
+	self.batches = batches
+	if len(merged) == 0 {
+		return items
+	}
+	result := make([]render_item, 0, len(items)-len(skipped))
+	for i, item := range items {
+		if batch := merged[i]; batch != nil {
+			identity := geom3d.NewMatrix4()
+			vwmd := camera.GetViewModelMatrix(batch.key.origin, identity, self.renderer.rte)
+			e := vwmd.GetElements()
+			distance := e[12]*e[12] + e[13]*e[13] + e[14]*e[14]
+			result = append(result, render_item{sobj: batch.sobj, vwmd: vwmd, model: identity, origin: batch.key.origin, distance: distance})
+		} else if !skipped[i] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func get_batch_key(item render_item) (batch_key, bool) {
+	s := item.sobj
+	g, ok := s.Geometry.(*Geometry)
+	if !s.Static || !s.UseDepth || s.UseBlend || s.poses != nil || s.is_highlighted() || !ok || !g.IsDataBufferReady() {
+		return batch_key{}, false
+	}
+	key := batch_key{vshader: s.VShader, eshader: s.EShader, fshader: s.FShader, material: s.Material, origin: item.origin, receive: s.ReceiveShadow}
+	key.finfo, key.vinfo, key.fextra, key.tuvfloat = g.fpoint_info, g.vpoint_info, g.GetWebGLBufferExtra(), g.fpoint_tuv_float
+	key.buffers[0], key.buffers[1] = g.data_buffer_vpoints != nil, g.data_buffer_fpoints != nil
+	key.buffers[2], key.buffers[3] = g.data_buffer_lines != nil, g.data_buffer_faces != nil
+	key.buffers[4] = len(g.data_buffer_vpoints) > 0 && len(g.data_buffer_fpoints) > 0 && &g.data_buffer_vpoints[0] == &g.data_buffer_fpoints[0]
+	return key, true
+}
+
+func new_batch_member(item render_item) batch_member {
+	g := item.sobj.Geometry.(*Geometry)
+	m := batch_member{sobj: item.sobj, geometry: g, model: *item.model.GetElements()}
+	if len(g.data_buffer_vpoints) > 0 {
+		m.vpoints = &g.data_buffer_vpoints[0]
+	}
+	if len(g.data_buffer_fpoints) > 0 {
+		m.fpoints = &g.data_buffer_fpoints[0]
+	}
+	if len(g.data_buffer_lines) > 0 {
+		m.lines = &g.data_buffer_lines[0]
+	}
+	if len(g.data_buffer_faces) > 0 {
+		m.faces = &g.data_buffer_faces[0]
+	}
+	m.lengths = [4]int{len(g.data_buffer_vpoints), len(g.data_buffer_fpoints), len(g.data_buffer_lines), len(g.data_buffer_faces)}
+	m.dirty = g.dirty_vpoints.IsDirty() || g.dirty_fpoints.IsDirty()
+	return m
+}
+
+func (self *static_batcher) find_batch(key batch_key, members []batch_member) *static_batch {
+	// find the batch of the last frame with the same members (without any change)
+	for _, batch := range self.batches {
+		if batch.key != key || len(batch.members) != len(members) {
+			continue
+		}
+		same := true
+		for k := range members {
+			if members[k] != batch.members[k] {
+				same = false
+				break
+			}
+		}
+		if same {
+			return batch
+		}
+	}
+	return nil
+}
+
+func (self *static_batcher) build_batch(key batch_key, members []batch_member, items []render_item, indices []int) *static_batch {
+	// Merge the data buffers of the members, with their points transformed by their model matrices
+	merged := NewGeometry()
+	merged.fpoint_info, merged.vpoint_info, merged.fpoint_extra = key.finfo, key.vinfo, key.fextra
+	merged.fpoint_tuv_float = key.tuvfloat
+	vcount, fcount := uint32(0), uint32(0) // number of points merged so far
+	for k, m := range members {
+		g, model := m.geometry, items[indices[k]].model
+		nmatrix := model.GetNormalMatrix() // (for normal vectors)
+		vbase, fbase := vcount, fcount
+		if key.buffers[1] {
+			points := transform_points(g.data_buffer_fpoints, key.finfo, key.fextra, model, nmatrix)
+			merged.data_buffer_fpoints = append(merged.data_buffer_fpoints, points...)
+			fcount += uint32(len(points) / key.finfo[0])
+		}
+		if key.buffers[0] && key.buffers[4] {
+			vbase, vcount = fbase, fcount // (vertex points shared with the points for triangles)
+		} else if key.buffers[0] {
+			points := transform_points(g.data_buffer_vpoints, key.vinfo, [2]int{0, 0}, model, nmatrix)
+			merged.data_buffer_vpoints = append(merged.data_buffer_vpoints, points...)
+			vcount += uint32(len(points) / key.vinfo[0])
+		}
+		for _, vidx := range g.data_buffer_lines {
+			merged.data_buffer_lines = append(merged.data_buffer_lines, vbase+vidx)
+		}
+		if !key.buffers[1] {
+			fbase = vbase // (triangles with vertex points)
+		}
+		for _, vidx := range g.data_buffer_faces {
+			merged.data_buffer_faces = append(merged.data_buffer_faces, fbase+vidx)
+		}
+		// the modified data of the member is merged already, so upload it (if necessary) to avoid rebuilding again
+		if g.IsWebGLBufferReady() {
+			g.UpdateWebGLBuffers(self.renderer.wctx)
+		} else {
+			g.dirty_vpoints.Reset()
+			g.dirty_fpoints.Reset()
+		}
+		members[k].dirty = false
+	}
+	if key.buffers[4] {
+		merged.data_buffer_vpoints = merged.data_buffer_fpoints // shared with vertex points
+	}
+	sobj := NewSceneObject(merged, key.material, key.vshader, key.eshader, key.fshader)
+	sobj.ReceiveShadow = key.receive
+	return &static_batch{key: key, members: members, sobj: sobj}
+}
+
+func (self *static_batcher) release_batches(keep []*static_batch) {
+	// delete the WebGL buffers of the batches which are not rendered any more
+	context := self.renderer.wctx.GetContext()
+	for _, batch := range self.batches {
+		kept := false
+		for _, b := range keep {
+			kept = kept || b == batch
+		}
+		if g := batch.sobj.Geometry.(*Geometry); !kept && g.IsWebGLBufferReady() {
+			for _, buffer := range []interface{}{g.webgl_buffer_vpoints, g.webgl_buffer_fpoints, g.webgl_buffer_lines, g.webgl_buffer_faces} {
+				context.Call("deleteBuffer", buffer)
+			}
+			g.Clear(false, false, true)
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Transforming serialized points
+// ----------------------------------------------------------------------------
+
+func transform_points(points []float32, pinfo [4]int, extra [2]int, model *geom3d.Matrix4, nmatrix *geom2d.Matrix3) []float32 {
+	// Copy the serialized points, with their coordinates, normal vectors and tangent vectors transformed
+	stride := pinfo[0] // [ stride, xyz_offset, uv_offset, normal_offset ]
+	result := append([]float32{}, points...)
+	for pos := 0; stride > 0 && pos+stride <= len(result); pos += stride {
+		p := pos + pinfo[1]
+		xyz := model.MultiplyVector3([3]float32{result[p], result[p+1], result[p+2]})
+		result[p], result[p+1], result[p+2] = xyz[0], xyz[1], xyz[2]
+		if pinfo[3] > 0 { // normal vector in 3 bytes
+			n := unpack_bytes(result[pos+pinfo[3]])
+			v := normalize_safely(nmatrix.MultiplyVector3([3]float32{n[0], n[1], n[2]}))
+			result[pos+pinfo[3]] = pack_bytes([4]float32{v[0], v[1], v[2], 0})
+		}
+		if extra[0] > 0 { // tangent vector with handedness in 4 bytes
+			t := unpack_bytes(result[pos+extra[0]])
+			v := normalize_safely(model.MultiplyDirection3([3]float32{t[0], t[1], t[2]}))
+			result[pos+extra[0]] = pack_bytes([4]float32{v[0], v[1], v[2], t[3]})
+		}
+	}
+	return result
+}
+
+func unpack_bytes(f float32) [4]float32 {
+	// four signed normalized bytes packed in a float32 (LittleEndian, like normal & tangent vectors)
+	bits := math.Float32bits(f)
+	return [4]float32{
+		float32(int8(uint8(bits))) / 127, float32(int8(uint8(bits>>8))) / 127,
+		float32(int8(uint8(bits>>16))) / 127, float32(int8(uint8(bits>>24))) / 127}
+}
+
+func pack_bytes(v [4]float32) float32 {
+	x, y := uint32(uint8(int8(v[0]*127))), uint32(uint8(int8(v[1]*127)))
+	z, w := uint32(uint8(int8(v[2]*127))), uint32(uint8(int8(v[3]*127)))
+	return math.Float32frombits(x + y<<8 + z<<16 + w<<24) // LittleEndian (lower byte comes first)
+}
+
+func normalize_safely(v [3]float32) [3]float32 {
+	if geom3d.Length(v) == 0 {
+		return v
+	}
+	return geom3d.Normalize(v)
+}
@@ -24,6 +24,7 @@ type Camera struct {
 	// camera pose
 	viewmatrix geom3d.Matrix4 // view matrix Mcw (transformation from WORLD to CAMERA space)
 	center     [3]float32     // camera position in world space
+	center64   [3]float64     // camera position in world space (in double-precision, for relative-to-eye rendering)
 	// Ref: http://www.songho.ca/opengl/gl_projectionmatrix.html
 }
 
@@ -47,6 +48,10 @@ func (self *Camera) GetCenter() [3]float32 {
 	return self.center
 }
 
+func (self *Camera) GetCenterHighPrecision() [3]float64 {
+	return self.center64
+}
+
 func (self *Camera) GetProjMatrix() *geom3d.Matrix4 {
 	return self.projection.GetMatrix()
 }
@@ -123,6 +128,19 @@ func (self *Camera) SetPoseWithCameraAxes(camX [3]float32, camY [3]float32, camZ
 		camZ[0], camZ[1], camZ[2], Tcw[2],
 		0, 0, 0, 1)
 	self.center = [3]float32{Twc[0], Twc[1], Twc[2]}
+	self.center64 = [3]float64{float64(Twc[0]), float64(Twc[1]), float64(Twc[2])}
+	return self
+}
+
+func (self *Camera) SetPoseHighPrecision(from [3]float64, lookat [3]float64, up [3]float32) *Camera {
+	// Set camera pose with double-precision coordinates (like geographic or CAD coordinates in millions),
+	// which will be kept for relative-to-eye rendering (Renderer.SetRelativeToEye(true)).
+	camZ := geom3d.Vector3d(from).Sub(lookat).Normalize().ToVector3()
+	camY := geom3d.Normalize(up)
+	camX := geom3d.Normalize(geom3d.CrossAB(camY, camZ))
+	camY = geom3d.CrossAB(camZ, camX)
+	self.SetPoseWithCameraAxes(camX, camY, camZ, geom3d.Vector3d(from).ToVector3())
+	self.center64 = from
 	return self
 }
 
@@ -137,13 +155,29 @@ func (self *Camera) SetPoseWithMcw(Mcw *geom3d.Matrix4) *Camera {
 	z := -(me[8]*Tcw[0] + me[9]*Tcw[1] + me[10]*Tcw[2])
 	self.viewmatrix.SetCopy(Mcw)
 	self.center = [3]float32{x, y, z}
+	self.center64 = [3]float64{float64(x), float64(y), float64(z)}
 	return self
 }
 
+func (self *Camera) SetPoseWithQuaternion(center [3]float32, q *geom3d.Quaternion) *Camera {
+	// Set camera pose with its position and orientation (rotation from CAMERA to WORLD space, looking along -Z axis)
+	// For example, 'geom3d.NewQuaternion().SetLookRotation(direction, up)' can be used for the orientation.
+	camX := q.RotateVector3([3]float32{1, 0, 0})
+	camY := q.RotateVector3([3]float32{0, 1, 0})
+	camZ := q.RotateVector3([3]float32{0, 0, 1})
+	return self.SetPoseWithCameraAxes(camX, camY, camZ, center)
+}
+
+func (self *Camera) GetQuaternion() *geom3d.Quaternion {
+	// Get the camera orientation (rotation from CAMERA to WORLD space), which is the transpose of Rcw
+	return geom3d.NewQuaternion().SetRotationByMatrix4(self.get_rotation_to_world())
+}
+
 func (self *Camera) Translate(tx float32, ty float32, tz float32) *Camera {
 	translation := geom3d.NewMatrix4().SetTranslation(-tx, -ty, -tz)
 	self.viewmatrix.SetMultiplyMatrices(translation, &self.viewmatrix)
-	self.center = [3]float32{self.center[0] + tx, self.center[1] + ty, self.center[2] + tz}
+	self.center64 = [3]float64{self.center64[0] + float64(tx), self.center64[1] + float64(ty), self.center64[2] + float64(tz)}
+	self.center = geom3d.Vector3d(self.center64).ToVector3()
 	return self
 }
 
@@ -175,16 +209,92 @@ func (self *Camera) RotateAroundAxis(axis [3]float32, angle_in_degree float32) *
 	return self
 }
 
+func (self *Camera) RotateByQuaternion(q *geom3d.Quaternion) *Camera {
+	// Rotate the camera by 'q' in CAMERA space (around the camera center)
+	//   Rwc' = Rwc * q   =>   Mcw' = (q)^-1 * Mcw
+	rotation := q.Conjugate().GetMatrix4()
+	self.viewmatrix.SetMultiplyMatrices(rotation, &self.viewmatrix)
+	return self
+}
+
 func (self *Camera) RotateAroundPoint(distance float32, h_angle float32, v_angle float32) *Camera {
 	// Rotate camera around the point (0, 0, -distance) in CAMERA space
 	trn0 := geom3d.NewMatrix4().SetTranslation(0, 0, distance)
 	rotY := geom3d.NewMatrix4().SetRotationByAxis([3]float32{0, 1, 0}, +h_angle)
 	rotX := geom3d.NewMatrix4().SetRotationByAxis([3]float32{1, 0, 0}, +v_angle)
 	trn1 := geom3d.NewMatrix4().SetTranslation(0, 0, -distance)
-	self.viewmatrix.SetMultiplyMatrices(trn1, rotX, rotY, trn0, &self.viewmatrix)
+	transform := geom3d.NewMatrix4().SetMultiplyMatrices(trn1, rotX, rotY, trn0)
+	// new camera position in the old CAMERA space, and its displacement in WORLD space (small enough for float32)
+	if inverse := transform.Inverse(); inverse != nil {
+		delta := self.get_rotation_to_world().MultiplyVector3(inverse.MultiplyVector3([3]float32{0, 0, 0}))
+		self.center64 = geom3d.Vector3d(self.center64).Add(geom3d.NewVector3dFromVector3(delta))
+		self.center = geom3d.Vector3d(self.center64).ToVector3()
+	}
+	self.viewmatrix.SetMultiplyMatrices(transform, &self.viewmatrix)
 	return self
 }
 
+func (self *Camera) get_rotation_to_world() *geom3d.Matrix4 {
+	// rotation from CAMERA to WORLD space (Rwc), which is the transpose of Rcw
+	e := self.viewmatrix.GetElements()
+	return geom3d.NewMatrix4().Set(
+		e[0], e[1], e[2], 0,
+		e[4], e[5], e[6], 0,
+		e[8], e[9], e[10], 0,
+		0, 0, 0, 1)
+}
+
+// ----------------------------------------------------------------------------
+// Projection / Unprojection
+// ----------------------------------------------------------------------------
+
+func (self *Camera) ProjectWorldToCanvas(xyz [3]float32) [2]int {
+	wh, _, _, _ := self.projection.GetParameters()
+	cxyz := self.projection.GetMatrix().MultiplyVector4(self.viewmatrix.MultiplyVector4([4]float32{xyz[0], xyz[1], xyz[2], 1}))
+	if cxyz[3] != 0 {
+		cxyz[0], cxyz[1] = cxyz[0]/cxyz[3], cxyz[1]/cxyz[3] // perspective division
+	}
+	hw, hh := float32(wh[0])/2, float32(wh[1])/2
+	return [2]int{int(hw + cxyz[0]*hw), int(hh - cxyz[1]*hh)} // UpperLeft is (0,0)
+}
+
+func (self *Camera) UnprojectCanvasToRay(canvasxy [2]int) *geom3d.Ray {
+	// Get the ray in WORLD space, starting from the near plane and passing through the canvas position
+	// (for both perspective and orthographic projection).
+	wh, _, _, _ := self.projection.GetParameters()
+	hw, hh := (float32(wh[0]) / 2), (float32(wh[1]) / 2)
+	clipxy := [2]float32{(float32(canvasxy[0]) - hw) / hw, -(float32(canvasxy[1]) - hh) / hh}
+	inverse := self.projection.GetMatrix().MultiplyToTheRight(&self.viewmatrix).Inverse() // from CLIP to WORLD space
+	if inverse == nil {
+		return geom3d.NewRay(self.center, [3]float32{0, 0, -1})
+	}
+	near := geom3d.Vector4(inverse.MultiplyVector4([4]float32{clipxy[0], clipxy[1], -1, 1})).ToVector3()
+	far := geom3d.Vector4(inverse.MultiplyVector4([4]float32{clipxy[0], clipxy[1], +1, 1})).ToVector3()
+	return geom3d.NewRayFromPoints(near, far)
+}
+
+// ----------------------------------------------------------------------------
+// Relative-To-Eye
+// ----------------------------------------------------------------------------
+
+func (self *Camera) GetViewModelMatrix(origin [3]float64, modelmatrix *geom3d.Matrix4, relative_to_eye bool) *geom3d.Matrix4 {
+	// Get (View * Translation(origin) * Model) matrix for the object placed at 'origin' in WORLD space.
+	// If 'relative_to_eye' is true, then camera position is subtracted from 'origin' in double-precision,
+	// so that the vertices in float32 (relative to the origin) will not jitter even with huge coordinates.
+	if !relative_to_eye {
+		if origin == [3]float64{0, 0, 0} {
+			return self.viewmatrix.MultiplyToTheRight(modelmatrix)
+		}
+		translation := geom3d.NewMatrix4().SetTranslation(float32(origin[0]), float32(origin[1]), float32(origin[2]))
+		return self.viewmatrix.MultiplyToTheRight(translation).MultiplyToTheRight(modelmatrix)
+	}
+	rte := geom3d.Vector3d(origin).Sub(self.center64) // origin relative to the eye (camera position)
+	rotation := geom3d.NewMatrix4d().SetMatrix4(self.get_rotation_to_world().Transpose())
+	translation := geom3d.NewMatrix4d().SetTranslation(rte[0], rte[1], rte[2])
+	model := geom3d.NewMatrix4d().SetMatrix4(modelmatrix)
+	return geom3d.NewMatrix4d().SetMultiplyMatrices(rotation, translation, model).GetMatrix4()
+}
+
 // ----------------------------------------------------------------------------
 // Testing
 // ----------------------------------------------------------------------------
@@ -3,6 +3,7 @@ package webgl3d
 import (
 	"fmt"
 	"math"
+	"sort"
 	"syscall/js"
 
 	"github.com/go4orward/gowebgl/geom3d"
@@ -18,10 +19,12 @@ type Geometry struct {
 	edges [][]uint32   // edges
 	faces [][]uint32   // faces
 	tuvs  [][]float32  // texture uv coordinates (PER_FACE [nfaces][6] or PER_VERT [nverts][2])
+	tuvs2 [][]float32  // second texture uv coordinates, like for lightmaps (PER_FACE [nfaces][6] or PER_VERT [nverts][2])
 	norms [][3]float32 // normal vectors (PER_FACE [nfaces][3] or PER_VERT [nverts][3])
+	tangs [][4]float32 // tangent vectors with handedness in [3] (PER_FACE [nfaces][4] or PER_VERT [nverts][4])
 
 	data_buffer_vpoints []float32 // serialized data buffer for vertex points : COORD[]
-	data_buffer_fpoints []float32 // serialized data buffer for PER_FACE vertex points : COORD[3] + (UV[2]) + (NORMAL[3])
+	data_buffer_fpoints []float32 // serialized data buffer for PER_FACE vertex points : COORD[3] + (UV[2]) + (NORMAL[3]) + (TANGENT[4]) + (UV2[2])
 	data_buffer_lines   []uint32  // serialized data buffer for edge lines : vidx[2]
 	data_buffer_faces   []uint32  // serialized data buffer for face triangles : vidx[3]
 
@@ -30,16 +33,25 @@ type Geometry struct {
 	fpoint_vert_total int      // total count of vertices after PER_FACE data duplication
 	fpoint_info       [4]int   // data size of a point (for triangles) : [ stride, xyz_offset, uv_offset, normal_offset ]
 	vpoint_info       [4]int   // data size of a point (for points & lines)
+	fpoint_extra      [2]int   // offsets of optional data in a point (for triangles) : [ tangent_offset, uv2_offset ]
+	fpoint_tuv_float  bool     // texture UVs in 2 float32 (if any of them is out of [0 ~ 1]), instead of 2 uint16
 
 	webgl_buffer_vpoints js.Value // WebGL data buffer for data_buffer_vpoints (points for vertices)
 	webgl_buffer_fpoints js.Value // WebGL data buffer for data_buffer_fpoints (points for PER_FACE vertices)
 	webgl_buffer_lines   js.Value // WebGL data buffer for data_buffer_lines (indices for lines)
 	webgl_buffer_faces   js.Value // WebGL data buffer for data_buffer_faces (indices for triangles)
+
+	buffer_usage  string             // usage hint for WebGL buffers of points ("STATIC", "DYNAMIC" or "STREAM")
+	dirty_vpoints wcommon.DirtyRange // range of data_buffer_vpoints modified after the last upload
+	dirty_fpoints wcommon.DirtyRange // range of data_buffer_fpoints modified after the last upload
+	webgl_version int                // incremented whenever WebGL buffers are built (to tell new buffers from old ones)
+	sorted_vwmd   [16]float32        // (View * Model) matrix of the last back-to-front sorting of faces
 }
 
 func NewGeometry() *Geometry {
 	var geometry Geometry
 	geometry.Clear(true, true, true)
+	geometry.buffer_usage = "STATIC"
 	return &geometry
 }
 
@@ -49,7 +61,9 @@ func (self *Geometry) Clear(geom bool, data_buf bool, webgl_buf bool) *Geometry
 		self.edges = [][]uint32{}
 		self.faces = [][]uint32{}
 		self.tuvs = [][]float32{}
+		self.tuvs2 = [][]float32{}
 		self.norms = [][3]float32{}
+		self.tangs = [][4]float32{}
 	}
 	if data_buf || geom {
 		self.data_buffer_vpoints = nil
@@ -60,12 +74,17 @@ func (self *Geometry) Clear(geom bool, data_buf bool, webgl_buf bool) *Geometry
 		self.fpoint_vert_total = 0
 		self.fpoint_info = [4]int{0, 0, 0, 0}
 		self.vpoint_info = [4]int{0, 0, 0, 0}
+		self.fpoint_extra = [2]int{0, 0}
+		self.fpoint_tuv_float = false
 	}
 	if webgl_buf || data_buf || geom {
 		self.webgl_buffer_vpoints = js.Null()
 		self.webgl_buffer_fpoints = js.Null()
 		self.webgl_buffer_lines = js.Null()
 		self.webgl_buffer_faces = js.Null()
+		self.dirty_vpoints.Reset()
+		self.dirty_fpoints.Reset()
+		self.sorted_vwmd = [16]float32{}
 	}
 	return self
 }
@@ -90,6 +109,15 @@ func (self *Geometry) ShowInfo() {
 			fmt.Printf("    texture UV coords   : [%d][]float32   incomplete\n", len(self.tuvs))
 		}
 	}
+	if len(self.tuvs2) > 0 {
+		if self.HasTextureUV2For("VERTEX") {
+			fmt.Printf("    texture UV2 coords  : [%d][]float32   for each vertex\n", len(self.tuvs2))
+		} else if self.HasTextureUV2For("FACE") {
+			fmt.Printf("    texture UV2 coords  : [%d][]float32   for each face\n", len(self.tuvs2))
+		} else {
+			fmt.Printf("    texture UV2 coords  : [%d][]float32   incomplete\n", len(self.tuvs2))
+		}
+	}
 	if len(self.norms) > 0 {
 		if self.HasNormalFor("VERTEX") {
 			fmt.Printf("    normal vectors      : [%d][3]float32   for each vertex\n", len(self.norms))
@@ -99,6 +127,15 @@ func (self *Geometry) ShowInfo() {
 			fmt.Printf("    normal vectors      : [%d][3]float32   incomplete\n", len(self.norms))
 		}
 	}
+	if len(self.tangs) > 0 {
+		if self.HasTangentFor("VERTEX") {
+			fmt.Printf("    tangent vectors     : [%d][4]float32   for each vertex\n", len(self.tangs))
+		} else if self.HasTangentFor("FACE") {
+			fmt.Printf("    tangent vectors     : [%d][4]float32   for each face\n", len(self.tangs))
+		} else {
+			fmt.Printf("    tangent vectors     : [%d][4]float32   incomplete\n", len(self.tangs))
+		}
+	}
 	fmt.Printf("    data_buffer_vpoints : %4d (WebGL:%s)  pinfo=%v\n", len(self.data_buffer_vpoints), wblen(self.webgl_buffer_vpoints), self.vpoint_info)
 	fmt.Printf("    data_buffer_fpoints : %4d (WebGL:%s)  pinfo=%v\n", len(self.data_buffer_fpoints), wblen(self.webgl_buffer_fpoints), self.fpoint_info)
 	fmt.Printf("    data_buffer_lines   : %4d (WebGL:%s)\n", len(self.data_buffer_lines), wblen(self.webgl_buffer_lines))
@@ -148,13 +185,16 @@ func (self *Geometry) AddFace(face []uint32) uint32 {
 // Transformation of Vertex Coordinates
 // ----------------------------------------------------------------------------
 
+// Note that Translate(), Rotate(), Scale() and AppyMatrix4() update the existing data buffers in place (instead of clearing them),
+// and only the modified data will be uploaded to WebGL before rendering.
+
 func (self *Geometry) Translate(tx float32, ty float32, tz float32) *Geometry {
 	for i := 0; i < len(self.verts); i++ {
 		self.verts[i][0] += tx
 		self.verts[i][1] += ty
 		self.verts[i][2] += tz
 	}
-	self.Clear(false, true, true)
+	self.refresh_data_buffers(0, len(self.verts), false)
 	return self
 }
 
@@ -164,7 +204,14 @@ func (self *Geometry) Rotate(axis [3]float32, angle_in_degree float32) *Geometry
 	for i := 0; i < len(self.verts); i++ {
 		self.verts[i] = rM.MultiplyVector3(self.verts[i])
 	}
-	self.Clear(false, true, true)
+	for i := 0; i < len(self.norms); i++ { // normal vectors are rotated together
+		self.norms[i] = rM.MultiplyVector3(self.norms[i])
+	}
+	for i := 0; i < len(self.tangs); i++ { // tangent vectors are rotated together (keeping handedness)
+		t := rM.MultiplyVector3([3]float32{self.tangs[i][0], self.tangs[i][1], self.tangs[i][2]})
+		self.tangs[i] = [4]float32{t[0], t[1], t[2], self.tangs[i][3]}
+	}
+	self.refresh_data_buffers(0, len(self.verts), true)
 	return self
 }
 
@@ -174,7 +221,16 @@ func (self *Geometry) Scale(sx float32, sy float32, sz float32) *Geometry {
 		self.verts[i][1] *= sy
 		self.verts[i][2] *= sz
 	}
-	self.Clear(false, true, true)
+	if sx != 0 && sy != 0 && sz != 0 {
+		for i := 0; i < len(self.norms); i++ { // normal vectors are scaled inversely (inverse transpose)
+			self.norms[i] = geom3d.Normalize([3]float32{self.norms[i][0] / sx, self.norms[i][1] / sy, self.norms[i][2] / sz})
+		}
+		for i := 0; i < len(self.tangs); i++ {
+			t := geom3d.Normalize([3]float32{self.tangs[i][0] * sx, self.tangs[i][1] * sy, self.tangs[i][2] * sz})
+			self.tangs[i] = [4]float32{t[0], t[1], t[2], self.tangs[i][3]}
+		}
+	}
+	self.refresh_data_buffers(0, len(self.verts), true)
 	return self
 }
 
@@ -182,7 +238,15 @@ func (self *Geometry) AppyMatrix4(m *geom3d.Matrix4) *Geometry {
 	for i := 0; i < len(self.verts); i++ {
 		self.verts[i] = m.MultiplyVector3(self.verts[i])
 	}
-	self.Clear(false, true, true)
+	nM := m.GetNormalMatrix() // normal vectors are transformed with inverse transpose
+	for i := 0; i < len(self.norms); i++ {
+		self.norms[i] = geom3d.Normalize(nM.MultiplyVector3(self.norms[i]))
+	}
+	for i := 0; i < len(self.tangs); i++ {
+		t := geom3d.Normalize(m.MultiplyDirection3([3]float32{self.tangs[i][0], self.tangs[i][1], self.tangs[i][2]}))
+		self.tangs[i] = [4]float32{t[0], t[1], t[2], self.tangs[i][3]}
+	}
+	self.refresh_data_buffers(0, len(self.verts), true)
 	return self
 }
 
@@ -243,6 +307,44 @@ func (self *Geometry) SetTextureUVs(tuvs [][]float32) *Geometry {
 	return self
 }
 
+// ----------------------------------------------------------------------------
+// Second Texture UV coordinates (like for lightmaps)
+// ----------------------------------------------------------------------------
+
+func (self *Geometry) HasTextureUV2For(mode string) bool {
+	switch mode {
+	case "VERTEX":
+		return len(self.tuvs2) > 0 && len(self.tuvs2) == len(self.verts) && len(self.tuvs2[0]) == 2
+	case "FACE":
+		return len(self.tuvs2) > 0 && len(self.tuvs2) == len(self.faces) && len(self.tuvs2[0]) >= 6
+	default:
+		return self.HasTextureUV2For("VERTEX") || self.HasTextureUV2For("FACE")
+	}
+}
+
+func (self *Geometry) AddTextureUV2(tuv []float32) *Geometry {
+	if len(tuv) == 0 || len(tuv)%2 != 0 {
+		fmt.Printf("Invalid texture coordinates to add : %v\n", tuv)
+		return self
+	}
+	self.tuvs2 = append(self.tuvs2, tuv)
+	return self
+}
+
+func (self *Geometry) SetTextureUV2s(tuvs [][]float32) *Geometry {
+	self.tuvs2 = tuvs
+	return self
+}
+
+func (self *Geometry) BuildTextureUV2sByUnwrapping(max_angle_in_degree float32, margin float32) *Geometry {
+	// Build the second texture UV coordinates (PER_FACE) by unwrapping the faces into charts without overlap,
+	// which is required for lightmaps, while keeping the first texture UV coordinates (for repeating textures).
+	tuvs, tangs := self.tuvs, self.tangs
+	self.BuildTextureUVsByUnwrapping(max_angle_in_degree, margin)
+	self.tuvs, self.tuvs2, self.tangs = tuvs, self.tuvs, tangs
+	return self
+}
+
 // ----------------------------------------------------------------------------
 // Normal Vectors
 // ----------------------------------------------------------------------------
@@ -269,13 +371,92 @@ func (self *Geometry) SetNormals(normals [][3]float32) *Geometry {
 }
 
 func (self *Geometry) BuildNormalsForVertex() {
-	// self.norms == [nverts][3]float32
+	// Angle-weighted vertex normals, averaged over all the faces sharing the vertex.
 	self.norms = make([][3]float32, len(self.verts)) // self.norms == [nverts][3]float32
-	for i, _ := range self.verts {
-		self.norms[i] = self.GetVertexNormal(i)
+	for fidx, face := range self.faces {
+		face_normal := self.GetFaceNormal(fidx)
+		for i, vidx := range face {
+			weight := self.get_face_angle_at(face, i)
+			self.norms[vidx] = geom3d.AddAB(self.norms[vidx], geom3d.Scale(face_normal, weight))
+		}
+	}
+	for i, _ := range self.norms {
+		if geom3d.Length(self.norms[i]) > 0 {
+			self.norms[i] = geom3d.Normalize(self.norms[i])
+		}
 	}
 }
 
+func (self *Geometry) BuildNormalsForVertexWithCreaseAngle(crease_angle_in_degree float32) {
+	// Angle-weighted vertex normals, with the vertices on sharp edges split into multiple vertices.
+	//   Faces sharing a vertex are smoothed together only if the angle between their face normals
+	//   is smaller than 'crease_angle_in_degree'; otherwise the vertex is duplicated, and each copy
+	//   gets its own normal vector. (0 works like BuildNormalsForFace(), 180 like BuildNormalsForVertex())
+	// Note that vertices are appended (not reordered), and PER_VERT texture UVs are duplicated as well.
+	cos_crease := float32(math.Cos(float64(crease_angle_in_degree) * InRadian))
+	face_normals := make([][3]float32, len(self.faces))
+	vert_faces := make([][][2]int, len(self.verts)) // list of (fidx, i) sharing the vertex
+	for fidx, face := range self.faces {
+		face_normals[fidx] = self.GetFaceNormal(fidx)
+		for i, vidx := range face {
+			vert_faces[vidx] = append(vert_faces[vidx], [2]int{fidx, i})
+		}
+	}
+	// calculate the normal vector for each corner of the faces
+	corner_normals := make([][][3]float32, len(self.faces))
+	for fidx, face := range self.faces {
+		corner_normals[fidx] = make([][3]float32, len(face))
+		for i, vidx := range face {
+			normal := [3]float32{0, 0, 0}
+			for _, fi := range vert_faces[vidx] {
+				if geom3d.DotAB(face_normals[fidx], face_normals[fi[0]]) >= cos_crease-1e-6 {
+					weight := self.get_face_angle_at(self.faces[fi[0]], fi[1])
+					normal = geom3d.AddAB(normal, geom3d.Scale(face_normals[fi[0]], weight))
+				}
+			}
+			if geom3d.Length(normal) > 0 {
+				corner_normals[fidx][i] = geom3d.Normalize(normal)
+			} else {
+				corner_normals[fidx][i] = face_normals[fidx]
+			}
+		}
+	}
+	// split the vertices which have different normals on different faces
+	per_vert_tuvs := self.HasTextureFor("VERTEX")
+	nverts := len(self.verts)
+	vert_normals := make([][][3]float32, nverts) // distinct normals found for each original vertex
+	vert_copies := make([][]uint32, nverts)      // vertex index for each of the distinct normals
+	self.norms = make([][3]float32, nverts)
+	for fidx, face := range self.faces {
+		for i, vidx := range face {
+			normal, found := corner_normals[fidx][i], -1
+			for k, n := range vert_normals[vidx] {
+				if geom3d.DotAB(n, normal) > 0.9999 {
+					found = k
+					break
+				}
+			}
+			if found < 0 {
+				new_vidx := vidx
+				if len(vert_normals[vidx]) > 0 { // duplicate the vertex for a new normal
+					new_vidx = self.AddVertex(self.verts[vidx])
+					self.norms = append(self.norms, normal)
+					if per_vert_tuvs {
+						self.tuvs = append(self.tuvs, []float32{self.tuvs[vidx][0], self.tuvs[vidx][1]})
+					}
+				}
+				self.norms[new_vidx] = normal
+				vert_normals[vidx] = append(vert_normals[vidx], normal)
+				vert_copies[vidx] = append(vert_copies[vidx], new_vidx)
+				found = len(vert_normals[vidx]) - 1
+			}
+			self.faces[fidx][i] = vert_copies[vidx][found]
+		}
+	}
+	self.tangs = [][4]float32{}
+	self.Clear(false, true, true)
+}
+
 func (self *Geometry) BuildNormalsForFace() {
 	self.norms = make([][3]float32, len(self.faces)) // self.norms == [nfaces][3]float32
 	for i, _ := range self.faces {
@@ -293,44 +474,177 @@ func (self *Geometry) GetFaceNormal(fidx int) [3]float32 {
 }
 
 func (self *Geometry) GetVertexNormal(vidx int) [3]float32 {
-	if true {
-		normals := [][3]float32{}
-		for fidx, face := range self.faces {
-			for _, face_vidx := range face {
-				if face_vidx == uint32(vidx) {
-					face_normal := self.GetFaceNormal(fidx)
-					normals = append(normals, face_normal)
-					break
-				}
+	// Angle-weighted average of the normals of the faces sharing the vertex
+	normal := [3]float32{0, 0, 0}
+	for fidx, face := range self.faces {
+		for i, face_vidx := range face {
+			if face_vidx == uint32(vidx) {
+				weight := self.get_face_angle_at(face, i)
+				normal = geom3d.AddAB(normal, geom3d.Scale(self.GetFaceNormal(fidx), weight))
+				break
 			}
 		}
-		return geom3d.Normalize(geom3d.AverageAll(normals))
+	}
+	if geom3d.Length(normal) == 0 {
+		return normal
+	}
+	return geom3d.Normalize(normal)
+}
+
+func (self *Geometry) get_face_angle_at(face []uint32, i int) float32 {
+	// interior angle (in radian) of the face at its i-th corner
+	flen := len(face)
+	v := self.verts[face[i]]
+	vnext := geom3d.SubAB(self.verts[face[(i+1)%flen]], v)
+	vprev := geom3d.SubAB(self.verts[face[(i-1+flen)%flen]], v)
+	lnext, lprev := geom3d.Length(vnext), geom3d.Length(vprev)
+	if lnext == 0 || lprev == 0 {
+		return 0
+	}
+	cos := float64(geom3d.DotAB(vnext, vprev) / (lnext * lprev))
+	return float32(math.Acos(math.Max(-1, math.Min(cos, 1))))
+}
+
+func (self *Geometry) ChangeNormal(idx int, normal_vector [3]float32) *Geometry {
+	self.norms[idx] = normal_vector
+	return self
+}
+
+// ----------------------------------------------------------------------------
+// Tangent Vectors (for normal mapping)
+// ----------------------------------------------------------------------------
+
+func (self *Geometry) HasTangentFor(mode string) bool {
+	switch mode {
+	case "VERTEX":
+		return len(self.tangs) > 0 && len(self.tangs) == len(self.verts)
+	case "FACE":
+		return len(self.tangs) > 0 && len(self.tangs) == len(self.faces)
+	default:
+		return self.HasTangentFor("VERTEX") || self.HasTangentFor("FACE")
+	}
+}
+
+func (self *Geometry) BuildTangents() {
+	// Build tangent vectors from texture UV coordinates, in a way compatible with MikkTSpace;
+	//   tangents are weighted by face angles, orthogonalized against the normal vectors,
+	//   and the handedness of the bitangent is saved in tangent[3] (+1 or -1),
+	//   so that shaders can get the bitangent as 'cross(normal, tangent.xyz) * tangent.w'.
+	// Tangents are PER_VERT if both normals and texture UVs are PER_VERT, and PER_FACE otherwise.
+	if !self.HasTextureFor("") {
+		fmt.Printf("Failed to BuildTangents() : texture UV coordinates not found\n")
+		return
+	}
+	if !self.HasNormalFor("") {
+		self.BuildNormalsForVertex()
+	}
+	per_vert := self.HasNormalFor("VERTEX") && self.HasTextureFor("VERTEX")
+	if per_vert {
+		self.tangs = make([][4]float32, len(self.verts))
 	} else {
-		neighbors := [][2]uint32{}
-		for _, face_vlist := range self.faces {
-			for i, face_vidx := range face_vlist {
-				if face_vidx == uint32(vidx) {
-					face_len := len(face_vlist)
-					neighbor_next := uint32((i + 1) % face_len)
-					neighbor_prev := uint32((i - 1 + face_len) % face_len)
-					neighbors = append(neighbors, [2]uint32{neighbor_next, neighbor_prev})
-					break
+		self.tangs = make([][4]float32, len(self.faces))
+	}
+	tsums := make([][3]float32, len(self.tangs)) // sum of tangents
+	bsums := make([][3]float32, len(self.tangs)) // sum of bitangents
+	for fidx, face := range self.faces {
+		face_normal := self.GetFaceNormal(fidx)
+		for _, triangle := range self.get_triangulation(face, face_normal) {
+			corners := [3]int{}
+			for k := 0; k < 3; k++ {
+				for i := 0; i < len(face); i++ {
+					if face[i] == triangle[k] {
+						corners[k] = i
+						break
+					}
 				}
 			}
+			v0, v1, v2 := self.verts[triangle[0]], self.verts[triangle[1]], self.verts[triangle[2]]
+			uv0, uv1, uv2 := self.get_tuv(fidx, corners[0]), self.get_tuv(fidx, corners[1]), self.get_tuv(fidx, corners[2])
+			e1, e2 := geom3d.SubAB(v1, v0), geom3d.SubAB(v2, v0)
+			du1, dv1, du2, dv2 := uv1[0]-uv0[0], uv1[1]-uv0[1], uv2[0]-uv0[0], uv2[1]-uv0[1]
+			det := du1*dv2 - du2*dv1
+			if det == 0 { // degenerate UV mapping
+				continue
+			}
+			r := 1 / det
+			t := geom3d.Scale(geom3d.SubAB(geom3d.Scale(e1, dv2), geom3d.Scale(e2, dv1)), r)
+			b := geom3d.Scale(geom3d.SubAB(geom3d.Scale(e2, du1), geom3d.Scale(e1, du2)), r)
+			for k := 0; k < 3; k++ {
+				idx := fidx
+				if per_vert {
+					idx = int(triangle[k])
+				}
+				weight := self.get_triangle_angle_at(v0, v1, v2, k)
+				tsums[idx] = geom3d.AddAB(tsums[idx], geom3d.Scale(t, weight))
+				bsums[idx] = geom3d.AddAB(bsums[idx], geom3d.Scale(b, weight))
+			}
 		}
-		cross_sum := [3]float32{0, 0, 0}
-		for _, nv := range neighbors {
-			vnext := geom3d.SubAB(self.verts[nv[0]], self.verts[vidx])
-			vprev := geom3d.SubAB(self.verts[nv[1]], self.verts[vidx])
-			cross_sum = geom3d.AddAB(cross_sum, geom3d.CrossAB(vnext, vprev))
+	}
+	for idx := 0; idx < len(self.tangs); idx++ {
+		n := [3]float32{0, 0, 0}
+		if per_vert {
+			n = self.norms[idx]
+		} else {
+			n = self.GetFaceNormal(idx)
 		}
-		return geom3d.Normalize(cross_sum)
+		t := geom3d.SubAB(tsums[idx], geom3d.Scale(n, geom3d.DotAB(n, tsums[idx]))) // Gram-Schmidt
+		if geom3d.Length(t) == 0 {
+			t = get_any_perpendicular(n)
+		}
+		t = geom3d.Normalize(t)
+		w := float32(1)
+		if geom3d.DotAB(geom3d.CrossAB(n, t), bsums[idx]) < 0 {
+			w = -1
+		}
+		self.tangs[idx] = [4]float32{t[0], t[1], t[2], w}
 	}
 }
 
-func (self *Geometry) ChangeNormal(idx int, normal_vector [3]float32) *Geometry {
-	self.norms[idx] = normal_vector
-	return self
+func (self *Geometry) GetTangent(idx int) [4]float32 {
+	// tangent vector (xyz) with the handedness (w) of the bitangent
+	return self.tangs[idx]
+}
+
+func (self *Geometry) GetBitangent(idx int) [3]float32 {
+	// bitangent vector, as 'cross(normal, tangent.xyz) * tangent.w'
+	n, t := [3]float32{0, 0, 0}, self.tangs[idx]
+	if self.HasTangentFor("VERTEX") {
+		n = self.norms[idx]
+	} else {
+		n = self.GetFaceNormal(idx)
+	}
+	return geom3d.Scale(geom3d.CrossAB(n, [3]float32{t[0], t[1], t[2]}), t[3])
+}
+
+func (self *Geometry) get_tuv(fidx int, i int) [2]float32 {
+	// texture UV coordinates of the i-th corner of the face
+	if self.HasTextureFor("FACE") {
+		return [2]float32{self.tuvs[fidx][i*2+0], self.tuvs[fidx][i*2+1]}
+	} else {
+		vidx := self.faces[fidx][i]
+		return [2]float32{self.tuvs[vidx][0], self.tuvs[vidx][1]}
+	}
+}
+
+func (self *Geometry) get_triangle_angle_at(v0 [3]float32, v1 [3]float32, v2 [3]float32, k int) float32 {
+	// interior angle (in radian) of the triangle at its k-th corner
+	corners := [3][3]float32{v0, v1, v2}
+	v := corners[k]
+	a, b := geom3d.SubAB(corners[(k+1)%3], v), geom3d.SubAB(corners[(k+2)%3], v)
+	la, lb := geom3d.Length(a), geom3d.Length(b)
+	if la == 0 || lb == 0 {
+		return 0
+	}
+	cos := float64(geom3d.DotAB(a, b) / (la * lb))
+	return float32(math.Acos(math.Max(-1, math.Min(cos, 1))))
+}
+
+func get_any_perpendicular(n [3]float32) [3]float32 {
+	if math.Abs(float64(n[0])) < 0.9 {
+		return geom3d.Normalize(geom3d.CrossAB(n, [3]float32{1, 0, 0}))
+	} else {
+		return geom3d.Normalize(geom3d.CrossAB(n, [3]float32{0, 1, 0}))
+	}
 }
 
 // ----------------------------------------------------------------------------
@@ -420,12 +734,29 @@ func (self *Geometry) buffer_copy_xyz(buf []float32, pinfo [4]int, new_vidx int,
 
 func (self *Geometry) buffer_copy_tuv(buf []float32, pinfo [4]int, new_vidx int, tuv_idx int, tuv_offset int) {
 	stride, offset := pinfo[0], pinfo[2] // UV texture coordinates in 1 byte
+	if self.fpoint_tuv_float {           // UV texture coordinates in 2 float32 (for REPEAT wrapping)
+		pos := new_vidx*stride + offset
+		buf[pos+0], buf[pos+1] = self.tuvs[tuv_idx][tuv_offset+0], self.tuvs[tuv_idx][tuv_offset+1]
+		return
+	}
 	u := uint32(self.tuvs[tuv_idx][tuv_offset+0] * 65535)
 	v := uint32(self.tuvs[tuv_idx][tuv_offset+1] * 65535)
 	pos := new_vidx*stride + offset
 	buf[pos] = math.Float32frombits(u + v<<16) // LittleEndian (lower byte comes first)
 }
 
+func (self *Geometry) is_texture_uv_normalized() bool {
+	// check if all the texture UV coordinates are in [0 ~ 1] (to be serialized as normalized uint16)
+	for _, tuv := range self.tuvs {
+		for _, uv := range tuv {
+			if uv < 0 || uv > 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 func (self *Geometry) buffer_copy_nor(buf []float32, pinfo [4]int, new_vidx int, nor_idx int) {
 	stride, offset := pinfo[0], pinfo[3] // normal vector in 1 byte
 	nx := uint32(self.norms[nor_idx][0] * 127)
@@ -435,106 +766,99 @@ func (self *Geometry) buffer_copy_nor(buf []float32, pinfo [4]int, new_vidx int,
 	buf[pos] = math.Float32frombits(nx + ny<<8 + nz<<16) // LittleEndian (lower byte comes first)
 }
 
+func (self *Geometry) buffer_copy_tan(buf []float32, pinfo [4]int, new_vidx int, tan_idx int) {
+	stride, offset := pinfo[0], self.fpoint_extra[0] // tangent vector with handedness in 4 bytes
+	t := self.tangs[tan_idx]
+	tx, ty := uint32(uint8(int8(t[0]*127))), uint32(uint8(int8(t[1]*127)))
+	tz, tw := uint32(uint8(int8(t[2]*127))), uint32(uint8(int8(t[3]*127)))
+	pos := new_vidx*stride + offset
+	buf[pos] = math.Float32frombits(tx + ty<<8 + tz<<16 + tw<<24) // LittleEndian (lower byte comes first)
+}
+
+func (self *Geometry) buffer_copy_tuv2(buf []float32, pinfo [4]int, new_vidx int, tuv_idx int, tuv_offset int) {
+	stride, offset := pinfo[0], self.fpoint_extra[1] // second UV texture coordinates in 2 uint16
+	u := uint32(self.tuvs2[tuv_idx][tuv_offset+0] * 65535)
+	v := uint32(self.tuvs2[tuv_idx][tuv_offset+1] * 65535)
+	pos := new_vidx*stride + offset
+	buf[pos] = math.Float32frombits(u + v<<16) // LittleEndian (lower byte comes first)
+}
+
+func (self *Geometry) has_points_per_face() bool {
+	// vertices are duplicated for each face, if any of the point data is given PER_FACE
+	return self.HasNormalFor("FACE") || self.HasTextureFor("FACE") || self.HasTangentFor("FACE") || self.HasTextureUV2For("FACE")
+}
+
+func (self *Geometry) build_data_buffer_fpoints(per_face bool) {
+	// Serialize the points for triangles : COORD[3] + (UV[2]) + (NORMAL[3]) + (TANGENT[4]) + (UV2[2]),
+	// with PER_FACE data (vertices duplicated for each face), or with PER_VERT data only.
+	has_tuv, has_nor := self.HasTextureFor(""), self.HasNormalFor("")
+	has_tan, has_tuv2 := self.HasTangentFor(""), self.HasTextureUV2For("")
+	self.fpoint_info, self.fpoint_extra = [4]int{3, 0, 0, 0}, [2]int{0, 0} // size, xyz_off, uv_off, normal_off
+	self.fpoint_tuv_float = has_tuv && !self.is_texture_uv_normalized()
+	if has_tuv && self.fpoint_tuv_float {
+		self.fpoint_info[2], self.fpoint_info[0] = self.fpoint_info[0], self.fpoint_info[0]+2
+	} else if has_tuv {
+		self.fpoint_info[2], self.fpoint_info[0] = self.fpoint_info[0], self.fpoint_info[0]+1
+	}
+	if has_nor {
+		self.fpoint_info[3], self.fpoint_info[0] = self.fpoint_info[0], self.fpoint_info[0]+1
+	}
+	if has_tan {
+		self.fpoint_extra[0], self.fpoint_info[0] = self.fpoint_info[0], self.fpoint_info[0]+1
+	}
+	if has_tuv2 {
+		self.fpoint_extra[1], self.fpoint_info[0] = self.fpoint_info[0], self.fpoint_info[0]+1
+	}
+	copy_point := func(new_vidx int, vidx int, fidx int, i int) {
+		buf, pinfo := self.data_buffer_fpoints, self.fpoint_info
+		self.buffer_copy_xyz(buf, pinfo, new_vidx, vidx)
+		if self.HasTextureFor("FACE") {
+			self.buffer_copy_tuv(buf, pinfo, new_vidx, fidx, i*2)
+		} else if has_tuv {
+			self.buffer_copy_tuv(buf, pinfo, new_vidx, vidx, 0)
+		}
+		if self.HasNormalFor("FACE") {
+			self.buffer_copy_nor(buf, pinfo, new_vidx, fidx)
+		} else if has_nor {
+			self.buffer_copy_nor(buf, pinfo, new_vidx, vidx)
+		}
+		if has_tan && self.HasTangentFor("FACE") {
+			self.buffer_copy_tan(buf, pinfo, new_vidx, fidx)
+		} else if has_tan {
+			self.buffer_copy_tan(buf, pinfo, new_vidx, vidx)
+		}
+		if has_tuv2 && self.HasTextureUV2For("FACE") {
+			self.buffer_copy_tuv2(buf, pinfo, new_vidx, fidx, i*2)
+		} else if has_tuv2 {
+			self.buffer_copy_tuv2(buf, pinfo, new_vidx, vidx, 0)
+		}
+	}
+	if per_face {
+		self.count_fpoint_vidx_list()
+		self.data_buffer_fpoints = make([]float32, self.fpoint_vert_total*self.fpoint_info[0])
+		for fidx, face_vlist := range self.faces {
+			for i := 0; i < len(face_vlist); i++ {
+				copy_point(self.get_fpoint_new_vidx(fidx, i), int(face_vlist[i]), fidx, i)
+			}
+		}
+	} else {
+		self.data_buffer_fpoints = make([]float32, len(self.verts)*self.fpoint_info[0])
+		for vidx := 0; vidx < len(self.verts); vidx++ {
+			copy_point(vidx, vidx, -1, 0)
+		}
+		self.data_buffer_vpoints = self.data_buffer_fpoints // shared with vertex points
+		self.vpoint_info = self.fpoint_info
+	}
+}
+
 func (self *Geometry) BuildDataBuffers(for_points bool, for_lines bool, for_faces bool) {
 	// create data buffer for vertex points
 	self.data_buffer_vpoints, self.vpoint_info = nil, [4]int{0, 0, 0, 0}
 	self.data_buffer_fpoints, self.fpoint_info = nil, [4]int{0, 0, 0, 0}
 	points_per_face := false
 	if for_faces {
-		points_per_face = self.HasNormalFor("FACE") || self.HasTextureFor("FACE")
-		if points_per_face {
-			self.count_fpoint_vidx_list()
-		}
-		if self.HasNormalFor("FACE") && self.HasTextureFor("FACE") {
-			self.fpoint_info = [4]int{(3 + 1 + 1), 0, 3, 4} // size, xyz_off, uv_off, normal_off
-			self.data_buffer_fpoints = make([]float32, self.fpoint_vert_total*self.fpoint_info[0])
-			for fidx, face_vlist := range self.faces {
-				for i := 0; i < len(face_vlist); i++ {
-					new_vidx, vidx := self.get_fpoint_new_vidx(fidx, i), int(face_vlist[i])
-					self.buffer_copy_xyz(self.data_buffer_fpoints, self.fpoint_info, new_vidx, vidx)
-					self.buffer_copy_tuv(self.data_buffer_fpoints, self.fpoint_info, new_vidx, fidx, i*2)
-					self.buffer_copy_nor(self.data_buffer_fpoints, self.fpoint_info, new_vidx, fidx)
-				}
-			}
-		} else if self.HasNormalFor("FACE") && self.HasTextureFor("VERTEX") {
-			self.fpoint_info = [4]int{(3 + 1 + 1), 0, 3, 4} // size, xyz_off, uv_off, normal_off
-			self.data_buffer_fpoints = make([]float32, self.fpoint_vert_total*self.fpoint_info[0])
-			for fidx, face_vlist := range self.faces {
-				for i := 0; i < len(face_vlist); i++ {
-					new_vidx, vidx := self.get_fpoint_new_vidx(fidx, i), int(face_vlist[i])
-					self.buffer_copy_xyz(self.data_buffer_fpoints, self.fpoint_info, new_vidx, vidx)
-					self.buffer_copy_tuv(self.data_buffer_fpoints, self.fpoint_info, new_vidx, vidx, 0)
-					self.buffer_copy_nor(self.data_buffer_fpoints, self.fpoint_info, new_vidx, fidx)
-				}
-			}
-		} else if self.HasNormalFor("FACE") && !self.HasTextureFor("") {
-			self.fpoint_info = [4]int{(3 + 0 + 1), 0, 0, 3} // size, xyz_off, uv_off, normal_off
-			self.data_buffer_fpoints = make([]float32, self.fpoint_vert_total*self.fpoint_info[0])
-			for fidx, face_vlist := range self.faces {
-				for i := 0; i < len(face_vlist); i++ {
-					new_vidx, vidx := self.get_fpoint_new_vidx(fidx, i), int(face_vlist[i])
-					self.buffer_copy_xyz(self.data_buffer_fpoints, self.fpoint_info, new_vidx, vidx)
-					self.buffer_copy_nor(self.data_buffer_fpoints, self.fpoint_info, new_vidx, fidx)
-				}
-			}
-		} else if self.HasNormalFor("VERTEX") && self.HasTextureFor("FACE") {
-			self.fpoint_info = [4]int{(3 + 1 + 1), 0, 3, 4} // size, xyz_off, uv_off, normal_off
-			self.data_buffer_fpoints = make([]float32, self.fpoint_vert_total*self.fpoint_info[0])
-			for fidx, face_vlist := range self.faces {
-				for i := 0; i < len(face_vlist); i++ {
-					new_vidx, vidx := self.get_fpoint_new_vidx(fidx, i), int(face_vlist[i])
-					self.buffer_copy_xyz(self.data_buffer_fpoints, self.fpoint_info, new_vidx, vidx)
-					self.buffer_copy_tuv(self.data_buffer_fpoints, self.fpoint_info, new_vidx, fidx, i*2)
-					self.buffer_copy_nor(self.data_buffer_fpoints, self.fpoint_info, new_vidx, vidx)
-				}
-			}
-		} else if self.HasNormalFor("VERTEX") && self.HasTextureFor("VERTEX") {
-			self.fpoint_info = [4]int{(3 + 1 + 1), 0, 3, 4} // size, xyz_off, uv_off, normal_off
-			self.data_buffer_fpoints = make([]float32, len(self.verts)*self.fpoint_info[0])
-			for vidx := 0; vidx < len(self.verts); vidx++ {
-				self.buffer_copy_xyz(self.data_buffer_fpoints, self.fpoint_info, vidx, vidx)
-				self.buffer_copy_tuv(self.data_buffer_fpoints, self.fpoint_info, vidx, vidx, 0)
-				self.buffer_copy_nor(self.data_buffer_fpoints, self.fpoint_info, vidx, vidx)
-			}
-			self.data_buffer_vpoints = self.data_buffer_fpoints
-			self.vpoint_info = self.fpoint_info
-		} else if self.HasNormalFor("VERTEX") && !self.HasTextureFor("") {
-			self.fpoint_info = [4]int{(3 + 0 + 1), 0, 0, 3} // size, xyz_off, uv_off, normal_off
-			self.data_buffer_fpoints = make([]float32, len(self.verts)*self.fpoint_info[0])
-			for vidx := 0; vidx < len(self.verts); vidx++ {
-				self.buffer_copy_xyz(self.data_buffer_fpoints, self.fpoint_info, vidx, vidx)
-				self.buffer_copy_nor(self.data_buffer_fpoints, self.fpoint_info, vidx, vidx)
-			}
-			self.data_buffer_vpoints = self.data_buffer_fpoints
-			self.vpoint_info = self.fpoint_info
-		} else if !self.HasNormalFor("") && self.HasTextureFor("FACE") {
-			self.fpoint_info = [4]int{(3 + 1 + 0), 0, 3, 0} // size, xyz_offset, uv_offset, normal_offset
-			self.data_buffer_fpoints = make([]float32, self.fpoint_vert_total*self.fpoint_info[0])
-			for fidx, face_vlist := range self.faces {
-				for i := 0; i < len(face_vlist); i++ {
-					new_vidx, vidx := self.get_fpoint_new_vidx(fidx, i), int(face_vlist[i])
-					self.buffer_copy_xyz(self.data_buffer_fpoints, self.fpoint_info, new_vidx, vidx)
-					self.buffer_copy_tuv(self.data_buffer_fpoints, self.fpoint_info, new_vidx, fidx, i*2)
-				}
-			}
-		} else if !self.HasNormalFor("") && self.HasTextureFor("VERTEX") {
-			self.fpoint_info = [4]int{(3 + 1 + 0), 0, 3, 0} // size, xyz_offset, uv_offset, normal_offset
-			self.data_buffer_fpoints = make([]float32, len(self.verts)*self.fpoint_info[0])
-			for vidx := 0; vidx < len(self.verts); vidx++ {
-				self.buffer_copy_xyz(self.data_buffer_fpoints, self.fpoint_info, vidx, vidx)
-				self.buffer_copy_tuv(self.data_buffer_fpoints, self.fpoint_info, vidx, vidx, 0)
-			}
-			self.data_buffer_vpoints = self.data_buffer_fpoints
-			self.vpoint_info = self.fpoint_info
-		} else if !self.HasNormalFor("") && !self.HasTextureFor("") {
-			self.fpoint_info = [4]int{(3 + 0 + 0), 0, 0, 0} // size, xyz_offset, uv_offset, normal_offset
-			self.data_buffer_fpoints = make([]float32, len(self.verts)*self.fpoint_info[0])
-			for vidx := 0; vidx < len(self.verts); vidx++ {
-				self.buffer_copy_xyz(self.data_buffer_fpoints, self.fpoint_info, vidx, vidx)
-			}
-			self.data_buffer_vpoints = self.data_buffer_fpoints
-			self.vpoint_info = self.fpoint_info
-		}
+		points_per_face = self.has_points_per_face()
+		self.build_data_buffer_fpoints(points_per_face)
 	} else {
 		self.data_buffer_fpoints = nil
 	}
@@ -627,6 +951,97 @@ func (self *Geometry) BuildDataBuffersForWireframe() {
 	self.Clear(false, false, true)
 }
 
+// ----------------------------------------------------------------------------
+// Partial Update of Data Buffers
+// ----------------------------------------------------------------------------
+
+func (self *Geometry) UpdateVertices(vidx int, coords ...[3]float32) *Geometry {
+	// Change the coordinates of the vertices [vidx, vidx+len(coords)), and update the data buffers in place.
+	// Only the modified range will be uploaded to WebGL before rendering (normal vectors are not changed).
+	for i, xyz := range coords {
+		self.verts[vidx+i] = xyz
+	}
+	self.refresh_data_buffers(vidx, vidx+len(coords), false)
+	return self
+}
+
+func (self *Geometry) refresh_data_buffers(vstt int, vend int, with_normals bool) {
+	// Copy the vertices [vstt, vend) (and their normal & tangent vectors) into the existing data buffers, marking dirty ranges.
+	// If the data buffers are missing or out of date (after adding vertices or faces), they're cleared to be rebuilt.
+	self.sorted_vwmd = [16]float32{} // faces should be sorted again with the moved vertices
+	copy_tangent := with_normals && self.data_buffer_fpoints != nil && self.fpoint_extra[0] > 0
+	if copy_tangent && !self.HasTangentFor("") && self.HasTextureFor("") {
+		self.BuildTangents() // tangents are missing (while the data buffer has them), so rebuild them
+	}
+	if !self.IsDataBufferReady() || !self.is_data_buffer_size_valid() || (copy_tangent && !self.HasTangentFor("")) {
+		self.Clear(false, true, true)
+		return
+	}
+	per_face := self.has_points_per_face()
+	if self.data_buffer_fpoints != nil {
+		pinfo := self.fpoint_info
+		copy_normal := with_normals && pinfo[3] > 0
+		if per_face { // vertices were duplicated for each face
+			for fidx, face := range self.faces {
+				for i, v := range face {
+					if int(v) < vstt || int(v) >= vend {
+						continue
+					}
+					new_vidx := self.get_fpoint_new_vidx(fidx, i)
+					self.buffer_copy_xyz(self.data_buffer_fpoints, pinfo, new_vidx, int(v))
+					if copy_normal && self.HasNormalFor("FACE") {
+						self.buffer_copy_nor(self.data_buffer_fpoints, pinfo, new_vidx, fidx)
+					} else if copy_normal {
+						self.buffer_copy_nor(self.data_buffer_fpoints, pinfo, new_vidx, int(v))
+					}
+					if copy_tangent && self.HasTangentFor("FACE") {
+						self.buffer_copy_tan(self.data_buffer_fpoints, pinfo, new_vidx, fidx)
+					} else if copy_tangent {
+						self.buffer_copy_tan(self.data_buffer_fpoints, pinfo, new_vidx, int(v))
+					}
+					self.dirty_fpoints.Mark(new_vidx*pinfo[0], (new_vidx+1)*pinfo[0])
+				}
+			}
+		} else {
+			for v := vstt; v < vend; v++ {
+				self.buffer_copy_xyz(self.data_buffer_fpoints, pinfo, v, v)
+				if copy_normal {
+					self.buffer_copy_nor(self.data_buffer_fpoints, pinfo, v, v)
+				}
+				if copy_tangent {
+					self.buffer_copy_tan(self.data_buffer_fpoints, pinfo, v, v)
+				}
+			}
+			self.dirty_fpoints.Mark(vstt*pinfo[0], vend*pinfo[0])
+		}
+	}
+	if self.data_buffer_vpoints != nil {
+		shared := len(self.data_buffer_fpoints) > 0 && &self.data_buffer_vpoints[0] == &self.data_buffer_fpoints[0]
+		if !shared { // separate data buffer with coordinates only
+			for v := vstt; v < vend; v++ {
+				self.buffer_copy_xyz(self.data_buffer_vpoints, self.vpoint_info, v, v)
+			}
+		}
+		self.dirty_vpoints.Mark(vstt*self.vpoint_info[0], vend*self.vpoint_info[0])
+	}
+}
+
+func (self *Geometry) is_data_buffer_size_valid() bool {
+	if self.data_buffer_vpoints != nil && len(self.data_buffer_vpoints) != len(self.verts)*self.vpoint_info[0] {
+		return false
+	}
+	if self.data_buffer_fpoints != nil {
+		if self.has_points_per_face() {
+			if self.fpoint_vidx_list == nil || len(self.fpoint_vidx_list) != len(self.faces) {
+				return false
+			}
+			return len(self.data_buffer_fpoints) == self.fpoint_vert_total*self.fpoint_info[0]
+		}
+		return len(self.data_buffer_fpoints) == len(self.verts)*self.fpoint_info[0]
+	}
+	return true
+}
+
 // ----------------------------------------------------------------------------
 // Build WebGL Buffers
 // ----------------------------------------------------------------------------
@@ -635,14 +1050,27 @@ func (self *Geometry) IsWebGLBufferReady() bool {
 	return !self.webgl_buffer_vpoints.IsNull()
 }
 
+func (self *Geometry) SetBufferUsage(usage string) *Geometry {
+	// 'usage' : "STATIC" (default), "DYNAMIC" (for vertices updated frequently) or "STREAM" (updated every frame)
+	if usage != self.buffer_usage {
+		self.buffer_usage = usage
+		self.Clear(false, false, true) // WebGL buffers will be re-created with the new usage
+	}
+	return self
+}
+
 func (self *Geometry) BuildWebGLBuffers(wctx *wcommon.WebGLContext, for_points bool, for_lines bool, for_faces bool) {
 	context := wctx.GetContext()     // js.Value
 	constants := wctx.GetConstants() // *wcommon.Constants
+	usage := wcommon.GetBufferUsage(constants, self.buffer_usage)
+	self.webgl_version++
+	self.dirty_vpoints.Reset()
+	self.dirty_fpoints.Reset()
 	if for_points && self.data_buffer_vpoints != nil {
 		self.webgl_buffer_vpoints = context.Call("createBuffer", constants.ARRAY_BUFFER)
 		context.Call("bindBuffer", constants.ARRAY_BUFFER, self.webgl_buffer_vpoints)
 		var points_array = wcommon.ConvertGoSliceToJsTypedArray(self.data_buffer_vpoints)
-		context.Call("bufferData", constants.ARRAY_BUFFER, points_array, constants.STATIC_DRAW)
+		context.Call("bufferData", constants.ARRAY_BUFFER, points_array, usage)
 		context.Call("bindBuffer", constants.ARRAY_BUFFER, nil)
 	} else {
 		self.webgl_buffer_vpoints = js.Null()
@@ -661,7 +1089,7 @@ func (self *Geometry) BuildWebGLBuffers(wctx *wcommon.WebGLContext, for_points b
 			self.webgl_buffer_fpoints = context.Call("createBuffer", constants.ARRAY_BUFFER)
 			context.Call("bindBuffer", constants.ARRAY_BUFFER, self.webgl_buffer_fpoints)
 			var points_array = wcommon.ConvertGoSliceToJsTypedArray(self.data_buffer_fpoints)
-			context.Call("bufferData", constants.ARRAY_BUFFER, points_array, constants.STATIC_DRAW)
+			context.Call("bufferData", constants.ARRAY_BUFFER, points_array, usage)
 			context.Call("bindBuffer", constants.ARRAY_BUFFER, nil)
 		}
 		self.webgl_buffer_faces = context.Call("createBuffer", constants.ELEMENT_ARRAY_BUFFER)
@@ -674,6 +1102,59 @@ func (self *Geometry) BuildWebGLBuffers(wctx *wcommon.WebGLContext, for_points b
 	}
 }
 
+func (self *Geometry) UpdateWebGLBuffers(wctx *wcommon.WebGLContext) {
+	// THIS FUCNTION IS MEANT TO BE CALLED BY RENDERER. NO NEED TO BE EXPORTED
+	// Upload only the modified range of data buffers (with gl.bufferSubData)
+	wcommon.UploadDirtyRange(wctx, self.webgl_buffer_vpoints, self.data_buffer_vpoints, &self.dirty_vpoints)
+	wcommon.UploadDirtyRange(wctx, self.webgl_buffer_fpoints, self.data_buffer_fpoints, &self.dirty_fpoints)
+}
+
+func (self *Geometry) SortFacesBackToFront(wctx *wcommon.WebGLContext, vwmd *geom3d.Matrix4) {
+	// THIS FUCNTION IS MEANT TO BE CALLED BY RENDERER (for transparent SceneObjects with 'SortFaces')
+	// Sort the triangles by their depth in CAMERA space (farthest first), and upload the indices again.
+	// (Sorting is skipped, if (View * Model) matrix has not changed since the last sorting)
+	m := vwmd.GetElements()
+	if *m == self.sorted_vwmd || self.webgl_buffer_faces.IsNull() || len(self.data_buffer_faces) < 6 {
+		return
+	}
+	buf, pinfo := self.data_buffer_vpoints, self.vpoint_info
+	if self.data_buffer_fpoints != nil {
+		buf, pinfo = self.data_buffer_fpoints, self.fpoint_info
+	}
+	if buf == nil {
+		return
+	}
+	self.sorted_vwmd = *m
+	tcount := len(self.data_buffer_faces) / 3
+	depths := make([]float32, tcount) // (sum of Z of the three points in CAMERA space, without translation)
+	order := make([]int, tcount)
+	for t := 0; t < tcount; t++ {
+		for k := 0; k < 3; k++ {
+			p := int(self.data_buffer_faces[t*3+k])*pinfo[0] + pinfo[1]
+			depths[t] += m[2]*buf[p+0] + m[6]*buf[p+1] + m[10]*buf[p+2]
+		}
+		order[t] = t
+	}
+	sort.SliceStable(order, func(a, b int) bool { return depths[order[a]] < depths[order[b]] }) // farthest (most negative Z) first
+	sorted := make([]uint32, len(self.data_buffer_faces))
+	for i, t := range order {
+		copy(sorted[i*3:i*3+3], self.data_buffer_faces[t*3:t*3+3])
+	}
+	self.data_buffer_faces = sorted
+	context, constants := wctx.GetContext(), wctx.GetConstants()
+	context.Call("bindBuffer", constants.ELEMENT_ARRAY_BUFFER, self.webgl_buffer_faces)
+	context.Call("bufferSubData", constants.ELEMENT_ARRAY_BUFFER, 0, wcommon.ConvertGoSliceToJsTypedArray(sorted))
+	context.Call("bindBuffer", constants.ELEMENT_ARRAY_BUFFER, nil)
+}
+
+func (self *Geometry) GetWebGLBufferExtra() [2]int {
+	// offsets of optional data (tangent & second UV) in the points for triangles (0, if not found)
+	if self.data_buffer_fpoints == nil {
+		return [2]int{0, 0}
+	}
+	return self.fpoint_extra
+}
+
 func (self *Geometry) GetWebGLBuffer(draw_mode int) (js.Value, int, [4]int) {
 	switch draw_mode {
 	case 1: // "POINTS", "VERTICES":
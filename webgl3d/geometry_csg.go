@@ -0,0 +1,349 @@
+package webgl3d
+
+import (
+	"math"
+)
+
+// ----------------------------------------------------------------------------
+// Constructive Solid Geometry (CSG)
+// ----------------------------------------------------------------------------
+// Boolean operations between closed (watertight) geometries, using BSP trees.
+//   Ref: https://github.com/evanw/csg.js  (Evan Wallace, MIT license)
+// All the computations are done in float64, and faces/vertices are visited in the order of their index,
+// so that the result is deterministic for the same input.
+// Note that the resulting geometry has only vertices, faces and PER_FACE normal vectors.
+// (Texture UV coordinates can be built later, with BuildTextureUVsByProjection() or BuildTextureUVsByUnwrapping())
+
+const csg_epsilon = 1e-5 // tolerance for deciding whether a point is on the plane
+
+func (self *Geometry) CSGUnion(g *Geometry) *Geometry {
+	// Return a new geometry of (self + g)
+	a, b := new_csg_node(csg_polygons_from_geometry(self)), new_csg_node(csg_polygons_from_geometry(g))
+	a.clip_to(b)
+	b.clip_to(a)
+	b.invert()
+	b.clip_to(a)
+	b.invert()
+	a.build(b.all_polygons())
+	return csg_polygons_to_geometry(a.all_polygons())
+}
+
+func (self *Geometry) CSGIntersection(g *Geometry) *Geometry {
+	// Return a new geometry of (self * g)
+	a, b := new_csg_node(csg_polygons_from_geometry(self)), new_csg_node(csg_polygons_from_geometry(g))
+	a.invert()
+	b.clip_to(a)
+	b.invert()
+	a.clip_to(b)
+	b.clip_to(a)
+	a.build(b.all_polygons())
+	a.invert()
+	return csg_polygons_to_geometry(a.all_polygons())
+}
+
+func (self *Geometry) CSGDifference(g *Geometry) *Geometry {
+	// Return a new geometry of (self - g)  (for example, a cube with a hole drilled by a cylinder)
+	a, b := new_csg_node(csg_polygons_from_geometry(self)), new_csg_node(csg_polygons_from_geometry(g))
+	a.invert()
+	a.clip_to(b)
+	b.clip_to(a)
+	b.invert()
+	b.clip_to(a)
+	b.invert()
+	a.build(b.all_polygons())
+	a.invert()
+	return csg_polygons_to_geometry(a.all_polygons())
+}
+
+// ----------------------------------------------------------------------------
+// CSG Conversion from/to Geometry
+// ----------------------------------------------------------------------------
+
+func csg_polygons_from_geometry(g *Geometry) []*csg_polygon {
+	// Faces are triangulated first (with the normal by Newell's method, which is correct even for concave faces),
+	// since splitting by BSP planes works only for convex polygons (like an L-shaped face would be broken).
+	polygons := []*csg_polygon{}
+	for _, face := range g.faces {
+		if len(face) < 3 {
+			continue
+		}
+		triangles := [][]uint32{face}
+		if len(face) > 3 {
+			triangles = g.get_triangulation(face, get_face_normal_of(g.verts, face))
+		}
+		for _, triangle := range triangles {
+			verts := make([][3]float64, len(triangle))
+			for i, vidx := range triangle {
+				v := g.verts[vidx]
+				verts[i] = [3]float64{float64(v[0]), float64(v[1]), float64(v[2])}
+			}
+			if polygon := new_csg_polygon(verts); polygon != nil && polygon.is_planar() {
+				polygons = append(polygons, polygon)
+			} else { // non-planar (or degenerate) remainder of failed triangulation is split as a fan
+				for i := 1; i < len(verts)-1; i++ {
+					if triangle := new_csg_polygon([][3]float64{verts[0], verts[i], verts[i+1]}); triangle != nil {
+						polygons = append(polygons, triangle)
+					}
+				}
+			}
+		}
+	}
+	return polygons
+}
+
+func csg_polygons_to_geometry(polygons []*csg_polygon) *Geometry {
+	// Build a new geometry, while welding vertices at the same position.
+	geometry := NewGeometry()
+	vindex := map[[3]int64]uint32{}
+	for _, polygon := range polygons {
+		face := make([]uint32, 0, len(polygon.verts))
+		for _, v := range polygon.verts {
+			key := [3]int64{int64(math.Round(v[0] / csg_epsilon)), int64(math.Round(v[1] / csg_epsilon)), int64(math.Round(v[2] / csg_epsilon))}
+			vidx, ok := vindex[key]
+			if !ok {
+				vidx = geometry.AddVertex([3]float32{float32(v[0]), float32(v[1]), float32(v[2])})
+				vindex[key] = vidx
+			}
+			if len(face) == 0 || face[len(face)-1] != vidx {
+				face = append(face, vidx)
+			}
+		}
+		if len(face) > 1 && face[0] == face[len(face)-1] {
+			face = face[:len(face)-1]
+		}
+		if len(face) < 3 {
+			continue // degenerate polygon after welding
+		}
+		geometry.AddFace(face)
+		n := polygon.plane.normal
+		geometry.AddNormal([3]float32{float32(n[0]), float32(n[1]), float32(n[2])})
+	}
+	return geometry
+}
+
+// ----------------------------------------------------------------------------
+// CSG Plane & Polygon
+// ----------------------------------------------------------------------------
+
+type csg_plane struct {
+	normal [3]float64 // unit normal vector
+	w      float64    // distance from the origin (dot(normal, p) == w for any point p on the plane)
+}
+
+type csg_polygon struct {
+	verts [][3]float64 // vertices of a convex polygon (CCW)
+	plane csg_plane    // plane of the polygon
+}
+
+func new_csg_polygon(verts [][3]float64) *csg_polygon {
+	// Newell's method for the normal vector, which is robust for nearly-degenerate polygons
+	n, c := [3]float64{0, 0, 0}, [3]float64{0, 0, 0}
+	for i := 0; i < len(verts); i++ {
+		a, b := verts[i], verts[(i+1)%len(verts)]
+		n[0] += (a[1] - b[1]) * (a[2] + b[2])
+		n[1] += (a[2] - b[2]) * (a[0] + b[0])
+		n[2] += (a[0] - b[0]) * (a[1] + b[1])
+		c[0], c[1], c[2] = c[0]+a[0], c[1]+a[1], c[2]+a[2]
+	}
+	length := math.Sqrt(n[0]*n[0] + n[1]*n[1] + n[2]*n[2])
+	if length < csg_epsilon*csg_epsilon {
+		return nil
+	}
+	n = [3]float64{n[0] / length, n[1] / length, n[2] / length}
+	k := float64(len(verts))
+	w := n[0]*c[0]/k + n[1]*c[1]/k + n[2]*c[2]/k
+	return &csg_polygon{verts: verts, plane: csg_plane{normal: n, w: w}}
+}
+
+func (self *csg_polygon) is_planar() bool {
+	for _, v := range self.verts {
+		if math.Abs(self.plane.distance_to(v)) > csg_epsilon {
+			return false
+		}
+	}
+	return true
+}
+
+func (self *csg_polygon) flip() {
+	for i, j := 0, len(self.verts)-1; i < j; i, j = i+1, j-1 {
+		self.verts[i], self.verts[j] = self.verts[j], self.verts[i]
+	}
+	self.plane.flip()
+}
+
+func (self *csg_plane) flip() {
+	self.normal = [3]float64{-self.normal[0], -self.normal[1], -self.normal[2]}
+	self.w = -self.w
+}
+
+func (self *csg_plane) distance_to(p [3]float64) float64 {
+	return self.normal[0]*p[0] + self.normal[1]*p[1] + self.normal[2]*p[2] - self.w
+}
+
+func (self *csg_plane) split_polygon(polygon *csg_polygon, coplanar_front *[]*csg_polygon, coplanar_back *[]*csg_polygon, front *[]*csg_polygon, back *[]*csg_polygon) {
+	// Split the polygon by this plane, and put the pieces into the corresponding lists.
+	// Coplanar polygons go into either 'coplanar_front' or 'coplanar_back', depending on their orientation.
+	const (
+		COPLANAR = 0
+		FRONT    = 1
+		BACK     = 2
+		SPANNING = 3
+	)
+	polygon_type := 0
+	types := make([]int, len(polygon.verts))
+	for i, v := range polygon.verts {
+		t := self.distance_to(v)
+		if t < -csg_epsilon {
+			types[i] = BACK
+		} else if t > csg_epsilon {
+			types[i] = FRONT
+		} else {
+			types[i] = COPLANAR
+		}
+		polygon_type |= types[i]
+	}
+	switch polygon_type {
+	case COPLANAR:
+		n := polygon.plane.normal
+		if self.normal[0]*n[0]+self.normal[1]*n[1]+self.normal[2]*n[2] > 0 {
+			*coplanar_front = append(*coplanar_front, polygon)
+		} else {
+			*coplanar_back = append(*coplanar_back, polygon)
+		}
+	case FRONT:
+		*front = append(*front, polygon)
+	case BACK:
+		*back = append(*back, polygon)
+	case SPANNING:
+		f, b := [][3]float64{}, [][3]float64{}
+		for i := 0; i < len(polygon.verts); i++ {
+			j := (i + 1) % len(polygon.verts)
+			ti, tj := types[i], types[j]
+			vi, vj := polygon.verts[i], polygon.verts[j]
+			if ti != BACK {
+				f = append(f, vi)
+			}
+			if ti != FRONT {
+				b = append(b, vi)
+			}
+			if (ti | tj) == SPANNING {
+				t := (self.w - (self.normal[0]*vi[0] + self.normal[1]*vi[1] + self.normal[2]*vi[2])) /
+					(self.normal[0]*(vj[0]-vi[0]) + self.normal[1]*(vj[1]-vi[1]) + self.normal[2]*(vj[2]-vi[2]))
+				v := [3]float64{vi[0] + (vj[0]-vi[0])*t, vi[1] + (vj[1]-vi[1])*t, vi[2] + (vj[2]-vi[2])*t}
+				f = append(f, v)
+				b = append(b, v)
+			}
+		}
+		if len(f) >= 3 {
+			*front = append(*front, &csg_polygon{verts: f, plane: polygon.plane})
+		}
+		if len(b) >= 3 {
+			*back = append(*back, &csg_polygon{verts: b, plane: polygon.plane})
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// CSG BSP Tree Node
+// ----------------------------------------------------------------------------
+
+type csg_node struct {
+	plane    *csg_plane     // splitting plane of this node
+	front    *csg_node      // subtree in front of the plane
+	back     *csg_node      // subtree behind the plane
+	polygons []*csg_polygon // polygons coplanar with the splitting plane
+}
+
+func new_csg_node(polygons []*csg_polygon) *csg_node {
+	node := &csg_node{polygons: []*csg_polygon{}}
+	if len(polygons) > 0 {
+		node.build(polygons)
+	}
+	return node
+}
+
+func (self *csg_node) invert() {
+	// Convert solid space to empty space, and vice versa.
+	for _, polygon := range self.polygons {
+		polygon.flip()
+	}
+	if self.plane != nil {
+		self.plane.flip()
+	}
+	if self.front != nil {
+		self.front.invert()
+	}
+	if self.back != nil {
+		self.back.invert()
+	}
+	self.front, self.back = self.back, self.front
+}
+
+func (self *csg_node) clip_polygons(polygons []*csg_polygon) []*csg_polygon {
+	// Remove all the polygons (or their parts) that are inside of this BSP tree.
+	if self.plane == nil {
+		return append([]*csg_polygon{}, polygons...)
+	}
+	front, back := []*csg_polygon{}, []*csg_polygon{}
+	for _, polygon := range polygons {
+		self.plane.split_polygon(polygon, &front, &back, &front, &back)
+	}
+	if self.front != nil {
+		front = self.front.clip_polygons(front)
+	}
+	if self.back != nil {
+		back = self.back.clip_polygons(back)
+	} else {
+		back = []*csg_polygon{}
+	}
+	return append(front, back...)
+}
+
+func (self *csg_node) clip_to(bsp *csg_node) {
+	// Remove all the polygons in this BSP tree that are inside of the other BSP tree.
+	self.polygons = bsp.clip_polygons(self.polygons)
+	if self.front != nil {
+		self.front.clip_to(bsp)
+	}
+	if self.back != nil {
+		self.back.clip_to(bsp)
+	}
+}
+
+func (self *csg_node) all_polygons() []*csg_polygon {
+	polygons := append([]*csg_polygon{}, self.polygons...)
+	if self.front != nil {
+		polygons = append(polygons, self.front.all_polygons()...)
+	}
+	if self.back != nil {
+		polygons = append(polygons, self.back.all_polygons()...)
+	}
+	return polygons
+}
+
+func (self *csg_node) build(polygons []*csg_polygon) {
+	// Build (or extend) the BSP tree with the polygons, using the first polygon as the splitting plane.
+	if len(polygons) == 0 {
+		return
+	}
+	if self.plane == nil {
+		plane := polygons[0].plane
+		self.plane = &plane
+	}
+	front, back := []*csg_polygon{}, []*csg_polygon{}
+	for _, polygon := range polygons {
+		self.plane.split_polygon(polygon, &self.polygons, &self.polygons, &front, &back)
+	}
+	if len(front) > 0 {
+		if self.front == nil {
+			self.front = &csg_node{polygons: []*csg_polygon{}}
+		}
+		self.front.build(front)
+	}
+	if len(back) > 0 {
+		if self.back == nil {
+			self.back = &csg_node{polygons: []*csg_polygon{}}
+		}
+		self.back.build(back)
+	}
+}
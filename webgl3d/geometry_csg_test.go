@@ -0,0 +1,86 @@
+//go:build js && wasm
+
+package webgl3d
+
+import "testing"
+
+// Run with:  GOOS=js GOARCH=wasm go test -vet=off -exec="$(go env GOROOT)/lib/wasm/go_js_wasm_exec" ./webgl3d
+
+func get_volume_of_geometry(g *Geometry) float64 {
+	// signed volume of the closed geometry (divergence theorem with triangle fans of the faces)
+	volume := 0.0
+	for _, face := range g.faces {
+		a := g.verts[face[0]]
+		for k := 1; k+1 < len(face); k++ {
+			b, c := g.verts[face[k]], g.verts[face[k+1]]
+			volume += float64(a[0]*(b[1]*c[2]-b[2]*c[1])-a[1]*(b[0]*c[2]-b[2]*c[0])+a[2]*(b[0]*c[1]-b[1]*c[0])) / 6
+		}
+	}
+	return volume
+}
+
+func TestCSGOfTwoCubes(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     func(a *Geometry, b *Geometry) *Geometry
+		volume float64
+	}{
+		{"union", func(a *Geometry, b *Geometry) *Geometry { return a.CSGUnion(b) }, 15},
+		{"difference", func(a *Geometry, b *Geometry) *Geometry { return a.CSGDifference(b) }, 7},
+		{"intersection", func(a *Geometry, b *Geometry) *Geometry { return a.CSGIntersection(b) }, 1},
+	}
+	for _, tt := range tests {
+		var first *Geometry = nil
+		for run := 0; run < 3; run++ {
+			a := NewGeometry_Cube(2, 2, 2)
+			b := NewGeometry_Cube(2, 2, 2).Translate(1, 1, 1)
+			result := tt.op(a, b)
+			if v := get_volume_of_geometry(result); v < tt.volume-1e-4 || v > tt.volume+1e-4 {
+				t.Errorf("%s: volume = %v, want %v", tt.name, v, tt.volume)
+			}
+			if len(result.norms) != len(result.faces) {
+				t.Errorf("%s: %d normals for %d faces", tt.name, len(result.norms), len(result.faces))
+			}
+			if first == nil {
+				first = result
+				continue
+			}
+			// the result should be the same for every run
+			if len(result.verts) != len(first.verts) || len(result.faces) != len(first.faces) {
+				t.Errorf("%s: run %d gives %d verts & %d faces, while run 0 gives %d verts & %d faces",
+					tt.name, run, len(result.verts), len(result.faces), len(first.verts), len(first.faces))
+				continue
+			}
+			for i := range result.verts {
+				if result.verts[i] != first.verts[i] {
+					t.Errorf("%s: run %d gives vertex[%d] %v, while run 0 gives %v", tt.name, run, i, result.verts[i], first.verts[i])
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestCSGOfConcaveFaces(t *testing.T) {
+	// L-shaped solid (with concave top & bottom faces) of volume 3, and a cube overlapping its concave corner
+	lshape := [][3]float32{{0, 0, 0}, {2, 0, 0}, {2, 1, 0}, {1, 1, 0}, {1, 2, 0}, {0, 2, 0}}
+	tests := []struct {
+		name   string
+		op     func(a *Geometry, b *Geometry) *Geometry
+		volume float64
+	}{
+		{"union", func(a *Geometry, b *Geometry) *Geometry { return a.CSGUnion(b) }, 3 + 1 - 0.75},
+		{"difference", func(a *Geometry, b *Geometry) *Geometry { return a.CSGDifference(b) }, 3 - 0.75},
+		{"intersection", func(a *Geometry, b *Geometry) *Geometry { return a.CSGIntersection(b) }, 0.75},
+	}
+	for _, tt := range tests {
+		a := NewGeometry_SolidFromFaceAndHeight(lshape, 1)
+		b := NewGeometry_Cube(1, 1, 1).Translate(1, 1, 0.5)
+		if v := get_volume_of_geometry(a); v < 3-1e-4 || v > 3+1e-4 {
+			t.Fatalf("L-shaped solid has volume %v, want 3", v)
+		}
+		if v := get_volume_of_geometry(tt.op(a, b)); v < tt.volume-1e-4 || v > tt.volume+1e-4 {
+			t.Errorf("%s: volume = %v, want %v", tt.name, v, tt.volume)
+		}
+	}
+}
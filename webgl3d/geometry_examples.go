@@ -2,6 +2,8 @@ package webgl3d
 
 import (
 	"math"
+
+	"github.com/go4orward/gowebgl/geom3d"
 )
 
 const InRadian = (math.Pi / 180.0)
@@ -174,6 +176,24 @@ func NewGeometry_SolidFromCentersAndRadii(centers [][3]float32, radii []float32,
 	return geometry
 }
 
+func NewGeometry_ConvexHull(points [][3]float32) *Geometry {
+	geometry := NewGeometry() // convex hull of the points, with triangular faces (CCW seen from outside)
+	vmap := map[int]uint32{}
+	for _, t := range geom3d.ConvexHull(points) {
+		face := make([]uint32, 3)
+		for k, pidx := range t {
+			vidx, ok := vmap[pidx]
+			if !ok {
+				vidx = geometry.AddVertex(points[pidx])
+				vmap[pidx] = vidx
+			}
+			face[k] = vidx
+		}
+		geometry.AddFace(face)
+	}
+	return geometry
+}
+
 func NewGeometry_EmptyExample() *Geometry {
 	geometry := NewGeometry()
 	geometry.SetVertices([][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}})
@@ -0,0 +1,318 @@
+package webgl3d
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/go4orward/gowebgl/geom3d"
+)
+
+// ----------------------------------------------------------------------------
+// Texture UV Projection
+// ----------------------------------------------------------------------------
+
+func (self *Geometry) BuildTextureUVsByProjection(mode string, projector *geom3d.Matrix4, per_face bool) *Geometry {
+	// Build texture UV coordinates by projecting the vertices, after transforming them with 'projector'.
+	//   'mode' : "PLANAR"      : projected along Z axis onto XY plane, with [-0.5 ~ +0.5] mapped to [0 ~ 1]
+	//            "BOX"         : projected onto XY, YZ or ZX plane, depending on the face normal (always PER_FACE)
+	//            "CYLINDRICAL" : projected around Z axis, with angle for U and Z [-0.5 ~ +0.5] for V
+	//            "SPHERICAL"   : projected around the origin, with longitude for U and latitude for V
+	//   'projector' : transformation from MODEL space to PROJECTOR space (can be 'nil' for identity),
+	//            which can be used to move, rotate and scale the projection (to fit the geometry in unit size).
+	//   'per_face' : build PER_FACE texture UVs, which can avoid the texture seam of CYLINDRICAL & SPHERICAL.
+	// Note that UV coordinates are clamped into [0 ~ 1] (to be serialized as normalized UINT16), except for
+	// the PER_FACE UVs crossing the seam of CYLINDRICAL & SPHERICAL, which requires REPEAT wrapping of the texture.
+	if projector == nil {
+		projector = geom3d.NewMatrix4()
+	}
+	pverts := make([][3]float32, len(self.verts)) // vertices in PROJECTOR space
+	for i, v := range self.verts {
+		pverts[i] = projector.MultiplyVector3(v)
+	}
+	project := func(p [3]float32) [2]float32 {
+		switch mode {
+		case "CYLINDRICAL":
+			u := math.Atan2(float64(p[1]), float64(p[0]))/(2*math.Pi) + 0.5
+			return [2]float32{float32(u), p[2] + 0.5}
+		case "SPHERICAL":
+			r := geom3d.Length(p)
+			if r == 0 {
+				return [2]float32{0.5, 0.5}
+			}
+			u := math.Atan2(float64(p[1]), float64(p[0]))/(2*math.Pi) + 0.5
+			v := math.Asin(float64(p[2]/r))/math.Pi + 0.5
+			return [2]float32{float32(u), float32(v)}
+		default: // "PLANAR"
+			return [2]float32{p[0] + 0.5, p[1] + 0.5}
+		}
+	}
+	switch mode {
+	case "PLANAR", "CYLINDRICAL", "SPHERICAL":
+	case "BOX":
+		per_face = true
+	default:
+		fmt.Printf("Invalid mode '%s' for BuildTextureUVsByProjection()\n", mode)
+		return self
+	}
+	if per_face {
+		self.tuvs = make([][]float32, len(self.faces))
+		for fidx, face := range self.faces {
+			tuv := make([]float32, len(face)*2)
+			if mode == "BOX" {
+				n := get_face_normal_of(pverts, face)
+				ax, ay, az := math.Abs(float64(n[0])), math.Abs(float64(n[1])), math.Abs(float64(n[2]))
+				for i, vidx := range face {
+					p := pverts[vidx]
+					var uv [2]float32
+					if az >= ax && az >= ay { // XY plane
+						uv = [2]float32{p[0] * sign_of(n[2]), p[1]}
+					} else if ax >= ay { // YZ plane
+						uv = [2]float32{p[1] * sign_of(n[0]), p[2]}
+					} else { // ZX plane
+						uv = [2]float32{-p[0] * sign_of(n[1]), p[2]}
+					}
+					tuv[i*2+0], tuv[i*2+1] = uv[0]+0.5, uv[1]+0.5
+				}
+			} else {
+				for i, vidx := range face {
+					uv := project(pverts[vidx])
+					tuv[i*2+0], tuv[i*2+1] = uv[0], uv[1]
+				}
+				if (mode == "CYLINDRICAL" || mode == "SPHERICAL") && fix_texture_seam_of_face(tuv) {
+					self.tuvs[fidx] = tuv // U over 1.0 (for REPEAT wrapping) is kept without clamping
+					continue
+				}
+			}
+			self.tuvs[fidx] = clamp_texture_uvs(tuv)
+		}
+	} else {
+		self.tuvs = make([][]float32, len(self.verts))
+		for vidx, p := range pverts {
+			uv := project(p)
+			self.tuvs[vidx] = clamp_texture_uvs([]float32{uv[0], uv[1]})
+		}
+	}
+	self.tangs = [][4]float32{}
+	self.Clear(false, true, true) // data buffers should be rebuilt with the new texture UVs
+	return self
+}
+
+func fix_texture_seam_of_face(tuv []float32) bool {
+	// If a face crosses the seam (U wrapping around from 1 to 0), then move small U values over 1,
+	// which requires REPEAT wrapping of the texture (returns true if the face was fixed).
+	umin, umax := float32(1), float32(0)
+	for i := 0; i < len(tuv); i += 2 {
+		umin = float32(math.Min(float64(umin), float64(tuv[i])))
+		umax = float32(math.Max(float64(umax), float64(tuv[i])))
+	}
+	if umax-umin > 0.5 {
+		for i := 0; i < len(tuv); i += 2 {
+			if tuv[i] < 0.5 {
+				tuv[i] += 1.0
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func clamp_texture_uvs(tuv []float32) []float32 {
+	for i := 0; i < len(tuv); i++ {
+		tuv[i] = float32(math.Max(0, math.Min(float64(tuv[i]), 1)))
+	}
+	return tuv
+}
+
+func get_face_normal_of(verts [][3]float32, face []uint32) [3]float32 {
+	// Newell's method, which works for any (non-triangular) polygon
+	n := [3]float32{0, 0, 0}
+	for i := 0; i < len(face); i++ {
+		a, b := verts[face[i]], verts[face[(i+1)%len(face)]]
+		n[0] += (a[1] - b[1]) * (a[2] + b[2])
+		n[1] += (a[2] - b[2]) * (a[0] + b[0])
+		n[2] += (a[0] - b[0]) * (a[1] + b[1])
+	}
+	if geom3d.Length(n) == 0 {
+		return n
+	}
+	return geom3d.Normalize(n)
+}
+
+func sign_of(v float32) float32 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+// ----------------------------------------------------------------------------
+// Texture UV Unwrapping (chart-based)
+// ----------------------------------------------------------------------------
+
+func (self *Geometry) BuildTextureUVsByUnwrapping(max_angle_in_degree float32, margin float32) *Geometry {
+	// Build texture UV coordinates for an arbitrary mesh, by
+	//   (1) grouping neighboring faces into charts, as long as their normals are within 'max_angle_in_degree',
+	//   (2) projecting each chart onto the plane of its average normal,
+	//   (3) packing all the charts into the unit square, keeping their relative sizes, and
+	//   (4) building PER_VERT UVs if every vertex belongs to a single chart, or PER_FACE UVs otherwise
+	//       (since a vertex on the boundary of charts needs different UVs for each of them).
+	//   'margin' is the gap between charts, relative to the unit square (like 0.01).
+	// The result is deterministic for the same geometry (faces are visited in the order of their index).
+	cos_max := float32(math.Cos(float64(max_angle_in_degree) * InRadian))
+	face_normals := make([][3]float32, len(self.faces))
+	edge_faces := map[[2]uint32][]int{} // faces sharing each edge
+	for fidx, face := range self.faces {
+		face_normals[fidx] = get_face_normal_of(self.verts, face)
+		for i := 0; i < len(face); i++ {
+			key := get_edge_key(face[i], face[(i+1)%len(face)])
+			edge_faces[key] = append(edge_faces[key], fidx)
+		}
+	}
+	// (1) grow charts from seed faces
+	chart_of_face := make([]int, len(self.faces))
+	for i := range chart_of_face {
+		chart_of_face[i] = -1
+	}
+	charts := [][]int{}
+	for seed := 0; seed < len(self.faces); seed++ {
+		if chart_of_face[seed] >= 0 {
+			continue
+		}
+		cidx := len(charts)
+		chart, queue := []int{}, []int{seed}
+		chart_of_face[seed] = cidx
+		for len(queue) > 0 {
+			fidx := queue[0]
+			queue = queue[1:]
+			chart = append(chart, fidx)
+			face := self.faces[fidx]
+			for i := 0; i < len(face); i++ {
+				for _, nidx := range edge_faces[get_edge_key(face[i], face[(i+1)%len(face)])] {
+					if chart_of_face[nidx] < 0 && geom3d.DotAB(face_normals[seed], face_normals[nidx]) >= cos_max {
+						chart_of_face[nidx] = cidx
+						queue = append(queue, nidx)
+					}
+				}
+			}
+		}
+		charts = append(charts, chart)
+	}
+	// (2) project each chart onto its own plane
+	type chart_info struct {
+		index  int           // index of the chart
+		tuvs   [][]float32   // projected (unscaled) UVs of each face in the chart
+		bbox   [2][2]float32 // bounding box of the projected UVs
+		offset [2]float32    // position of the chart after packing
+	}
+	infos := make([]*chart_info, len(charts))
+	for cidx, chart := range charts {
+		normal := [3]float32{0, 0, 0}
+		for _, fidx := range chart {
+			normal = geom3d.AddAB(normal, face_normals[fidx])
+		}
+		if geom3d.Length(normal) == 0 {
+			normal = face_normals[chart[0]]
+		}
+		if geom3d.Length(normal) == 0 {
+			normal = [3]float32{0, 0, 1}
+		}
+		normal = geom3d.Normalize(normal)
+		uaxis := get_any_perpendicular(normal)
+		vaxis := geom3d.CrossAB(normal, uaxis)
+		info := &chart_info{index: cidx, tuvs: make([][]float32, len(chart))}
+		info.bbox = [2][2]float32{{+3.4e38, +3.4e38}, {-3.4e38, -3.4e38}}
+		for k, fidx := range chart {
+			face := self.faces[fidx]
+			info.tuvs[k] = make([]float32, len(face)*2)
+			for i, vidx := range face {
+				u, v := geom3d.DotAB(self.verts[vidx], uaxis), geom3d.DotAB(self.verts[vidx], vaxis)
+				info.tuvs[k][i*2+0], info.tuvs[k][i*2+1] = u, v
+				info.bbox[0][0] = float32(math.Min(float64(info.bbox[0][0]), float64(u)))
+				info.bbox[0][1] = float32(math.Min(float64(info.bbox[0][1]), float64(v)))
+				info.bbox[1][0] = float32(math.Max(float64(info.bbox[1][0]), float64(u)))
+				info.bbox[1][1] = float32(math.Max(float64(info.bbox[1][1]), float64(v)))
+			}
+		}
+		infos[cidx] = info
+	}
+	// (3) pack the charts into shelves, with the tallest charts first
+	order := make([]*chart_info, len(infos))
+	copy(order, infos)
+	sort.SliceStable(order, func(i, j int) bool {
+		hi, hj := order[i].bbox[1][1]-order[i].bbox[0][1], order[j].bbox[1][1]-order[j].bbox[0][1]
+		return hi > hj
+	})
+	total_area, max_width := float32(0), float32(0)
+	for _, info := range infos {
+		w, h := info.bbox[1][0]-info.bbox[0][0], info.bbox[1][1]-info.bbox[0][1]
+		total_area += w * h
+		max_width = float32(math.Max(float64(max_width), float64(w)))
+	}
+	shelf_width := float32(math.Max(math.Sqrt(float64(total_area)), float64(max_width)))
+	gap := margin * shelf_width
+	x, y, shelf_height, used_width := gap, gap, float32(0), float32(0)
+	for _, info := range order {
+		w, h := info.bbox[1][0]-info.bbox[0][0], info.bbox[1][1]-info.bbox[0][1]
+		if x > gap && x+w+gap > shelf_width+2*gap { // start a new shelf
+			x, y, shelf_height = gap, y+shelf_height+gap, 0
+		}
+		info.offset = [2]float32{x - info.bbox[0][0], y - info.bbox[0][1]}
+		x += w + gap
+		used_width = float32(math.Max(float64(used_width), float64(x)))
+		shelf_height = float32(math.Max(float64(shelf_height), float64(h)))
+	}
+	scale := float32(math.Max(float64(used_width), float64(y+shelf_height+gap)))
+	if scale == 0 {
+		scale = 1
+	}
+	face_tuvs := make([][]float32, len(self.faces))
+	for cidx, chart := range charts {
+		info := infos[cidx]
+		for k, fidx := range chart {
+			tuv := info.tuvs[k]
+			for i := 0; i < len(tuv); i += 2 {
+				tuv[i+0] = (tuv[i+0] + info.offset[0]) / scale
+				tuv[i+1] = (tuv[i+1] + info.offset[1]) / scale
+			}
+			face_tuvs[fidx] = clamp_texture_uvs(tuv)
+		}
+	}
+	// (4) use PER_VERT UVs, if no vertex is split by the chart boundaries
+	chart_of_vert := make([]int, len(self.verts))
+	for i := range chart_of_vert {
+		chart_of_vert[i] = -1
+	}
+	per_vert := true
+	for fidx, face := range self.faces {
+		for _, vidx := range face {
+			if chart_of_vert[vidx] >= 0 && chart_of_vert[vidx] != chart_of_face[fidx] {
+				per_vert = false
+			}
+			chart_of_vert[vidx] = chart_of_face[fidx]
+		}
+	}
+	if per_vert && len(self.faces) > 0 {
+		self.tuvs = make([][]float32, len(self.verts))
+		for vidx := range self.tuvs {
+			self.tuvs[vidx] = []float32{0, 0} // vertices not used by any face
+		}
+		for fidx, face := range self.faces {
+			for i, vidx := range face {
+				self.tuvs[vidx] = []float32{face_tuvs[fidx][i*2+0], face_tuvs[fidx][i*2+1]}
+			}
+		}
+	} else {
+		self.tuvs = face_tuvs
+	}
+	self.tangs = [][4]float32{}
+	self.Clear(false, true, true) // data buffers should be rebuilt with the new texture UVs
+	return self
+}
+
+func get_edge_key(a uint32, b uint32) [2]uint32 {
+	if a < b {
+		return [2]uint32{a, b}
+	}
+	return [2]uint32{b, a}
+}
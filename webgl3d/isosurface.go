@@ -0,0 +1,243 @@
+package webgl3d
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go4orward/gowebgl/geom3d"
+)
+
+// ----------------------------------------------------------------------------
+// Scalar Volume
+// ----------------------------------------------------------------------------
+
+type ScalarVolume struct {
+	dims    [3]int     // number of samples along X, Y and Z axis
+	spacing [3]float32 // distance between samples along X, Y and Z axis
+	origin  [3]float32 // position of the first sample
+	values  []float32  // samples, with X varying fastest : values[(z*ny+y)*nx+x]
+}
+
+func NewScalarVolume(dims [3]int, spacing [3]float32, values []float32) *ScalarVolume {
+	if len(values) != dims[0]*dims[1]*dims[2] {
+		fmt.Printf("Invalid number of values (%d) for ScalarVolume of %v\n", len(values), dims)
+		return nil
+	}
+	volume := ScalarVolume{dims: dims, spacing: spacing, origin: [3]float32{0, 0, 0}, values: values}
+	return &volume
+}
+
+func (self *ScalarVolume) SetOrigin(origin [3]float32) *ScalarVolume {
+	self.origin = origin
+	return self
+}
+
+func (self *ScalarVolume) GetRange() [2]float32 {
+	vrange := [2]float32{+3.4e38, -3.4e38}
+	for _, v := range self.values {
+		vrange[0] = float32(math.Min(float64(vrange[0]), float64(v)))
+		vrange[1] = float32(math.Max(float64(vrange[1]), float64(v)))
+	}
+	return vrange
+}
+
+func (self *ScalarVolume) GetValue(x int, y int, z int) float32 {
+	return self.values[(z*self.dims[1]+y)*self.dims[0]+x]
+}
+
+func (self *ScalarVolume) GetPosition(x int, y int, z int) [3]float32 {
+	return [3]float32{
+		self.origin[0] + float32(x)*self.spacing[0],
+		self.origin[1] + float32(y)*self.spacing[1],
+		self.origin[2] + float32(z)*self.spacing[2]}
+}
+
+func (self *ScalarVolume) GetGradient(x int, y int, z int) [3]float32 {
+	// gradient at the sample, using central differences (one-sided differences on the boundary)
+	idx := [3]int{x, y, z}
+	gradient := [3]float32{0, 0, 0}
+	for axis := 0; axis < 3; axis++ {
+		lo, hi := idx, idx
+		if lo[axis] > 0 {
+			lo[axis]--
+		}
+		if hi[axis] < self.dims[axis]-1 {
+			hi[axis]++
+		}
+		if hi[axis] > lo[axis] {
+			dv := self.GetValue(hi[0], hi[1], hi[2]) - self.GetValue(lo[0], lo[1], lo[2])
+			gradient[axis] = dv / (float32(hi[axis]-lo[axis]) * self.spacing[axis])
+		}
+	}
+	return gradient
+}
+
+func (self *ScalarVolume) GetValueAt(p [3]float32) float32 {
+	// trilinear interpolation of the value at position 'p' (clamped into the volume)
+	var i [3]int
+	var f [3]float32
+	for axis := 0; axis < 3; axis++ {
+		t := float64((p[axis] - self.origin[axis]) / self.spacing[axis])
+		t = math.Max(0, math.Min(t, float64(self.dims[axis]-1)))
+		i[axis] = int(math.Min(math.Floor(t), float64(self.dims[axis]-2)))
+		if i[axis] < 0 {
+			i[axis] = 0
+		}
+		f[axis] = float32(t) - float32(i[axis])
+	}
+	value := float32(0)
+	for c := 0; c < 8; c++ {
+		cx, cy, cz := c&1, (c>>1)&1, (c>>2)&1
+		x, y, z := i[0]+cx, i[1]+cy, i[2]+cz
+		if x >= self.dims[0] || y >= self.dims[1] || z >= self.dims[2] {
+			continue // (volume with a single sample along the axis)
+		}
+		w := lerp_weight(f[0], cx) * lerp_weight(f[1], cy) * lerp_weight(f[2], cz)
+		value += w * self.GetValue(x, y, z)
+	}
+	return value
+}
+
+func lerp_weight(f float32, c int) float32 {
+	if c == 0 {
+		return 1 - f
+	}
+	return f
+}
+
+// ----------------------------------------------------------------------------
+// Isosurface (Marching Cubes)
+// ----------------------------------------------------------------------------
+
+// Each cube cell is split into 6 tetrahedra sharing its main diagonal (Kuhn triangulation), and
+// each tetrahedron is polygonized separately. Since the split is the same for all the cells,
+// the surface is watertight, and the ambiguous cases of the classic 256-case table are avoided.
+var isosurface_cell_tetrahedra = [6][4]int{ // cube corners (bit0:X, bit1:Y, bit2:Z) of each tetrahedron
+	{0, 1, 3, 7}, {0, 1, 5, 7}, {0, 2, 3, 7}, {0, 2, 6, 7}, {0, 4, 5, 7}, {0, 4, 6, 7}}
+
+func NewGeometry_Isosurface(volume *ScalarVolume, iso_value float32) *Geometry {
+	// Build the isosurface of 'iso_value', with PER_VERT normal vectors interpolated from the gradients.
+	// Vertices on the same grid edge are welded, and normal vectors point outward (toward smaller values).
+	// To color the surface with another scalar field, use BuildTextureUVsByScalarVolume() afterwards.
+	geometry := NewGeometry()
+	if volume == nil {
+		return geometry
+	}
+	nx, ny, nz := volume.dims[0], volume.dims[1], volume.dims[2]
+	vindex := map[[2]int]uint32{} // welded vertex for each grid edge (pair of sample indices)
+	get_vertex := func(a [3]int, b [3]int) uint32 {
+		ia, ib := (a[2]*ny+a[1])*nx+a[0], (b[2]*ny+b[1])*nx+b[0]
+		va, vb := volume.values[ia], volume.values[ib]
+		t := float32(0.5)
+		if va != vb {
+			t = (iso_value - va) / (vb - va)
+		}
+		key := [2]int{ia, ib}
+		if t <= 0 { // the vertex is exactly on the sample
+			t, key = 0, [2]int{ia, ia}
+		} else if t >= 1 {
+			t, key = 1, [2]int{ib, ib}
+		} else if ia > ib {
+			key = [2]int{ib, ia}
+		}
+		if vidx, ok := vindex[key]; ok {
+			return vidx
+		}
+		pa, pb := volume.GetPosition(a[0], a[1], a[2]), volume.GetPosition(b[0], b[1], b[2])
+		ga, gb := volume.GetGradient(a[0], a[1], a[2]), volume.GetGradient(b[0], b[1], b[2])
+		vidx := geometry.AddVertex(geom3d.AddAB(pa, geom3d.Scale(geom3d.SubAB(pb, pa), t)))
+		normal := geom3d.Scale(geom3d.AddAB(ga, geom3d.Scale(geom3d.SubAB(gb, ga), t)), -1)
+		if geom3d.Length(normal) > 0 {
+			normal = geom3d.Normalize(normal)
+		}
+		geometry.AddNormal(normal)
+		vindex[key] = vidx
+		return vidx
+	}
+	add_triangle := func(a uint32, b uint32, c uint32, outward [3]float32) {
+		if a == b || b == c || c == a {
+			return // degenerate triangle (on the sample)
+		}
+		va, vb, vc := geometry.verts[a], geometry.verts[b], geometry.verts[c]
+		n := geom3d.CrossAB(geom3d.SubAB(vb, va), geom3d.SubAB(vc, va))
+		if geom3d.DotAB(n, outward) < 0 {
+			b, c = c, b
+		}
+		geometry.AddFace([]uint32{a, b, c})
+	}
+	for z := 0; z < nz-1; z++ {
+		for y := 0; y < ny-1; y++ {
+			for x := 0; x < nx-1; x++ {
+				for _, tet := range isosurface_cell_tetrahedra {
+					var corners [4][3]int
+					inside, outside := []int{}, []int{}
+					for k, c := range tet {
+						corners[k] = [3]int{x + c&1, y + (c>>1)&1, z + (c>>2)&1}
+						if volume.GetValue(corners[k][0], corners[k][1], corners[k][2]) >= iso_value {
+							inside = append(inside, k)
+						} else {
+							outside = append(outside, k)
+						}
+					}
+					if len(inside) == 0 || len(outside) == 0 {
+						continue
+					}
+					// direction from inside to outside, for the orientation of the triangles
+					cin, cout := [3]float32{0, 0, 0}, [3]float32{0, 0, 0}
+					for _, k := range inside {
+						cin = geom3d.AddAB(cin, volume.GetPosition(corners[k][0], corners[k][1], corners[k][2]))
+					}
+					for _, k := range outside {
+						cout = geom3d.AddAB(cout, volume.GetPosition(corners[k][0], corners[k][1], corners[k][2]))
+					}
+					outward := geom3d.SubAB(geom3d.Scale(cout, 1/float32(len(outside))), geom3d.Scale(cin, 1/float32(len(inside))))
+					switch len(inside) {
+					case 1, 3: // one corner is separated from the others (single triangle)
+						single, others := inside, outside
+						if len(inside) == 3 {
+							single, others = outside, inside
+						}
+						s := corners[single[0]]
+						v0 := get_vertex(s, corners[others[0]])
+						v1 := get_vertex(s, corners[others[1]])
+						v2 := get_vertex(s, corners[others[2]])
+						add_triangle(v0, v1, v2, outward)
+					case 2: // two corners are separated from the other two (quad as two triangles)
+						i0, i1, o0, o1 := corners[inside[0]], corners[inside[1]], corners[outside[0]], corners[outside[1]]
+						v00, v01 := get_vertex(i0, o0), get_vertex(i0, o1)
+						v10, v11 := get_vertex(i1, o0), get_vertex(i1, o1)
+						add_triangle(v00, v01, v11, outward)
+						add_triangle(v00, v11, v10, outward)
+					}
+				}
+			}
+		}
+	}
+	for vidx, normal := range geometry.norms { // (zero gradient, on the flat plateau of values)
+		if geom3d.Length(normal) == 0 {
+			geometry.norms[vidx] = geometry.GetVertexNormal(vidx)
+		}
+	}
+	return geometry
+}
+
+func (self *Geometry) BuildTextureUVsByScalarVolume(volume *ScalarVolume, value_range [2]float32) *Geometry {
+	// Build PER_VERT texture UV coordinates (U : normalized value in 'value_range', V : 0.5),
+	// so that the geometry can be colored with a 1D colormap texture (like the isosurface colored by temperature).
+	if volume == nil {
+		fmt.Printf("Failed to BuildTextureUVsByScalarVolume() : invalid (nil) volume\n")
+		return self
+	}
+	self.tuvs = make([][]float32, len(self.verts))
+	vsize := value_range[1] - value_range[0]
+	for vidx, v := range self.verts {
+		u := float32(0.5)
+		if vsize != 0 {
+			u = (volume.GetValueAt(v) - value_range[0]) / vsize
+		}
+		self.tuvs[vidx] = clamp_texture_uvs([]float32{u, 0.5})
+	}
+	self.tangs = [][4]float32{}
+	self.Clear(false, true, true) // data buffers should be rebuilt with the new texture UVs
+	return self
+}
@@ -1,9 +1,379 @@
 package webgl3d
 
+import (
+	"fmt"
+	"math"
+
+	"github.com/go4orward/gowebgl/geom2d"
+	"github.com/go4orward/gowebgl/geom3d"
+	"github.com/go4orward/gowebgl/wcommon"
+)
+
+// Maximum number of lights (of each type) fed to shaders, which should be the size of uniform arrays.
+const (
+	MAX_DIRECTIONAL_LIGHTS = 4
+	MAX_POINT_LIGHTS       = 4
+	MAX_SPOT_LIGHTS        = 4
+)
+
 type Lighting struct {
+	ambient     [3]float32       // ambient light color (multiplied by its intensity)
+	lights      []*Light         // directional / point / spot lights
+	environment *wcommon.Texture // OPTIONAL, environment map for image-based lighting (equirectangular)
+	env_scale   float32          // intensity of image-based lighting
 }
 
 func NewLighting() *Lighting {
+	// Lighting without any light (call AddLight() to add lights)
 	var lighting Lighting
+	lighting.lights = make([]*Light, 0)
 	return &lighting
 }
+
+func NewLighting_Default() *Lighting {
+	// Lighting with a single headlight (white directional light following the camera), and no ambient light
+	return NewLighting().AddLight(NewLight_Headlight("#ffffff", 1.0))
+}
+
+func (self *Lighting) ShowInfo() {
+	fmt.Printf("Lighting with %d lights (ambient=%v)\n", len(self.lights), self.ambient)
+	for _, light := range self.lights {
+		fmt.Printf("  ")
+		light.ShowInfo()
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Ambient Light
+// ----------------------------------------------------------------------------
+
+func (self *Lighting) SetAmbient(color string, intensity float32) *Lighting {
+	rgba := wcommon.ParseHexColor(color)
+	self.ambient = [3]float32{rgba[0] * intensity, rgba[1] * intensity, rgba[2] * intensity}
+	return self
+}
+
+func (self *Lighting) GetAmbient() [3]float32 {
+	return self.ambient
+}
+
+// ----------------------------------------------------------------------------
+// Environment Map (for Image-Based Lighting)
+// ----------------------------------------------------------------------------
+
+func (self *Lighting) SetEnvironment(texture *wcommon.Texture, intensity float32) *Lighting {
+	// Environment map for image-based lighting (of PBR shaders), in equirectangular (longitude-latitude) projection
+	// with +Z up in WORLD space. POWER-OF-2 size is recommended, since its mipmaps are used for rough surfaces.
+	self.environment = texture
+	self.env_scale = intensity
+	return self
+}
+
+func (self *Lighting) GetEnvironment() (*wcommon.Texture, float32) {
+	return self.environment, self.env_scale
+}
+
+// ----------------------------------------------------------------------------
+// Managing Lights
+// ----------------------------------------------------------------------------
+
+func (self *Lighting) AddLight(light ...*Light) *Lighting {
+	for i := 0; i < len(light); i++ {
+		self.lights = append(self.lights, light[i])
+	}
+	return self
+}
+
+func (self *Lighting) RemoveLight(light *Light) *Lighting {
+	for i, l := range self.lights {
+		if l == light {
+			self.lights = append(self.lights[:i], self.lights[i+1:]...)
+			break
+		}
+	}
+	return self
+}
+
+func (self *Lighting) ClearLights() *Lighting {
+	self.lights = make([]*Light, 0)
+	return self
+}
+
+func (self *Lighting) GetLights() []*Light {
+	return self.lights
+}
+
+// ----------------------------------------------------------------------------
+// Uniform Values (for "lighting.*" autobindings)
+// ----------------------------------------------------------------------------
+
+func (self *Lighting) get_uniform_values(view *geom3d.Matrix4) map[string][]float32 {
+	// Collect the uniform values of all the enabled lights in CAMERA space, with the keys of autobinding.
+	// Note that directions are given as the direction TOWARD the light ('ddir'), except for spot lights ('sdir').
+	values := map[string][]float32{}
+	ddir, dcolor := []float32{}, []float32{}
+	ppos, pcolor, patten := []float32{}, []float32{}, []float32{}
+	spos, sdir, scolor, satten, scone := []float32{}, []float32{}, []float32{}, []float32{}, []float32{}
+	dcount, pcount, scount := 0, 0, 0
+	for _, light := range self.lights {
+		if !light.Enabled {
+			continue
+		}
+		position, direction := light.get_camera_space_pose(view)
+		color := [3]float32{light.Color[0] * light.Intensity, light.Color[1] * light.Intensity, light.Color[2] * light.Intensity}
+		switch light.Type {
+		case "DIRECTIONAL":
+			if dcount < MAX_DIRECTIONAL_LIGHTS {
+				ddir = append(ddir, -direction[0], -direction[1], -direction[2])
+				dcolor = append(dcolor, color[:]...)
+				dcount++
+			}
+		case "POINT":
+			if pcount < MAX_POINT_LIGHTS {
+				ppos = append(ppos, position[:]...)
+				pcolor = append(pcolor, color[:]...)
+				patten = append(patten, light.Attenuation[:]...)
+				pcount++
+			}
+		case "SPOT":
+			if scount < MAX_SPOT_LIGHTS {
+				spos = append(spos, position[:]...)
+				sdir = append(sdir, direction[:]...)
+				scolor = append(scolor, color[:]...)
+				satten = append(satten, light.Attenuation[:]...)
+				inner := float32(math.Cos(float64(light.SpotAngles[0]) * math.Pi / 180.0))
+				outer := float32(math.Cos(float64(light.SpotAngles[1]) * math.Pi / 180.0))
+				scone = append(scone, inner, outer)
+				scount++
+			}
+		}
+	}
+	values["lighting.ambient"] = self.ambient[:]
+	values["lighting.envintensity"] = []float32{0}
+	if self.environment != nil {
+		values["lighting.envintensity"] = []float32{self.env_scale}
+	}
+	values["lighting.dcount"] = []float32{float32(dcount)}
+	values["lighting.ddir"] = pad_float32_slice(ddir, MAX_DIRECTIONAL_LIGHTS*3)
+	values["lighting.dcolor"] = pad_float32_slice(dcolor, MAX_DIRECTIONAL_LIGHTS*3)
+	values["lighting.pcount"] = []float32{float32(pcount)}
+	values["lighting.ppos"] = pad_float32_slice(ppos, MAX_POINT_LIGHTS*3)
+	values["lighting.pcolor"] = pad_float32_slice(pcolor, MAX_POINT_LIGHTS*3)
+	values["lighting.patten"] = pad_float32_slice(patten, MAX_POINT_LIGHTS*3)
+	values["lighting.scount"] = []float32{float32(scount)}
+	values["lighting.spos"] = pad_float32_slice(spos, MAX_SPOT_LIGHTS*3)
+	values["lighting.sdir"] = pad_float32_slice(sdir, MAX_SPOT_LIGHTS*3)
+	values["lighting.scolor"] = pad_float32_slice(scolor, MAX_SPOT_LIGHTS*3)
+	values["lighting.satten"] = pad_float32_slice(satten, MAX_SPOT_LIGHTS*3)
+	values["lighting.scone"] = pad_float32_slice(scone, MAX_SPOT_LIGHTS*2)
+	// single directional light (the first one) with ambient light, for simple shaders
+	d, c, a := values["lighting.ddir"], values["lighting.dcolor"], self.ambient
+	dlight := geom2d.NewMatrix3().Set(d[0], c[0], a[0], d[1], c[1], a[1], d[2], c[2], a[2])
+	values["lighting.dlight"] = (*dlight.GetElements())[:] // ([0]:direction, [1]:color, [2]:ambient) COLUMN-MAJOR
+	return values
+}
+
+// shadow_caster is a light casting shadows, with its pose in CAMERA space
+type shadow_caster struct {
+	light     *Light     //
+	code      int        // index of the light in shaders (directional : 0 ~ 3, spot : 4 ~ 7)
+	position  [3]float32 // position in CAMERA space
+	direction [3]float32 // direction (in which the light travels) in CAMERA space
+}
+
+func (self *Lighting) get_shadow_casters(view *geom3d.Matrix4) []shadow_caster {
+	// Collect the enabled lights casting shadows, with the same indices as get_uniform_values()
+	casters := []shadow_caster{}
+	dcount, scount := 0, 0
+	for _, light := range self.lights {
+		if !light.Enabled {
+			continue
+		}
+		code := -1
+		switch light.Type {
+		case "DIRECTIONAL":
+			if dcount < MAX_DIRECTIONAL_LIGHTS {
+				code = dcount
+			}
+			dcount++
+		case "SPOT":
+			if scount < MAX_SPOT_LIGHTS {
+				code = MAX_DIRECTIONAL_LIGHTS + scount
+			}
+			scount++
+		}
+		if code >= 0 && light.CastShadow {
+			position, direction := light.get_camera_space_pose(view)
+			casters = append(casters, shadow_caster{light: light, code: code, position: position, direction: direction})
+		}
+	}
+	return casters
+}
+
+func pad_float32_slice(values []float32, length int) []float32 {
+	padded := make([]float32, length)
+	copy(padded, values)
+	return padded
+}
+
+// ----------------------------------------------------------------------------
+// Light
+// ----------------------------------------------------------------------------
+
+type Light struct {
+	Type          string     // "DIRECTIONAL", "POINT" or "SPOT"
+	Color         [3]float32 // color of the light
+	Intensity     float32    // intensity (multiplied to the color)
+	Position      [3]float32 // position of POINT & SPOT lights
+	Direction     [3]float32 // direction (in which the light travels) of DIRECTIONAL & SPOT lights
+	Attenuation   [3]float32 // (constant, linear, quadratic) attenuation of POINT & SPOT lights with distance
+	SpotAngles    [2]float32 // inner & outer cone angles of SPOT light (in degree, from its direction)
+	InCameraSpace bool       // if true, the light is given in CAMERA space (following the camera, like headlight)
+	Enabled       bool       // (default is true)
+	// shadows (DIRECTIONAL & SPOT lights only)
+	CastShadow     bool       // if true, the light casts shadows (default is false)
+	ShadowBias     [2]float32 // (depth bias, normal offset) to avoid shadow acne, in (depth range, texels)
+	ShadowPCF      int        // radius of PCF filtering (0:hard, 1:3x3, 2:5x5 samples)
+	ShadowCascades int        // number of cascaded shadow maps for DIRECTIONAL light (1 ~ MAX_SHADOW_MAPS)
+	ShadowDistance float32    // distance covered by shadows (from the camera for DIRECTIONAL, from SPOT light)
+}
+
+func NewLight_Directional(color string, intensity float32, direction [3]float32) *Light {
+	// Directional light (like sunlight), travelling in the given direction (in WORLD space)
+	light := Light{Type: "DIRECTIONAL", Intensity: intensity, Direction: geom3d.Normalize(direction), Enabled: true}
+	light.SetShadowBias(0.001, 1.0).SetShadowPCF(1).SetShadowCascades(1, 100)
+	return light.SetColor(color).SetAttenuation(1, 0, 0)
+}
+
+func NewLight_Headlight(color string, intensity float32) *Light {
+	// Directional light following the camera, travelling in the viewing direction (-Z in CAMERA space)
+	light := NewLight_Directional(color, intensity, [3]float32{0, 0, -1})
+	light.InCameraSpace = true
+	return light
+}
+
+func NewLight_Point(color string, intensity float32, position [3]float32) *Light {
+	// Point light at the given position (in WORLD space), without attenuation by default
+	light := Light{Type: "POINT", Intensity: intensity, Position: position, Enabled: true}
+	return light.SetColor(color).SetAttenuation(1, 0, 0)
+}
+
+func NewLight_Spot(color string, intensity float32, position [3]float32, direction [3]float32, inner_angle float32, outer_angle float32) *Light {
+	// Spot light at the given position (in WORLD space) with its cone, between the inner angle (full intensity)
+	// and the outer angle (no intensity), without attenuation by default
+	light := Light{Type: "SPOT", Intensity: intensity, Position: position, Direction: geom3d.Normalize(direction), Enabled: true}
+	light.SpotAngles = [2]float32{inner_angle, outer_angle}
+	light.SetShadowBias(0.001, 1.0).SetShadowPCF(1).SetShadowCascades(1, 100)
+	return light.SetColor(color).SetAttenuation(1, 0, 0)
+}
+
+func (self *Light) ShowInfo() {
+	fmt.Printf("Light %-11s : color=%v intensity=%v pos=%v dir=%v camera_space=%t shadow=%t enabled=%t\n",
+		self.Type, self.Color, self.Intensity, self.Position, self.Direction, self.InCameraSpace, self.CastShadow, self.Enabled)
+}
+
+func (self *Light) SetColor(color string) *Light {
+	rgba := wcommon.ParseHexColor(color)
+	self.Color = [3]float32{rgba[0], rgba[1], rgba[2]}
+	return self
+}
+
+func (self *Light) SetIntensity(intensity float32) *Light {
+	self.Intensity = intensity
+	return self
+}
+
+func (self *Light) SetAttenuation(constant float32, linear float32, quadratic float32) *Light {
+	// attenuation = 1 / (constant + linear * distance + quadratic * distance^2)
+	self.Attenuation = [3]float32{constant, linear, quadratic}
+	return self
+}
+
+func (self *Light) SetEnabled(enabled bool) *Light {
+	self.Enabled = enabled
+	return self
+}
+
+func (self *Light) get_camera_space_pose(view *geom3d.Matrix4) ([3]float32, [3]float32) {
+	position, direction := self.Position, self.Direction
+	if !self.InCameraSpace {
+		position = view.MultiplyVector3(position)
+		direction = view.MultiplyDirection3(direction)
+	}
+	return position, geom3d.Normalize(direction)
+}
+
+// ----------------------------------------------------------------------------
+// Shadows of Light
+// ----------------------------------------------------------------------------
+
+func (self *Light) SetShadow(cast_shadow bool) *Light {
+	// Let the light cast shadows (only for DIRECTIONAL & SPOT lights, and only on the SceneObjects with
+	// 'CastShadow' & 'ReceiveShadow' flags, rendered with the shaders supporting shadows)
+	if cast_shadow && self.Type == "POINT" {
+		fmt.Printf("Light.SetShadow() failed : shadows of POINT light are not supported\n")
+		return self
+	}
+	self.CastShadow = cast_shadow
+	return self
+}
+
+func (self *Light) SetShadowBias(depth_bias float32, normal_offset float32) *Light {
+	// 'depth_bias'    : bias of depth comparison, in the depth range of the shadow map (0.0 ~ 1.0)
+	// 'normal_offset' : offset of the position along its normal vector, in the texel size of the shadow map
+	self.ShadowBias = [2]float32{depth_bias, normal_offset}
+	return self
+}
+
+func (self *Light) SetShadowPCF(radius int) *Light {
+	// Percentage-closer filtering for soft edges of shadows, with (2*radius+1)^2 samples (radius 0 ~ 2)
+	if radius < 0 || radius > 2 {
+		fmt.Printf("Light.SetShadowPCF() failed : invalid radius %d (0 ~ 2)\n", radius)
+		return self
+	}
+	self.ShadowPCF = radius
+	return self
+}
+
+func (self *Light) SetShadowCascades(count int, distance float32) *Light {
+	// Cascaded shadow maps (for DIRECTIONAL light, like the sun), which split the view frustum
+	// up to 'distance' from the camera, so that the shadows near the camera get more texels.
+	// ('count' is ignored for SPOT light, and 'distance' is the range of the light from its position)
+	if count < 1 || count > MAX_SHADOW_MAPS {
+		fmt.Printf("Light.SetShadowCascades() failed : invalid count %d (1 ~ %d)\n", count, MAX_SHADOW_MAPS)
+		return self
+	}
+	self.ShadowCascades = count
+	self.ShadowDistance = distance
+	return self
+}
+
+// ----------------------------------------------------------------------------
+// Translation & Rotation of Light
+// ----------------------------------------------------------------------------
+
+func (self *Light) SetPosition(xyz [3]float32) *Light {
+	self.Position = xyz
+	return self
+}
+
+func (self *Light) SetDirection(direction [3]float32) *Light {
+	self.Direction = geom3d.Normalize(direction)
+	return self
+}
+
+func (self *Light) Translate(tx float32, ty float32, tz float32) *Light {
+	self.Position = [3]float32{self.Position[0] + tx, self.Position[1] + ty, self.Position[2] + tz}
+	return self
+}
+
+func (self *Light) Rotate(axis [3]float32, angle_in_degree float32) *Light {
+	// Rotate the light (both its position and direction) around the axis through the origin
+	return self.ApplyMatrix4(geom3d.NewMatrix4().SetRotationByAxis(axis, angle_in_degree))
+}
+
+func (self *Light) ApplyMatrix4(m *geom3d.Matrix4) *Light {
+	self.Position = m.MultiplyVector3(self.Position)
+	self.Direction = geom3d.Normalize(m.MultiplyDirection3(self.Direction))
+	return self
+}
@@ -7,19 +7,20 @@ import (
 )
 
 type OverlayMarkerLayer struct {
-	wctx    *wcommon.WebGLContext //
-	Markers []*SceneObject        // list of OverlayMarkers to be rendered (in pixels in CAMERA space)
+	wctx     *wcommon.WebGLContext //
+	renderer *Renderer             // renderer for the markers (keeping its highlight shaders)
+	Markers  []*SceneObject        // list of OverlayMarkers to be rendered (in pixels in CAMERA space)
 }
 
 func NewOverlayMarkerLayer(wctx *wcommon.WebGLContext) *OverlayMarkerLayer {
-	self := OverlayMarkerLayer{wctx: wctx}
+	self := OverlayMarkerLayer{wctx: wctx, renderer: NewRenderer(wctx)}
 	self.Markers = make([]*SceneObject, 0)
 	return &self
 }
 
 func (self *OverlayMarkerLayer) Render(proj *geom3d.Matrix4, vwmd *geom3d.Matrix4) {
 	// 'Overlay' interface function, called by Renderer
-	renderer := NewRenderer(self.wctx)
+	renderer := self.renderer
 	for _, marker := range self.Markers {
 		if marker.poses != nil {
 			renderer.RenderSceneObject(marker, proj, vwmd)
@@ -30,10 +31,30 @@ func (self *OverlayMarkerLayer) Render(proj *geom3d.Matrix4, vwmd *geom3d.Matrix
 	}
 }
 
+func (self *OverlayMarkerLayer) render_with_camera(proj *geom3d.Matrix4, camera *Camera, rte bool) {
+	// Render the markers with their origins (relative to the eye, if 'rte'), called by Renderer instead of Render()
+	for _, marker := range self.Markers {
+		self.renderer.RenderSceneObject(marker, proj, get_marker_viewmodel(marker, camera, rte))
+	}
+}
+
+func get_marker_viewmodel(marker *SceneObject, camera *Camera, rte bool) *geom3d.Matrix4 {
+	// (View * Model) matrix of the marker, shared by rendering and GPU picking
+	if marker.poses != nil {
+		return camera.GetViewModelMatrix(marker.origin, geom3d.NewMatrix4(), rte) // (instance poses in WORLD space)
+	}
+	return camera.GetViewModelMatrix(marker.origin, &marker.modelmatrix, rte)
+}
+
 // ----------------------------------------------------------------------------
 // Managing Markers
 // ----------------------------------------------------------------------------
 
+func (self *OverlayMarkerLayer) GetRenderer() *Renderer {
+	// Renderer of the markers (like for setting its highlight colors)
+	return self.renderer
+}
+
 func (self *OverlayMarkerLayer) AddMarker(marker ...*SceneObject) *OverlayMarkerLayer {
 	for i := 0; i < len(marker); i++ {
 		self.Markers = append(self.Markers, marker[i])
@@ -0,0 +1,301 @@
+package webgl3d
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"syscall/js"
+
+	"github.com/go4orward/gowebgl/geom3d"
+	"github.com/go4orward/gowebgl/wcommon"
+)
+
+// ----------------------------------------------------------------------------
+// Picking SceneObjects (by GPU Color-ID)
+// ----------------------------------------------------------------------------
+
+// GPUPicker renders every SceneObject, instance and overlay marker with a unique ID color
+// into an offscreen framebuffer, and reads back the pixels to find what is under the cursor.
+// It's much faster than ray casting for scenes with a huge number of instances,
+// since the cost of picking does not depend on the number of objects (after rendering).
+// Note that the ID colors are computed in a variant of the original vertex shader,
+// so the same 'instance.pose' attribute bindings can be used without any change.
+// (Fragments discarded by the original fragment shader, like transparent sprites, are not discarded.)
+
+type GPUPicker struct {
+	renderer    *Renderer                                    // renderer for binding uniforms & attributes
+	framebuffer js.Value                                     // offscreen framebuffer
+	texture     js.Value                                     // color attachment (RGBA / UNSIGNED_BYTE)
+	depthbuffer js.Value                                     // depth attachment (DEPTH_COMPONENT16)
+	wh          [2]int                                       // size of the framebuffer
+	shaders     map[*wcommon.Shader]map[bool]*wcommon.Shader // picking variants of shaders (for instanced or not)
+	entries     []gpu_picking_entry                          // ID ranges of the objects rendered last time
+}
+
+type GPUPickHit struct {
+	SceneObject   *SceneObject // picked SceneObject (or OverlayMarker)
+	Path          []int        // indices of the SceneObject (in the scene and its parents), to be used with Scene.Get()
+	InstanceIndex int          // index of the instance pose (-1, if the SceneObject has no poses)
+	Overlay       bool         // true, if it's a marker in OverlayMarkerLayer (with Path of [overlay_index, marker_index])
+}
+
+type gpu_picking_entry struct {
+	sobj    *SceneObject
+	path    []int
+	overlay bool
+	base    uint32 // the first ID of the object (IDs from 'base' to 'base+count-1' belong to it)
+	count   uint32 // number of instances (1, if the object has no poses)
+}
+
+func NewGPUPicker(renderer *Renderer) *GPUPicker {
+	// GPUPicker shares the relative-to-eye option of the renderer
+	picker := GPUPicker{renderer: renderer, wh: [2]int{0, 0}}
+	picker.framebuffer = js.Null()
+	picker.texture = js.Null()
+	picker.depthbuffer = js.Null()
+	picker.shaders = map[*wcommon.Shader]map[bool]*wcommon.Shader{}
+	picker.entries = []gpu_picking_entry{}
+	return &picker
+}
+
+// ----------------------------------------------------------------------------
+// Picking
+// ----------------------------------------------------------------------------
+
+func (self *GPUPicker) PickScene(scene *Scene, camera *Camera, canvasxy [2]int) *GPUPickHit {
+	// Convenience function to render the scene for picking and then pick at the canvas position.
+	// (If the scene and the camera didn't change, then call Pick() only, without rendering again.)
+	if err := self.Render(scene, camera); err != nil {
+		return nil
+	}
+	return self.Pick(canvasxy)
+}
+
+func (self *GPUPicker) Pick(canvasxy [2]int) *GPUPickHit {
+	// Pick the SceneObject (and its instance) at the canvas position, from the last rendering (nil, if not found).
+	hits := self.PickRegion(canvasxy, [2]int{1, 1})
+	if len(hits) == 0 {
+		return nil
+	}
+	return hits[0]
+}
+
+func (self *GPUPicker) PickRegion(canvasxy [2]int, wh [2]int) []*GPUPickHit {
+	// Pick all the SceneObjects (and their instances) visible in the region, from the last rendering.
+	// 'canvasxy' is the top-left corner of the region, and 'wh' is its size in pixels.
+	hits := []*GPUPickHit{}
+	if self.framebuffer.IsNull() {
+		return hits
+	}
+	x0, y0 := max_int(canvasxy[0], 0), max_int(canvasxy[1], 0)
+	x1, y1 := min_int(canvasxy[0]+wh[0], self.wh[0]), min_int(canvasxy[1]+wh[1], self.wh[1])
+	if x1 <= x0 || y1 <= y0 {
+		return hits
+	}
+	context := self.renderer.wctx.GetContext()
+	constants := self.renderer.wctx.GetConstants()
+	w, h := x1-x0, y1-y0
+	pixels := make([]byte, w*h*4)
+	js_pixels := js.Global().Get("Uint8Array").New(len(pixels))
+	context.Call("bindFramebuffer", constants.FRAMEBUFFER, self.framebuffer)
+	context.Call("readPixels", x0, self.wh[1]-y1, w, h, constants.RGBA, constants.UNSIGNED_BYTE, js_pixels) // (origin at lower-left)
+	wcommon.BindRenderTarget(self.renderer.wctx, self.renderer.target)
+	js.CopyBytesToGo(pixels, js_pixels)
+	found := map[uint32]bool{}
+	for i := 0; i < w*h; i++ {
+		id := uint32(pixels[i*4+0]) + uint32(pixels[i*4+1])<<8 + uint32(pixels[i*4+2])<<16
+		if id == 0 || found[id] { // ID 0 is for background
+			continue
+		}
+		found[id] = true
+		if hit := self.get_hit_by_id(id); hit != nil {
+			hits = append(hits, hit)
+		}
+	}
+	return hits
+}
+
+func (self *GPUPicker) get_hit_by_id(id uint32) *GPUPickHit {
+	id = id - 1 // IDs start from 1 (0 is for background)
+	k := sort.Search(len(self.entries), func(i int) bool { return self.entries[i].base+self.entries[i].count > id })
+	if k >= len(self.entries) || id < self.entries[k].base {
+		return nil
+	}
+	e := self.entries[k]
+	instance := -1
+	if e.sobj.poses != nil {
+		instance = int(id - e.base)
+	}
+	return &GPUPickHit{SceneObject: e.sobj, Path: e.path, InstanceIndex: instance, Overlay: e.overlay}
+}
+
+// ----------------------------------------------------------------------------
+// Rendering for Picking
+// ----------------------------------------------------------------------------
+
+func (self *GPUPicker) Render(scene *Scene, camera *Camera) error {
+	// Render all the SceneObjects and OverlayMarkers with their ID colors into the offscreen framebuffer
+	wctx := self.renderer.wctx
+	context := wctx.GetContext()
+	constants := wctx.GetConstants()
+	if err := self.setup_framebuffer(wctx.GetWH()); err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	context.Call("bindFramebuffer", constants.FRAMEBUFFER, self.framebuffer)
+	context.Call("viewport", 0, 0, self.wh[0], self.wh[1])
+	context.Call("clearColor", 0, 0, 0, 0) // ID 0 for background
+	context.Call("clear", constants.COLOR_BUFFER_BIT)
+	context.Call("clear", constants.DEPTH_BUFFER_BIT)
+	self.renderer.state.Invalidate()         // WebGL states might have been changed outside
+	self.renderer.state.SetBlendMode("NONE") // ID colors should not be blended
+	self.entries = self.entries[:0]
+	proj := camera.projection.GetMatrix()
+	for idx, sobj := range scene.objects {
+		vwmd := camera.GetViewModelMatrix(sobj.origin, &sobj.modelmatrix, self.renderer.rte)
+		self.render_scene_object(sobj, proj, vwmd, []int{idx}, false)
+	}
+	for oidx, overlay := range scene.overlays {
+		if layer, ok := overlay.(*OverlayMarkerLayer); ok {
+			for midx, marker := range layer.Markers {
+				vwmd := get_marker_viewmodel(marker, camera, self.renderer.rte)
+				self.render_scene_object(marker, proj, vwmd, []int{oidx, midx}, true)
+			}
+		}
+	}
+	wcommon.BindRenderTarget(wctx, self.renderer.target) // back to the RenderTarget (or the canvas)
+	return nil
+}
+
+func (self *GPUPicker) render_scene_object(sobj *SceneObject, proj *geom3d.Matrix4, vwmd *geom3d.Matrix4, path []int, overlay bool) {
+	if self.renderer.prepare_scene_object_buffers(sobj) == nil {
+		// assign a range of IDs to the object (one for each instance)
+		entry := gpu_picking_entry{sobj: sobj, path: path, overlay: overlay, base: 0, count: 1}
+		if len(self.entries) > 0 {
+			last := self.entries[len(self.entries)-1]
+			entry.base = last.base + last.count
+		}
+		if sobj.poses != nil {
+			entry.count = uint32(sobj.poses.Count)
+		}
+		self.renderer.state.SetDepthTest(sobj.UseDepth)
+		rendered := false
+		for draw_mode, shader := range [4]*wcommon.Shader{nil, sobj.VShader, sobj.EShader, sobj.FShader} {
+			if draw_mode == 0 {
+				continue
+			}
+			if pick_shader := self.get_picking_shader(shader, sobj.poses != nil); pick_shader != nil {
+				pick_shader.GetUniformBindings()["_pick_base"]["value"] = []float32{float32(entry.base)}
+				if self.renderer.render_scene_object_with_shader(sobj, proj, vwmd, draw_mode, pick_shader) == nil {
+					rendered = true
+				}
+			}
+		}
+		if rendered && entry.count > 0 {
+			self.entries = append(self.entries, entry)
+		}
+	}
+	for cidx, child := range sobj.children {
+		child_path := append(append([]int{}, path...), cidx)
+		self.render_scene_object(child, proj, vwmd.MultiplyToTheRight(&child.modelmatrix), child_path, overlay)
+	}
+}
+
+func (self *GPUPicker) setup_framebuffer(wh [2]int) error {
+	// create the offscreen framebuffer, or resize it (if the size of canvas has changed)
+	context := self.renderer.wctx.GetContext()
+	c := self.renderer.wctx.GetConstants()
+	if self.framebuffer.IsNull() {
+		self.framebuffer = context.Call("createFramebuffer")
+		self.texture = context.Call("createTexture")
+		self.depthbuffer = context.Call("createRenderbuffer")
+	} else if self.wh == wh {
+		return nil
+	}
+	self.wh = wh
+	context.Call("bindTexture", c.TEXTURE_2D, self.texture)
+	context.Call("texImage2D", c.TEXTURE_2D, 0, c.RGBA, wh[0], wh[1], 0, c.RGBA, c.UNSIGNED_BYTE, js.Null())
+	context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_S, c.CLAMP_TO_EDGE)
+	context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_T, c.CLAMP_TO_EDGE)
+	context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MIN_FILTER, c.NEAREST)
+	context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MAG_FILTER, c.NEAREST)
+	context.Call("bindRenderbuffer", c.RENDERBUFFER, self.depthbuffer)
+	context.Call("renderbufferStorage", c.RENDERBUFFER, c.DEPTH_COMPONENT16, wh[0], wh[1])
+	context.Call("bindFramebuffer", c.FRAMEBUFFER, self.framebuffer)
+	context.Call("framebufferTexture2D", c.FRAMEBUFFER, c.COLOR_ATTACHMENT0, c.TEXTURE_2D, self.texture, 0)
+	context.Call("framebufferRenderbuffer", c.FRAMEBUFFER, c.DEPTH_ATTACHMENT, c.RENDERBUFFER, self.depthbuffer)
+	status := context.Call("checkFramebufferStatus", c.FRAMEBUFFER)
+	context.Call("bindFramebuffer", c.FRAMEBUFFER, js.Null())
+	context.Call("bindRenderbuffer", c.RENDERBUFFER, js.Null())
+	context.Call("bindTexture", c.TEXTURE_2D, js.Null())
+	if !status.Equal(c.FRAMEBUFFER_COMPLETE) {
+		return errors.New("Failed to setup framebuffer for GPUPicker : incomplete framebuffer")
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Shader for Picking (a variant of the original shader)
+// ----------------------------------------------------------------------------
+
+func (self *GPUPicker) get_picking_shader(shader *wcommon.Shader, instanced bool) *wcommon.Shader {
+	if shader == nil {
+		return nil
+	}
+	if self.shaders[shader] == nil {
+		self.shaders[shader] = map[bool]*wcommon.Shader{}
+	}
+	if pick_shader, ok := self.shaders[shader][instanced]; ok {
+		return pick_shader // (it can be nil, if it failed to compile before)
+	}
+	pick_shader, err := build_picking_shader(shader, instanced)
+	if err != nil {
+		pick_shader = nil
+	}
+	self.shaders[shader][instanced] = pick_shader
+	return pick_shader
+}
+
+func build_picking_shader(shader *wcommon.Shader, instanced bool) (*wcommon.Shader, error) {
+	// The original vertex shader is followed by computing the ID color
+	// from '_pick_base' (the first ID of the object) and '_pick_index' (instance index).
+	decls, index := "uniform highp float _pick_base;\n", "0.0"
+	if instanced {
+		decls, index = decls+"attribute highp float _pick_index;\n", "_pick_index"
+	}
+	decls += "varying vec3 _pick_color;"
+	vmain := `	highp float id = _pick_base + ` + index + ` + 1.0;
+	highp float b = floor(id / 65536.0);
+	highp float g = floor((id - b * 65536.0) / 256.0);
+	highp float r = id - b * 65536.0 - g * 256.0;
+	_pick_color = vec3(r, g, b) / 255.0;`
+	fsource := `precision mediump float;
+varying vec3 _pick_color;
+void main() {
+	gl_FragColor = vec4(_pick_color, 1.0);
+}`
+	pick_shader, err := shader.BuildVariant(decls, vmain, fsource)
+	if err != nil {
+		return nil, err
+	}
+	pick_shader.SetBindingForUniform("_pick_base", "float", []float32{0})
+	if instanced {
+		pick_shader.SetBindingForAttribute("_pick_index", "float", "instance.index")
+	}
+	pick_shader.CheckBindings()
+	return pick_shader, nil
+}
+
+func min_int(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max_int(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 	"syscall/js"
 
-	"github.com/go4orward/gowebgl/geom2d"
 	"github.com/go4orward/gowebgl/geom3d"
 	"github.com/go4orward/gowebgl/wcommon"
 )
@@ -15,13 +14,129 @@ import (
 type Renderer struct {
 	wctx *wcommon.WebGLContext
 	axes *SceneObject
+	rte  bool // relative-to-eye rendering (for huge coordinates)
+
+	target *wcommon.RenderTarget // OPTIONAL, offscreen RenderTarget to render into (nil for the canvas)
+
+	lights   map[string][]float32        // uniform values of the lights in CAMERA space (for "lighting.*" autobindings)
+	view     geom3d.Matrix4              // VIEW matrix of the camera (for "renderer.view" autobinding)
+	envmap   *wcommon.Texture            // environment map of the lighting (for "lighting.envmap" autobinding)
+	tonemap  string                      // tone mapping ("NONE", "REINHARD" or "ACES")
+	exposure float32                     // exposure for tone mapping
+	defaults map[string]*wcommon.Texture // default textures for missing texture maps (like 1x1 WHITE)
+	defcube  *wcommon.TextureCube        // default cube map for missing cube maps (1x1 BLACK)
+	shadows  *shadow_mapper              // shadow maps of the lights casting shadows (for "shadow.*" autobindings)
+	receiver bool                        // receiving shadow flag of the SceneObject being rendered
+
+	transparency string        // transparency mode ("NONE", "SORTED" or "OIT")
+	oit          *oit_renderer // weighted blended order-independent transparency (only for "OIT" mode)
+
+	state   *wcommon.StateCache // WebGL states & uniform values set last (to skip redundant WebGL calls)
+	batcher *static_batcher     // batches of 'Static' SceneObjects merged for fewer draw calls
+
+	hlcolors  [2][4]float32                                // highlight colors for HOVERED & SELECTED states
+	hlmode    string                                       // highlight mode ("COLOR" or "OUTLINE")
+	hlshaders map[*wcommon.Shader]map[bool]*wcommon.Shader // highlight variants of shaders (for instanced or not)
 }
 
 func NewRenderer(wctx *wcommon.WebGLContext) *Renderer {
-	renderer := Renderer{wctx: wctx, axes: nil}
+	renderer := Renderer{wctx: wctx, axes: nil, rte: false}
+	renderer.SetHighlightColors("#ffff0066", "#ff880099").SetHighlightMode("COLOR")
+	renderer.hlshaders = map[*wcommon.Shader]map[bool]*wcommon.Shader{}
+	renderer.shadows = new_shadow_mapper(&renderer, 1024)            // shadow maps (rendered only if any light casts shadows)
+	renderer.SetLighting(NewLighting_Default(), geom3d.NewMatrix4()) // default headlight (without any Scene)
+	renderer.SetToneMapping("NONE", 1.0)                             // no tone mapping by default
+	renderer.SetTransparencyMode("SORTED")                           // transparent objects sorted back-to-front by default
+	renderer.oit = new_oit_renderer(&renderer)                       // OIT buffers (created only if "OIT" mode is used)
+	renderer.state = wcommon.NewStateCache(wctx)                     // redundant WebGL calls are skipped
+	renderer.batcher = new_static_batcher(&renderer)                 // 'Static' SceneObjects are merged automatically
+	renderer.defaults = map[string]*wcommon.Texture{}
 	return &renderer
 }
 
+func (self *Renderer) SetRelativeToEye(rte bool) *Renderer {
+	// Relative-to-eye rendering subtracts the camera position from the origin of each SceneObject
+	// in double-precision, before its (View * Model) matrix is uploaded to WebGL.
+	// Use it with Camera.SetPoseHighPrecision() and SceneObject.SetOrigin() for huge coordinates.
+	self.rte = rte
+	return self
+}
+
+func (self *Renderer) IsRelativeToEye() bool {
+	return self.rte
+}
+
+func (self *Renderer) SetLighting(lighting *Lighting, view *geom3d.Matrix4) *Renderer {
+	// Set the lighting for the SceneObjects rendered next, with the VIEW matrix of the camera.
+	// (RenderScene() calls it with the lighting of the Scene, so call it only for rendering SceneObjects directly.)
+	self.lights = lighting.get_uniform_values(view)
+	self.view.SetCopy(view)
+	self.envmap = lighting.environment
+	self.shadows.reset() // shadow maps are rendered again by RenderScene()
+	return self
+}
+
+func (self *Renderer) SetShadowMapSize(size int) *Renderer {
+	// Size of each shadow map (default is 1024), with all the shadow maps in a single atlas of (2*size x 2*size).
+	// (Which lights cast shadows can be set with Light.SetShadow(), and which objects with SceneObject's flags)
+	if size <= 0 || size&(size-1) != 0 {
+		fmt.Printf("Renderer.SetShadowMapSize() failed : invalid size %d (power of 2)\n", size)
+		return self
+	}
+	self.shadows.tilesize = size
+	return self
+}
+
+func (self *Renderer) SetToneMapping(mode string, exposure float32) *Renderer {
+	// Tone mapping of HDR colors (in shaders with "renderer.tonemap" & "renderer.exposure", like PBR shader)
+	// 'mode' : "NONE" (clamping only), "REINHARD" or "ACES" (filmic)
+	self.tonemap = mode
+	self.exposure = exposure
+	return self
+}
+
+func (self *Renderer) SetTransparencyMode(mode string) *Renderer {
+	// Rendering of TRANSPARENT SceneObjects (with 'UseBlend') in RenderScene(), after all the OPAQUE ones.
+	// 'mode' : "NONE" (in the order of the scene), "SORTED" (back-to-front) or "OIT" (order-independent)
+	switch mode {
+	case "NONE", "SORTED", "OIT":
+		self.transparency = mode
+	default:
+		fmt.Printf("Failed to SetTransparencyMode('%s') : try 'NONE', 'SORTED' or 'OIT'\n", mode)
+	}
+	return self
+}
+
+// ----------------------------------------------------------------------------
+// Render Target
+// ----------------------------------------------------------------------------
+
+func (self *Renderer) SetRenderTarget(target *wcommon.RenderTarget) *Renderer {
+	// Render into the offscreen RenderTarget ('nil' for the canvas), starting from the next Clear() or RenderScene().
+	// (Note that the aspect ratio of the camera should match the size of the RenderTarget)
+	self.target = target
+	return self
+}
+
+func (self *Renderer) GetRenderTarget() *wcommon.RenderTarget {
+	return self.target
+}
+
+func (self *Renderer) get_target_wh() [2]int {
+	// size of the RenderTarget (or the canvas) being rendered
+	if self.target != nil && self.target.IsReady() {
+		return self.target.GetWH()
+	}
+	return self.wctx.GetWH()
+}
+
+func (self *Renderer) InvalidateStates() *Renderer {
+	// Forget the WebGL states set last (like shader program, depth test, blending and attributes), so that all of them
+	// are set again. (Clear() and RenderScene() call it, so call it only after changing WebGL states directly.)
+	self.state.Invalidate()
+	return self
+}
+
 // ----------------------------------------------------------------------------
 // Clear
 // ----------------------------------------------------------------------------
@@ -29,6 +144,8 @@ func NewRenderer(wctx *wcommon.WebGLContext) *Renderer {
 func (self *Renderer) Clear(scene *Scene) {
 	context := self.wctx.GetContext()
 	constants := self.wctx.GetConstants()
+	wcommon.BindRenderTarget(self.wctx, self.target) // clear the RenderTarget (or the canvas)
+	self.state.Invalidate()                          // WebGL states might have been changed outside
 	rgb := scene.GetBkgColor()
 	context.Call("clearColor", rgb[0], rgb[1], rgb[2], 1.0) // set clearing color
 	context.Call("clear", constants.COLOR_BUFFER_BIT)       // clear the canvas
@@ -44,89 +161,231 @@ func (self *Renderer) RenderAxes(camera *Camera, length float32) {
 	if self.axes == nil {
 		self.axes = NewSceneObject_3DAxes(self.wctx, length)
 	}
-	self.RenderSceneObject(self.axes, camera.projection.GetMatrix(), &camera.viewmatrix)
+	new_viewmodel := camera.GetViewModelMatrix([3]float64{0, 0, 0}, geom3d.NewMatrix4(), self.rte)
+	self.RenderSceneObject(self.axes, camera.projection.GetMatrix(), new_viewmodel)
 	// camera.TestDataBuffer(self.axes.geometry.data_buffer_vpoints, self.axes.geometry.vpoint_info[0])
 }
 
+// ----------------------------------------------------------------------------
+// Highlighting (Selection & Hover States)
+// ----------------------------------------------------------------------------
+
+func (self *Renderer) SetHighlightColors(hovered string, selected string) *Renderer {
+	// Colors to highlight hovered/selected SceneObjects (and instances), with alpha for blending (like "#ffff0066")
+	self.hlcolors = [2][4]float32{wcommon.ParseHexColor(hovered), wcommon.ParseHexColor(selected)}
+	return self
+}
+
+func (self *Renderer) SetHighlightMode(mode string) *Renderer {
+	// 'mode' : "COLOR" (default, tinting the faces) or "OUTLINE" (drawing the edges, if any)
+	self.hlmode = mode
+	return self
+}
+
+func (self *Renderer) get_highlight_drawing(sobj *SceneObject) (int, *wcommon.Shader) {
+	// decide which draw_mode & (original) shader to use for highlighting
+	_, edge_count, _ := sobj.Geometry.GetWebGLBuffer(2)
+	if self.hlmode == "OUTLINE" && edge_count > 0 {
+		if sobj.EShader != nil {
+			return 2, sobj.EShader
+		} else if sobj.FShader != nil {
+			return 2, sobj.FShader // vertex shader of the FACE shader works for the edges as well
+		}
+	}
+	if sobj.FShader != nil {
+		return 3, sobj.FShader
+	} else if sobj.EShader != nil {
+		return 2, sobj.EShader
+	} else if sobj.VShader != nil {
+		return 1, sobj.VShader
+	}
+	return 0, nil
+}
+
+func (self *Renderer) get_highlight_shader(shader *wcommon.Shader, instanced bool) *wcommon.Shader {
+	if self.hlshaders[shader] == nil {
+		self.hlshaders[shader] = map[bool]*wcommon.Shader{}
+	}
+	if hl_shader, ok := self.hlshaders[shader][instanced]; ok {
+		return hl_shader // (it can be nil, if it failed to compile before)
+	}
+	// The original vertex shader is followed by choosing the highlight color with the state
+	// of the object ('_hl_state') and the state of the instance ('_hl_istate'), while
+	// the vertices without any state are moved out of the clip space.
+	decls, istate := "uniform vec4 _hl_hovered;\nuniform vec4 _hl_selected;\nuniform float _hl_state;\n", "0.0"
+	if instanced {
+		decls, istate = decls+"attribute float _hl_istate;\n", "_hl_istate"
+	}
+	decls += "varying vec4 _hl_color;"
+	vmain := `	float state = max(_hl_state, ` + istate + `);
+	if (state >= 2.0) {
+		_hl_color = _hl_selected;
+	} else if (state >= 1.0) {
+		_hl_color = _hl_hovered;
+	} else {
+		_hl_color = vec4(0.0);
+		gl_Position = vec4(2.0, 2.0, 2.0, 1.0);
+	}`
+	fsource := `precision mediump float;
+varying vec4 _hl_color;
+void main() {
+	gl_FragColor = vec4(_hl_color.rgb * _hl_color.a, _hl_color.a);
+}`
+	hl_shader, err := shader.BuildVariant(decls, vmain, fsource)
+	if err == nil {
+		hl_shader.SetBindingForUniform("_hl_hovered", "vec4", []float32{0, 0, 0, 0})
+		hl_shader.SetBindingForUniform("_hl_selected", "vec4", []float32{0, 0, 0, 0})
+		hl_shader.SetBindingForUniform("_hl_state", "float", []float32{0})
+		if instanced {
+			hl_shader.SetBindingForAttribute("_hl_istate", "float", "instance.state")
+		}
+		hl_shader.CheckBindings()
+	} else {
+		hl_shader = nil
+	}
+	self.hlshaders[shader][instanced] = hl_shader
+	return hl_shader
+}
+
 // ----------------------------------------------------------------------------
 // Rendering Scene
 // ----------------------------------------------------------------------------
 
 func (self *Renderer) RenderScene(scene *Scene, camera *Camera) {
 	// Render all the SceneObjects in the Scene
+	self.state.Invalidate() // WebGL states might have been changed outside
+	self.SetLighting(scene.lighting, &camera.viewmatrix)
+	// Render the shadow maps first, if any light casts shadows
+	if casters := scene.lighting.get_shadow_casters(&camera.viewmatrix); len(casters) > 0 {
+		self.shadows.Render(scene, camera, casters)
+	}
+	wcommon.BindRenderTarget(self.wctx, self.target) // render into the RenderTarget (or the canvas)
+	// Render the skybox behind everything, if any
+	if scene.skybox != nil {
+		self.RenderSkybox(scene.skybox, camera.projection.GetMatrix(), &camera.viewmatrix)
+	}
+	// Render OPAQUE SceneObjects first (sorted by their states), followed by TRANSPARENT ones (see transparency.go)
+	items := []render_item{}
 	for _, sobj := range scene.objects {
-		new_viewmodel := camera.viewmatrix.MultiplyToTheRight(&sobj.modelmatrix)
-		self.RenderSceneObject(sobj, camera.projection.GetMatrix(), new_viewmodel)
+		new_viewmodel := camera.GetViewModelMatrix(sobj.origin, &sobj.modelmatrix, self.rte)
+		items = collect_render_items(sobj, sobj.origin, &sobj.modelmatrix, new_viewmodel, items)
 	}
+	items = self.batcher.batch_items(items, camera) // 'Static' SceneObjects merged into batches (see batching.go)
+	self.render_items(items, camera.projection.GetMatrix())
 	// Render all the OverlayLayers
 	for _, overlay := range scene.overlays {
-		overlay.Render(camera.projection.GetMatrix(), &camera.viewmatrix)
+		if layer, ok := overlay.(*OverlayMarkerLayer); ok {
+			layer.render_with_camera(camera.projection.GetMatrix(), camera, self.rte) // (same as GPU picking)
+		} else {
+			overlay.Render(camera.projection.GetMatrix(), &camera.viewmatrix)
+		}
 	}
 }
 
+func (self *Renderer) RenderSkybox(skybox *SceneObject, proj *geom3d.Matrix4, view *geom3d.Matrix4) error {
+	// Render the skybox (like NewSceneObject_Skybox()) with the rotation of the camera only,
+	// so that it stays behind everything regardless of the camera translation (without writing depth).
+	rotation := view.Copy()
+	e := rotation.GetElements()
+	e[12], e[13], e[14] = 0, 0, 0 // remove translation of VIEW matrix
+	dmask := self.state.GetDepthMask()
+	self.state.SetDepthMask(false)
+	err := self.RenderSceneObject(skybox, proj, rotation)
+	self.state.SetDepthMask(dmask)
+	return err
+}
+
 // ----------------------------------------------------------------------------
 // Rendering SceneObject
 // ----------------------------------------------------------------------------
 
 func (self *Renderer) RenderSceneObject(scnobj *SceneObject, proj *geom3d.Matrix4, vwmd *geom3d.Matrix4) error {
-	context := self.wctx.GetContext()
-	constants := self.wctx.GetConstants()
-	// Set DepthTest & Blending options
-	if scnobj.UseDepth {
-		context.Call("enable", constants.DEPTH_TEST) // Enable depth test
-		context.Call("depthFunc", constants.LEQUAL)  // Near things obscure far things
-	} else {
-		context.Call("disable", constants.DEPTH_TEST) // Disable depth test
-	}
-	if scnobj.UseBlend {
-		context.Call("enable", constants.BLEND)                                 // for pre-multiplied alpha
-		context.Call("blendFunc", constants.ONE, constants.ONE_MINUS_SRC_ALPHA) // for pre-multiplied alpha
-		// context.Call("blendFunc", constants.SRC_ALPHA, constants.ONE_MINUS_SRC_ALPHA) // for non pre-multiplied alpha
-	} else {
-		context.Call("disable", constants.BLEND) // Disable blending
-	}
-	// If necessary, then build WebGLBuffers for the SceneObject's Geometry
-	if scnobj.Geometry.IsDataBufferReady() == false {
-		return errors.New("Failed to RenderSceneObject() : empty geometry data buffer")
+	// Render the SceneObject and all its children (in order, regardless of transparency)
+	if err := self.render_scene_object(scnobj, proj, vwmd); err != nil {
+		return err
 	}
-	if scnobj.Geometry.IsWebGLBufferReady() == false {
-		scnobj.Geometry.BuildWebGLBuffers(self.wctx, true, true, true)
+	// Render all the children
+	for _, child := range scnobj.children {
+		new_viewmodel := vwmd.MultiplyToTheRight(&child.modelmatrix)
+		self.RenderSceneObject(child, proj, new_viewmodel)
 	}
-	if scnobj.poses != nil && scnobj.poses.IsWebGLBufferReady() == false {
-		scnobj.poses.BuildWebGLBuffer(self.wctx)
-		if !self.wctx.IsExtensionReady("ANGLE") {
-			self.wctx.SetupExtension("ANGLE")
+	return nil
+}
+
+func (self *Renderer) render_scene_object(scnobj *SceneObject, proj *geom3d.Matrix4, vwmd *geom3d.Matrix4) error {
+	// Render the SceneObject only (without its children)
+	// Set DepthTest & Blending options (only if they're changed)
+	self.state.SetDepthTest(scnobj.UseDepth)
+	if self.oit.pass == oit_pass_none { // (otherwise, blending is set by the OIT pass)
+		if scnobj.UseBlend {
+			self.state.SetBlendMode(scnobj.BlendMode) // "PREMULTIPLIED" by default
+		} else {
+			self.state.SetBlendMode("NONE") // Disable blending
 		}
 	}
+	// If necessary, then build WebGLBuffers for the SceneObject's Geometry
+	if err := self.prepare_scene_object_buffers(scnobj); err != nil {
+		return err
+	}
+	self.receiver = scnobj.ReceiveShadow
 	// R3: Render the object with FACE shader
 	if scnobj.FShader != nil {
-		err := self.render_scene_object_with_shader(scnobj, proj, vwmd, 3, scnobj.FShader)
+		err := self.render_scene_object_with_shader(scnobj, proj, vwmd, 3, self.oit.get_pass_shader(scnobj, scnobj.FShader))
 		if err != nil {
 			return err
 		}
 	}
 	// R2: Render the object with EDGE shader
 	if scnobj.EShader != nil {
-		err := self.render_scene_object_with_shader(scnobj, proj, vwmd, 2, scnobj.EShader)
+		err := self.render_scene_object_with_shader(scnobj, proj, vwmd, 2, self.oit.get_pass_shader(scnobj, scnobj.EShader))
 		if err != nil {
 			return err
 		}
 	}
 	// R1: Render the object with VERTEX shader
 	if scnobj.VShader != nil {
-		err := self.render_scene_object_with_shader(scnobj, proj, vwmd, 1, scnobj.VShader)
+		err := self.render_scene_object_with_shader(scnobj, proj, vwmd, 1, self.oit.get_pass_shader(scnobj, scnobj.VShader))
 		if err != nil {
 			return err
 		}
 	}
-	// Render all the children
-	for _, child := range scnobj.children {
-		new_viewmodel := vwmd.MultiplyToTheRight(&child.modelmatrix)
-		self.RenderSceneObject(child, proj, new_viewmodel)
+	// Highlight the object (or its instances), if it's hovered or selected
+	if scnobj.is_highlighted() && self.oit.pass == oit_pass_none {
+		self.render_highlight(scnobj, proj, vwmd)
+	}
+	return nil
+}
+
+func (self *Renderer) prepare_scene_object_buffers(scnobj *SceneObject) error {
+	// Build (or update) WebGLBuffers for the SceneObject's Geometry and its instance poses
+	if scnobj.Geometry.IsDataBufferReady() == false {
+		return errors.New("Failed to RenderSceneObject() : empty geometry data buffer")
+	}
+	if scnobj.Geometry.IsWebGLBufferReady() == false {
+		scnobj.Geometry.BuildWebGLBuffers(self.wctx, true, true, true)
+		self.state.CheckAttributes(nil) // attributes should be bound again with the new buffers
+	} else {
+		scnobj.Geometry.UpdateWebGLBuffers(self.wctx) // upload modified data only (if any)
+	}
+	if scnobj.poses != nil && scnobj.poses.IsWebGLBufferReady() == false {
+		scnobj.poses.BuildWebGLBuffer(self.wctx)
+		self.state.CheckAttributes(nil) // attributes should be bound again with the new buffers
+		if !self.wctx.IsExtensionReady("ANGLE") {
+			self.wctx.SetupExtension("ANGLE")
+		}
+	} else if scnobj.poses != nil && scnobj.poses.IsDirty() {
+		scnobj.poses.UpdateWebGLBuffer(self.wctx) // upload modified poses only
 	}
 	return nil
 }
 
+type attribute_key struct {
+	shader    *wcommon.Shader //
+	geometry  *Geometry       //
+	version   int             // version of the WebGL buffers of the geometry (which can be rebuilt by anyone)
+	draw_mode int             //
+}
+
 func (self *Renderer) render_scene_object_with_shader(scnobj *SceneObject, proj *geom3d.Matrix4, vwmd *geom3d.Matrix4, draw_mode int, shader *wcommon.Shader) error {
 	context := self.wctx.GetContext()
 	constants := self.wctx.GetConstants()
@@ -134,8 +393,8 @@ func (self *Renderer) render_scene_object_with_shader(scnobj *SceneObject, proj
 	if shader == nil {
 		return errors.New("Failed to RenderSceneObject() : shader not found")
 	}
-	context.Call("useProgram", shader.GetShaderProgram())
-	// 2. bind the uniforms of the shader program
+	self.state.UseShader(shader) // (only if it's not in use)
+	// 2. bind the uniforms of the shader program (only those with changed values)
 	for uname, umap := range shader.GetUniformBindings() {
 		if err := self.bind_uniform(uname, umap, draw_mode, scnobj.Material, proj, vwmd); err != nil {
 			if err.Error() != "Texture is not ready" {
@@ -144,11 +403,18 @@ func (self *Renderer) render_scene_object_with_shader(scnobj *SceneObject, proj
 			return err
 		}
 	}
-	// 3. bind the attributes of the shader program
-	for aname, amap := range shader.GetAttributeBindings() {
-		if err := self.bind_attribute(aname, amap, draw_mode, scnobj.Geometry, scnobj.poses); err != nil {
-			fmt.Println(err.Error())
-			return err
+	// 3. bind the attributes of the shader program (only if they're not bound already, except for instance poses)
+	var key interface{} = nil
+	if g, ok := scnobj.Geometry.(*Geometry); ok && scnobj.poses == nil {
+		key = attribute_key{shader: shader, geometry: g, version: g.webgl_version, draw_mode: draw_mode}
+	}
+	if self.state.CheckAttributes(key) {
+		for aname, amap := range shader.GetAttributeBindings() {
+			if err := self.bind_attribute(aname, amap, draw_mode, scnobj.Geometry, scnobj.poses); err != nil {
+				fmt.Println(err.Error())
+				self.state.CheckAttributes(nil)
+				return err
+			}
 		}
 	}
 	// 4. draw  (Note that ARRAY_BUFFER was binded already in the attribut-binding step)
@@ -196,6 +462,36 @@ func (self *Renderer) render_scene_object_with_shader(scnobj *SceneObject, proj
 	return nil
 }
 
+func (self *Renderer) render_highlight(sobj *SceneObject, proj *geom3d.Matrix4, vwmd *geom3d.Matrix4) error {
+	// Render the highlight of hovered/selected SceneObject (or its instances) over the original rendering,
+	// without swapping its material or shaders.
+	draw_mode, shader := self.get_highlight_drawing(sobj)
+	if shader == nil {
+		return nil
+	}
+	hl_shader := self.get_highlight_shader(shader, sobj.poses != nil)
+	if hl_shader == nil {
+		return errors.New("Failed to render highlight : invalid shader")
+	}
+	state := float32(0)
+	if sobj.hovered {
+		state = 1
+	}
+	if sobj.selected {
+		state = 2
+	}
+	uniforms := hl_shader.GetUniformBindings()
+	uniforms["_hl_hovered"]["value"] = self.hlcolors[0][:]
+	uniforms["_hl_selected"]["value"] = self.hlcolors[1][:]
+	uniforms["_hl_state"]["value"] = []float32{state}
+	dmask := self.state.GetDepthMask()       // (TRANSPARENT objects are rendered without writing depth)
+	self.state.SetBlendMode("PREMULTIPLIED") // for pre-multiplied alpha
+	self.state.SetDepthMask(false)           // keep the depth of the original rendering
+	err := self.render_scene_object_with_shader(sobj, proj, vwmd, draw_mode, hl_shader)
+	self.state.SetDepthMask(dmask)
+	return err
+}
+
 func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 	draw_mode int, material *wcommon.Material, proj *geom3d.Matrix4, vwmd *geom3d.Matrix4) error {
 	context := self.wctx.GetContext()
@@ -204,7 +500,7 @@ func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 		err := errors.New("Failed to bind uniform : call 'shader.CheckBinding()' before rendering")
 		return err
 	}
-	location, dtype := umap["location"].(js.Value), umap["dtype"].(string)
+	dtype := umap["dtype"].(string)
 	if umap["autobinding"] != nil {
 		autobinding := umap["autobinding"].(string)
 		// fmt.Printf("Uniform (%s) : autobinding= '%s'\n", dtype, autobinding)
@@ -212,24 +508,37 @@ func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 		autobinding0 := autobinding_split[0]
 		switch autobinding0 {
 		case "renderer.aspect": // vec2
-			wh := self.wctx.GetWH()
-			context.Call("uniform2f", location, float32(wh[0]), float32(wh[1]))
+			wh := self.get_target_wh()
+			self.state.SetUniform(umap, "uniform2f", []float32{float32(wh[0]), float32(wh[1])})
 			return nil
 		case "renderer.proj": // mat4
-			e := (*proj.GetElements())[:]
-			m := wcommon.ConvertGoSliceToJsTypedArray(e)         // Projection matrix, converted to JavaScript 'Float32Array'
-			context.Call("uniformMatrix4fv", location, false, m) // gl.uniformMatrix4fv(location, transpose, values_array)
+			e := (*proj.GetElements())[:]                      // Projection matrix
+			self.state.SetUniform(umap, "uniformMatrix4fv", e) // gl.uniformMatrix4fv(location, transpose, values_array)
 			return nil
 		case "renderer.vwmd": // mat4
-			e := (*vwmd.GetElements())[:]
-			m := wcommon.ConvertGoSliceToJsTypedArray(e)         // View * Models matrix, converted to JavaScript 'Float32Array'
-			context.Call("uniformMatrix4fv", location, false, m) // gl.uniformMatrix4fv(location, transpose, values_array)
+			e := (*vwmd.GetElements())[:]                      // View * Models matrix
+			self.state.SetUniform(umap, "uniformMatrix4fv", e) // gl.uniformMatrix4fv(location, transpose, values_array)
+			return nil
+		case "renderer.normal": // mat3
+			nrml := vwmd.GetNormalMatrix()                     // inverse transpose of (View * Models) matrix
+			e := (*nrml.GetElements())[:]                      // (for normal vectors in camera space)
+			self.state.SetUniform(umap, "uniformMatrix3fv", e) // gl.uniformMatrix3fv(location, transpose, values_array)
+			return nil
+		case "renderer.view": // mat4
+			e := (*self.view.GetElements())[:]                 // View matrix
+			self.state.SetUniform(umap, "uniformMatrix4fv", e) // gl.uniformMatrix4fv(location, transpose, values_array)
+			return nil
+		case "renderer.tonemap": // int
+			tonemap := map[string]int{"NONE": 0, "REINHARD": 1, "ACES": 2}[self.tonemap]
+			self.state.SetUniform(umap, "uniform1i", []float32{float32(tonemap)})
+			return nil
+		case "renderer.exposure": // float
+			self.state.SetUniform(umap, "uniform1f", []float32{self.exposure})
 			return nil
 		case "renderer.pvm": // mat4
-			pvm := proj.MultiplyToTheRight(vwmd)                 // (Proj * View * Models) matrix
-			e := (*pvm.GetElements())[:]                         //
-			m := wcommon.ConvertGoSliceToJsTypedArray(e)         // P*V*M matrix, converted to JavaScript 'Float32Array'
-			context.Call("uniformMatrix4fv", location, false, m) // gl.uniformMatrix4fv(location, transpose, values_array)
+			pvm := proj.MultiplyToTheRight(vwmd)               // (Proj * View * Models) matrix
+			e := (*pvm.GetElements())[:]                       //
+			self.state.SetUniform(umap, "uniformMatrix4fv", e) // gl.uniformMatrix4fv(location, transpose, values_array)
 			return nil
 		case "material.color":
 			c := [4]float32{0, 1, 1, 1}
@@ -238,12 +547,125 @@ func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 			}
 			switch dtype {
 			case "vec3":
-				context.Call("uniform3f", location, c[0], c[1], c[2])
+				self.state.SetUniform(umap, "uniform3f", c[:3])
 				return nil
 			case "vec4":
-				context.Call("uniform4f", location, c[0], c[1], c[2], c[3])
+				self.state.SetUniform(umap, "uniform4f", c[:])
+				return nil
+			}
+		case "material.specular": // vec3
+			c := [3]float32{0, 0, 0}
+			if material != nil {
+				c = material.GetSpecular()
+			}
+			self.state.SetUniform(umap, "uniform3f", c[:])
+			return nil
+		case "material.shininess": // float
+			shininess := float32(1)
+			if material != nil && material.GetShininess() > 0 {
+				shininess = material.GetShininess()
+			}
+			self.state.SetUniform(umap, "uniform1f", []float32{shininess})
+			return nil
+		case "material.emissive": // vec3
+			c := [3]float32{0, 0, 0}
+			if material != nil {
+				c = material.GetEmissive()
+			}
+			self.state.SetUniform(umap, "uniform3f", c[:])
+			return nil
+		case "material.metallic", "material.roughness", "material.normalscale", "material.occlusion": // float
+			value := map[string]float32{"material.metallic": 0, "material.roughness": 1, "material.normalscale": 1, "material.occlusion": 1}[autobinding0]
+			if material != nil {
+				switch autobinding0 {
+				case "material.metallic":
+					value = material.GetMetallic()
+				case "material.roughness":
+					value = material.GetRoughness()
+				case "material.normalscale":
+					value = material.GetNormalScale()
+				case "material.occlusion":
+					value = material.GetOcclusionStrength()
+				}
+			}
+			self.state.SetUniform(umap, "uniform1f", []float32{value})
+			return nil
+		case "material.map": // sampler2D, like "material.map:<slot>:<unit>"
+			if len(autobinding_split) == 3 {
+				slot := autobinding_split[1]
+				txt_unit, _ := strconv.Atoi(autobinding_split[2])
+				var texture *wcommon.Texture = nil
+				if material != nil {
+					texture = material.GetTextureMap(slot)
+				}
+				if slot == "diffuse" && texture == nil && material != nil && material.IsTextureReady() && !material.IsTextureLoading() {
+					self.bind_texture(umap, txt_unit, material.GetTexture()) // single texture of the material
+					return nil
+				}
+				if texture == nil || !texture.IsReady() {
+					texture = self.get_default_texture(slot) // use default texture, while loading or if missing
+				}
+				self.bind_texture(umap, txt_unit, texture.GetTexture())
 				return nil
 			}
+		case "material.maptransform": // mat3, like "material.maptransform:<slot>"
+			if len(autobinding_split) == 2 {
+				e := [9]float32{1, 0, 0, 0, 1, 0, 0, 0, 1}
+				if material != nil {
+					e = material.GetTextureMapTransform(autobinding_split[1])
+				}
+				self.state.SetUniform(umap, "uniformMatrix3fv", e[:]) // UV transformation
+				return nil
+			}
+		case "material.mapuvset": // float, like "material.mapuvset:<slot>"
+			if len(autobinding_split) == 2 {
+				uvset := 0
+				if material != nil {
+					uvset = material.GetTextureMapUVSet(autobinding_split[1])
+				}
+				self.state.SetUniform(umap, "uniform1f", []float32{float32(uvset)})
+				return nil
+			}
+		case "material.cubemap": // samplerCube, like "material.cubemap:<unit>"
+			txt_unit := 0
+			if len(autobinding_split) >= 2 {
+				txt_unit, _ = strconv.Atoi(autobinding_split[1])
+			}
+			var cubemap *wcommon.TextureCube = nil
+			if material != nil {
+				cubemap = material.GetCubeMap()
+			}
+			if cubemap == nil || !cubemap.IsReady() {
+				if self.defcube == nil { // use default cube map, while loading or if missing
+					self.defcube = wcommon.NewTextureCube_SolidColor(self.wctx, [4]uint8{0, 0, 0, 255})
+				}
+				cubemap = self.defcube
+			}
+			texture_unit := js.ValueOf(constants.TEXTURE0.Int() + txt_unit)
+			context.Call("activeTexture", texture_unit)                                   // activate texture unit N
+			context.Call("bindTexture", constants.TEXTURE_CUBE_MAP, cubemap.GetTexture()) // bind the cube map
+			self.state.SetUniform(umap, "uniform1i", []float32{float32(txt_unit)})        // give shader the unit number
+			return nil
+		case "material.reflectivity", "material.refraction": // float
+			value := float32(0)
+			if material != nil && autobinding0 == "material.reflectivity" {
+				value = material.GetReflectivity()
+			} else if material != nil {
+				value = material.GetRefractionRatio()
+			}
+			self.state.SetUniform(umap, "uniform1f", []float32{value})
+			return nil
+		case "lighting.envmap": // sampler2D, like "lighting.envmap:<unit>"
+			txt_unit := 0
+			if len(autobinding_split) >= 2 {
+				txt_unit, _ = strconv.Atoi(autobinding_split[1])
+			}
+			texture := self.envmap
+			if texture == nil || !texture.IsReady() {
+				texture = self.get_default_texture("environment")
+			}
+			self.bind_texture(umap, txt_unit, texture.GetTexture())
+			return nil
 		case "material.texture":
 			if material == nil || !material.IsTextureReady() || material.IsTextureLoading() {
 				return errors.New("Texture is not ready")
@@ -255,33 +677,80 @@ func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 			texture_unit := js.ValueOf(constants.TEXTURE0.Int() + txt_unit)
 			context.Call("activeTexture", texture_unit)                              // activate texture unit N
 			context.Call("bindTexture", constants.TEXTURE_2D, material.GetTexture()) // bind the texture
-			context.Call("uniform1i", location, txt_unit)                            // give shader the unit number
+			self.state.SetUniform(umap, "uniform1i", []float32{float32(txt_unit)})   // give shader the unit number
 			return nil
-		case "lighting.dlight": // mat3
-			dlight := geom2d.NewMatrix3().Set(0, 1, 0, 0, 1, 0, 1, 1, 0) // directional light (in camera space)
-			e := (*dlight.GetElements())[:]                              // (direction[3] + intensity[3] + ambient[3])
-			m := wcommon.ConvertGoSliceToJsTypedArray(e)                 // converted to JavaScript 'Float32Array'
-			context.Call("uniformMatrix3fv", location, false, m)         // gl.uniformMatrix4fv(location, transpose, values_array)
+		case "shadow.map": // sampler2D, like "shadow.map:<unit>"
+			txt_unit := 0
+			if len(autobinding_split) >= 2 {
+				txt_unit, _ = strconv.Atoi(autobinding_split[1])
+			}
+			if self.shadows.texture.IsNull() {
+				self.bind_texture(umap, txt_unit, self.get_default_texture("shadow").GetTexture())
+			} else {
+				self.bind_texture(umap, txt_unit, self.shadows.texture)
+			}
 			return nil
+		case "shadow.receive": // float
+			receive := float32(0)
+			if self.receiver {
+				receive = 1
+			}
+			self.state.SetUniform(umap, "uniform1f", []float32{receive})
+			return nil
+		case "shadow.count", "shadow.matrix", "shadow.info", "shadow.tile", "shadow.filter":
+			v := self.shadows.uniforms[autobinding0] // uniform values of the shadow maps (in CAMERA space)
+			switch dtype {
+			case "int": // number of shadow maps
+				self.state.SetUniform(umap, "uniform1i", v[:1])
+				return nil
+			case "vec4": // array of vec4
+				self.state.SetUniform(umap, "uniform4fv", v)
+				return nil
+			case "mat4": // array of mat4
+				self.state.SetUniform(umap, "uniformMatrix4fv", v)
+				return nil
+			}
+		case "lighting.dlight", "lighting.ambient", "lighting.envintensity",
+			"lighting.dcount", "lighting.ddir", "lighting.dcolor",
+			"lighting.pcount", "lighting.ppos", "lighting.pcolor", "lighting.patten",
+			"lighting.scount", "lighting.spos", "lighting.sdir", "lighting.scolor", "lighting.satten", "lighting.scone":
+			v := self.lights[autobinding0] // uniform values of the lights in CAMERA space
+			switch dtype {
+			case "int": // number of lights
+				self.state.SetUniform(umap, "uniform1i", v[:1])
+				return nil
+			case "vec2": // (array of) vec2
+				self.state.SetUniform(umap, "uniform2fv", v)
+				return nil
+			case "vec3": // (array of) vec3
+				self.state.SetUniform(umap, "uniform3fv", v)
+				return nil
+			case "float": // intensity
+				self.state.SetUniform(umap, "uniform1f", v[:1])
+				return nil
+			case "mat3": // single directional light ([0]:direction, [1]:color, [2]:ambient)
+				self.state.SetUniform(umap, "uniformMatrix3fv", v)
+				return nil
+			}
 		}
 		return fmt.Errorf("Failed to bind uniform '%s' (%s) with %v", uname, dtype, autobinding, umap)
 	} else if umap["value"] != nil {
 		v := umap["value"].([]float32)
 		switch dtype {
 		case "int":
-			context.Call("uniform1i", location, int(v[0]))
+			self.state.SetUniform(umap, "uniform1i", v[:1])
 			return nil
 		case "float":
-			context.Call("uniform1f", location, v[0])
+			self.state.SetUniform(umap, "uniform1f", v[:1])
 			return nil
 		case "vec2":
-			context.Call("uniform2f", location, v[0], v[1])
+			self.state.SetUniform(umap, "uniform2f", v[:2])
 			return nil
 		case "vec3":
-			context.Call("uniform3f", location, v[0], v[1], v[2])
+			self.state.SetUniform(umap, "uniform3f", v[:3])
 			return nil
 		case "vec4":
-			context.Call("uniform4f", location, v[0], v[1], v[2], v[3])
+			self.state.SetUniform(umap, "uniform4f", v[:4])
 			return nil
 		}
 		return fmt.Errorf("Failed to bind uniform '%s' (%s) with %v", uname, dtype, v)
@@ -290,6 +759,30 @@ func (self *Renderer) bind_uniform(uname string, umap map[string]interface{},
 	}
 }
 
+func (self *Renderer) bind_texture(umap map[string]interface{}, txt_unit int, texture js.Value) {
+	context := self.wctx.GetContext()
+	constants := self.wctx.GetConstants()
+	texture_unit := js.ValueOf(constants.TEXTURE0.Int() + txt_unit)
+	context.Call("activeTexture", texture_unit)                            // activate texture unit N
+	context.Call("bindTexture", constants.TEXTURE_2D, texture)             // bind the texture
+	self.state.SetUniform(umap, "uniform1i", []float32{float32(txt_unit)}) // give shader the unit number
+}
+
+func (self *Renderer) get_default_texture(slot string) *wcommon.Texture {
+	// default texture for the missing texture map, which doesn't change the result of the shader
+	if self.defaults[slot] == nil {
+		switch slot {
+		case "normal": // flat normal (0,0,1)
+			self.defaults[slot] = wcommon.NewTexture_SolidColor(self.wctx, [4]uint8{128, 128, 255, 255})
+		case "environment": // no image-based lighting
+			self.defaults[slot] = wcommon.NewTexture_SolidColor(self.wctx, [4]uint8{0, 0, 0, 255})
+		default: // WHITE, to be multiplied by the factors ("diffuse", "specular", "lightmap", "basecolor", "metalrough", "occlusion" & "emissive"), or the farthest depth ("shadow")
+			self.defaults[slot] = wcommon.NewTexture_SolidColor(self.wctx, [4]uint8{255, 255, 255, 255})
+		}
+	}
+	return self.defaults[slot]
+}
+
 func (self *Renderer) bind_attribute(aname string, amap map[string]interface{},
 	draw_mode int, geometry wcommon.Geometry, poses *wcommon.SceneObjectPoses) error {
 	context := self.wctx.GetContext()
@@ -314,10 +807,14 @@ func (self *Renderer) bind_attribute(aname string, amap map[string]interface{},
 			self.wctx.GetExtension("ANGLE").Call("vertexAttribDivisorANGLE", location, 0) // divisor == 0
 		}
 		return nil
-	case "geometry.textuv": // 2 * uint16 in 4 bytes (1 float32)
+	case "geometry.textuv": // 2 * uint16 in 4 bytes (1 float32), or 2 float32 if out of [0 ~ 1]
 		buffer, _, pinfo := geometry.GetWebGLBuffer(1)
 		context.Call("bindBuffer", constants.ARRAY_BUFFER, buffer)
-		context.Call("vertexAttribPointer", location, 2, constants.UNSIGNED_SHORT, true, pinfo[0]*4, pinfo[2]*4)
+		if g, ok := geometry.(*Geometry); ok && g.fpoint_tuv_float {
+			context.Call("vertexAttribPointer", location, 2, constants.FLOAT, false, pinfo[0]*4, pinfo[2]*4)
+		} else {
+			context.Call("vertexAttribPointer", location, 2, constants.UNSIGNED_SHORT, true, pinfo[0]*4, pinfo[2]*4)
+		}
 		context.Call("enableVertexAttribArray", location)
 		if pinfo[1] == pinfo[2] {
 			fmt.Printf("Renderer Warning : Texture UV coordinates not found (pinfo=%v)\n", pinfo)
@@ -327,6 +824,33 @@ func (self *Renderer) bind_attribute(aname string, amap map[string]interface{},
 			self.wctx.GetExtension("ANGLE").Call("vertexAttribDivisorANGLE", location, 0) // divisor == 0
 		}
 		return nil
+	case "geometry.textuv2", "geometry.tangent": // 2 * uint16 or 4 * byte in 4 bytes (1 float32)
+		buffer, _, pinfo := geometry.GetWebGLBuffer(1)
+		extra := [2]int{0, 0} // offsets of [ tangent, uv2 ]
+		if g, ok := geometry.(*Geometry); ok {
+			extra = g.GetWebGLBufferExtra()
+		}
+		offset := extra[0]
+		if autobinding0 == "geometry.textuv2" {
+			offset = extra[1]
+		}
+		if offset == 0 { // OPTIONAL data not found in the geometry, so use (0,0,0,0) for all the vertices
+			context.Call("disableVertexAttribArray", location)
+			context.Call("vertexAttrib4f", location, 0, 0, 0, 0)
+			return nil
+		}
+		context.Call("bindBuffer", constants.ARRAY_BUFFER, buffer)
+		if autobinding0 == "geometry.tangent" {
+			context.Call("vertexAttribPointer", location, 4, constants.BYTE, true, pinfo[0]*4, offset*4)
+		} else {
+			context.Call("vertexAttribPointer", location, 2, constants.UNSIGNED_SHORT, true, pinfo[0]*4, offset*4)
+		}
+		context.Call("enableVertexAttribArray", location)
+		if self.wctx.IsExtensionReady("ANGLE") {
+			// context.ext_angle.vertexAttribDivisorANGLE(attribute_loc, divisor);
+			self.wctx.GetExtension("ANGLE").Call("vertexAttribDivisorANGLE", location, 0) // divisor == 0
+		}
+		return nil
 	case "geometry.normal": // 3 * byte in 4 bytes (1 float32)
 		buffer, _, pinfo := geometry.GetWebGLBuffer(1)
 		count := get_count_from_type(dtype)
@@ -341,6 +865,22 @@ func (self *Renderer) bind_attribute(aname string, amap map[string]interface{},
 			self.wctx.GetExtension("ANGLE").Call("vertexAttribDivisorANGLE", location, 0) // divisor == 0
 		}
 		return nil
+	case "instance.index": // 1 float32 for each instance
+		if poses != nil {
+			context.Call("bindBuffer", constants.ARRAY_BUFFER, poses.BuildIndexBuffer(self.wctx))
+			context.Call("vertexAttribPointer", location, 1, constants.FLOAT, false, 0, 0)
+			context.Call("enableVertexAttribArray", location)
+			self.wctx.GetExtension("ANGLE").Call("vertexAttribDivisorANGLE", location, 1) // divisor == 1
+			return nil
+		}
+	case "instance.state": // 1 float32 (highlight state) for each instance
+		if poses != nil {
+			context.Call("bindBuffer", constants.ARRAY_BUFFER, poses.BuildStateBuffer(self.wctx))
+			context.Call("vertexAttribPointer", location, 1, constants.FLOAT, false, 0, 0)
+			context.Call("enableVertexAttribArray", location)
+			self.wctx.GetExtension("ANGLE").Call("vertexAttribDivisorANGLE", location, 1) // divisor == 1
+			return nil
+		}
 	case "instance.pose":
 		if poses != nil && len(autobinding_split) == 3 { // it's like "instance.pose:<stride>:<offset>"
 			count := get_count_from_type(dtype)
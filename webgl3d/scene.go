@@ -6,6 +6,8 @@ type Scene struct {
 	bkgcolor [3]float32     // background color of the scene
 	objects  []*SceneObject // SceneObjects in the scene
 	overlays []Overlay      // list of Overlay (interface) layers
+	lighting *Lighting      // lights of the scene
+	skybox   *SceneObject   // OPTIONAL, skybox rendered behind everything (like NewSceneObject_Skybox())
 }
 
 func NewScene(bkg_color string) *Scene {
@@ -13,6 +15,7 @@ func NewScene(bkg_color string) *Scene {
 	scene.SetBkgColor(bkg_color)
 	scene.objects = make([]*SceneObject, 0)
 	scene.overlays = make([]Overlay, 0)
+	scene.lighting = NewLighting_Default() // single headlight by default
 	return &scene
 }
 
@@ -30,6 +33,33 @@ func (self *Scene) GetBkgColor() [3]float32 {
 	return self.bkgcolor
 }
 
+// ----------------------------------------------------------------------------
+// Lighting
+// ----------------------------------------------------------------------------
+
+func (self *Scene) SetLighting(lighting *Lighting) *Scene {
+	self.lighting = lighting
+	return self
+}
+
+func (self *Scene) GetLighting() *Lighting {
+	return self.lighting
+}
+
+// ----------------------------------------------------------------------------
+// Skybox
+// ----------------------------------------------------------------------------
+
+func (self *Scene) SetSkybox(skybox *SceneObject) *Scene {
+	// Skybox is rendered before all the SceneObjects, regardless of camera translation ('nil' to remove it)
+	self.skybox = skybox
+	return self
+}
+
+func (self *Scene) GetSkybox() *SceneObject {
+	return self.skybox
+}
+
 // ----------------------------------------------------------------------------
 // Handling SceneObject
 // ----------------------------------------------------------------------------
@@ -60,6 +90,14 @@ func (self *Scene) Get(indices ...int) *SceneObject {
 	return nil
 }
 
+func (self *Scene) ClearStates() *Scene {
+	// Clear the selection/hover states of all the SceneObjects (and their instances) in the scene
+	for _, sobj := range self.objects {
+		sobj.ClearStates(true)
+	}
+	return self
+}
+
 // ----------------------------------------------------------------------------
 // Managing OverlayLayers
 // ----------------------------------------------------------------------------
@@ -8,16 +8,25 @@ import (
 )
 
 type SceneObject struct {
-	Geometry    wcommon.Geometry          // geometry interface
-	Material    *wcommon.Material         // material
-	VShader     *wcommon.Shader           // vert shader and its bindings
-	EShader     *wcommon.Shader           // edge shader and its bindings
-	FShader     *wcommon.Shader           // face shader and its bindings
-	modelmatrix geom3d.Matrix4            //
-	UseDepth    bool                      // depth test flag (default is true)
-	UseBlend    bool                      // blending flag with alpha (default is false)
-	poses       *wcommon.SceneObjectPoses // poses for multiple instances of this (geometry+material) object
-	children    []*SceneObject            //
+	Geometry      wcommon.Geometry          // geometry interface
+	Material      *wcommon.Material         // material
+	VShader       *wcommon.Shader           // vert shader and its bindings
+	EShader       *wcommon.Shader           // edge shader and its bindings
+	FShader       *wcommon.Shader           // face shader and its bindings
+	modelmatrix   geom3d.Matrix4            //
+	origin        [3]float64                // origin in double-precision (for huge coordinates, in WORLD space)
+	UseDepth      bool                      // depth test flag (default is true)
+	UseBlend      bool                      // blending flag with alpha (default is false), rendered after opaque objects
+	BlendMode     string                    // blending mode with UseBlend ("PREMULTIPLIED", "NON_PREMULTIPLIED", "ADDITIVE" or "MULTIPLY")
+	SortFaces     bool                      // sorting faces back-to-front with UseBlend (default is false)
+	CastShadow    bool                      // casting shadow flag (default is true)
+	ReceiveShadow bool                      // receiving shadow flag (default is true)
+	Static        bool                      // static flag (default is false), merged with others sharing shaders & material
+	poses         *wcommon.SceneObjectPoses // poses for multiple instances of this (geometry+material) object
+	children      []*SceneObject            //
+	selected      bool                      // selection state (to be highlighted by Renderer)
+	hovered       bool                      // hover state (to be highlighted by Renderer)
+	on_state      StateChangeHandler        // OPTIONAL, callback for the changes of selection/hover state
 }
 
 func NewSceneObject(geometry wcommon.Geometry, material *wcommon.Material,
@@ -34,8 +43,13 @@ func NewSceneObject(geometry wcommon.Geometry, material *wcommon.Material,
 	// Note that 'material' & 'shader' can be nil, in which case its parent's 'material' & 'shader' will be used to render.
 	sobj := SceneObject{Geometry: geometry, Material: material, VShader: vshader, EShader: eshader, FShader: fshader}
 	sobj.modelmatrix.SetIdentity()
-	sobj.UseDepth = true  // depth test is turned on by default
-	sobj.UseBlend = false // alpha blending is turned off by default
+	sobj.UseDepth = true             // depth test is turned on by default
+	sobj.UseBlend = false            // alpha blending is turned off by default
+	sobj.BlendMode = "PREMULTIPLIED" // blending for colors pre-multiplied by alpha by default
+	sobj.SortFaces = false           // sorting faces of transparent objects is turned off by default
+	sobj.CastShadow = true           // casting shadows is turned on by default (if any light casts shadows)
+	sobj.ReceiveShadow = true        // receiving shadows is turned on by default (if its shader supports shadows)
+	sobj.Static = false              // static batching is turned off by default (see batching.go)
 	sobj.poses = nil
 	sobj.children = nil
 	return &sobj
@@ -64,7 +78,7 @@ func (self *SceneObject) ShowInfo() {
 		fmt.Printf("  FACE ")
 		self.FShader.ShowInfo()
 	}
-	fmt.Printf("  Flags    : UseDepth=%t  UseBlend=%t\n", self.UseDepth, self.UseBlend)
+	fmt.Printf("  Flags    : UseDepth=%t  UseBlend=%t (%s)  CastShadow=%t  ReceiveShadow=%t  Static=%t\n", self.UseDepth, self.UseBlend, self.BlendMode, self.CastShadow, self.ReceiveShadow, self.Static)
 	fmt.Printf("  Children : %d\n", len(self.children))
 }
 
@@ -114,6 +128,19 @@ func (self *SceneObject) SetPoseValues(index int, offset int, values ...float32)
 // Translation, Rotation, Scaling (by manipulating MODEL matrix)
 // ----------------------------------------------------------------------------
 
+func (self *SceneObject) SetOrigin(origin [3]float64) *SceneObject {
+	// Set the origin of the object in double-precision, which is applied before MODEL matrix.
+	// With huge coordinates (like geographic or CAD coordinates in millions), place the geometry relative to
+	// the origin, and render it with Renderer.SetRelativeToEye(true) to avoid jittering.
+	// Note that the origin of children is ignored (since they are placed relative to their parent).
+	self.origin = origin
+	return self
+}
+
+func (self *SceneObject) GetOrigin() [3]float64 {
+	return self.origin
+}
+
 func (self *SceneObject) SetTransformation(txyz [3]float32, axis [3]float32, angle_in_degree float32, sxyz [3]float32) *SceneObject {
 	translation := geom3d.NewMatrix4().SetTranslation(txyz[0], txyz[1], txyz[2])
 	rotation := geom3d.NewMatrix4().SetRotationByAxis(axis, angle_in_degree)
@@ -122,6 +149,14 @@ func (self *SceneObject) SetTransformation(txyz [3]float32, axis [3]float32, ang
 	return self
 }
 
+func (self *SceneObject) SetTransformationWithQuaternion(txyz [3]float32, q *geom3d.Quaternion, sxyz [3]float32) *SceneObject {
+	translation := geom3d.NewMatrix4().SetTranslation(txyz[0], txyz[1], txyz[2])
+	rotation := geom3d.NewMatrix4().SetRotationByQuaternion(q)
+	scaling := geom3d.NewMatrix4().SetScaling(sxyz[0], sxyz[1], sxyz[2])
+	self.modelmatrix.SetMultiplyMatrices(translation, rotation, scaling)
+	return self
+}
+
 func (self *SceneObject) Translate(tx float32, ty float32, tz float32) *SceneObject {
 	translation := geom3d.NewMatrix4().SetTranslation(tx, ty, tz)
 	self.modelmatrix.SetMultiplyMatrices(translation, &self.modelmatrix)
@@ -134,8 +169,111 @@ func (self *SceneObject) Rotate(axis [3]float32, angle_in_degree float32) *Scene
 	return self
 }
 
+func (self *SceneObject) RotateByQuaternion(q *geom3d.Quaternion) *SceneObject {
+	rotation := geom3d.NewMatrix4().SetRotationByQuaternion(q)
+	self.modelmatrix.SetMultiplyMatrices(rotation, &self.modelmatrix)
+	return self
+}
+
 func (self *SceneObject) Scale(sx float32, sy float32, sz float32) *SceneObject {
 	scaling := geom3d.NewMatrix4().SetScaling(sx, sy, sz)
 	self.modelmatrix.SetMultiplyMatrices(scaling, &self.modelmatrix)
 	return self
 }
+
+// ----------------------------------------------------------------------------
+// Selection & Hover States (highlighted by Renderer)
+// ----------------------------------------------------------------------------
+
+// StateChangeHandler is called whenever the selection/hover state of a SceneObject (or its instance) changes.
+// 'instance' is the index of the instance pose (-1 for the SceneObject itself), and 'state' is "SELECTED" or "HOVERED".
+type StateChangeHandler func(sobj *SceneObject, instance int, state string, on bool)
+
+func (self *SceneObject) SetStateChangeHandler(handler StateChangeHandler) *SceneObject {
+	self.on_state = handler
+	return self
+}
+
+func (self *SceneObject) SetSelected(on bool) *SceneObject {
+	if self.selected != on {
+		self.selected = on
+		self.notify_state_change(-1, "SELECTED", on)
+	}
+	return self
+}
+
+func (self *SceneObject) IsSelected() bool {
+	return self.selected
+}
+
+func (self *SceneObject) SetHovered(on bool) *SceneObject {
+	if self.hovered != on {
+		self.hovered = on
+		self.notify_state_change(-1, "HOVERED", on)
+	}
+	return self
+}
+
+func (self *SceneObject) IsHovered() bool {
+	return self.hovered
+}
+
+func (self *SceneObject) SetInstanceSelected(index int, on bool) *SceneObject {
+	if self.poses != nil && self.poses.SetState(index, wcommon.INSTANCE_SELECTED, on) {
+		self.notify_state_change(index, "SELECTED", on)
+	}
+	return self
+}
+
+func (self *SceneObject) IsInstanceSelected(index int) bool {
+	return self.poses != nil && self.poses.GetState(index)&wcommon.INSTANCE_SELECTED != 0
+}
+
+func (self *SceneObject) SetInstanceHovered(index int, on bool) *SceneObject {
+	if self.poses != nil && self.poses.SetState(index, wcommon.INSTANCE_HOVERED, on) {
+		self.notify_state_change(index, "HOVERED", on)
+	}
+	return self
+}
+
+func (self *SceneObject) IsInstanceHovered(index int) bool {
+	return self.poses != nil && self.poses.GetState(index)&wcommon.INSTANCE_HOVERED != 0
+}
+
+func (self *SceneObject) GetSelectedInstances() []int {
+	selected := []int{}
+	if self.poses != nil && self.poses.HasStates() {
+		for i := 0; i < self.poses.Count; i++ {
+			if self.poses.GetState(i)&wcommon.INSTANCE_SELECTED != 0 {
+				selected = append(selected, i)
+			}
+		}
+	}
+	return selected
+}
+
+func (self *SceneObject) ClearStates(recursive bool) *SceneObject {
+	// Clear all the selection/hover states of the SceneObject and its instances (and its children, if 'recursive')
+	self.SetSelected(false).SetHovered(false)
+	if self.poses != nil && self.poses.HasStates() {
+		for i := 0; i < self.poses.Count; i++ {
+			self.SetInstanceSelected(i, false).SetInstanceHovered(i, false)
+		}
+	}
+	if recursive {
+		for _, child := range self.children {
+			child.ClearStates(true)
+		}
+	}
+	return self
+}
+
+func (self *SceneObject) is_highlighted() bool {
+	return self.selected || self.hovered || (self.poses != nil && self.poses.HasStates())
+}
+
+func (self *SceneObject) notify_state_change(instance int, state string, on bool) {
+	if self.on_state != nil {
+		self.on_state(self, instance, state, on)
+	}
+}
@@ -80,3 +80,14 @@ func NewSceneObject_Airplane(wctx *wcommon.WebGLContext) *SceneObject {
 	scnobj := NewSceneObject(geometry, material, nil, nil, shader) // set up the scene object (draw FACES only)
 	return scnobj
 }
+
+func NewSceneObject_Skybox(wctx *wcommon.WebGLContext, cubemap *wcommon.TextureCube) *SceneObject {
+	// This example creates a skybox with the cube map, to be rendered by Scene.SetSkybox() or Renderer.RenderSkybox()
+	geometry := NewGeometry_Cube(2, 2, 2)                          // create a cube (its size doesn't matter)
+	geometry.BuildDataBuffers(true, false, true)                   //
+	material := wcommon.NewMaterial(wctx, "").SetCubeMap(cubemap)  // create material with the cube map
+	shader := NewShader_Skybox(wctx)                               // use the SKYBOX shader
+	scnobj := NewSceneObject(geometry, material, nil, nil, shader) // set up the scene object (draw FACES only)
+	scnobj.CastShadow, scnobj.ReceiveShadow = false, false
+	return scnobj
+}
@@ -0,0 +1,98 @@
+package webgl3d
+
+import (
+	"sort"
+
+	"github.com/go4orward/gowebgl/geom3d"
+)
+
+// ----------------------------------------------------------------------------
+// Picking SceneObjects (by Ray Casting)
+// ----------------------------------------------------------------------------
+
+type PickHit struct {
+	SceneObject   *SceneObject // SceneObject hit by the ray
+	Path          []int        // indices of the SceneObject (in the scene and its parents), to be used with Scene.Get()
+	InstanceIndex int          // index of the instance pose (-1, if the SceneObject has no poses)
+	FaceIndex     int          // index of the face hit by the ray
+	Vertices      [3]uint32    // vertex indices of the triangle (of the face) hit by the ray
+	Barycentric   [3]float32   // barycentric coordinates of the hit point in the triangle
+	Point         [3]float32   // hit point in WORLD space
+	Distance      float32      // distance from the origin of the ray
+}
+
+func (self *Scene) Pick(camera *Camera, canvasxy [2]int) []*PickHit {
+	// Pick the faces of SceneObjects at the canvas position, sorted by distance (the closest first)
+	return self.PickWithRay(camera.UnprojectCanvasToRay(canvasxy))
+}
+
+func (self *Scene) PickWithRay(ray *geom3d.Ray) []*PickHit {
+	// Pick the faces of SceneObjects hit by the ray (in WORLD space), sorted by distance (the closest first).
+	// Note that only the SceneObjects with '*webgl3d.Geometry' can be picked, and the instance poses
+	// are assumed to have XYZ translation in their first three values (like "instance.pose:<stride>:0").
+	hits := []*PickHit{}
+	for idx, sobj := range self.objects {
+		world := geom3d.NewMatrix4()
+		if sobj.origin != [3]float64{0, 0, 0} {
+			world.SetTranslation(float32(sobj.origin[0]), float32(sobj.origin[1]), float32(sobj.origin[2]))
+		}
+		hits = pick_scene_object(ray, sobj, world, []int{idx}, hits)
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Distance < hits[j].Distance })
+	return hits
+}
+
+func pick_scene_object(ray *geom3d.Ray, sobj *SceneObject, parent *geom3d.Matrix4, path []int, hits []*PickHit) []*PickHit {
+	world := parent.MultiplyToTheRight(&sobj.modelmatrix)
+	if geometry, ok := sobj.Geometry.(*Geometry); ok && len(geometry.faces) > 0 {
+		if sobj.poses == nil {
+			hits = pick_geometry(ray, geometry, world, sobj, path, -1, hits)
+		} else {
+			poses := sobj.poses
+			for i := 0; i < poses.Count; i++ {
+				p := poses.DataBuffer[i*poses.Size : (i+1)*poses.Size]
+				if len(p) < 3 {
+					break
+				}
+				translation := geom3d.NewMatrix4().SetTranslation(p[0], p[1], p[2])
+				hits = pick_geometry(ray, geometry, world.MultiplyToTheRight(translation), sobj, path, i, hits)
+			}
+		}
+	}
+	for cidx, child := range sobj.children {
+		child_path := append(append([]int{}, path...), cidx)
+		hits = pick_scene_object(ray, child, world, child_path, hits)
+	}
+	return hits
+}
+
+func pick_geometry(ray *geom3d.Ray, geometry *Geometry, world *geom3d.Matrix4, sobj *SceneObject, path []int, instance int, hits []*PickHit) []*PickHit {
+	// The ray is transformed into MODEL space without normalizing its direction,
+	// so that the distance along the ray remains the same as in WORLD space.
+	inverse := world.Inverse()
+	if inverse == nil {
+		return hits
+	}
+	local := geom3d.Ray{Origin: inverse.MultiplyVector3(ray.Origin), Direction: inverse.MultiplyDirection3(ray.Direction)}
+	if local.IntersectAABB(geom3d.NewAABBFromPoints(geometry.verts)) == nil {
+		return hits
+	}
+	var closest *PickHit = nil
+	for fidx, face := range geometry.faces {
+		// same triangles as rendered (to be correct for concave faces)
+		for _, tv := range geometry.get_triangulation(face, geometry.GetFaceNormal(fidx)) {
+			triangle := geom3d.NewTriangle(geometry.verts[tv[0]], geometry.verts[tv[1]], geometry.verts[tv[2]])
+			hit := local.IntersectTriangle(triangle, false)
+			if hit == nil || (closest != nil && hit.Distance >= closest.Distance) {
+				continue
+			}
+			closest = &PickHit{SceneObject: sobj, Path: path, InstanceIndex: instance, FaceIndex: fidx,
+				Vertices: [3]uint32{tv[0], tv[1], tv[2]}, Barycentric: hit.Barycentric,
+				Point: ray.GetPoint(hit.Distance), Distance: hit.Distance}
+		}
+	}
+	if closest != nil {
+		hits = append(hits, closest)
+	}
+	return hits
+}
@@ -1,6 +1,8 @@
 package webgl3d
 
 import (
+	"fmt"
+
 	"github.com/go4orward/gowebgl/wcommon"
 )
 
@@ -58,15 +60,14 @@ func NewShader_NormalColor(wctx *wcommon.WebGLContext) *wcommon.Shader {
 		precision mediump float;
 		uniform mat4 proj;			// Projection matrix
 		uniform mat4 vwmd;			// ModelView matrix
+		uniform mat3 nrml;			// Normal matrix (inverse transpose of ModelView)
 		uniform mat3 light;			// directional light ([0]:direction, [1]:color, [2]:ambient) COLUMN-MAJOR!
 		attribute vec3 xyz;			// XYZ coordinates
 		attribute vec3 nor;			// normal vector
 		varying vec3 v_light;   	// (varying) lighting intensity for the point
 		void main() {
 			gl_Position = proj * vwmd * vec4(xyz.x, xyz.y, xyz.z, 1.0);
-			float s = sqrt( vwmd[0][0]*vwmd[0][0] + vwmd[0][1]*vwmd[0][1] + vwmd[0][2]*vwmd[0][2]);  // scaling 
-			mat3  mvRot = mat3( vwmd[0][0]/s, vwmd[0][1]/s, vwmd[0][2]/s, vwmd[1][0]/s, vwmd[1][1]/s, vwmd[1][2]/s, vwmd[2][0]/s, vwmd[2][1]/s, vwmd[2][2]/s );
-			vec3  normal    = mvRot * nor;               		// normal vector in camera space
+			vec3  normal    = normalize(nrml * nor);     		// normal vector in camera space
 			float intensity = max(dot(normal, light[0]), 0.0);	// light_intensity = dot(face_normal,light_direction)
 			v_light = intensity * light[1] + light[2];        	// intensity * light_color + ambient_color
 		}`
@@ -80,6 +81,7 @@ func NewShader_NormalColor(wctx *wcommon.WebGLContext) *wcommon.Shader {
 	shader, _ := wcommon.NewShader(wctx, vertex_shader_code, fragment_shader_code)
 	shader.SetBindingForUniform("proj", "mat4", "renderer.proj")    // (Projection) matrix
 	shader.SetBindingForUniform("vwmd", "mat4", "renderer.vwmd")    // (View * Models) matrix
+	shader.SetBindingForUniform("nrml", "mat3", "renderer.normal")  // (Normal) matrix
 	shader.SetBindingForUniform("color", "vec4", "material.color")  // material color
 	shader.SetBindingForUniform("light", "mat3", "lighting.dlight") // directional lighting
 	shader.SetBindingForAttribute("xyz", "vec3", "geometry.coords") // point XYZ coordinates
@@ -88,6 +90,461 @@ func NewShader_NormalColor(wctx *wcommon.WebGLContext) *wcommon.Shader {
 	return shader
 }
 
+func NewShader_NormalColorWithLights(wctx *wcommon.WebGLContext) *wcommon.Shader {
+	// Shader for (XYZ + NORMAL) Geometry & (COLOR) Material & (AMBIENT + DIRECTIONAL + POINT + SPOT) Lighting
+	var vertex_shader_code = _VSHADER_FOR_FRAGMENT_LIGHTING
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform vec4 color;			// material color
+		varying vec3 v_pos;			// (varying) position in camera space
+		varying vec3 v_nor;			// (varying) normal vector in camera space` + _GLSL_LIGHTING + `
+		void main() {
+			vec3 diffuse, specular;
+			compute_lighting(v_pos, normalize(v_nor), 1.0, diffuse, specular);
+			gl_FragColor = vec4(color.rgb * (ambient + diffuse), color.a);
+		}`
+	shader, _ := wcommon.NewShader(wctx, vertex_shader_code, fragment_shader_code)
+	shader.SetBindingForUniform("proj", "mat4", "renderer.proj")    // (Projection) matrix
+	shader.SetBindingForUniform("vwmd", "mat4", "renderer.vwmd")    // (View * Models) matrix
+	shader.SetBindingForUniform("nrml", "mat3", "renderer.normal")  // (Normal) matrix
+	shader.SetBindingForUniform("color", "vec4", "material.color")  // material color
+	set_bindings_for_lighting(shader)                               // ambient, directional, point & spot lights
+	shader.SetBindingForAttribute("xyz", "vec3", "geometry.coords") // point XYZ coordinates
+	shader.SetBindingForAttribute("nor", "vec3", "geometry.normal") // point normal vectors
+	shader.CheckBindings()                                          // check validity of the shader
+	return shader
+}
+
+func NewShader_NormalColorBlinnPhong(wctx *wcommon.WebGLContext) *wcommon.Shader {
+	// Shader for (XYZ + NORMAL) Geometry & (COLOR + SPECULAR + EMISSIVE) Material & (ALL) Lighting,
+	// with per-fragment Blinn-Phong shading (like for metal or plastic parts)
+	var vertex_shader_code = _VSHADER_FOR_FRAGMENT_LIGHTING
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform vec4  color;		// material color
+		uniform vec3  spec;			// material specular color
+		uniform float shin;			// material shininess (specular exponent)
+		uniform vec3  emis;			// material emissive color
+		varying vec3  v_pos;		// (varying) position in camera space
+		varying vec3  v_nor;		// (varying) normal vector in camera space` + _GLSL_LIGHTING + `
+		void main() {
+			vec3 diffuse, specular;
+			compute_lighting(v_pos, normalize(v_nor), shin, diffuse, specular);
+			gl_FragColor = vec4(color.rgb * (ambient + diffuse) + spec * specular + emis, color.a);
+		}`
+	shader, _ := wcommon.NewShader(wctx, vertex_shader_code, fragment_shader_code)
+	shader.SetBindingForUniform("proj", "mat4", "renderer.proj")       // (Projection) matrix
+	shader.SetBindingForUniform("vwmd", "mat4", "renderer.vwmd")       // (View * Models) matrix
+	shader.SetBindingForUniform("nrml", "mat3", "renderer.normal")     // (Normal) matrix
+	shader.SetBindingForUniform("color", "vec4", "material.color")     // material color
+	shader.SetBindingForUniform("spec", "vec3", "material.specular")   // material specular color
+	shader.SetBindingForUniform("shin", "float", "material.shininess") // material shininess
+	shader.SetBindingForUniform("emis", "vec3", "material.emissive")   // material emissive color
+	set_bindings_for_lighting(shader)                                  // ambient, directional, point & spot lights
+	shader.SetBindingForAttribute("xyz", "vec3", "geometry.coords")    // point XYZ coordinates
+	shader.SetBindingForAttribute("nor", "vec3", "geometry.normal")    // point normal vectors
+	shader.CheckBindings()                                             // check validity of the shader
+	return shader
+}
+
+func NewShader_NormalTextureBlinnPhong(wctx *wcommon.WebGLContext) *wcommon.Shader {
+	// Shader for (XYZ + UV + NORMAL) Geometry & (TEXTURE + SPECULAR + EMISSIVE) Material & (ALL) Lighting,
+	// with per-fragment Blinn-Phong shading
+	var vertex_shader_code = `
+		precision mediump float;
+		uniform mat4 proj;			// Projection matrix
+		uniform mat4 vwmd;			// ModelView matrix
+		uniform mat3 nrml;			// Normal matrix (inverse transpose of ModelView)
+		attribute vec3 xyz;			// XYZ coordinates
+		attribute vec2 tuv;			// texture coordinates
+		attribute vec3 nor;			// normal vector
+		varying vec3 v_pos;			// (varying) position in camera space
+		varying vec3 v_nor;			// (varying) normal vector in camera space
+		varying vec2 v_tuv;			// (varying) texture coordinates
+		void main() {
+			vec4 pos = vwmd * vec4(xyz.x, xyz.y, xyz.z, 1.0);
+			gl_Position = proj * pos;
+			v_pos = pos.xyz;
+			v_nor = nrml * nor;
+			v_tuv = tuv;
+		}`
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform sampler2D text;		// texture sampler (unit)
+		uniform vec3  spec;			// material specular color
+		uniform float shin;			// material shininess (specular exponent)
+		uniform vec3  emis;			// material emissive color
+		varying vec3  v_pos;		// (varying) position in camera space
+		varying vec3  v_nor;		// (varying) normal vector in camera space
+		varying vec2  v_tuv;		// (varying) texture coordinates` + _GLSL_LIGHTING + `
+		void main() {
+			vec4 color = texture2D(text, v_tuv);
+			vec3 diffuse, specular;
+			compute_lighting(v_pos, normalize(v_nor), shin, diffuse, specular);
+			gl_FragColor = vec4(color.rgb * (ambient + diffuse) + spec * specular + emis, color.a);
+		}`
+	shader, _ := wcommon.NewShader(wctx, vertex_shader_code, fragment_shader_code)
+	shader.SetBindingForUniform("proj", "mat4", "renderer.proj")         // (Projection) matrix
+	shader.SetBindingForUniform("vwmd", "mat4", "renderer.vwmd")         // (View * Models) matrix
+	shader.SetBindingForUniform("nrml", "mat3", "renderer.normal")       // (Normal) matrix
+	shader.SetBindingForUniform("text", "sampler2D", "material.texture") // texture sampler (unit:0)
+	shader.SetBindingForUniform("spec", "vec3", "material.specular")     // material specular color
+	shader.SetBindingForUniform("shin", "float", "material.shininess")   // material shininess
+	shader.SetBindingForUniform("emis", "vec3", "material.emissive")     // material emissive color
+	set_bindings_for_lighting(shader)                                    // ambient, directional, point & spot lights
+	shader.SetBindingForAttribute("xyz", "vec3", "geometry.coords")      // point XYZ coordinates
+	shader.SetBindingForAttribute("tuv", "vec2", "geometry.textuv")      // point UV coordinates (texture)
+	shader.SetBindingForAttribute("nor", "vec3", "geometry.normal")      // point normal vector
+	shader.CheckBindings()                                               // check validity of the shader
+	return shader
+}
+
+func NewShader_NormalMapping(wctx *wcommon.WebGLContext) *wcommon.Shader {
+	// Shader for (XYZ + UV + NORMAL + TANGENT + (UV2)) Geometry & (TEXTURE MAPS + SPECULAR + EMISSIVE) Material & (ALL) Lighting,
+	// with per-fragment Blinn-Phong shading and tangent-space normal mapping (like for building facades or terrain).
+	// Texture units : 0:diffuse, 1:normal, 2:specular, 3:emissive, 4:occlusion, 5:lightmap  (each with its own UV set & transform)
+	// (Note that Geometry.BuildTangents() is required for normal mapping, and Geometry.SetTextureUV2s() for the second UV set)
+	var vertex_shader_code = `
+		precision mediump float;
+		uniform mat4 proj;			// Projection matrix
+		uniform mat4 vwmd;			// ModelView matrix
+		uniform mat3 nrml;			// Normal matrix (inverse transpose of ModelView)
+		attribute vec3 xyz;			// XYZ coordinates
+		attribute vec2 tuv;			// texture coordinates
+		attribute vec2 tuv2;		// second texture coordinates (like for lightmaps)
+		attribute vec3 nor;			// normal vector
+		attribute vec4 tan;			// tangent vector with handedness
+		varying vec3 v_pos;			// (varying) position in camera space
+		varying vec3 v_nor;			// (varying) normal vector in camera space
+		varying vec4 v_tan;			// (varying) tangent vector in camera space, with handedness
+		varying vec2 v_tuv;			// (varying) texture coordinates
+		varying vec2 v_tuv2;		// (varying) second texture coordinates
+		void main() {
+			vec4 pos = vwmd * vec4(xyz.x, xyz.y, xyz.z, 1.0);
+			gl_Position = proj * pos;
+			v_pos = pos.xyz;
+			v_nor = nrml * nor;
+			v_tan = vec4((vwmd * vec4(tan.xyz, 0.0)).xyz, tan.w);
+			v_tuv = tuv;
+			v_tuv2 = tuv2;
+		}`
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform sampler2D dmap, nmap, smap, emap, omap, lmap;		// texture maps
+		uniform mat3  dmapt, nmapt, smapt, emapt, omapt, lmapt;		// UV transformation of texture maps
+		uniform float dmapu, nmapu, smapu, emapu, omapu, lmapu;		// UV set (0 or 1) of texture maps
+		uniform vec3  spec;			// material specular color
+		uniform float shin;			// material shininess (specular exponent)
+		uniform vec3  emis;			// material emissive color
+		uniform float nscale;		// normal map scale
+		uniform float occl;			// occlusion map strength
+		varying vec3  v_pos;		// (varying) position in camera space
+		varying vec3  v_nor;		// (varying) normal vector in camera space
+		varying vec4  v_tan;		// (varying) tangent vector in camera space, with handedness
+		varying vec2  v_tuv;		// (varying) texture coordinates
+		varying vec2  v_tuv2;		// (varying) second texture coordinates` + _GLSL_LIGHTING + `
+		vec2 map_uv(mat3 transform, float uvset) {
+			return (transform * vec3(mix(v_tuv, v_tuv2, uvset), 1.0)).xy;
+		}
+		vec3 perturb_normal(vec3 normal) {
+			if (dot(v_tan.xyz, v_tan.xyz) < 1e-6) return normal;	// tangents not found
+			vec3 t = normalize(v_tan.xyz - normal * dot(normal, v_tan.xyz));
+			vec3 b = cross(normal, t) * (v_tan.w < 0.0 ? -1.0 : 1.0);
+			vec3 mapn = texture2D(nmap, map_uv(nmapt, nmapu)).xyz * 2.0 - 1.0;
+			mapn.xy *= nscale;
+			return normalize(mat3(t, b, normal) * mapn);
+		}
+		void main() {
+			vec4  color = texture2D(dmap, map_uv(dmapt, dmapu));
+			vec3  n     = perturb_normal(normalize(v_nor));
+			float ao    = mix(1.0, texture2D(omap, map_uv(omapt, omapu)).r, occl);
+			vec3  lmap  = texture2D(lmap, map_uv(lmapt, lmapu)).rgb;
+			vec3  smap  = texture2D(smap, map_uv(smapt, smapu)).rgb;
+			vec3  emap  = texture2D(emap, map_uv(emapt, emapu)).rgb;
+			vec3 diffuse, specular;
+			compute_lighting(v_pos, n, shin, diffuse, specular);
+			gl_FragColor = vec4(color.rgb * (ambient * ao + diffuse) * lmap + spec * smap * specular + emis * emap, color.a);
+		}`
+	shader, _ := wcommon.NewShader(wctx, vertex_shader_code, fragment_shader_code)
+	shader.SetBindingForUniform("proj", "mat4", "renderer.proj")           // (Projection) matrix
+	shader.SetBindingForUniform("vwmd", "mat4", "renderer.vwmd")           // (View * Models) matrix
+	shader.SetBindingForUniform("nrml", "mat3", "renderer.normal")         // (Normal) matrix
+	shader.SetBindingForUniform("spec", "vec3", "material.specular")       // material specular color
+	shader.SetBindingForUniform("shin", "float", "material.shininess")     // material shininess
+	shader.SetBindingForUniform("emis", "vec3", "material.emissive")       // material emissive color
+	shader.SetBindingForUniform("nscale", "float", "material.normalscale") // normal map scale
+	shader.SetBindingForUniform("occl", "float", "material.occlusion")     // occlusion map strength
+	set_bindings_for_texture_map(shader, "dmap", "diffuse", 0)             // diffuse map (or the texture of Material)
+	set_bindings_for_texture_map(shader, "nmap", "normal", 1)              // normal map (tangent space)
+	set_bindings_for_texture_map(shader, "smap", "specular", 2)            // specular map
+	set_bindings_for_texture_map(shader, "emap", "emissive", 3)            // emissive map
+	set_bindings_for_texture_map(shader, "omap", "occlusion", 4)           // ambient occlusion map
+	set_bindings_for_texture_map(shader, "lmap", "lightmap", 5)            // lightmap
+	set_bindings_for_lighting(shader)                                      // ambient, directional, point & spot lights
+	shader.SetBindingForAttribute("xyz", "vec3", "geometry.coords")        // point XYZ coordinates
+	shader.SetBindingForAttribute("tuv", "vec2", "geometry.textuv")        // point UV coordinates (texture)
+	shader.SetBindingForAttribute("tuv2", "vec2", "geometry.textuv2")      // point UV coordinates (second texture)
+	shader.SetBindingForAttribute("nor", "vec3", "geometry.normal")        // point normal vector
+	shader.SetBindingForAttribute("tan", "vec4", "geometry.tangent")       // point tangent vector
+	shader.CheckBindings()                                                 // check validity of the shader
+	return shader
+}
+
+func NewShader_Skybox(wctx *wcommon.WebGLContext) *wcommon.Shader {
+	// Shader for (XYZ) Geometry & (CUBE MAP) Material, rendered at the farthest depth
+	// (with the rotation of the camera only, by Renderer.RenderSkybox())
+	var vertex_shader_code = `
+		precision mediump float;
+		uniform mat4 pvm;			// Projection * View(rotation only) * Model matrix
+		attribute vec3 xyz;			// XYZ coordinates
+		varying vec3 v_dir;			// (varying) direction in WORLD space
+		void main() {
+			vec4 pos = pvm * vec4(xyz.x, xyz.y, xyz.z, 1.0);
+			gl_Position = pos.xyww;		// at the farthest depth (1.0)
+			v_dir = xyz;
+		}`
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform samplerCube cmap;	// cube map sampler (unit)
+		varying vec3 v_dir;			// (varying) direction in WORLD space
+		void main() {
+			gl_FragColor = vec4(textureCube(cmap, v_dir).rgb, 1.0);
+		}`
+	shader, _ := wcommon.NewShader(wctx, vertex_shader_code, fragment_shader_code)
+	shader.SetBindingForUniform("pvm", "mat4", "renderer.pvm")               // (Proj * View * Models) matrix
+	shader.SetBindingForUniform("cmap", "samplerCube", "material.cubemap:6") // cube map sampler (unit:6)
+	shader.SetBindingForAttribute("xyz", "vec3", "geometry.coords")          // point XYZ coordinates
+	shader.CheckBindings()                                                   // check validity of the shader
+	return shader
+}
+
+func NewShader_NormalColorEnvironment(wctx *wcommon.WebGLContext) *wcommon.Shader {
+	// Shader for (XYZ + NORMAL) Geometry & (COLOR + SPECULAR + CUBE MAP) Material & (ALL) Lighting,
+	// with per-fragment Blinn-Phong shading, reflecting and refracting the environment (cube map)
+	// (like NewMaterial_Mirror() or NewMaterial_Glass())
+	var vertex_shader_code = _VSHADER_FOR_FRAGMENT_LIGHTING
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform vec4  color;		// material color
+		uniform vec3  spec;			// material specular color
+		uniform float shin;			// material shininess (specular exponent)
+		uniform float refl;			// material reflectivity
+		uniform float eta;			// ratio of indices of refraction (0 for opaque)
+		uniform mat4  view;			// View matrix (for directions in WORLD space)
+		uniform samplerCube cmap;	// cube map sampler (unit)
+		varying vec3  v_pos;		// (varying) position in camera space
+		varying vec3  v_nor;		// (varying) normal vector in camera space` + _GLSL_LIGHTING + `
+		vec3 sample_environment(vec3 dir) {
+			vec3 w = vec3(dot(view[0].xyz, dir), dot(view[1].xyz, dir), dot(view[2].xyz, dir));
+			return textureCube(cmap, w).rgb;
+		}
+		void main() {
+			vec3 n = normalize(v_nor);
+			vec3 v = normalize(v_pos);		// direction from the camera
+			vec3 diffuse, specular;
+			compute_lighting(v_pos, n, shin, diffuse, specular);
+			vec3 base = color.rgb * (ambient + diffuse);
+			float amount = refl;
+			if (eta > 0.0) {				// transparent, with more reflection at grazing angles (Schlick)
+				base = color.rgb * sample_environment(refract(v, n, eta));
+				amount = refl + (1.0 - refl) * pow(1.0 - max(dot(-v, n), 0.0), 5.0);
+			}
+			vec3 result = mix(base, sample_environment(reflect(v, n)), amount);
+			gl_FragColor = vec4(result + spec * specular, color.a);
+		}`
+	shader, _ := wcommon.NewShader(wctx, vertex_shader_code, fragment_shader_code)
+	shader.SetBindingForUniform("proj", "mat4", "renderer.proj")             // (Projection) matrix
+	shader.SetBindingForUniform("vwmd", "mat4", "renderer.vwmd")             // (View * Models) matrix
+	shader.SetBindingForUniform("nrml", "mat3", "renderer.normal")           // (Normal) matrix
+	shader.SetBindingForUniform("view", "mat4", "renderer.view")             // (View) matrix
+	shader.SetBindingForUniform("color", "vec4", "material.color")           // material color
+	shader.SetBindingForUniform("spec", "vec3", "material.specular")         // material specular color
+	shader.SetBindingForUniform("shin", "float", "material.shininess")       // material shininess
+	shader.SetBindingForUniform("refl", "float", "material.reflectivity")    // material reflectivity
+	shader.SetBindingForUniform("eta", "float", "material.refraction")       // material refraction ratio
+	shader.SetBindingForUniform("cmap", "samplerCube", "material.cubemap:6") // cube map sampler (unit:6)
+	set_bindings_for_lighting(shader)                                        // ambient, directional, point & spot lights
+	shader.SetBindingForAttribute("xyz", "vec3", "geometry.coords")          // point XYZ coordinates
+	shader.SetBindingForAttribute("nor", "vec3", "geometry.normal")          // point normal vectors
+	shader.CheckBindings()                                                   // check validity of the shader
+	return shader
+}
+
+func NewShader_PBR(wctx *wcommon.WebGLContext) *wcommon.Shader {
+	// Shader for (XYZ + UV + NORMAL) Geometry & (PBR metallic-roughness) Material & (ALL) Lighting,
+	// with image-based lighting from the environment map, tone mapping and sRGB output (just like glTF viewers).
+	// Texture units : 0:basecolor, 1:metalrough, 2:normal, 3:occlusion, 4:emissive, 5:environment
+	// (Note that normal mapping requires "OES_standard_derivatives" extension, and it's ignored without the extension)
+	var vertex_shader_code = `
+		precision mediump float;
+		uniform mat4 proj;			// Projection matrix
+		uniform mat4 vwmd;			// ModelView matrix
+		uniform mat3 nrml;			// Normal matrix (inverse transpose of ModelView)
+		attribute vec3 xyz;			// XYZ coordinates
+		attribute vec2 tuv;			// texture coordinates
+		attribute vec3 nor;			// normal vector
+		varying vec3 v_pos;			// (varying) position in camera space
+		varying vec3 v_nor;			// (varying) normal vector in camera space
+		varying vec2 v_tuv;			// (varying) texture coordinates
+		void main() {
+			vec4 pos = vwmd * vec4(xyz.x, xyz.y, xyz.z, 1.0);
+			gl_Position = proj * pos;
+			v_pos = pos.xyz;
+			v_nor = nrml * nor;
+			v_tuv = tuv;
+		}`
+	var extension_code = "#extension GL_OES_standard_derivatives : enable\n"
+	var perturb_normal_code = `
+		vec3 perturb_normal(vec3 normal, vec3 pos, vec2 uv) {
+			vec3 mapn = texture2D(nmap, uv).xyz * 2.0 - 1.0;
+			mapn.xy *= nscale;
+			vec3 dp1 = dFdx(pos), dp2 = dFdy(pos);		// cotangent frame from screen-space derivatives
+			vec2 duv1 = dFdx(uv), duv2 = dFdy(uv);
+			vec3 dp2perp = cross(dp2, normal), dp1perp = cross(normal, dp1);
+			vec3 t = dp2perp * duv1.x + dp1perp * duv2.x;
+			vec3 b = dp2perp * duv1.y + dp1perp * duv2.y;
+			float invmax = inversesqrt(max(max(dot(t, t), dot(b, b)), 1e-8));
+			return normalize(mat3(t * invmax, b * invmax, normal) * mapn);
+		}`
+	has_derivatives := wctx.IsExtensionReady("DERIVATIVES")
+	if !has_derivatives { // without normal mapping (instead of failing to compile)
+		extension_code = ""
+		perturb_normal_code = `
+		vec3 perturb_normal(vec3 normal, vec3 pos, vec2 uv) { return normal; }`
+	}
+	var fragment_shader_code = extension_code + `
+		#ifdef GL_FRAGMENT_PRECISION_HIGH
+		precision highp float;
+		#else
+		precision mediump float;
+		#endif
+		uniform vec4  color;		// base color factor (sRGB)
+		uniform float metal;		// metallic factor
+		uniform float rough;		// roughness factor
+		uniform float nscale;		// normal map scale
+		uniform float ostren;		// occlusion strength
+		uniform vec3  emis;			// emissive factor (sRGB)
+		uniform sampler2D bmap;		// base color map (sRGB)
+		uniform sampler2D mrmap;	// metallic-roughness map (G:roughness, B:metallic)
+		uniform sampler2D nmap;		// normal map (tangent space)
+		uniform sampler2D omap;		// occlusion map (R)
+		uniform sampler2D emap;		// emissive map (sRGB)
+		uniform sampler2D envmap;	// environment map (equirectangular, +Z up in world space)
+		uniform float envint;		// intensity of image-based lighting
+		uniform mat4  view;			// View matrix (to get world directions for environment map)
+		uniform int   tonemap;		// tone mapping (0:NONE, 1:REINHARD, 2:ACES)
+		uniform float exposure;		// exposure for tone mapping
+		varying vec3  v_pos;		// (varying) position in camera space
+		varying vec3  v_nor;		// (varying) normal vector in camera space
+		varying vec2  v_tuv;		// (varying) texture coordinates` + _GLSL_LIGHT_UNIFORMS + _GLSL_SHADOW + `
+		const float PI = 3.14159265;
+		vec3 srgb_to_linear(vec3 c) { return pow(c, vec3(2.2)); }
+		vec3 linear_to_srgb(vec3 c) { return pow(c, vec3(1.0 / 2.2)); }` + perturb_normal_code + `
+		vec3 brdf(vec3 n, vec3 v, vec3 l, vec3 albedo, float metallic, float roughness, vec3 f0) {
+			vec3  h = normalize(v + l);				// Cook-Torrance BRDF with GGX distribution
+			float ndotl = max(dot(n, l), 0.0), ndotv = max(dot(n, v), 1e-4);
+			float ndoth = max(dot(n, h), 0.0), vdoth = max(dot(v, h), 0.0);
+			float a2 = roughness * roughness * roughness * roughness;
+			float dd = ndoth * ndoth * (a2 - 1.0) + 1.0;
+			float d  = a2 / (PI * dd * dd);
+			float k  = (roughness + 1.0) * (roughness + 1.0) / 8.0;
+			float g  = (ndotv / (ndotv * (1.0 - k) + k)) * (ndotl / (ndotl * (1.0 - k) + k));
+			vec3  f  = f0 + (1.0 - f0) * pow(1.0 - vdoth, 5.0);
+			vec3  kd = (1.0 - f) * (1.0 - metallic);
+			return (kd * albedo / PI + d * g * f / (4.0 * ndotv * max(ndotl, 1e-4))) * ndotl;
+		}
+		vec3 sample_environment(vec3 dir, float bias) {
+			vec3 w = normalize(vec3(dot(view[0].xyz, dir), dot(view[1].xyz, dir), dot(view[2].xyz, dir)));
+			vec2 uv = vec2(atan(w.y, w.x) / (2.0 * PI) + 0.5, acos(clamp(w.z, -1.0, 1.0)) / PI);
+			return srgb_to_linear(texture2D(envmap, uv, bias).rgb);
+		}
+		vec2 env_brdf_approx(float ndotv, float roughness) {	// (Karis 2014)
+			const vec4 c0 = vec4(-1.0, -0.0275, -0.572, 0.022);
+			const vec4 c1 = vec4(1.0, 0.0425, 1.04, -0.04);
+			vec4  r = roughness * c0 + c1;
+			float a004 = min(r.x * r.x, exp2(-9.28 * ndotv)) * r.x + r.y;
+			return vec2(-1.04, 1.04) * a004 + r.zw;
+		}
+		vec3 tone_map(vec3 c) {
+			c *= exposure;
+			if (tonemap == 1) {
+				c = c / (1.0 + c);
+			} else if (tonemap == 2) {
+				c = (c * (2.51 * c + 0.03)) / (c * (2.43 * c + 0.59) + 0.14);
+			}
+			return clamp(c, 0.0, 1.0);
+		}
+		void main() {
+			vec4  base = texture2D(bmap, v_tuv);
+			vec3  albedo = srgb_to_linear(color.rgb) * srgb_to_linear(base.rgb);
+			vec4  mr = texture2D(mrmap, v_tuv);
+			float metallic  = clamp(metal * mr.b, 0.0, 1.0);
+			float roughness = clamp(rough * mr.g, 0.04, 1.0);
+			float occlusion = 1.0 + ostren * (texture2D(omap, v_tuv).r - 1.0);
+			vec3  n = perturb_normal(normalize(v_nor), v_pos, v_tuv);
+			vec3  v = normalize(-v_pos);
+			vec3  f0 = mix(vec3(0.04), albedo, metallic);
+			vec3  radiance = vec3(0.0);
+			for (int i = 0; i < 4; i++) {
+				if (i >= dcount) break;
+				float shad = shadow_factor(i, v_pos, normalize(v_nor));
+				radiance += brdf(n, v, ddir[i], albedo, metallic, roughness, f0) * shad * dcolor[i] * PI;
+			}
+			for (int i = 0; i < 4; i++) {
+				if (i >= pcount) break;
+				vec3  ldir = ppos[i] - v_pos;
+				float dist = length(ldir);
+				float attn = 1.0 / (patten[i].x + patten[i].y * dist + patten[i].z * dist * dist);
+				radiance += brdf(n, v, ldir / dist, albedo, metallic, roughness, f0) * attn * pcolor[i] * PI;
+			}
+			for (int i = 0; i < 4; i++) {
+				if (i >= scount) break;
+				vec3  ldir = spos[i] - v_pos;
+				float dist = length(ldir);
+				float attn = 1.0 / (satten[i].x + satten[i].y * dist + satten[i].z * dist * dist);
+				float cone = smoothstep(scone[i].y, scone[i].x, dot(-ldir / dist, sdir[i]));
+				float shad = shadow_factor(4 + i, v_pos, normalize(v_nor));
+				radiance += brdf(n, v, ldir / dist, albedo, metallic, roughness, f0) * attn * cone * shad * scolor[i] * PI;
+			}
+			// image-based lighting (approximated with the mipmaps of the environment map)
+			float ndotv = max(dot(n, v), 1e-4);
+			vec2  ab = env_brdf_approx(ndotv, roughness);
+			vec3  ibl_diffuse  = sample_environment(n, 10.0) * albedo * (1.0 - metallic);
+			vec3  ibl_specular = sample_environment(reflect(-v, n), roughness * 8.0) * (f0 * ab.x + ab.y);
+			radiance += (ambient * albedo + (ibl_diffuse + ibl_specular) * envint) * occlusion;
+			radiance += srgb_to_linear(emis) * srgb_to_linear(texture2D(emap, v_tuv).rgb);
+			gl_FragColor = vec4(linear_to_srgb(tone_map(radiance)), color.a * base.a);
+		}`
+	shader, _ := wcommon.NewShader(wctx, vertex_shader_code, fragment_shader_code)
+	shader.SetBindingForUniform("proj", "mat4", "renderer.proj")                   // (Projection) matrix
+	shader.SetBindingForUniform("vwmd", "mat4", "renderer.vwmd")                   // (View * Models) matrix
+	shader.SetBindingForUniform("nrml", "mat3", "renderer.normal")                 // (Normal) matrix
+	shader.SetBindingForUniform("view", "mat4", "renderer.view")                   // (View) matrix
+	shader.SetBindingForUniform("tonemap", "int", "renderer.tonemap")              // tone mapping
+	shader.SetBindingForUniform("exposure", "float", "renderer.exposure")          // exposure
+	shader.SetBindingForUniform("color", "vec4", "material.color")                 // base color factor
+	shader.SetBindingForUniform("metal", "float", "material.metallic")             // metallic factor
+	shader.SetBindingForUniform("rough", "float", "material.roughness")            // roughness factor
+	shader.SetBindingForUniform("ostren", "float", "material.occlusion")           // occlusion strength
+	shader.SetBindingForUniform("emis", "vec3", "material.emissive")               // emissive factor
+	shader.SetBindingForUniform("bmap", "sampler2D", "material.map:basecolor:0")   // base color map
+	shader.SetBindingForUniform("mrmap", "sampler2D", "material.map:metalrough:1") // metallic-roughness map
+	if has_derivatives {
+		shader.SetBindingForUniform("nscale", "float", "material.normalscale")    // normal map scale
+		shader.SetBindingForUniform("nmap", "sampler2D", "material.map:normal:2") // normal map
+	}
+	shader.SetBindingForUniform("omap", "sampler2D", "material.map:occlusion:3") // occlusion map
+	shader.SetBindingForUniform("emap", "sampler2D", "material.map:emissive:4")  // emissive map
+	shader.SetBindingForUniform("envmap", "sampler2D", "lighting.envmap:5")      // environment map
+	shader.SetBindingForUniform("envint", "float", "lighting.envintensity")      // environment intensity
+	set_bindings_for_lighting(shader)                                            // ambient, directional, point & spot lights
+	shader.SetBindingForAttribute("xyz", "vec3", "geometry.coords")              // point XYZ coordinates
+	shader.SetBindingForAttribute("tuv", "vec2", "geometry.textuv")              // point UV coordinates (texture)
+	shader.SetBindingForAttribute("nor", "vec3", "geometry.normal")              // point normal vector
+	shader.CheckBindings()                                                       // check validity of the shader
+	return shader
+}
+
 func NewShader_TextureOnly(wctx *wcommon.WebGLContext) *wcommon.Shader {
 	// Shader for (XYZ + UV + NORMAL) Geometry & (TEXTURE) Material & (DIRECTIONAL) Lighting
 	var vertex_shader_code = `
@@ -124,6 +581,7 @@ func NewShader_NormalTexture(wctx *wcommon.WebGLContext) *wcommon.Shader {
 		precision mediump float;
 		uniform mat4 proj;			// Projection matrix
 		uniform mat4 vwmd;			// ModelView matrix
+		uniform mat3 nrml;			// Normal matrix (inverse transpose of ModelView)
 		uniform mat3 light;			// directional light ([0]:direction, [1]:color, [2]:ambient) COLUMN-MAJOR!
 		attribute vec3 xyz;			// XYZ coordinates
 		attribute vec2 tuv;			// texture coordinates
@@ -132,9 +590,7 @@ func NewShader_NormalTexture(wctx *wcommon.WebGLContext) *wcommon.Shader {
 		varying vec3 v_light;		// (varying) lighting intensity for the point
 		void main() {
 			gl_Position = proj * vwmd * vec4(xyz.x, xyz.y, xyz.z, 1.0);
-			float s = sqrt( vwmd[0][0]*vwmd[0][0] + vwmd[0][1]*vwmd[0][1] + vwmd[0][2]*vwmd[0][2]);  // scaling 
-			mat3  mvRot = mat3( vwmd[0][0]/s, vwmd[0][1]/s, vwmd[0][2]/s, vwmd[1][0]/s, vwmd[1][1]/s, vwmd[1][2]/s, vwmd[2][0]/s, vwmd[2][1]/s, vwmd[2][2]/s );
-			vec3  normal    = mvRot * nor;               		// normal vector in camera space
+			vec3  normal    = normalize(nrml * nor);     		// normal vector in camera space
 			float intensity = max(dot(normal, light[0]), 0.0);	// light_intensity = dot(face_normal,light_direction)
 			v_light = intensity * light[1] + light[2];        	// intensity * light_color + ambient_color
 			v_tuv = tuv;
@@ -151,6 +607,7 @@ func NewShader_NormalTexture(wctx *wcommon.WebGLContext) *wcommon.Shader {
 	shader, _ := wcommon.NewShader(wctx, vertex_shader_code, fragment_shader_code)
 	shader.SetBindingForUniform("proj", "mat4", "renderer.proj")         // (Projection) matrix
 	shader.SetBindingForUniform("vwmd", "mat4", "renderer.vwmd")         // (View * Models) matrix
+	shader.SetBindingForUniform("nrml", "mat3", "renderer.normal")       // (Normal) matrix
 	shader.SetBindingForUniform("light", "mat3", "lighting.dlight")      // directional lighting
 	shader.SetBindingForUniform("text", "sampler2D", "material.texture") // texture sampler (unit:0)
 	shader.SetBindingForAttribute("xyz", "vec3", "geometry.coords")      // point XYZ coordinates
@@ -166,6 +623,7 @@ func NewShader_InstancePoseColor(wctx *wcommon.WebGLContext) *wcommon.Shader {
 		precision mediump float;
 		uniform mat4 proj;			// Projection matrix
 		uniform mat4 vwmd;			// ModelView matrix
+		uniform mat3 nrml;			// Normal matrix (inverse transpose of ModelView)
 		attribute vec3 xyz;			// XYZ coordinates
 		attribute vec3 nor;			// normal vector
 		attribute vec3 ixyz;		// instance pose : XYZ translation
@@ -175,9 +633,7 @@ func NewShader_InstancePoseColor(wctx *wcommon.WebGLContext) *wcommon.Shader {
 		varying vec3 v_light;    	// (varying) lighting intensity
 		void main() {
 			gl_Position = proj * vwmd * vec4(xyz.x + ixyz[0], xyz.y + ixyz[1], xyz.z + ixyz[2], 1.0);
-			float s = sqrt( vwmd[0][0]*vwmd[0][0] + vwmd[0][1]*vwmd[0][1] + vwmd[0][2]*vwmd[0][2]);  // scaling 
-			mat3  mvRot = mat3( vwmd[0][0]/s, vwmd[0][1]/s, vwmd[0][2]/s, vwmd[1][0]/s, vwmd[1][1]/s, vwmd[1][2]/s, vwmd[2][0]/s, vwmd[2][1]/s, vwmd[2][2]/s );
-			vec3  normal    = mvRot * nor;               		// normal vector in camera space
+			vec3  normal    = normalize(nrml * nor);     		// normal vector in camera space
 			float intensity = max(dot(normal, light[0]), 0.0);	// light_intensity = dot(face_normal,light_direction)
 			v_light = intensity * light[1] + light[2];        	// intensity * light_color + ambient_color
 			v_color = icolor;
@@ -192,6 +648,7 @@ func NewShader_InstancePoseColor(wctx *wcommon.WebGLContext) *wcommon.Shader {
 	shader, _ := wcommon.NewShader(wctx, vertex_shader_code, fragment_shader_code)
 	shader.SetBindingForUniform("proj", "mat4", "renderer.proj")         // (Projection) matrix
 	shader.SetBindingForUniform("vwmd", "mat4", "renderer.vwmd")         // (View * Models) matrix
+	shader.SetBindingForUniform("nrml", "mat3", "renderer.normal")       // (Normal) matrix
 	shader.SetBindingForUniform("light", "mat3", "lighting.dlight")      // directional lighting
 	shader.SetBindingForAttribute("xyz", "vec3", "geometry.coords")      // point XYZ coordinates
 	shader.SetBindingForAttribute("nor", "vec3", "geometry.normal")      // point normal vectors
@@ -200,3 +657,162 @@ func NewShader_InstancePoseColor(wctx *wcommon.WebGLContext) *wcommon.Shader {
 	shader.CheckBindings()                                               // check validity of the shader
 	return shader
 }
+
+// ----------------------------------------------------------------------------
+// Per-Fragment Lighting (shared by the shaders above)
+// ----------------------------------------------------------------------------
+
+// vertex shader passing the position & normal vector in CAMERA space to the fragment shader
+const _VSHADER_FOR_FRAGMENT_LIGHTING = `
+		precision mediump float;
+		uniform mat4 proj;			// Projection matrix
+		uniform mat4 vwmd;			// ModelView matrix
+		uniform mat3 nrml;			// Normal matrix (inverse transpose of ModelView)
+		attribute vec3 xyz;			// XYZ coordinates
+		attribute vec3 nor;			// normal vector
+		varying vec3 v_pos;			// (varying) position in camera space
+		varying vec3 v_nor;			// (varying) normal vector in camera space
+		void main() {
+			vec4 pos = vwmd * vec4(xyz.x, xyz.y, xyz.z, 1.0);
+			gl_Position = proj * pos;
+			v_pos = pos.xyz;
+			v_nor = nrml * nor;
+		}`
+
+// GLSL uniforms for all the lights in CAMERA space, to be included in fragment shaders.
+// (Note that the size of light arrays should be MAX_DIRECTIONAL_LIGHTS, MAX_POINT_LIGHTS & MAX_SPOT_LIGHTS)
+const _GLSL_LIGHT_UNIFORMS = `
+		uniform vec3 ambient;		// ambient light color
+		uniform int  dcount;		// number of directional lights
+		uniform vec3 ddir[4];		// direction toward directional lights
+		uniform vec3 dcolor[4];		// color of directional lights
+		uniform int  pcount;		// number of point lights
+		uniform vec3 ppos[4];		// position of point lights
+		uniform vec3 pcolor[4];		// color of point lights
+		uniform vec3 patten[4];		// attenuation of point lights (constant, linear, quadratic)
+		uniform int  scount;		// number of spot lights
+		uniform vec3 spos[4];		// position of spot lights
+		uniform vec3 sdir[4];		// direction of spot lights
+		uniform vec3 scolor[4];		// color of spot lights
+		uniform vec3 satten[4];		// attenuation of spot lights (constant, linear, quadratic)
+		uniform vec2 scone[4];		// cosine of inner & outer cone angles of spot lights`
+
+// GLSL uniforms & functions for the shadows of directional & spot lights (with the index 0~3 & 4~7),
+// to be included in fragment shaders after _GLSL_LIGHT_UNIFORMS.
+// (Note that the size of shadow arrays should be MAX_SHADOW_MAPS)
+const _GLSL_SHADOW = `
+		#ifdef GL_FRAGMENT_PRECISION_HIGH
+		#define SHADOW_PRECISION highp
+		#else
+		#define SHADOW_PRECISION mediump
+		#endif
+		uniform int  shcount;						// number of shadow maps
+		uniform SHADOW_PRECISION mat4 shmat[4];		// from camera space to shadow maps (XY in clip space, Z in linear depth)
+		uniform SHADOW_PRECISION vec4 shinfo[4];	// (light index, near & far depth of cascade, depth bias)
+		uniform vec4 shtile[4];						// tile of shadow maps in the atlas (U, V, width, height)
+		uniform vec4 shfilt[4];						// (PCF radius, texel size of atlas, normal offset, perspective)
+		uniform sampler2D shmap;					// shadow map atlas (with depth packed in RGBA)
+		uniform float shrecv;						// 1.0 if the object receives shadows
+		SHADOW_PRECISION float unpack_depth(vec4 rgba) {
+			return dot(rgba, vec4(1.0, 1.0 / 255.0, 1.0 / 65025.0, 1.0 / 16581375.0));
+		}
+		float shadow_factor(int light, vec3 pos, vec3 normal) {		// 0.0 (in shadow) ~ 1.0 (lit)
+			if (shrecv < 0.5) return 1.0;
+			for (int k = 0; k < 4; k++) {
+				if (k >= shcount) break;
+				if (abs(shinfo[k].x - float(light)) > 0.5 || -pos.z < shinfo[k].y || -pos.z >= shinfo[k].z) continue;
+				SHADOW_PRECISION vec4 p = shmat[k] * vec4(pos, 1.0);
+				float offset = shfilt[k].z * (shfilt[k].w > 0.5 ? p.w : 1.0);
+				p = shmat[k] * vec4(pos + normal * offset, 1.0);
+				vec2 ndc = p.xy / p.w;
+				if (p.w <= 0.0 || abs(ndc.x) > 1.0 || abs(ndc.y) > 1.0 || p.z > 1.0) return 1.0;
+				vec2 uv = shtile[k].xy + (ndc * 0.5 + 0.5) * shtile[k].zw;
+				vec2 uvmin = shtile[k].xy + 0.5 * shfilt[k].y;
+				vec2 uvmax = shtile[k].xy + shtile[k].zw - 0.5 * shfilt[k].y;
+				SHADOW_PRECISION float depth = p.z - shinfo[k].w;
+				float lit = 0.0, count = 0.0;
+				for (int x = -2; x <= 2; x++) {		// PCF (percentage-closer filtering)
+					for (int y = -2; y <= 2; y++) {
+						if (abs(float(x)) > shfilt[k].x || abs(float(y)) > shfilt[k].x) continue;
+						vec2 suv = clamp(uv + vec2(float(x), float(y)) * shfilt[k].y, uvmin, uvmax);
+						lit += (depth <= unpack_depth(texture2D(shmap, suv)) ? 1.0 : 0.0);
+						count += 1.0;
+					}
+				}
+				return lit / count;
+			}
+			return 1.0;
+		}`
+
+// GLSL uniforms & functions for Blinn-Phong shading with all the lights, to be included in fragment shaders.
+const _GLSL_LIGHTING = _GLSL_LIGHT_UNIFORMS + _GLSL_SHADOW + `
+		void add_light(vec3 normal, vec3 view, vec3 ldir, vec3 lcolor, float shininess, inout vec3 diffuse, inout vec3 specular) {
+			float ndotl = max(dot(normal, ldir), 0.0);
+			diffuse += ndotl * lcolor;
+			if (ndotl > 0.0) {		// Blinn-Phong specular with the halfway vector
+				vec3 hvec = normalize(ldir + view);
+				specular += pow(max(dot(normal, hvec), 0.0), shininess) * lcolor;
+			}
+		}
+		void compute_lighting(vec3 pos, vec3 normal, float shininess, out vec3 diffuse, out vec3 specular) {
+			vec3 view = normalize(-pos);	// direction toward the camera
+			diffuse  = vec3(0.0);
+			specular = vec3(0.0);
+			for (int i = 0; i < 4; i++) {
+				if (i >= dcount) break;
+				add_light(normal, view, ddir[i], shadow_factor(i, pos, normal) * dcolor[i], shininess, diffuse, specular);
+			}
+			for (int i = 0; i < 4; i++) {
+				if (i >= pcount) break;
+				vec3  ldir = ppos[i] - pos;
+				float dist = length(ldir);
+				float attn = 1.0 / (patten[i].x + patten[i].y * dist + patten[i].z * dist * dist);
+				add_light(normal, view, ldir / dist, attn * pcolor[i], shininess, diffuse, specular);
+			}
+			for (int i = 0; i < 4; i++) {
+				if (i >= scount) break;
+				vec3  ldir = spos[i] - pos;
+				float dist = length(ldir);
+				float attn = 1.0 / (satten[i].x + satten[i].y * dist + satten[i].z * dist * dist);
+				float cone = smoothstep(scone[i].y, scone[i].x, dot(-ldir / dist, sdir[i]));
+				float shad = shadow_factor(4 + i, pos, normal);
+				add_light(normal, view, ldir / dist, attn * cone * shad * scolor[i], shininess, diffuse, specular);
+			}
+		}`
+
+func set_bindings_for_lighting(shader *wcommon.Shader) {
+	// bindings for the uniforms in _GLSL_LIGHTING (including _GLSL_SHADOW)
+	shader.SetBindingForUniform("ambient", "vec3", "lighting.ambient") // ambient light
+	shader.SetBindingForUniform("dcount", "int", "lighting.dcount")    // directional lights
+	shader.SetBindingForUniform("ddir", "vec3", "lighting.ddir")       //
+	shader.SetBindingForUniform("dcolor", "vec3", "lighting.dcolor")   //
+	shader.SetBindingForUniform("pcount", "int", "lighting.pcount")    // point lights
+	shader.SetBindingForUniform("ppos", "vec3", "lighting.ppos")       //
+	shader.SetBindingForUniform("pcolor", "vec3", "lighting.pcolor")   //
+	shader.SetBindingForUniform("patten", "vec3", "lighting.patten")   //
+	shader.SetBindingForUniform("scount", "int", "lighting.scount")    // spot lights
+	shader.SetBindingForUniform("spos", "vec3", "lighting.spos")       //
+	shader.SetBindingForUniform("sdir", "vec3", "lighting.sdir")       //
+	shader.SetBindingForUniform("scolor", "vec3", "lighting.scolor")   //
+	shader.SetBindingForUniform("satten", "vec3", "lighting.satten")   //
+	shader.SetBindingForUniform("scone", "vec2", "lighting.scone")     //
+	set_bindings_for_shadow(shader)                                    // shadows of directional & spot lights
+}
+
+func set_bindings_for_shadow(shader *wcommon.Shader) {
+	// bindings for the uniforms in _GLSL_SHADOW (with the shadow map atlas in texture unit 7)
+	shader.SetBindingForUniform("shcount", "int", "shadow.count")
+	shader.SetBindingForUniform("shmat", "mat4", "shadow.matrix")
+	shader.SetBindingForUniform("shinfo", "vec4", "shadow.info")
+	shader.SetBindingForUniform("shtile", "vec4", "shadow.tile")
+	shader.SetBindingForUniform("shfilt", "vec4", "shadow.filter")
+	shader.SetBindingForUniform("shmap", "sampler2D", "shadow.map:7")
+	shader.SetBindingForUniform("shrecv", "float", "shadow.receive")
+}
+
+func set_bindings_for_texture_map(shader *wcommon.Shader, name string, slot string, unit int) {
+	// bindings for a texture map of Material (sampler 'name', with UV transform 'name'+"t" & UV set 'name'+"u")
+	shader.SetBindingForUniform(name, "sampler2D", fmt.Sprintf("material.map:%s:%d", slot, unit))
+	shader.SetBindingForUniform(name+"t", "mat3", "material.maptransform:"+slot)
+	shader.SetBindingForUniform(name+"u", "float", "material.mapuvset:"+slot)
+}
@@ -0,0 +1,304 @@
+package webgl3d
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/go4orward/gowebgl/geom3d"
+	"github.com/go4orward/gowebgl/wcommon"
+)
+
+// ----------------------------------------------------------------------------
+// Shadow Mapping (for DIRECTIONAL & SPOT lights)
+// ----------------------------------------------------------------------------
+
+// shadow_mapper renders the depth of the SceneObjects (with 'CastShadow' flag) seen from each light
+// casting shadows, into the tiles of a single shadow map atlas (2x2 tiles, packed in RGBA / UNSIGNED_BYTE).
+// DIRECTIONAL light may use several tiles for its cascades, which split the view frustum of the camera.
+// All the shadow matrices are built in CAMERA space (not in WORLD space), so that the shadows work
+// with relative-to-eye rendering as well, and the fragment shaders can use them with camera-space positions.
+// Note that the depth is computed in a variant of the original vertex shader (like GPU picking),
+// so the same 'instance.pose' attribute bindings can be used without any change.
+
+const MAX_SHADOW_MAPS = 4 // maximum number of shadow maps (including cascades), which is the size of uniform arrays
+
+type shadow_mapper struct {
+	renderer       *Renderer                           // renderer for binding uniforms & attributes
+	framebuffer    js.Value                            // offscreen framebuffer
+	texture        js.Value                            // color attachment (shadow map atlas, with depth packed in RGBA)
+	depthbuffer    js.Value                            // depth attachment (DEPTH_COMPONENT16)
+	tilesize       int                                 // size of each shadow map (tile) in the atlas
+	wh             [2]int                              // size of the atlas
+	shaders        map[*wcommon.Shader]*wcommon.Shader // depth variants of shaders
+	uniforms       map[string][]float32                // uniform values for "shadow.*" autobindings
+	cascade_lambda float32                             // weight of logarithmic split of cascades (against uniform split)
+}
+
+type shadow_map struct {
+	caster shadow_caster  // light casting the shadow
+	proj   geom3d.Matrix4 // projection matrix of the light
+	view   geom3d.Matrix4 // view matrix of the light (from CAMERA space)
+	depth  [3]float32     // coefficients for linear depth (0.0 ~ 1.0) from (clip.z, clip.w, 1)
+	split  [2]float32     // near & far depth of the cascade (in CAMERA space)
+	texel  float32        // size of a texel (in CAMERA space, or at the distance 1 for SPOT light)
+}
+
+func new_shadow_mapper(renderer *Renderer, tilesize int) *shadow_mapper {
+	mapper := shadow_mapper{renderer: renderer, tilesize: tilesize, wh: [2]int{0, 0}, cascade_lambda: 0.75}
+	mapper.framebuffer = js.Null()
+	mapper.texture = js.Null()
+	mapper.depthbuffer = js.Null()
+	mapper.shaders = map[*wcommon.Shader]*wcommon.Shader{}
+	mapper.reset()
+	return &mapper
+}
+
+func (self *shadow_mapper) reset() {
+	// uniform values without any shadow map
+	self.uniforms = map[string][]float32{
+		"shadow.count":  {0},
+		"shadow.matrix": make([]float32, MAX_SHADOW_MAPS*16),
+		"shadow.info":   make([]float32, MAX_SHADOW_MAPS*4),
+		"shadow.tile":   make([]float32, MAX_SHADOW_MAPS*4),
+		"shadow.filter": make([]float32, MAX_SHADOW_MAPS*4),
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Rendering Shadow Maps
+// ----------------------------------------------------------------------------
+
+func (self *shadow_mapper) Render(scene *Scene, camera *Camera, casters []shadow_caster) error {
+	// Render the shadow maps of all the lights casting shadows, and update the uniform values.
+	wctx := self.renderer.wctx
+	context := wctx.GetContext()
+	constants := wctx.GetConstants()
+	self.reset()
+	smaps := self.build_shadow_maps(camera, casters)
+	if len(smaps) == 0 {
+		return nil
+	}
+	if err := self.setup_framebuffer([2]int{2 * self.tilesize, 2 * self.tilesize}); err != nil {
+		fmt.Println(err.Error())
+		return err
+	}
+	context.Call("bindFramebuffer", constants.FRAMEBUFFER, self.framebuffer)
+	context.Call("clearColor", 1, 1, 1, 1) // the farthest depth
+	context.Call("clear", constants.COLOR_BUFFER_BIT)
+	context.Call("clear", constants.DEPTH_BUFFER_BIT)
+	self.renderer.state.Invalidate()         // WebGL states might have been changed outside
+	self.renderer.state.SetBlendMode("NONE") // packed depth should not be blended
+	self.renderer.state.SetDepthTest(true)   // (with LEQUAL)
+	for k, smap := range smaps {
+		tx, ty := (k%2)*self.tilesize, (k/2)*self.tilesize
+		context.Call("viewport", tx, ty, self.tilesize, self.tilesize)
+		for _, sobj := range scene.objects {
+			vwmd := smap.view.MultiplyToTheRight(camera.GetViewModelMatrix(sobj.origin, &sobj.modelmatrix, self.renderer.rte))
+			self.render_scene_object(sobj, &smap.proj, vwmd, smap.depth)
+		}
+		self.set_uniform_values(k, smap)
+	}
+	self.uniforms["shadow.count"] = []float32{float32(len(smaps))}
+	wcommon.BindRenderTarget(wctx, self.renderer.target) // back to the RenderTarget (or the canvas)
+	return nil
+}
+
+func (self *shadow_mapper) render_scene_object(sobj *SceneObject, proj *geom3d.Matrix4, vwmd *geom3d.Matrix4, depth [3]float32) {
+	if sobj.CastShadow && sobj.FShader != nil && self.renderer.prepare_scene_object_buffers(sobj) == nil {
+		if depth_shader := self.get_depth_shader(sobj.FShader); depth_shader != nil {
+			depth_shader.GetUniformBindings()["_sh_depth"]["value"] = depth[:]
+			self.renderer.render_scene_object_with_shader(sobj, proj, vwmd, 3, depth_shader)
+		}
+	}
+	for _, child := range sobj.children {
+		self.render_scene_object(child, proj, vwmd.MultiplyToTheRight(&child.modelmatrix), depth)
+	}
+}
+
+func (self *shadow_mapper) set_uniform_values(k int, smap *shadow_map) {
+	// matrix from CAMERA space to the shadow map, with (X,Y) in CLIP space and linear depth in Z (not divided by W)
+	pv := smap.proj.MultiplyToTheRight(&smap.view)
+	e, m := pv.GetElements(), geom3d.NewMatrix4()
+	me := m.GetElements()
+	for c := 0; c < 4; c++ { // (COLUMN-MAJOR)
+		w := float32(0)
+		if c == 3 {
+			w = 1
+		}
+		me[c*4+0], me[c*4+1], me[c*4+3] = e[c*4+0], e[c*4+1], e[c*4+3]
+		me[c*4+2] = smap.depth[0]*e[c*4+2] + smap.depth[1]*e[c*4+3] + smap.depth[2]*w
+	}
+	perspective := float32(0)
+	if smap.caster.light.Type == "SPOT" {
+		perspective = 1
+	}
+	light := smap.caster.light
+	copy(self.uniforms["shadow.matrix"][k*16:], me[:])
+	copy(self.uniforms["shadow.info"][k*4:], []float32{float32(smap.caster.code), smap.split[0], smap.split[1], light.ShadowBias[0]})
+	copy(self.uniforms["shadow.tile"][k*4:], []float32{float32(k%2) * 0.5, float32(k/2) * 0.5, 0.5, 0.5})
+	copy(self.uniforms["shadow.filter"][k*4:], []float32{float32(light.ShadowPCF), 1.0 / float32(self.wh[0]), light.ShadowBias[1] * smap.texel, perspective})
+}
+
+// ----------------------------------------------------------------------------
+// Projection of Shadow Maps
+// ----------------------------------------------------------------------------
+
+func (self *shadow_mapper) build_shadow_maps(camera *Camera, casters []shadow_caster) []*shadow_map {
+	smaps := []*shadow_map{}
+	for _, caster := range casters {
+		switch caster.light.Type {
+		case "DIRECTIONAL":
+			splits := self.get_cascade_splits(camera, caster.light)
+			for i := 0; i+1 < len(splits) && len(smaps) < MAX_SHADOW_MAPS; i++ {
+				smaps = append(smaps, self.build_directional_shadow_map(camera, caster, [2]float32{splits[i], splits[i+1]}))
+			}
+		case "SPOT":
+			if len(smaps) < MAX_SHADOW_MAPS {
+				smaps = append(smaps, self.build_spot_shadow_map(caster))
+			}
+		}
+	}
+	return smaps
+}
+
+func (self *shadow_mapper) get_cascade_splits(camera *Camera, light *Light) []float32 {
+	// split the depth range of the camera (up to the shadow distance) with the 'practical split scheme',
+	// which is a weighted average of logarithmic and uniform splits
+	_, _, _, nearfar := camera.projection.GetParameters()
+	near, far := nearfar[0], float32(math.Min(float64(nearfar[1]), float64(light.ShadowDistance)))
+	count, lambda := light.ShadowCascades, self.cascade_lambda
+	if !camera.projection.IsPerspective() {
+		lambda = 0 // uniform split for orthographic camera
+	}
+	splits := make([]float32, count+1)
+	for i := 0; i <= count; i++ {
+		t := float64(i) / float64(count)
+		logarithmic := float64(near) * math.Pow(float64(far/near), t)
+		uniform := float64(near) + float64(far-near)*t
+		splits[i] = float32(float64(lambda)*logarithmic + float64(1-lambda)*uniform)
+	}
+	return splits
+}
+
+func (self *shadow_mapper) build_directional_shadow_map(camera *Camera, caster shadow_caster, split [2]float32) *shadow_map {
+	// orthographic projection of the light, covering the slice of the view frustum of the camera
+	smap := shadow_map{caster: caster, split: split}
+	e := camera.projection.GetMatrix().GetElements()
+	corners := make([][3]float32, 0, 8)
+	for _, z := range split {
+		hw, hh := 1/e[0], 1/e[5] // half width & height of the view frustum (in CAMERA space)
+		if camera.projection.IsPerspective() {
+			hw, hh = z/e[0], z/e[5]
+		}
+		for _, xy := range [4][2]float32{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+			corners = append(corners, [3]float32{xy[0] * hw, xy[1] * hh, -z})
+		}
+	}
+	center := geom3d.AverageAll(corners)
+	smap.view.SetLookAt(geom3d.SubAB(center, caster.direction), center, [3]float32{0, 1, 0})
+	minxyz, maxxyz := [3]float32{math.MaxFloat32, math.MaxFloat32, math.MaxFloat32}, [3]float32{-math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	for _, corner := range corners {
+		p := smap.view.MultiplyVector3(corner)
+		for j := 0; j < 3; j++ {
+			minxyz[j] = float32(math.Min(float64(minxyz[j]), float64(p[j])))
+			maxxyz[j] = float32(math.Max(float64(maxxyz[j]), float64(p[j])))
+		}
+	}
+	// extend the depth range toward the light, to include the casters outside of the slice
+	l, r, b, t := minxyz[0], maxxyz[0], minxyz[1], maxxyz[1]
+	n, f := -maxxyz[2]-caster.light.ShadowDistance, -minxyz[2]
+	smap.proj.Set(
+		2/(r-l), 0, 0, -(r+l)/(r-l),
+		0, 2/(t-b), 0, -(t+b)/(t-b),
+		0, 0, -2/(f-n), -(f+n)/(f-n),
+		0, 0, 0, 1)
+	smap.depth = [3]float32{0.5, 0, 0.5} // (clip.z * 0.5 + 0.5)
+	smap.texel = float32(math.Max(float64(r-l), float64(t-b))) / float32(self.tilesize)
+	return &smap
+}
+
+func (self *shadow_mapper) build_spot_shadow_map(caster shadow_caster) *shadow_map {
+	// perspective projection of the light, covering its outer cone
+	smap := shadow_map{caster: caster, split: [2]float32{0, math.MaxFloat32}}
+	up := [3]float32{0, 1, 0}
+	smap.view.SetLookAt(caster.position, geom3d.AddAB(caster.position, caster.direction), up)
+	fov := math.Min(float64(caster.light.SpotAngles[1])*2+2, 170) * math.Pi / 180.0
+	ff := float32(1 / math.Tan(fov/2))
+	n, f := caster.light.ShadowDistance/1000, caster.light.ShadowDistance
+	smap.proj.Set(
+		ff, 0, 0, 0,
+		0, ff, 0, 0,
+		0, 0, -(f+n)/(f-n), -2*f*n/(f-n),
+		0, 0, -1, 0)
+	smap.depth = [3]float32{0, 1 / (f - n), -n / (f - n)} // ((clip.w - n) / (f - n))
+	smap.texel = 2 / ff / float32(self.tilesize)
+	return &smap
+}
+
+// ----------------------------------------------------------------------------
+// Framebuffer & Shader for Shadow Maps
+// ----------------------------------------------------------------------------
+
+func (self *shadow_mapper) setup_framebuffer(wh [2]int) error {
+	// create the offscreen framebuffer, or resize it (if the size of shadow maps has changed)
+	context := self.renderer.wctx.GetContext()
+	c := self.renderer.wctx.GetConstants()
+	if self.framebuffer.IsNull() {
+		self.framebuffer = context.Call("createFramebuffer")
+		self.texture = context.Call("createTexture")
+		self.depthbuffer = context.Call("createRenderbuffer")
+	} else if self.wh == wh {
+		return nil
+	}
+	self.wh = wh
+	context.Call("bindTexture", c.TEXTURE_2D, self.texture)
+	context.Call("texImage2D", c.TEXTURE_2D, 0, c.RGBA, wh[0], wh[1], 0, c.RGBA, c.UNSIGNED_BYTE, js.Null())
+	context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_S, c.CLAMP_TO_EDGE)
+	context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_T, c.CLAMP_TO_EDGE)
+	context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MIN_FILTER, c.NEAREST)
+	context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MAG_FILTER, c.NEAREST)
+	context.Call("bindRenderbuffer", c.RENDERBUFFER, self.depthbuffer)
+	context.Call("renderbufferStorage", c.RENDERBUFFER, c.DEPTH_COMPONENT16, wh[0], wh[1])
+	context.Call("bindFramebuffer", c.FRAMEBUFFER, self.framebuffer)
+	context.Call("framebufferTexture2D", c.FRAMEBUFFER, c.COLOR_ATTACHMENT0, c.TEXTURE_2D, self.texture, 0)
+	context.Call("framebufferRenderbuffer", c.FRAMEBUFFER, c.DEPTH_ATTACHMENT, c.RENDERBUFFER, self.depthbuffer)
+	status := context.Call("checkFramebufferStatus", c.FRAMEBUFFER)
+	context.Call("bindFramebuffer", c.FRAMEBUFFER, js.Null())
+	context.Call("bindRenderbuffer", c.RENDERBUFFER, js.Null())
+	context.Call("bindTexture", c.TEXTURE_2D, js.Null())
+	if !status.Equal(c.FRAMEBUFFER_COMPLETE) {
+		return errors.New("Failed to setup framebuffer for shadow maps : incomplete framebuffer")
+	}
+	return nil
+}
+
+func (self *shadow_mapper) get_depth_shader(shader *wcommon.Shader) *wcommon.Shader {
+	if depth_shader, ok := self.shaders[shader]; ok {
+		return depth_shader // (it can be nil, if it failed to compile before)
+	}
+	// The original vertex shader is followed by computing the linear depth (0.0 ~ 1.0) with '_sh_depth',
+	// which is packed into RGBA in the fragment shader.
+	decls := "uniform highp vec3 _sh_depth;\nvarying highp float _sh_z;"
+	vmain := "	_sh_z = dot(vec3(gl_Position.z, gl_Position.w, 1.0), _sh_depth);"
+	fsource := `#ifdef GL_FRAGMENT_PRECISION_HIGH
+precision highp float;
+#else
+precision mediump float;
+#endif
+varying float _sh_z;
+void main() {
+	vec4 rgba = fract(clamp(_sh_z, 0.0, 0.999999) * vec4(1.0, 255.0, 65025.0, 16581375.0));
+	gl_FragColor = rgba - rgba.yzww * vec4(1.0 / 255.0, 1.0 / 255.0, 1.0 / 255.0, 0.0);
+}`
+	depth_shader, err := shader.BuildVariant(decls, vmain, fsource)
+	if err == nil {
+		depth_shader.SetBindingForUniform("_sh_depth", "vec3", []float32{0.5, 0, 0.5})
+		depth_shader.CheckBindings()
+	} else {
+		depth_shader = nil
+	}
+	self.shaders[shader] = depth_shader
+	return depth_shader
+}
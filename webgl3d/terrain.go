@@ -0,0 +1,317 @@
+package webgl3d
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"math"
+
+	"github.com/go4orward/gowebgl/geom3d"
+	"github.com/go4orward/gowebgl/wcommon"
+)
+
+// ----------------------------------------------------------------------------
+// Terrain (from heightmap)
+// ----------------------------------------------------------------------------
+// Terrain lies on XY plane (with Z for height), and it is split into square chunks of cells.
+// Each chunk has its own SceneObject, whose Geometry is switched among the cached LOD geometries
+// (LOD 0 with every sample, LOD k with every 2^k-th sample) by UpdateLOD() with the camera.
+// Skirts (vertical strips hanging down along the border of each chunk) hide the cracks between
+// neighboring chunks of different LOD.
+
+type Terrain struct {
+	dims         [2]int     // number of height samples along X and Y axis
+	spacing      [2]float32 // distance between samples along X and Y axis
+	heights      []float32  // height samples, with X varying fastest : heights[y*nx+x]
+	exaggeration float32    // vertical exaggeration (scale of heights)
+	chunk_size   int        // number of cells along each side of a chunk
+	lod_levels   int        // number of LOD levels
+	lod_distance float32    // distance from the camera, beyond which LOD 1 is used (LOD k beyond distance*2^(k-1))
+	skirt_depth  float32    // depth of skirts (relative to the lowest height of the chunk)
+	skirt_auto   bool       // true, if skirt_depth is derived from the chunk size and the height range
+	chunks       []*TerrainChunk
+}
+
+type TerrainChunk struct {
+	cell_range [2][2]int    // range of cells [[x0,y0], [x1,y1]] (exclusive for [x1,y1])
+	center     [3]float32   // center of the chunk (for LOD decision)
+	geometries []*Geometry  // cached geometries for each LOD
+	lod        int          // current LOD
+	scnobj     *SceneObject // SceneObject of the chunk
+}
+
+func NewTerrain(dims [2]int, spacing [2]float32, heights []float32) *Terrain {
+	// Create a terrain from the grid of height samples (with X varying fastest : heights[y*nx+x])
+	if dims[0] < 2 || dims[1] < 2 || len(heights) != dims[0]*dims[1] {
+		fmt.Printf("Invalid number of heights (%d) for Terrain of %v\n", len(heights), dims)
+		return nil
+	}
+	terrain := Terrain{dims: dims, spacing: spacing, heights: heights, exaggeration: 1.0}
+	terrain.chunk_size = 32
+	terrain.lod_levels = 4
+	terrain.lod_distance = 32 * float32(math.Max(float64(spacing[0]), float64(spacing[1]))) * 2
+	terrain.skirt_depth, terrain.skirt_auto = terrain.get_default_skirt_depth(), true
+	return &terrain
+}
+
+func NewTerrainFromImage(img image.Image, spacing [2]float32, height_range [2]float32) *Terrain {
+	// Create a terrain from grayscale heightmap image, with black for height_range[0] and white for height_range[1].
+	// Note that the top row of the image is the north (+Y) side of the terrain.
+	size := img.Bounds().Size()
+	min := img.Bounds().Min
+	heights := make([]float32, size.X*size.Y)
+	for row := 0; row < size.Y; row++ {
+		y := size.Y - 1 - row
+		for x := 0; x < size.X; x++ {
+			gray := color.Gray16Model.Convert(img.At(min.X+x, min.Y+row)).(color.Gray16)
+			heights[y*size.X+x] = height_range[0] + (height_range[1]-height_range[0])*float32(gray.Y)/65535
+		}
+	}
+	return NewTerrain([2]int{size.X, size.Y}, spacing, heights)
+}
+
+func LoadTerrainFromServer(path string, spacing [2]float32, height_range [2]float32, callback func(terrain *Terrain)) {
+	// Load grayscale heightmap image (PNG or JPEG) from server path, and call 'callback' with the new terrain.
+	go func() {
+		img, err := wcommon.LoadImageFromServer(path)
+		if err != nil {
+			log.Println(err.Error())
+			return
+		}
+		if terrain := NewTerrainFromImage(img, spacing, height_range); terrain != nil {
+			callback(terrain)
+		}
+	}()
+}
+
+func (self *Terrain) ShowInfo() {
+	fmt.Printf("Terrain with %dx%d samples (spacing %v)  exaggeration %.2f\n", self.dims[0], self.dims[1], self.spacing, self.exaggeration)
+	fmt.Printf("    chunks : %d  (chunk_size %d, lod_levels %d, lod_distance %.2f, skirt_depth %.2f)\n",
+		len(self.chunks), self.chunk_size, self.lod_levels, self.lod_distance, self.skirt_depth)
+}
+
+// ----------------------------------------------------------------------------
+// Settings
+// ----------------------------------------------------------------------------
+
+func (self *Terrain) SetVerticalExaggeration(exaggeration float32) *Terrain {
+	self.exaggeration = exaggeration
+	if self.skirt_auto {
+		self.skirt_depth = self.get_default_skirt_depth()
+	}
+	self.clear_geometries()
+	return self
+}
+
+func (self *Terrain) SetChunking(chunk_size int, lod_levels int, lod_distance float32) *Terrain {
+	// 'chunk_size'   : number of cells along each side of a chunk (power of 2, like 32 or 64)
+	// 'lod_levels'   : number of LOD levels (LOD k uses every 2^k-th sample)
+	// 'lod_distance' : distance from the camera, beyond which LOD 1 is used (LOD k beyond lod_distance*2^(k-1))
+	// Note that it should be called before GetSceneObjects(), since the chunks are built again (with new SceneObjects).
+	// SceneObjects taken before are left unchanged, so they have to be removed from the Scene and replaced by new ones.
+	if chunk_size < 1 || lod_levels < 1 {
+		fmt.Printf("Invalid chunking (chunk_size %d, lod_levels %d) for Terrain\n", chunk_size, lod_levels)
+		return self
+	}
+	self.chunk_size, self.lod_levels, self.lod_distance = chunk_size, lod_levels, lod_distance
+	if self.skirt_auto {
+		self.skirt_depth = self.get_default_skirt_depth()
+	}
+	self.chunks = nil
+	return self
+}
+
+func (self *Terrain) SetSkirtDepth(depth float32) *Terrain {
+	// Set the depth of skirts explicitly (0 to disable them), instead of the default one
+	self.skirt_depth, self.skirt_auto = depth, false
+	self.clear_geometries()
+	return self
+}
+
+func (self *Terrain) get_default_skirt_depth() float32 {
+	// Cracks between the chunks of different LOD can't be deeper than the height range (of the whole terrain),
+	// and they are usually much smaller than the size of a chunk, so skirts are as deep as the smaller one.
+	hmin, hmax := self.heights[0], self.heights[0]
+	for _, h := range self.heights {
+		hmin, hmax = float32(math.Min(float64(hmin), float64(h))), float32(math.Max(float64(hmax), float64(h)))
+	}
+	size := float64(self.chunk_size) * math.Max(float64(self.spacing[0]), float64(self.spacing[1]))
+	depth := math.Min(float64(hmax-hmin)*math.Abs(float64(self.exaggeration)), size/4)
+	return float32(math.Max(depth, size/100)) // (never zero, even for flat terrain)
+}
+
+func (self *Terrain) clear_geometries() {
+	for _, chunk := range self.chunks {
+		chunk.geometries = make([]*Geometry, self.lod_levels)
+		if chunk.scnobj != nil {
+			chunk.scnobj.Geometry = self.get_chunk_geometry(chunk, chunk.lod)
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Heights
+// ----------------------------------------------------------------------------
+
+func (self *Terrain) GetSample(x int, y int) float32 {
+	// height of the sample (with vertical exaggeration)
+	return self.heights[y*self.dims[0]+x] * self.exaggeration
+}
+
+func (self *Terrain) GetHeightAt(px float32, py float32) float32 {
+	// height at the position (bilinear interpolation, with vertical exaggeration)
+	fx := math.Max(0, math.Min(float64(px/self.spacing[0]), float64(self.dims[0]-1)))
+	fy := math.Max(0, math.Min(float64(py/self.spacing[1]), float64(self.dims[1]-1)))
+	x0, y0 := int(math.Min(math.Floor(fx), float64(self.dims[0]-2))), int(math.Min(math.Floor(fy), float64(self.dims[1]-2)))
+	tx, ty := float32(fx)-float32(x0), float32(fy)-float32(y0)
+	h00, h10 := self.GetSample(x0, y0), self.GetSample(x0+1, y0)
+	h01, h11 := self.GetSample(x0, y0+1), self.GetSample(x0+1, y0+1)
+	return (h00*(1-tx)+h10*tx)*(1-ty) + (h01*(1-tx)+h11*tx)*ty
+}
+
+func (self *Terrain) GetNormalAt(x int, y int) [3]float32 {
+	// normal vector at the sample, using central differences of full resolution (so that LODs are shaded alike)
+	x0, x1 := int(math.Max(float64(x-1), 0)), int(math.Min(float64(x+1), float64(self.dims[0]-1)))
+	y0, y1 := int(math.Max(float64(y-1), 0)), int(math.Min(float64(y+1), float64(self.dims[1]-1)))
+	dhdx := (self.GetSample(x1, y) - self.GetSample(x0, y)) / (float32(x1-x0) * self.spacing[0])
+	dhdy := (self.GetSample(x, y1) - self.GetSample(x, y0)) / (float32(y1-y0) * self.spacing[1])
+	return geom3d.Normalize([3]float32{-dhdx, -dhdy, 1})
+}
+
+func (self *Terrain) GetSize() [2]float32 {
+	return [2]float32{float32(self.dims[0]-1) * self.spacing[0], float32(self.dims[1]-1) * self.spacing[1]}
+}
+
+// ----------------------------------------------------------------------------
+// Chunks & SceneObjects
+// ----------------------------------------------------------------------------
+
+func (self *Terrain) GetSceneObjects(material *wcommon.Material, vshader *wcommon.Shader, eshader *wcommon.Shader, fshader *wcommon.Shader) []*SceneObject {
+	// Get the SceneObjects (one for each chunk) to be added to the Scene, like 'scene.Add(terrain.GetSceneObjects(...)...)'.
+	// The geometries have PER_VERT normal vectors and texture UV coordinates (draping a single texture over the terrain).
+	if self.chunks == nil {
+		self.build_chunks()
+	}
+	scnobjs := make([]*SceneObject, len(self.chunks))
+	for i, chunk := range self.chunks {
+		if chunk.scnobj == nil {
+			chunk.scnobj = NewSceneObject(self.get_chunk_geometry(chunk, chunk.lod), material, vshader, eshader, fshader)
+		}
+		scnobjs[i] = chunk.scnobj
+	}
+	return scnobjs
+}
+
+func (self *Terrain) UpdateLOD(camera *Camera) int {
+	// Choose the LOD of each chunk by its distance from the camera, and return the number of chunks changed.
+	// Note that the terrain is assumed to be rendered without any transformation (identity model matrix).
+	if self.chunks == nil {
+		return 0
+	}
+	changed := 0
+	cam_center := camera.GetCenter()
+	for _, chunk := range self.chunks {
+		lod := 0
+		distance := geom3d.Length(geom3d.SubAB(cam_center, chunk.center))
+		if self.lod_distance > 0 && distance > self.lod_distance {
+			lod = int(math.Floor(math.Log2(float64(distance/self.lod_distance)))) + 1
+		}
+		if lod > self.lod_levels-1 {
+			lod = self.lod_levels - 1
+		}
+		if lod != chunk.lod {
+			chunk.lod = lod
+			if chunk.scnobj != nil {
+				chunk.scnobj.Geometry = self.get_chunk_geometry(chunk, lod)
+			}
+			changed++
+		}
+	}
+	return changed
+}
+
+func (self *Terrain) build_chunks() {
+	self.chunks = []*TerrainChunk{}
+	ncx, ncy := self.dims[0]-1, self.dims[1]-1 // number of cells
+	for y0 := 0; y0 < ncy; y0 += self.chunk_size {
+		for x0 := 0; x0 < ncx; x0 += self.chunk_size {
+			x1, y1 := int(math.Min(float64(x0+self.chunk_size), float64(ncx))), int(math.Min(float64(y0+self.chunk_size), float64(ncy)))
+			chunk := TerrainChunk{cell_range: [2][2]int{{x0, y0}, {x1, y1}}, lod: 0}
+			chunk.geometries = make([]*Geometry, self.lod_levels)
+			cx, cy := float32(x0+x1)/2*self.spacing[0], float32(y0+y1)/2*self.spacing[1]
+			chunk.center = [3]float32{cx, cy, self.GetHeightAt(cx, cy)}
+			self.chunks = append(self.chunks, &chunk)
+		}
+	}
+}
+
+func (self *Terrain) get_chunk_geometry(chunk *TerrainChunk, lod int) *Geometry {
+	if chunk.geometries[lod] == nil {
+		chunk.geometries[lod] = self.build_chunk_geometry(chunk, lod)
+	}
+	return chunk.geometries[lod]
+}
+
+func (self *Terrain) build_chunk_geometry(chunk *TerrainChunk, lod int) *Geometry {
+	geometry := NewGeometry()
+	step := 1 << uint(lod)
+	// sample indices along X and Y (always including the last one, for the border shared with the next chunk)
+	get_samples := func(s0 int, s1 int) []int {
+		samples := []int{}
+		for s := s0; s < s1; s += step {
+			samples = append(samples, s)
+		}
+		return append(samples, s1)
+	}
+	xs := get_samples(chunk.cell_range[0][0], chunk.cell_range[1][0])
+	ys := get_samples(chunk.cell_range[0][1], chunk.cell_range[1][1])
+	nx, ny := len(xs), len(ys)
+	add_vertex := func(x int, y int, z float32) uint32 {
+		vidx := geometry.AddVertex([3]float32{float32(x) * self.spacing[0], float32(y) * self.spacing[1], z})
+		geometry.AddNormal(self.GetNormalAt(x, y))
+		geometry.AddTextureUV([]float32{float32(x) / float32(self.dims[0]-1), float32(y) / float32(self.dims[1]-1)})
+		return vidx
+	}
+	lowest := float32(+3.4e38)
+	for _, y := range ys {
+		for _, x := range xs {
+			h := self.GetSample(x, y)
+			lowest = float32(math.Min(float64(lowest), float64(h)))
+			add_vertex(x, y, h)
+		}
+	}
+	for j := 0; j < ny-1; j++ {
+		for i := 0; i < nx-1; i++ {
+			v00 := uint32(j*nx + i)
+			geometry.AddFace([]uint32{v00, v00 + 1, v00 + 1 + uint32(nx), v00 + uint32(nx)}) // CCW seen from above
+		}
+	}
+	if self.skirt_depth > 0 {
+		// border vertices in CCW order (seen from above), starting from the corner (x0,y0)
+		border := []uint32{}
+		for i := 0; i < nx-1; i++ { // south
+			border = append(border, uint32(i))
+		}
+		for j := 0; j < ny-1; j++ { // east
+			border = append(border, uint32(j*nx+nx-1))
+		}
+		for i := nx - 1; i > 0; i-- { // north
+			border = append(border, uint32((ny-1)*nx+i))
+		}
+		for j := ny - 1; j > 0; j-- { // west
+			border = append(border, uint32(j*nx))
+		}
+		skirt := make([]uint32, len(border))
+		for k, vidx := range border {
+			x, y := xs[int(vidx)%nx], ys[int(vidx)/nx]
+			skirt[k] = add_vertex(x, y, lowest-self.skirt_depth)
+		}
+		for k := 0; k < len(border); k++ {
+			next := (k + 1) % len(border)
+			geometry.AddFace([]uint32{skirt[k], skirt[next], border[next], border[k]}) // facing outward
+		}
+	}
+	geometry.BuildDataBuffers(true, false, true)
+	return geometry
+}
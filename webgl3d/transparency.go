@@ -0,0 +1,362 @@
+package webgl3d
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"syscall/js"
+
+	"github.com/go4orward/gowebgl/geom3d"
+	"github.com/go4orward/gowebgl/wcommon"
+)
+
+// ----------------------------------------------------------------------------
+// Transparency (OPAQUE & TRANSPARENT passes)
+// ----------------------------------------------------------------------------
+
+// RenderScene() renders all the OPAQUE SceneObjects first, and then the TRANSPARENT ones (with 'UseBlend')
+// without writing depth, depending on the transparency mode of the Renderer:
+//   "NONE"   : all the SceneObjects in the order of the scene (except OPAQUE ones sorted by their states)
+//   "SORTED" : TRANSPARENT SceneObjects sorted back-to-front by the distance of their origins from the camera,
+//              with their faces sorted as well (only if 'SortFaces' is set, and without instance poses)
+//   "OIT"    : weighted blended order-independent transparency (McGuire & Bavoil, 2013) for "PREMULTIPLIED" and
+//              "NON_PREMULTIPLIED" blending (others are "SORTED"), which works for heavily overlapping objects.
+//              It requires rendering into HALF_FLOAT textures, and falls back to "SORTED" without them.
+
+type render_item struct {
+	sobj     *SceneObject    //
+	vwmd     *geom3d.Matrix4 // (View * Model) matrix
+	model    *geom3d.Matrix4 // Model matrix (including those of its parents, relative to 'origin')
+	origin   [3]float64      // origin of the top-level SceneObject (in WORLD space)
+	distance float32         // squared distance of the origin from the camera
+}
+
+func collect_render_items(sobj *SceneObject, origin [3]float64, model *geom3d.Matrix4, vwmd *geom3d.Matrix4, items []render_item) []render_item {
+	// Collect the SceneObject and all its children (in the order of RenderSceneObject())
+	e := vwmd.GetElements() // origin of the object in CAMERA space is (e[12], e[13], e[14])
+	items = append(items, render_item{sobj: sobj, vwmd: vwmd, model: model, origin: origin, distance: e[12]*e[12] + e[13]*e[13] + e[14]*e[14]})
+	for _, child := range sobj.children {
+		child_model := model.MultiplyToTheRight(&child.modelmatrix)
+		items = collect_render_items(child, origin, child_model, vwmd.MultiplyToTheRight(&child.modelmatrix), items)
+	}
+	return items
+}
+
+func (self *Renderer) render_items(items []render_item, proj *geom3d.Matrix4) {
+	if self.transparency == "NONE" {
+		sort_render_queue(items)
+		for _, item := range items {
+			self.render_scene_object(item.sobj, proj, item.vwmd)
+		}
+		return
+	}
+	opaque, transparent := []render_item{}, []render_item{}
+	for _, item := range items {
+		if item.sobj.UseBlend {
+			transparent = append(transparent, item)
+		} else {
+			opaque = append(opaque, item)
+		}
+	}
+	sort_render_queue(opaque)
+	for _, item := range opaque {
+		self.render_scene_object(item.sobj, proj, item.vwmd)
+	}
+	if len(transparent) == 0 {
+		return
+	}
+	sort.SliceStable(transparent, func(a, b int) bool { return transparent[a].distance > transparent[b].distance }) // farthest first
+	if self.transparency == "OIT" && self.oit.is_supported() {
+		blended, others := []render_item{}, []render_item{}
+		for _, item := range transparent {
+			if mode := item.sobj.BlendMode; mode == "" || mode == "PREMULTIPLIED" || mode == "NON_PREMULTIPLIED" {
+				blended = append(blended, item)
+			} else {
+				others = append(others, item)
+			}
+		}
+		if err := self.oit.Render(opaque, blended, proj); err == nil {
+			transparent = others
+		}
+	}
+	self.state.SetDepthMask(false) // TRANSPARENT objects should not hide the others behind them
+	for _, item := range transparent {
+		if g, ok := item.sobj.Geometry.(*Geometry); ok && item.sobj.SortFaces && item.sobj.poses == nil {
+			g.SortFacesBackToFront(self.wctx, item.vwmd)
+		}
+		self.render_scene_object(item.sobj, proj, item.vwmd)
+	}
+	self.state.SetDepthMask(true)
+}
+
+// ----------------------------------------------------------------------------
+// Weighted Blended Order-Independent Transparency
+// ----------------------------------------------------------------------------
+
+// oit_renderer accumulates TRANSPARENT fragments into two offscreen textures, sharing a depth buffer
+// (filled with the depth of OPAQUE objects first), in two passes without depending on "WEBGL_draw_buffers":
+//   ACCUM  : sum of (premultiplied color, alpha) weighted by depth & alpha  (HALF_FLOAT, blended with ONE & ONE)
+//   REVEAL : product of (1 - alpha)                                        (UNSIGNED_BYTE, with ZERO & ONE_MINUS_SRC_COLOR)
+// and then composites their weighted average onto the RenderTarget (or the canvas).
+// Note that the fragment shaders of the objects are extended with variants (like highlight shaders).
+
+const (
+	oit_pass_none   = 0 // normal rendering
+	oit_pass_depth  = 1 // depth of OPAQUE objects
+	oit_pass_accum  = 2 // accumulation of TRANSPARENT objects
+	oit_pass_reveal = 3 // revealage of TRANSPARENT objects
+)
+
+type oit_renderer struct {
+	renderer     *Renderer                                   // renderer for rendering the SceneObjects
+	framebuffers [2]js.Value                                 // offscreen framebuffers for ACCUM & REVEAL
+	textures     [2]js.Value                                 // color attachments for ACCUM & REVEAL
+	depthbuffer  js.Value                                    // depth attachment shared by both framebuffers
+	wh           [2]int                                      // size of the framebuffers
+	supported    int                                         // 0:UNKNOWN, 1:SUPPORTED, -1:NOT_SUPPORTED
+	pass         int                                         // OIT pass being rendered
+	shaders      map[*wcommon.Shader]map[int]*wcommon.Shader // ACCUM & REVEAL variants of shaders
+	composite    *wcommon.Shader                             // shader for compositing onto the RenderTarget
+	triangle     js.Value                                    // vertex buffer of a single triangle covering the whole screen
+}
+
+func new_oit_renderer(renderer *Renderer) *oit_renderer {
+	oit := oit_renderer{renderer: renderer, wh: [2]int{0, 0}, supported: 0, pass: oit_pass_none}
+	oit.framebuffers = [2]js.Value{js.Null(), js.Null()}
+	oit.textures = [2]js.Value{js.Null(), js.Null()}
+	oit.depthbuffer = js.Null()
+	oit.shaders = map[*wcommon.Shader]map[int]*wcommon.Shader{}
+	return &oit
+}
+
+func (self *oit_renderer) is_supported() bool {
+	if self.supported == 0 {
+		wctx := self.renderer.wctx
+		if !wctx.IsExtensionReady("HALFFLOAT") {
+			wctx.SetupExtension("HALFFLOAT")
+		}
+		if wctx.IsExtensionReady("HALFFLOAT") {
+			self.supported = 1
+		} else {
+			fmt.Printf("Renderer Warning : HALF_FLOAT textures not supported for 'OIT' (using 'SORTED' instead)\n")
+			self.supported = -1
+		}
+	}
+	return self.supported > 0
+}
+
+func (self *oit_renderer) Render(opaque []render_item, blended []render_item, proj *geom3d.Matrix4) error {
+	wctx := self.renderer.wctx
+	context := wctx.GetContext()
+	constants := wctx.GetConstants()
+	wh := self.renderer.get_target_wh()
+	if err := self.setup_framebuffers(wh); err != nil {
+		fmt.Println(err.Error())
+		self.supported = -1 // fall back to "SORTED"
+		return err
+	}
+	if len(blended) == 0 {
+		return nil
+	}
+	// 1. Render the depth of OPAQUE objects (so that TRANSPARENT fragments behind them are discarded)
+	context.Call("bindFramebuffer", constants.FRAMEBUFFER, self.framebuffers[0])
+	context.Call("viewport", 0, 0, wh[0], wh[1])
+	context.Call("clearColor", 0, 0, 0, 0)
+	context.Call("clear", constants.COLOR_BUFFER_BIT)
+	context.Call("clear", constants.DEPTH_BUFFER_BIT)
+	context.Call("disable", constants.BLEND)
+	context.Call("colorMask", false, false, false, false)
+	self.pass = oit_pass_depth
+	for _, item := range opaque {
+		self.renderer.render_scene_object(item.sobj, proj, item.vwmd)
+	}
+	context.Call("colorMask", true, true, true, true)
+	// 2. Accumulate the weighted colors of TRANSPARENT objects
+	self.renderer.state.SetDepthMask(false)
+	context.Call("enable", constants.BLEND)
+	context.Call("blendFunc", constants.ONE, constants.ONE)
+	self.pass = oit_pass_accum
+	for _, item := range blended {
+		self.renderer.render_scene_object(item.sobj, proj, item.vwmd)
+	}
+	// 3. Multiply the revealage (1 - alpha) of TRANSPARENT objects
+	context.Call("bindFramebuffer", constants.FRAMEBUFFER, self.framebuffers[1])
+	context.Call("clearColor", 1, 1, 1, 1)
+	context.Call("clear", constants.COLOR_BUFFER_BIT) // (keeping the shared depth)
+	context.Call("blendFunc", constants.ZERO, constants.ONE_MINUS_SRC_COLOR)
+	self.pass = oit_pass_reveal
+	for _, item := range blended {
+		self.renderer.render_scene_object(item.sobj, proj, item.vwmd)
+	}
+	self.pass = oit_pass_none
+	self.renderer.state.SetDepthMask(true)
+	// 4. Composite the average color onto the RenderTarget (or the canvas)
+	wcommon.BindRenderTarget(wctx, self.renderer.target)
+	context.Call("disable", constants.DEPTH_TEST)
+	context.Call("blendFunc", constants.SRC_ALPHA, constants.ONE_MINUS_SRC_ALPHA)
+	err := self.render_composite(wh)
+	self.renderer.state.Invalidate() // (WebGL states were changed directly by the OIT passes)
+	// Highlight the TRANSPARENT objects (or their instances), if they're hovered or selected
+	for _, item := range blended {
+		if item.sobj.is_highlighted() {
+			self.renderer.render_highlight(item.sobj, proj, item.vwmd)
+		}
+	}
+	return err
+}
+
+func (self *oit_renderer) render_composite(wh [2]int) error {
+	wctx := self.renderer.wctx
+	context := wctx.GetContext()
+	c := wctx.GetConstants()
+	shader := self.get_composite_shader()
+	if shader == nil {
+		return errors.New("Failed to render OIT : composite shader not ready")
+	}
+	context.Call("useProgram", shader.GetShaderProgram())
+	uniforms := shader.GetUniformBindings()
+	for i, uname := range []string{"_oit_accum", "_oit_reveal"} {
+		context.Call("activeTexture", js.ValueOf(c.TEXTURE0.Int()+i))
+		context.Call("bindTexture", c.TEXTURE_2D, self.textures[i])
+		context.Call("uniform1i", uniforms[uname]["location"], i)
+	}
+	context.Call("uniform2f", uniforms["_oit_size"]["location"], float32(wh[0]), float32(wh[1]))
+	location := shader.GetAttributeBindings()["xy"]["location"]
+	context.Call("bindBuffer", c.ARRAY_BUFFER, self.triangle)
+	context.Call("vertexAttribPointer", location, 2, c.FLOAT, false, 0, 0)
+	context.Call("enableVertexAttribArray", location)
+	if wctx.IsExtensionReady("ANGLE") {
+		wctx.GetExtension("ANGLE").Call("vertexAttribDivisorANGLE", location, 0) // divisor == 0
+	}
+	context.Call("drawArrays", c.TRIANGLES, 0, 3) // (mode, first, count)
+	// unbind the textures, to avoid feedback loops in the next frame
+	for i := 0; i < 2; i++ {
+		context.Call("activeTexture", js.ValueOf(c.TEXTURE0.Int()+i))
+		context.Call("bindTexture", c.TEXTURE_2D, js.Null())
+	}
+	return nil
+}
+
+func (self *oit_renderer) setup_framebuffers(wh [2]int) error {
+	// create the offscreen framebuffers, or resize them (if the size of the RenderTarget has changed)
+	context := self.renderer.wctx.GetContext()
+	c := self.renderer.wctx.GetConstants()
+	if self.depthbuffer.IsNull() {
+		for i := 0; i < 2; i++ {
+			self.framebuffers[i] = context.Call("createFramebuffer")
+			self.textures[i] = context.Call("createTexture")
+		}
+		self.depthbuffer = context.Call("createRenderbuffer")
+		self.triangle = context.Call("createBuffer")
+		context.Call("bindBuffer", c.ARRAY_BUFFER, self.triangle)
+		context.Call("bufferData", c.ARRAY_BUFFER, wcommon.ConvertGoSliceToJsTypedArray([]float32{-1, -1, 3, -1, -1, 3}), c.STATIC_DRAW)
+	} else if self.wh == wh {
+		return nil
+	}
+	self.wh = wh
+	half_float := self.renderer.wctx.GetExtension("HALFFLOAT").Get("HALF_FLOAT_OES")
+	context.Call("bindRenderbuffer", c.RENDERBUFFER, self.depthbuffer)
+	context.Call("renderbufferStorage", c.RENDERBUFFER, c.DEPTH_COMPONENT16, wh[0], wh[1])
+	for i := 0; i < 2; i++ {
+		dtype := c.UNSIGNED_BYTE // REVEAL
+		if i == 0 {
+			dtype = half_float // ACCUM
+		}
+		context.Call("bindTexture", c.TEXTURE_2D, self.textures[i])
+		context.Call("texImage2D", c.TEXTURE_2D, 0, c.RGBA, wh[0], wh[1], 0, c.RGBA, dtype, js.Null())
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_S, c.CLAMP_TO_EDGE)
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_WRAP_T, c.CLAMP_TO_EDGE)
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MIN_FILTER, c.NEAREST)
+		context.Call("texParameteri", c.TEXTURE_2D, c.TEXTURE_MAG_FILTER, c.NEAREST)
+		context.Call("bindFramebuffer", c.FRAMEBUFFER, self.framebuffers[i])
+		context.Call("framebufferTexture2D", c.FRAMEBUFFER, c.COLOR_ATTACHMENT0, c.TEXTURE_2D, self.textures[i], 0)
+		context.Call("framebufferRenderbuffer", c.FRAMEBUFFER, c.DEPTH_ATTACHMENT, c.RENDERBUFFER, self.depthbuffer)
+		if status := context.Call("checkFramebufferStatus", c.FRAMEBUFFER); !status.Equal(c.FRAMEBUFFER_COMPLETE) {
+			context.Call("bindFramebuffer", c.FRAMEBUFFER, js.Null())
+			return errors.New("Failed to setup framebuffers for OIT : incomplete framebuffer")
+		}
+	}
+	context.Call("bindFramebuffer", c.FRAMEBUFFER, js.Null())
+	context.Call("bindRenderbuffer", c.RENDERBUFFER, js.Null())
+	context.Call("bindTexture", c.TEXTURE_2D, js.Null())
+	return nil
+}
+
+func (self *oit_renderer) get_pass_shader(sobj *SceneObject, shader *wcommon.Shader) *wcommon.Shader {
+	// shader to be used for the current pass (the original shader, or its ACCUM & REVEAL variants)
+	if shader == nil || (self.pass != oit_pass_accum && self.pass != oit_pass_reveal) {
+		return shader
+	}
+	if self.shaders[shader] == nil {
+		self.shaders[shader] = map[int]*wcommon.Shader{}
+	}
+	variant, ok := self.shaders[shader][self.pass]
+	if !ok {
+		variant = self.build_variant(shader, self.pass)
+		self.shaders[shader][self.pass] = variant
+	}
+	if variant != nil && self.pass == oit_pass_accum {
+		premul := float32(0)
+		if sobj.BlendMode == "" || sobj.BlendMode == "PREMULTIPLIED" {
+			premul = 1
+		}
+		variant.GetUniformBindings()["_oit_premul"]["value"] = []float32{premul}
+	}
+	return variant
+}
+
+func (self *oit_renderer) build_variant(shader *wcommon.Shader, pass int) *wcommon.Shader {
+	// The original fragment shader is followed by weighting its color (ACCUM) or taking its alpha (REVEAL).
+	decls, fmain := "", "	gl_FragColor = vec4(gl_FragColor.a);"
+	if pass == oit_pass_accum {
+		decls = "uniform float _oit_premul;"
+		fmain = `	vec4  c = gl_FragColor;
+	vec3  rgb = (_oit_premul > 0.5 ? c.rgb : c.rgb * c.a);
+	float w = c.a * clamp(3e2 * pow(1.0 - 0.9 * gl_FragCoord.z, 3.0), 1e-2, 3e2);
+	gl_FragColor = vec4(rgb, c.a) * w;`
+	}
+	variant, err := shader.BuildFragmentVariant(decls, fmain)
+	if err != nil {
+		return nil
+	}
+	if pass == oit_pass_accum {
+		variant.SetBindingForUniform("_oit_premul", "float", []float32{1})
+	}
+	variant.CheckBindings()
+	return variant
+}
+
+func (self *oit_renderer) get_composite_shader() *wcommon.Shader {
+	if self.composite != nil {
+		return self.composite
+	}
+	var vertex_shader_code = `
+		precision mediump float;
+		attribute vec2 xy;			// XY coordinates of the full-screen triangle
+		void main() {
+			gl_Position = vec4(xy, 0.0, 1.0);
+		}`
+	var fragment_shader_code = `
+		precision mediump float;
+		uniform sampler2D _oit_accum;	// sum of weighted (premultiplied color, alpha)
+		uniform sampler2D _oit_reveal;	// product of (1 - alpha)
+		uniform vec2 _oit_size;			// size of the textures
+		void main() {
+			vec2  uv = gl_FragCoord.xy / _oit_size;
+			float reveal = texture2D(_oit_reveal, uv).r;
+			if (reveal >= 1.0) discard;	// no TRANSPARENT fragment
+			vec4  accum = texture2D(_oit_accum, uv);
+			gl_FragColor = vec4(accum.rgb / max(accum.a, 1e-5), 1.0 - reveal);
+		}`
+	shader, err := wcommon.NewShader(self.renderer.wctx, vertex_shader_code, fragment_shader_code)
+	if err != nil {
+		return nil
+	}
+	shader.SetBindingForUniform("_oit_accum", "int", []float32{0})
+	shader.SetBindingForUniform("_oit_reveal", "int", []float32{1})
+	shader.SetBindingForUniform("_oit_size", "vec2", []float32{1, 1})
+	shader.SetBindingForAttribute("xy", "vec2", "geometry.coords")
+	shader.CheckBindings()
+	self.composite = shader
+	return shader
+}
@@ -12,7 +12,9 @@ type Globe struct {
 	bkgcolor    [3]float32           // background color of the globe
 	GSphere     *webgl3d.SceneObject // globe sphere with texture & vertex normals
 	GlowRing    *webgl3d.SceneObject // glow ring around the globe
+	Skybox      *webgl3d.SceneObject // OPTIONAL, skybox behind the globe (like NewSceneObject_StarrySky())
 	modelmatrix geom3d.Matrix4       // Model matrix of the globe & its layers
+	origin      [3]float64           // origin of the globe in double-precision (for relative-to-eye rendering)
 }
 
 func NewGlobe(wctx *wcommon.WebGLContext, bkg_color string) *Globe {
@@ -42,10 +44,31 @@ func (self *Globe) GetBkgColor() [3]float32 {
 	return self.bkgcolor
 }
 
+// ----------------------------------------------------------------------------
+// Skybox
+// ----------------------------------------------------------------------------
+
+func (self *Globe) SetSkybox(skybox *webgl3d.SceneObject) *Globe {
+	// Skybox rendered behind the globe, like NewSceneObject_StarrySky() ('nil' for the background color only)
+	self.Skybox = skybox
+	return self
+}
+
 // ----------------------------------------------------------------------------
 // Translation, Rotation, Scaling (by manipulating MODEL matrix)
 // ----------------------------------------------------------------------------
 
+func (self *Globe) SetOrigin(origin [3]float64) *Globe {
+	// Set the origin of the globe in double-precision, which is applied before MODEL matrix
+	// (like the globe placed in a solar system, rendered with WorldRenderer.SetRelativeToEye(true)).
+	self.origin = origin
+	return self
+}
+
+func (self *Globe) GetOrigin() [3]float64 {
+	return self.origin
+}
+
 func (self *Globe) SetTransformation(txyz [3]float32, axis [3]float32, angle_in_degree float32, scale float32) *Globe {
 	translation := geom3d.NewMatrix4().SetTranslation(txyz[0], txyz[1], txyz[2])
 	rotation := geom3d.NewMatrix4().SetRotationByAxis(axis, angle_in_degree)
@@ -54,6 +77,14 @@ func (self *Globe) SetTransformation(txyz [3]float32, axis [3]float32, angle_in_
 	return self
 }
 
+func (self *Globe) SetTransformationWithQuaternion(txyz [3]float32, q *geom3d.Quaternion, scale float32) *Globe {
+	translation := geom3d.NewMatrix4().SetTranslation(txyz[0], txyz[1], txyz[2])
+	rotation := geom3d.NewMatrix4().SetRotationByQuaternion(q)
+	scaling := geom3d.NewMatrix4().SetScaling(scale, scale, scale)
+	self.modelmatrix.SetMultiplyMatrices(translation, rotation, scaling)
+	return self
+}
+
 func (self *Globe) Translate(tx float32, ty float32, tz float32) *Globe {
 	translation := geom3d.NewMatrix4().SetTranslation(tx, ty, tz)
 	self.modelmatrix.SetMultiplyMatrices(translation, &self.modelmatrix)
@@ -66,6 +97,12 @@ func (self *Globe) Rotate(axis [3]float32, angle_in_degree float32) *Globe {
 	return self
 }
 
+func (self *Globe) RotateByQuaternion(q *geom3d.Quaternion) *Globe {
+	rotation := geom3d.NewMatrix4().SetRotationByQuaternion(q)
+	self.modelmatrix.SetMultiplyMatrices(rotation, &self.modelmatrix)
+	return self
+}
+
 func (self *Globe) Scale(scale float32) *Globe {
 	scaling := geom3d.NewMatrix4().SetScaling(scale, scale, scale)
 	self.modelmatrix.SetMultiplyMatrices(scaling, &self.modelmatrix)
@@ -94,6 +131,12 @@ func NewSceneObject_Globe(wctx *wcommon.WebGLContext) *webgl3d.SceneObject {
 	return webgl3d.NewSceneObject(geometry, material, nil, nil, shader) // set up the scene object
 }
 
+func NewSceneObject_StarrySky(wctx *wcommon.WebGLContext) *webgl3d.SceneObject {
+	// Skybox of black sky with random stars (to be set with Globe.SetSkybox())
+	cubemap := wcommon.NewTextureCube_StarrySky(wctx, 512, 3000)
+	return webgl3d.NewSceneObject_Skybox(wctx, cubemap)
+}
+
 func build_globe_geometry(radius float32, wsegs int, hsegs int, use_normals bool) *webgl3d.Geometry {
 	// Globe (sphere) geometry with UV coordinates per vertex (to be used with a texture image)
 	//   Note that multiple vertices are assigned to north/south poles, as well as 0/360 longitude.
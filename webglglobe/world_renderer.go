@@ -10,6 +10,7 @@ type WorldRenderer struct {
 	wctx     *wcommon.WebGLContext // WebGL context
 	renderer *webgl3d.Renderer     // Renderer for rendering 3D SceneObjects
 	axes     *webgl3d.SceneObject  // XYZ axes for visual reference (only if required)
+	origin   [3]float64            // origin of the Globe rendered last (in double-precision)
 }
 
 func NewWorldRenderer(wctx *wcommon.WebGLContext) *WorldRenderer {
@@ -17,6 +18,18 @@ func NewWorldRenderer(wctx *wcommon.WebGLContext) *WorldRenderer {
 	return &renderer
 }
 
+func (self *WorldRenderer) SetRelativeToEye(rte bool) *WorldRenderer {
+	// Relative-to-eye rendering subtracts the camera position from the origin of the Globe in double-precision.
+	self.renderer.SetRelativeToEye(rte)
+	return self
+}
+
+func (self *WorldRenderer) SetRenderTarget(target *wcommon.RenderTarget) *WorldRenderer {
+	// Render the world into the offscreen RenderTarget ('nil' for the canvas).
+	self.renderer.SetRenderTarget(target)
+	return self
+}
+
 // ----------------------------------------------------------------------------
 // Clear
 // ----------------------------------------------------------------------------
@@ -24,6 +37,7 @@ func NewWorldRenderer(wctx *wcommon.WebGLContext) *WorldRenderer {
 func (self *WorldRenderer) Clear(globe *Globe) {
 	context := self.wctx.GetContext()
 	constants := self.wctx.GetConstants()
+	wcommon.BindRenderTarget(self.wctx, self.renderer.GetRenderTarget()) // clear the RenderTarget (or the canvas)
 	rgb := globe.GetBkgColor()
 	context.Call("clearColor", rgb[0], rgb[1], rgb[2], 1.0) // set clearing color
 	context.Call("clear", constants.COLOR_BUFFER_BIT)       // clear the canvas
@@ -35,11 +49,12 @@ func (self *WorldRenderer) Clear(globe *Globe) {
 // ----------------------------------------------------------------------------
 
 func (self *WorldRenderer) RenderAxes(wcamera *WorldCamera, length float32) {
-	// Render three axes (X:RED, Y:GREEN, Z:BLUE) for visual reference
+	// Render three axes (X:RED, Y:GREEN, Z:BLUE) for visual reference, at the origin of the Globe rendered last
 	if self.axes == nil {
 		self.axes = webgl3d.NewSceneObject_3DAxes(self.wctx, length)
 	}
-	self.renderer.RenderSceneObject(self.axes, wcamera.gcam.GetProjMatrix(), wcamera.gcam.GetViewMatrix())
+	new_viewmodel := wcamera.gcam.GetViewModelMatrix(self.origin, geom3d.NewMatrix4(), self.renderer.IsRelativeToEye())
+	self.renderer.RenderSceneObject(self.axes, wcamera.gcam.GetProjMatrix(), new_viewmodel)
 }
 
 // ----------------------------------------------------------------------------
@@ -47,13 +62,20 @@ func (self *WorldRenderer) RenderAxes(wcamera *WorldCamera, length float32) {
 // ----------------------------------------------------------------------------
 
 func (self *WorldRenderer) RenderWorld(globe *Globe, wcamera *WorldCamera) {
+	wcommon.BindRenderTarget(self.wctx, self.renderer.GetRenderTarget()) // render into the RenderTarget (or the canvas)
+	self.renderer.InvalidateStates()                                     // WebGL states might have been changed outside
+	if globe.Skybox != nil {
+		// Render the Skybox behind everything (with the rotation of the camera only)
+		self.renderer.RenderSkybox(globe.Skybox, wcamera.gcam.GetProjMatrix(), wcamera.gcam.GetViewMatrix())
+	}
+	self.origin = globe.origin
 	if globe.IsReadyToRender() {
 		// Render the Globe
-		new_viewmodel := wcamera.gcam.GetViewMatrix().MultiplyToTheRight(&globe.modelmatrix)
+		new_viewmodel := wcamera.gcam.GetViewModelMatrix(globe.origin, &globe.modelmatrix, self.renderer.IsRelativeToEye())
 		self.renderer.RenderSceneObject(globe.GSphere, wcamera.gcam.GetProjMatrix(), new_viewmodel)
 		// Render the GlowRing (in CAMERA space)
-		distance := geom3d.Length(wcamera.gcam.GetCenter())
-		translation := geom3d.NewMatrix4().SetTranslation(0, 0, -distance)
+		distance := geom3d.Vector3d(wcamera.gcam.GetCenterHighPrecision()).DistanceTo(geom3d.Vector3d(globe.origin))
+		translation := geom3d.NewMatrix4().SetTranslation(0, 0, -float32(distance))
 		self.renderer.RenderSceneObject(globe.GlowRing, wcamera.gcam.GetProjMatrix(), translation)
 	}
 }